@@ -8,6 +8,7 @@ import (
 type Application struct {
 	// Name of the application
 	name          string
+	buildInfo     core.BuildInfo
 	configuration interface{}
 }
 
@@ -25,10 +26,23 @@ func (app *Application) SetName(name string) {
 	app.name = name
 }
 
+// BuildInfo returns build information of the application.
+func (app *Application) BuildInfo() core.BuildInfo {
+	return app.buildInfo
+}
+
+// SetBuildInfo sets version, commit and build time of the application
+// which are displayed in admin page /info.
+func (app *Application) SetBuildInfo(info core.BuildInfo) {
+	app.buildInfo = info
+}
+
 // Initializes the application bootstrap.
 func (app *Application) Initialize(bootstrap *core.Bootstrap) {
 	bootstrap.AddCommand(&CheckCommand{})
 	bootstrap.AddCommand(&ServerCommand{})
+	bootstrap.AddCommand(&SchemaCommand{})
+	bootstrap.AddCommand(&CompletionCommand{})
 }
 
 // When the application runs, this is called after the Bundles are run.
@@ -4,19 +4,33 @@ Package assets provides a bundle for serving static asset files.
 package assets
 
 import (
+	"errors"
+	"io/fs"
 	"net/http"
+	"path"
+	"strings"
 
-	"github.com/goburrow/gol"
 	"github.com/goburrow/gomelon/core"
 )
 
 const (
 	assetsLoggerName = "gomelon/assets"
+
+	defaultIndexFile = "index.html"
 )
 
 // Bundle serves static asset files.
 type Bundle struct {
-	dir     string
+	// IndexFile is served for directory requests. Default is index.html.
+	// Directory contents are listed if the index file does not exist.
+	IndexFile string
+	// CacheControl is the value of Cache-Control header in responses.
+	CacheControl string
+	// Fallback serves the index file in the root directory for any
+	// files not found, which is used by single-page applications.
+	Fallback bool
+
+	fs      http.FileSystem
 	urlPath string
 }
 
@@ -26,9 +40,16 @@ var _ core.Bundle = (*Bundle)(nil)
 // NewAssetsBundle allocates and returns a new AssetsBundle.
 // urlPath must always start with "/".
 func NewBundle(dir, urlPath string) *Bundle {
+	return NewFileSystemBundle(http.Dir(dir), urlPath)
+}
+
+// NewFileSystemBundle allocates and returns a new Bundle serving files from
+// the given file system, e.g. http.FS(embedFS).
+func NewFileSystemBundle(fsys http.FileSystem, urlPath string) *Bundle {
 	return &Bundle{
-		dir:     dir,
-		urlPath: urlPath,
+		IndexFile: defaultIndexFile,
+		fs:        fsys,
+		urlPath:   urlPath,
 	}
 }
 
@@ -38,11 +59,17 @@ func (bundle *Bundle) Initialize(bootstrap *core.Bootstrap) {
 
 // Run registers current AssetsBundle to the server in the given environment.
 func (bundle *Bundle) Run(_ interface{}, env *core.Environment) error {
-	gol.GetLogger(assetsLoggerName).Info("registering AssetsBundle for path %s", bundle.urlPath)
+	core.GetLogger(assetsLoggerName).Info("registering AssetsBundle for path %s", bundle.urlPath)
 
 	// Add slashes if necessary
 	p := addSlashes(bundle.urlPath)
-	handler := http.FileServer(http.Dir(bundle.dir))
+	var handler http.Handler = &assetsHandler{
+		fs:           bundle.fs,
+		indexFile:    bundle.IndexFile,
+		cacheControl: bundle.CacheControl,
+		fallback:     bundle.Fallback,
+		fileServer:   http.FileServer(bundle.fs),
+	}
 	// Strip path prefix if needed
 	if p != "/" {
 		handler = http.StripPrefix(p, handler)
@@ -64,3 +91,58 @@ func addSlashes(p string) string {
 	}
 	return p
 }
+
+// assetsHandler serves index and fallback files and delegates other
+// requests to http.FileServer.
+type assetsHandler struct {
+	fs           http.FileSystem
+	indexFile    string
+	cacheControl string
+	fallback     bool
+
+	fileServer http.Handler
+}
+
+func (h *assetsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.cacheControl != "" {
+		w.Header().Set("Cache-Control", h.cacheControl)
+	}
+	if h.indexFile != "" {
+		name := path.Clean("/" + r.URL.Path)
+		info, err := stat(h.fs, name)
+		if err == nil {
+			if info.IsDir() && strings.HasSuffix(r.URL.Path, "/") &&
+				h.serveFile(w, r, path.Join(name, h.indexFile)) {
+				return
+			}
+		} else if h.fallback && errors.Is(err, fs.ErrNotExist) &&
+			h.serveFile(w, r, "/"+h.indexFile) {
+			return
+		}
+	}
+	h.fileServer.ServeHTTP(w, r)
+}
+
+// serveFile writes content of the file and returns true if it exists.
+func (h *assetsHandler) serveFile(w http.ResponseWriter, r *http.Request, name string) bool {
+	f, err := h.fs.Open(name)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil || info.IsDir() {
+		return false
+	}
+	http.ServeContent(w, r, info.Name(), info.ModTime(), f)
+	return true
+}
+
+func stat(fsys http.FileSystem, name string) (fs.FileInfo, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return f.Stat()
+}
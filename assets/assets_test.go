@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"testing/fstest"
 
 	"github.com/goburrow/gomelon/core"
 	"github.com/goburrow/gomelon/server"
@@ -28,7 +29,7 @@ func TestAssetsBundle(t *testing.T) {
 		t.Fatal(err)
 	}
 	// Start server
-	server := httptest.NewServer(handler.ServeMux)
+	server := httptest.NewServer(handler)
 	defer server.Close()
 	// Get dir
 	res, err := http.Get(server.URL + "/static/")
@@ -59,3 +60,50 @@ func TestAssetsBundle(t *testing.T) {
 		t.Fatalf("unexpected response body: %s", body)
 	}
 }
+
+func TestFileSystemBundle(t *testing.T) {
+	fs := fstest.MapFS{
+		"app.html":    &fstest.MapFile{Data: []byte("app")},
+		"js/app.js":   &fstest.MapFile{Data: []byte("js")},
+		"docs/a.html": &fstest.MapFile{Data: []byte("docs")},
+	}
+	env := core.NewEnvironment()
+	handler := server.NewHandler()
+	env.Server.ServerHandler = handler
+	bundle := NewFileSystemBundle(http.FS(fs), "/")
+	bundle.IndexFile = "app.html"
+	bundle.CacheControl = "max-age=60"
+	bundle.Fallback = true
+	err := bundle.Run(nil, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	tests := []struct {
+		path string
+		body string
+	}{
+		{"/", "app"},
+		{"/js/app.js", "js"},
+		{"/users/1", "app"},
+	}
+	for _, test := range tests {
+		res, err := http.Get(server.URL + test.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.StatusCode != 200 || string(body) != test.body {
+			t.Fatalf("unexpected response for %s: %d %s", test.path, res.StatusCode, body)
+		}
+		if res.Header.Get("Cache-Control") != "max-age=60" {
+			t.Fatalf("unexpected header %v", res.Header)
+		}
+	}
+}
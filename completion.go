@@ -0,0 +1,203 @@
+package gomelon
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/goburrow/gomelon/core"
+)
+
+// CompletionCommand prints shell completion script of all registered
+// commands and their flags, e.g.
+//
+//	source <(app completion bash)
+type CompletionCommand struct {
+}
+
+var _ core.Command = (*CompletionCommand)(nil)
+var _ core.CommandUsage = (*CompletionCommand)(nil)
+
+// Name returns name of the CompletionCommand.
+func (command *CompletionCommand) Name() string {
+	return "completion"
+}
+
+// Description returns description of the CompletionCommand.
+func (command *CompletionCommand) Description() string {
+	return "prints shell completion script (bash, zsh or fish)"
+}
+
+// Synopsis returns arguments of the CompletionCommand.
+func (command *CompletionCommand) Synopsis() string {
+	return "bash|zsh|fish"
+}
+
+// Examples returns examples of the CompletionCommand.
+func (command *CompletionCommand) Examples() []string {
+	return []string{"completion bash > /etc/bash_completion.d/app"}
+}
+
+// Run prints completion script for the shell given in the first argument.
+func (command *CompletionCommand) Run(bootstrap *core.Bootstrap) error {
+	if len(bootstrap.Arguments) < 2 {
+		return fmt.Errorf("gomelon: shell is required: bash, zsh or fish")
+	}
+	return printCompletion(os.Stdout, bootstrap.Arguments[1], filepath.Base(os.Args[0]), bootstrap.Commands())
+}
+
+// completionFlag is a flag of a command used in completion scripts.
+type completionFlag struct {
+	name  string
+	usage string
+	// isBool is set when the flag does not take a value.
+	isBool bool
+}
+
+func commandFlags(command core.Command) []completionFlag {
+	var flags []completionFlag
+	newFlagSet(command).VisitAll(func(f *flag.Flag) {
+		b, ok := f.Value.(interface {
+			IsBoolFlag() bool
+		})
+		flags = append(flags, completionFlag{
+			name:   f.Name,
+			usage:  f.Usage,
+			isBool: ok && b.IsBoolFlag(),
+		})
+	})
+	return flags
+}
+
+func printCompletion(w io.Writer, shell, program string, commands []core.Command) error {
+	var buf bytes.Buffer
+	switch shell {
+	case "bash":
+		writeBashCompletion(&buf, program, commands)
+	case "zsh":
+		writeZshCompletion(&buf, program, commands)
+	case "fish":
+		writeFishCompletion(&buf, program, commands)
+	default:
+		return fmt.Errorf("gomelon: unsupported shell %q", shell)
+	}
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+func writeBashCompletion(w io.Writer, program string, commands []core.Command) {
+	fn := "_" + shellIdentifier(program)
+	names := make([]string, len(commands))
+	for i, command := range commands {
+		names[i] = command.Name()
+	}
+	fmt.Fprintf(w, "%s() {\n", fn)
+	fmt.Fprintf(w, "    local cur=\"${COMP_WORDS[COMP_CWORD]}\"\n")
+	fmt.Fprintf(w, "    if [ \"$COMP_CWORD\" -eq 1 ]; then\n")
+	fmt.Fprintf(w, "        COMPREPLY=($(compgen -W %s -- \"$cur\"))\n", shellQuote(strings.Join(names, " ")))
+	fmt.Fprintf(w, "        return\n")
+	fmt.Fprintf(w, "    fi\n")
+	fmt.Fprintf(w, "    case \"${COMP_WORDS[1]}\" in\n")
+	for _, command := range commands {
+		flags := commandFlags(command)
+		if len(flags) == 0 {
+			continue
+		}
+		words := make([]string, len(flags))
+		for i, f := range flags {
+			words[i] = "--" + f.name
+		}
+		fmt.Fprintf(w, "    %s)\n", shellQuote(command.Name()))
+		fmt.Fprintf(w, "        if [[ \"$cur\" == -* ]]; then\n")
+		fmt.Fprintf(w, "            COMPREPLY=($(compgen -W %s -- \"$cur\"))\n", shellQuote(strings.Join(words, " ")))
+		fmt.Fprintf(w, "        fi\n")
+		fmt.Fprintf(w, "        ;;\n")
+	}
+	fmt.Fprintf(w, "    esac\n")
+	fmt.Fprintf(w, "}\n")
+	fmt.Fprintf(w, "complete -o default -F %s %s\n", fn, shellQuote(program))
+}
+
+func writeZshCompletion(w io.Writer, program string, commands []core.Command) {
+	fn := "_" + shellIdentifier(program)
+	fmt.Fprintf(w, "#compdef %s\n\n", program)
+	fmt.Fprintf(w, "%s() {\n", fn)
+	fmt.Fprintf(w, "    local -a commands\n")
+	fmt.Fprintf(w, "    commands=(\n")
+	for _, command := range commands {
+		desc := strings.Replace(command.Description(), ":", "\\:", -1)
+		fmt.Fprintf(w, "        %s\n", shellQuote(command.Name()+":"+desc))
+	}
+	fmt.Fprintf(w, "    )\n")
+	fmt.Fprintf(w, "    if (( CURRENT == 2 )); then\n")
+	fmt.Fprintf(w, "        _describe 'command' commands\n")
+	fmt.Fprintf(w, "        return\n")
+	fmt.Fprintf(w, "    fi\n")
+	fmt.Fprintf(w, "    case $words[2] in\n")
+	for _, command := range commands {
+		flags := commandFlags(command)
+		if len(flags) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "    %s)\n", shellQuote(command.Name()))
+		fmt.Fprintf(w, "        _arguments")
+		for _, f := range flags {
+			spec := "--" + f.name + "[" + zshEscape(f.usage) + "]"
+			if !f.isBool {
+				spec += ":" + f.name + ":_files"
+			}
+			fmt.Fprintf(w, " \\\n            %s", shellQuote(spec))
+		}
+		fmt.Fprintf(w, " \\\n            '*:file:_files'\n")
+		fmt.Fprintf(w, "        ;;\n")
+	}
+	fmt.Fprintf(w, "    *)\n")
+	fmt.Fprintf(w, "        _files\n")
+	fmt.Fprintf(w, "        ;;\n")
+	fmt.Fprintf(w, "    esac\n")
+	fmt.Fprintf(w, "}\n\n")
+	fmt.Fprintf(w, "compdef %s %s\n", fn, program)
+}
+
+func writeFishCompletion(w io.Writer, program string, commands []core.Command) {
+	p := shellQuote(program)
+	for _, command := range commands {
+		fmt.Fprintf(w, "complete -c %s -f -n __fish_use_subcommand -a %s -d %s\n",
+			p, shellQuote(command.Name()), shellQuote(command.Description()))
+	}
+	for _, command := range commands {
+		cond := shellQuote("__fish_seen_subcommand_from " + command.Name())
+		for _, f := range commandFlags(command) {
+			fmt.Fprintf(w, "complete -c %s -n %s -l %s -d %s", p, cond, shellQuote(f.name), shellQuote(f.usage))
+			if !f.isBool {
+				fmt.Fprintf(w, " -r")
+			}
+			fmt.Fprintln(w)
+		}
+	}
+}
+
+// shellQuote quotes s in single quotes for POSIX shells and fish.
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+// zshEscape escapes brackets and colons in descriptions of _arguments.
+func zshEscape(s string) string {
+	r := strings.NewReplacer("[", `\[`, "]", `\]`, ":", `\:`)
+	return r.Replace(s)
+}
+
+// shellIdentifier converts name to a valid shell function name.
+func shellIdentifier(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' {
+			return r
+		}
+		return '_'
+	}, name)
+}
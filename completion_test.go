@@ -0,0 +1,36 @@
+package gomelon
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/goburrow/gomelon/core"
+)
+
+func TestPrintCompletion(t *testing.T) {
+	commands := []core.Command{&flagCommand{}, &ServerCommand{}}
+	tests := []struct {
+		shell    string
+		expected []string
+	}{
+		{"bash", []string{"compgen -W 'test server'", "'--name --verbose'", "'--config --dry-run'", "complete -o default -F _my_app 'my-app'"}},
+		{"zsh", []string{"#compdef my-app", "'test:tests flags'", "'--name[name]:name:_files'", "'--verbose[verbose output]'", "compdef _my_app my-app"}},
+		{"fish", []string{"-a 'server' -d 'runs the application as an HTTP server'", "-l 'config'", "-l 'dry-run' -d 'build the server without starting it'\n"}},
+	}
+	for _, test := range tests {
+		var buf bytes.Buffer
+		if err := printCompletion(&buf, test.shell, "my-app", commands); err != nil {
+			t.Fatal(err)
+		}
+		out := buf.String()
+		for _, s := range test.expected {
+			if !strings.Contains(out, s) {
+				t.Fatalf("%s: %q not found in:\n%s", test.shell, s, out)
+			}
+		}
+	}
+	if err := printCompletion(&bytes.Buffer{}, "csh", "my-app", commands); err == nil {
+		t.Fatal("error expected")
+	}
+}
@@ -1,10 +1,15 @@
 package gomelon
 
 import (
+	"encoding/json"
+	"flag"
 	"fmt"
+	"os"
 
-	"github.com/goburrow/gol"
+	"github.com/goburrow/gomelon/configuration"
 	"github.com/goburrow/gomelon/core"
+	"github.com/goburrow/gomelon/debug"
+	"github.com/goburrow/gomelon/healthcheck"
 	"github.com/goburrow/gomelon/logging"
 	"github.com/goburrow/gomelon/metrics"
 	"github.com/goburrow/gomelon/server"
@@ -20,10 +25,14 @@ type Configuration struct {
 	Server  server.Factory
 	Logging logging.Factory
 	Metrics metrics.Factory
+	Debug   debug.Factory
+
+	HealthChecks healthcheck.Factory
 }
 
 // Configuration implements core.Configuration interface.
 var _ core.Configuration = (*Configuration)(nil)
+var _ core.HealthCheckConfiguration = (*Configuration)(nil)
 
 func (c *Configuration) ServerFactory() core.ServerFactory {
 	return &c.Server
@@ -37,31 +46,52 @@ func (c *Configuration) MetricsFactory() core.MetricsFactory {
 	return &c.Metrics
 }
 
-// ConfigurationCommand parses configuration.
+// HealthCheckFactory implements core.HealthCheckConfiguration interface.
+func (c *Configuration) HealthCheckFactory() core.HealthCheckFactory {
+	return &c.HealthChecks
+}
+
+// DebugFactory is used by debug.Bundle.
+func (c *Configuration) DebugFactory() *debug.Factory {
+	return &c.Debug
+}
+
+// ConfigurationCommand parses configuration. Errors are returned as
+// ConfigurationError.
 type ConfigurationCommand struct {
 	// Configuration is the original configuration provided by application.
 	Configuration interface{}
 
 	// configuration is the interface used internally.
 	configuration core.Configuration
+	// path is given by flag --config.
+	path string
+}
+
+// AddFlags adds flag --config.
+func (command *ConfigurationCommand) AddFlags(flags *flag.FlagSet) {
+	flags.StringVar(&command.path, "config", "", "configuration `file` (JSON or YAML)")
 }
 
 func (command *ConfigurationCommand) Run(bootstrap *core.Bootstrap) error {
 	var err error
+	if command.path != "" {
+		bootstrap.ConfigurationPath = command.path
+	}
 	if command.Configuration, err = bootstrap.ConfigurationFactory.Build(bootstrap); err != nil {
-		return err
+		return &ConfigurationError{Err: err}
 	}
 	if err = bootstrap.ValidatorFactory.Validator().Validate(command.Configuration); err != nil {
-		gol.GetLogger(configurationLoggerName).Error("configuration is invalid: %v", err)
-		return err
+		core.GetLogger(configurationLoggerName).Error("configuration is invalid: %v", err)
+		return &ConfigurationError{Err: err}
 	}
 	// Configuration provided must implement core.Configuration interface.
 	var ok bool
 	if command.configuration, ok = command.Configuration.(core.Configuration); !ok {
-		gol.GetLogger(configurationLoggerName).Error(
+		core.GetLogger(configurationLoggerName).Error(
 			"configuration does not implement core.Configuration interface %[1]v %[1]T",
 			command.Configuration)
-		return fmt.Errorf("configuration: unsupported type %T", command.Configuration)
+		return &ConfigurationError{Err: fmt.Errorf("configuration: unsupported type %T", command.Configuration)}
 	}
 	return nil
 }
@@ -71,6 +101,7 @@ type CheckCommand struct {
 }
 
 var _ core.Command = (*CheckCommand)(nil)
+var _ core.CommandUsage = (*CheckCommand)(nil)
 
 func (c *CheckCommand) Name() string {
 	return "check"
@@ -80,12 +111,51 @@ func (c *CheckCommand) Description() string {
 	return "parses and validates the configuration file"
 }
 
+func (c *CheckCommand) Synopsis() string {
+	return "[flags] [config]"
+}
+
+func (c *CheckCommand) Examples() []string {
+	return []string{"check config.yml"}
+}
+
 func (c *CheckCommand) Run(bootstrap *core.Bootstrap) error {
 	if err := c.ConfigurationCommand.Run(bootstrap); err != nil {
 		return err
 	}
 
-	gol.GetLogger(configurationLoggerName).Debug("configuration: %+v", c.ConfigurationCommand.Configuration)
+	core.GetLogger(configurationLoggerName).Debug("configuration: %+v", c.ConfigurationCommand.Configuration)
 	fmt.Println("Configuration is OK")
 	return nil
 }
+
+// SchemaCommand prints JSON Schema of the application configuration.
+type SchemaCommand struct {
+}
+
+var _ core.Command = (*SchemaCommand)(nil)
+
+func (c *SchemaCommand) Name() string {
+	return "schema"
+}
+
+func (c *SchemaCommand) Description() string {
+	return "prints JSON schema of the configuration"
+}
+
+func (c *SchemaCommand) Run(bootstrap *core.Bootstrap) error {
+	factory, ok := bootstrap.ConfigurationFactory.(*configuration.Factory)
+	if !ok {
+		return fmt.Errorf("configuration: unsupported factory %T", bootstrap.ConfigurationFactory)
+	}
+	schema := configuration.Schema(factory.Configuration)
+	schema["title"] = bootstrap.Application.Name()
+
+	b, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+	_, err = os.Stdout.Write(b)
+	return err
+}
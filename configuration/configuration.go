@@ -7,11 +7,11 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 
-	"github.com/goburrow/gol"
 	"github.com/goburrow/gomelon/core"
 
 	"github.com/ghodss/yaml"
@@ -21,22 +21,49 @@ const (
 	loggerName = "gomelon/configuration"
 )
 
+// SourceProvider provides contents of configuration sources.
+type SourceProvider interface {
+	// Open returns a reader for the configuration source at the given path.
+	Open(path string) (io.ReadCloser, error)
+}
+
+// FileSourceProvider reads configuration from the file system.
+type FileSourceProvider struct {
+}
+
+var _ SourceProvider = (*FileSourceProvider)(nil)
+
+func (*FileSourceProvider) Open(path string) (io.ReadCloser, error) {
+	return os.Open(path)
+}
+
 // Factory implements gomelon.ConfigurationFactory interface.
 type Factory struct {
 	// Configuration is the type/pointer of application configuration.
 	Configuration interface{}
+	// SourceProvider opens the configuration source given in command arguments.
+	// FileSourceProvider is used if it is nil.
+	SourceProvider SourceProvider
 }
 
 var _ core.ConfigurationFactory = (*Factory)(nil)
 
 // BuildConfiguration parse config file and returns the factory configuration.
 func (factory *Factory) Build(bootstrap *core.Bootstrap) (interface{}, error) {
-	if len(bootstrap.Arguments) < 2 {
-		gol.GetLogger(loggerName).Error("configuration file is not specified in command arguments: %v", bootstrap.Arguments)
-		return nil, errors.New("configuration: no file specified")
+	path := bootstrap.ConfigurationPath
+	if path == "" {
+		if len(bootstrap.Arguments) < 2 {
+			core.GetLogger(loggerName).Error("configuration file is not specified in command arguments: %v", bootstrap.Arguments)
+			return nil, errors.New("configuration: no file specified")
+		}
+		path = bootstrap.Arguments[1]
+	}
+	provider := factory.SourceProvider
+	if provider == nil {
+		provider = &FileSourceProvider{}
 	}
-	if err := Unmarshal(bootstrap.Arguments[1], factory.Configuration); err != nil {
-		gol.GetLogger(loggerName).Error("%v", err)
+	if err := UnmarshalFrom(provider, path, factory.Configuration); err != nil {
+		core.GetLogger(loggerName).Error("%v", err)
 		return nil, err
 	}
 	return factory.Configuration, nil
@@ -44,32 +71,54 @@ func (factory *Factory) Build(bootstrap *core.Bootstrap) (interface{}, error) {
 
 // Unmarshal decodes the given file to output type.
 func Unmarshal(path string, output interface{}) error {
-	f, err := os.Open(path)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
+	return UnmarshalFrom(&FileSourceProvider{}, path, output)
+}
 
+// UnmarshalFrom decodes the source opened by the given provider to output type.
+// The format of the source is determined by extension of the path.
+func UnmarshalFrom(provider SourceProvider, path string, output interface{}) error {
 	ext := filepath.Ext(path)
+	var unmarshal func([]byte, interface{}) error
 	switch ext {
 	case ".json", ".js":
-		return unmarshalJSON(f, output)
+		unmarshal = unmarshalJSON
 	case ".yaml", ".yml":
-		return unmarshalYAML(f, output)
+		unmarshal = unmarshalYAML
 	default:
 		return fmt.Errorf("configuration: unsupported file type %s", ext)
 	}
+	r, err := provider.Open(path)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	return unmarshal(content, output)
 }
 
-func unmarshalJSON(f *os.File, output interface{}) error {
-	decoder := json.NewDecoder(f)
-	return decoder.Decode(output)
+func unmarshalJSON(content []byte, output interface{}) error {
+	if hasDeprecatedKeys(output) {
+		var err error
+		if content, err = renameDeprecatedKeys(content, output); err != nil {
+			return err
+		}
+	}
+	return json.Unmarshal(content, output)
 }
 
-func unmarshalYAML(f *os.File, output interface{}) error {
-	content, err := ioutil.ReadAll(f)
-	if err != nil {
-		return err
+func unmarshalYAML(content []byte, output interface{}) error {
+	if hasDeprecatedKeys(output) {
+		// JSON is also YAML.
+		var err error
+		if content, err = yaml.YAMLToJSON(content); err != nil {
+			return err
+		}
+		if content, err = renameDeprecatedKeys(content, output); err != nil {
+			return err
+		}
 	}
 	return yaml.Unmarshal(content, output)
 }
@@ -1,6 +1,9 @@
 package configuration
 
 import (
+	"io"
+	"io/ioutil"
+	"strings"
 	"testing"
 
 	"github.com/goburrow/gomelon/core"
@@ -48,6 +51,41 @@ func TestLoadYAML(t *testing.T) {
 	testFactory(t, &bootstrap)
 }
 
+func TestLoadConfigurationPath(t *testing.T) {
+	bootstrap := core.Bootstrap{
+		Arguments:         []string{"server"},
+		ConfigurationPath: "configuration_test.yaml",
+	}
+	testFactory(t, &bootstrap)
+}
+
+// stringSourceProvider provides configuration from memory.
+type stringSourceProvider map[string]string
+
+func (p stringSourceProvider) Open(path string) (io.ReadCloser, error) {
+	return ioutil.NopCloser(strings.NewReader(p[path])), nil
+}
+
+func TestSourceProvider(t *testing.T) {
+	bootstrap := core.Bootstrap{
+		Arguments: []string{"server", "config.yml"},
+	}
+	factory := Factory{
+		Configuration: &configuration{},
+		SourceProvider: stringSourceProvider{
+			"config.yml": "metrics:\n  frequency: 2s\n",
+		},
+	}
+	c, err := factory.Build(&bootstrap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := c.(*configuration)
+	if config.Metrics.Frequency != "2s" {
+		t.Fatalf("Invalid Metrics: %+v", config.Metrics)
+	}
+}
+
 func testFactory(t *testing.T, bootstrap *core.Bootstrap) {
 	factory := Factory{Configuration: &configuration{}}
 	c, err := factory.Build(bootstrap)
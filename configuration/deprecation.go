@@ -0,0 +1,159 @@
+package configuration
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/goburrow/gomelon/core"
+)
+
+const (
+	deprecatedTag = "deprecated"
+)
+
+// renameDeprecatedKeys replaces keys in the JSON document which are declared
+// in the "deprecated" tag of fields in output type with their current names.
+// A warning is logged for each deprecated key found.
+// Multiple old names are separated by comma, e.g.:
+//
+//	Frequency string `deprecated:"freq,interval"`
+//
+// Fields inside polytype.Type are not supported since their types are unknown
+// until the configuration is decoded.
+func renameDeprecatedKeys(content []byte, output interface{}) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+	var doc interface{}
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, err
+	}
+	if !renameKeys(doc, reflect.TypeOf(output), "") {
+		return content, nil
+	}
+	return json.Marshal(doc)
+}
+
+// hasDeprecatedKeys returns true if any field in type of v has deprecated tag.
+func hasDeprecatedKeys(v interface{}) bool {
+	return hasDeprecatedTag(reflect.TypeOf(v), make(map[reflect.Type]bool))
+}
+
+func hasDeprecatedTag(t reflect.Type, visited map[reflect.Type]bool) bool {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || visited[t] {
+		return false
+	}
+	visited[t] = true
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Tag.Get(deprecatedTag) != "" || hasDeprecatedTag(field.Type, visited) {
+			return true
+		}
+	}
+	return false
+}
+
+// renameKeys walks the document along with type t and returns true if
+// the document has been changed.
+func renameKeys(doc interface{}, t reflect.Type, path string) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	changed := false
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		if list, ok := doc.([]interface{}); ok {
+			for i, v := range list {
+				if renameKeys(v, t.Elem(), path+"["+strconv.Itoa(i)+"]") {
+					changed = true
+				}
+			}
+		}
+	case reflect.Map:
+		if m, ok := doc.(map[string]interface{}); ok {
+			for k, v := range m {
+				if renameKeys(v, t.Elem(), joinPath(path, k)) {
+					changed = true
+				}
+			}
+		}
+	case reflect.Struct:
+		if m, ok := doc.(map[string]interface{}); ok {
+			changed = renameStructKeys(m, t, path)
+		}
+	}
+	return changed
+}
+
+func renameStructKeys(m map[string]interface{}, t reflect.Type, path string) bool {
+	changed := false
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous {
+			ft := field.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct && ft != polytypeType && renameStructKeys(m, ft, path) {
+				changed = true
+			}
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		name, ok := propertyName(field)
+		if !ok {
+			continue
+		}
+		key, found := lookupKey(m, name)
+		if tag := field.Tag.Get(deprecatedTag); tag != "" {
+			for _, old := range strings.Split(tag, ",") {
+				oldKey, oldFound := lookupKey(m, old)
+				if !oldFound {
+					continue
+				}
+				if found {
+					core.GetLogger(loggerName).Warn("%s is deprecated and ignored as %s is also specified",
+						joinPath(path, oldKey), joinPath(path, key))
+				} else {
+					core.GetLogger(loggerName).Warn("%s is deprecated, use %s instead",
+						joinPath(path, oldKey), joinPath(path, name))
+					m[name] = m[oldKey]
+					key, found = name, true
+				}
+				delete(m, oldKey)
+				changed = true
+			}
+		}
+		if found && renameKeys(m[key], field.Type, joinPath(path, key)) {
+			changed = true
+		}
+	}
+	return changed
+}
+
+// lookupKey finds key in the map case-insensitively as encoding/json does.
+func lookupKey(m map[string]interface{}, name string) (string, bool) {
+	if _, ok := m[name]; ok {
+		return name, true
+	}
+	for k := range m {
+		if strings.EqualFold(k, name) {
+			return k, true
+		}
+	}
+	return "", false
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,63 @@
+package configuration
+
+import (
+	"testing"
+
+	"github.com/goburrow/gomelon/core"
+)
+
+type deprecatedConnector struct {
+	Addr string `deprecated:"address,bind"`
+}
+
+type deprecatedConfiguration struct {
+	Connectors []deprecatedConnector `deprecated:"listeners"`
+	Metrics    struct {
+		Frequency string `deprecated:"freq"`
+	}
+}
+
+func TestDeprecatedKeys(t *testing.T) {
+	bootstrap := core.Bootstrap{
+		Arguments: []string{"server", "config.yaml"},
+	}
+	factory := Factory{
+		Configuration: &deprecatedConfiguration{},
+		SourceProvider: stringSourceProvider{
+			"config.yaml": `
+listeners:
+- address: :8080
+- addr: :8081
+  bind: :8082
+metrics:
+  freq: 1s
+`,
+		},
+	}
+	c, err := factory.Build(&bootstrap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := c.(*deprecatedConfiguration)
+	if len(config.Connectors) != 2 ||
+		config.Connectors[0].Addr != ":8080" ||
+		config.Connectors[1].Addr != ":8081" {
+		t.Fatalf("unexpected connectors %+v", config.Connectors)
+	}
+	if config.Metrics.Frequency != "1s" {
+		t.Fatalf("unexpected metrics %+v", config.Metrics)
+	}
+}
+
+func TestDeprecatedKeysJSON(t *testing.T) {
+	config := &deprecatedConfiguration{}
+	err := UnmarshalFrom(stringSourceProvider{
+		"config.json": `{"Metrics": {"Freq": "2s"}}`,
+	}, "config.json", config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Metrics.Frequency != "2s" {
+		t.Fatalf("unexpected metrics %+v", config.Metrics)
+	}
+}
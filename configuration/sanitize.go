@@ -0,0 +1,144 @@
+package configuration
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"github.com/goburrow/polytype"
+)
+
+const (
+	secretTag  = "secret"
+	secretMask = "********"
+
+	handlerURI = "/configuration"
+)
+
+// Sanitize converts the given configuration to a generic structure of maps
+// and slices which can be encoded in JSON. Properties are named as they are
+// in configuration files and values of fields tagged `secret:"true"` are
+// masked.
+func Sanitize(v interface{}) interface{} {
+	return sanitize(reflect.ValueOf(v))
+}
+
+func sanitize(v reflect.Value) interface{} {
+	switch v.Kind() {
+	case reflect.Invalid:
+		return nil
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return sanitize(v.Elem())
+	case reflect.Struct:
+		m := make(map[string]interface{})
+		addSanitizedFields(v, m)
+		return m
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+		list := make([]interface{}, v.Len())
+		for i := range list {
+			list[i] = sanitize(v.Index(i))
+		}
+		return list
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		m := make(map[string]interface{}, v.Len())
+		for _, key := range v.MapKeys() {
+			m[fmt.Sprint(key.Interface())] = sanitize(v.MapIndex(key))
+		}
+		return m
+	}
+	if v.CanInterface() {
+		return v.Interface()
+	}
+	return nil
+}
+
+func addSanitizedFields(v reflect.Value, m map[string]interface{}) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		fv := v.Field(i)
+		if field.Anonymous {
+			if field.Type == polytypeType {
+				// Properties of the actual value are promoted.
+				if fv.CanAddr() {
+					value := fv.Addr().Interface().(*polytype.Type).Value()
+					if vm, ok := sanitize(reflect.ValueOf(value)).(map[string]interface{}); ok {
+						for k, e := range vm {
+							m[k] = e
+						}
+					}
+				}
+				continue
+			}
+			for fv.Kind() == reflect.Ptr && !fv.IsNil() {
+				fv = fv.Elem()
+			}
+			// Fields of embedded structs are promoted.
+			if fv.Kind() == reflect.Struct {
+				addSanitizedFields(fv, m)
+			}
+			continue
+		}
+		if field.PkgPath != "" {
+			// Unexported
+			continue
+		}
+		name, ok := propertyName(field)
+		if !ok {
+			continue
+		}
+		if field.Tag.Get(secretTag) == "true" {
+			// Empty secrets are not masked so that missing values can be noticed.
+			if !isZero(fv) {
+				m[name] = secretMask
+			} else {
+				m[name] = sanitize(fv)
+			}
+			continue
+		}
+		m[name] = sanitize(fv)
+	}
+}
+
+func isZero(v reflect.Value) bool {
+	return reflect.DeepEqual(v.Interface(), reflect.Zero(v.Type()).Interface())
+}
+
+// Handler is an admin handler displaying the sanitized configuration.
+type Handler struct {
+	configuration interface{}
+}
+
+// NewHandler allocates and returns a new Handler for the given configuration.
+func NewHandler(configuration interface{}) *Handler {
+	return &Handler{configuration}
+}
+
+func (handler *Handler) Name() string {
+	return "Configuration"
+}
+
+func (handler *Handler) Path() string {
+	return handlerURI
+}
+
+func (handler *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	data, err := json.MarshalIndent(Sanitize(handler.configuration), "", "  ")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Cache-Control", "must-revalidate,no-cache,no-store")
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
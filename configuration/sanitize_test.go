@@ -0,0 +1,70 @@
+package configuration
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+type sanitizeAuth struct {
+	Username string
+	Password string `secret:"true"`
+	Token    string `secret:"true"`
+}
+
+type sanitizeConfiguration struct {
+	schemaCommon
+
+	Name      string
+	Port      int
+	Auth      sanitizeAuth
+	Loggers   map[string]string
+	Appenders []schemaAppender
+	Ignored   string `json:"-"`
+	Renamed   string `json:"other"`
+	hidden    string
+}
+
+func TestSanitize(t *testing.T) {
+	c := &sanitizeConfiguration{
+		Name: "app",
+		Port: 8080,
+		Auth: sanitizeAuth{
+			Username: "admin",
+			Password: "password",
+		},
+		Loggers: map[string]string{"a": "INFO"},
+		Ignored: "ignored",
+		Renamed: "renamed",
+		hidden:  "hidden",
+	}
+	c.RequestLog.SetValue(&sanitizeAuth{Token: "token"})
+	expected := map[string]interface{}{
+		"requestLog": map[string]interface{}{"username": "", "password": "", "token": secretMask},
+		"name":       "app",
+		"port":       8080,
+		"auth":       map[string]interface{}{"username": "admin", "password": secretMask, "token": ""},
+		"loggers":    map[string]interface{}{"a": "INFO"},
+		"appenders":  nil,
+		"other":      "renamed",
+	}
+	if v := Sanitize(c); !reflect.DeepEqual(expected, v) {
+		t.Fatalf("unexpected configuration %#v", v)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	handler := NewHandler(&sanitizeConfiguration{Auth: sanitizeAuth{Password: "password"}})
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", handlerURI, nil)
+	handler.ServeHTTP(w, r)
+	var v map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &v); err != nil {
+		t.Fatal(err)
+	}
+	if v["auth"].(map[string]interface{})["password"] != secretMask {
+		t.Fatalf("unexpected response %s", w.Body.String())
+	}
+}
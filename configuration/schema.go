@@ -0,0 +1,217 @@
+package configuration
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/goburrow/polytype"
+)
+
+const (
+	schemaVersion = "http://json-schema.org/draft-04/schema#"
+	validatorTag  = "valid"
+)
+
+var polytypeType = reflect.TypeOf(polytype.Type{})
+
+// Schema generates JSON Schema of the given configuration.
+// Properties are named in lower camel case as they are in configuration files.
+// Fields which embed polytype.Type only require the "type" property as their
+// actual types are resolved when configuration is loaded.
+// Recursive types are referenced with "$ref" to the root schema or to
+// their schemas in "definitions".
+func Schema(v interface{}) map[string]interface{} {
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	b := &schemaBuilder{
+		root:        t,
+		visiting:    make(map[reflect.Type]bool),
+		recursive:   make(map[reflect.Type]bool),
+		definitions: make(map[string]interface{}),
+	}
+	schema := b.schemaOf(t)
+	if len(b.definitions) > 0 {
+		schema["definitions"] = b.definitions
+	}
+	schema["$schema"] = schemaVersion
+	return schema
+}
+
+// schemaBuilder tracks struct types being visited to stop recursing on
+// recursive types.
+type schemaBuilder struct {
+	root        reflect.Type
+	visiting    map[reflect.Type]bool
+	recursive   map[reflect.Type]bool
+	definitions map[string]interface{}
+}
+
+func (b *schemaBuilder) schemaOf(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{
+			"type":  "array",
+			"items": b.schemaOf(t.Elem()),
+		}
+	case reflect.Map:
+		return map[string]interface{}{
+			"type":                 "object",
+			"additionalProperties": b.schemaOf(t.Elem()),
+		}
+	case reflect.Struct:
+		if b.visiting[t] {
+			b.recursive[t] = true
+			return map[string]interface{}{"$ref": b.ref(t)}
+		}
+		b.visiting[t] = true
+		schema := b.structSchemaOf(t)
+		delete(b.visiting, t)
+		if b.recursive[t] && t != b.root {
+			b.definitions[t.String()] = schema
+			return map[string]interface{}{"$ref": b.ref(t)}
+		}
+		return schema
+	}
+	// Interfaces and other types can be anything.
+	return map[string]interface{}{}
+}
+
+// ref returns the reference to schema of the recursive type t.
+func (b *schemaBuilder) ref(t reflect.Type) string {
+	if t == b.root {
+		return "#"
+	}
+	return "#/definitions/" + t.String()
+}
+
+func (b *schemaBuilder) structSchemaOf(t reflect.Type) map[string]interface{} {
+	properties := make(map[string]interface{})
+	var required []string
+
+	schema := map[string]interface{}{
+		"type": "object",
+	}
+	if isPolytype(t) {
+		properties["type"] = map[string]interface{}{"type": "string"}
+		required = append(required, "type")
+	}
+	b.addStructProperties(t, properties, &required)
+	if len(properties) > 0 {
+		schema["properties"] = properties
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func (b *schemaBuilder) addStructProperties(t reflect.Type, properties map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous {
+			ft := field.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			// Fields of embedded structs are promoted.
+			if ft.Kind() == reflect.Struct && ft != polytypeType {
+				b.addStructProperties(ft, properties, required)
+			}
+			continue
+		}
+		if field.PkgPath != "" {
+			// Unexported
+			continue
+		}
+		name, ok := propertyName(field)
+		if !ok {
+			continue
+		}
+		property := b.schemaOf(field.Type)
+		if addValidation(field, property) {
+			*required = append(*required, name)
+		}
+		properties[name] = property
+	}
+}
+
+// isPolytype returns true if t embeds polytype.Type.
+func isPolytype(t reflect.Type) bool {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type == polytypeType {
+			return true
+		}
+	}
+	return false
+}
+
+// propertyName returns name in json tag if specified or the field name in
+// lower camel case.
+func propertyName(field reflect.StructField) (string, bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+	if idx := strings.Index(tag, ","); idx >= 0 {
+		tag = tag[:idx]
+	}
+	if tag != "" {
+		return tag, true
+	}
+	r, n := utf8.DecodeRuneInString(field.Name)
+	return string(unicode.ToLower(r)) + field.Name[n:], true
+}
+
+// addValidation adds constraints from validator tag to the property and
+// returns true if the field is required.
+func addValidation(field reflect.StructField, property map[string]interface{}) bool {
+	tag := field.Tag.Get(validatorTag)
+	if tag == "" {
+		return false
+	}
+	required := false
+	for _, rule := range strings.Split(tag, ",") {
+		switch {
+		case rule == "nonzero":
+			required = true
+		case strings.HasPrefix(rule, "min="):
+			if n, err := strconv.ParseFloat(rule[4:], 64); err == nil {
+				addLimit(property, "minimum", "minLength", "minItems", n)
+			}
+		case strings.HasPrefix(rule, "max="):
+			if n, err := strconv.ParseFloat(rule[4:], 64); err == nil {
+				addLimit(property, "maximum", "maxLength", "maxItems", n)
+			}
+		}
+	}
+	return required
+}
+
+func addLimit(property map[string]interface{}, number, str, array string, n float64) {
+	switch property["type"] {
+	case "integer", "number":
+		property[number] = n
+	case "string":
+		property[str] = int(n)
+	case "array":
+		property[array] = int(n)
+	}
+}
@@ -0,0 +1,102 @@
+package configuration
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/goburrow/polytype"
+)
+
+type schemaAppender struct {
+	polytype.Type
+}
+
+type schemaCommon struct {
+	RequestLog schemaAppender
+}
+
+type schemaConfiguration struct {
+	schemaCommon
+
+	Name      string `valid:"nonzero"`
+	Port      int    `valid:"min=1,max=65535"`
+	Enabled   bool
+	Loggers   map[string]string
+	Appenders []schemaAppender
+	Ignored   string `json:"-"`
+	Renamed   string `json:"other"`
+	hidden    string
+}
+
+func TestSchema(t *testing.T) {
+	schema := Schema(&schemaConfiguration{})
+	if schema["$schema"] != schemaVersion || schema["type"] != "object" {
+		t.Fatalf("unexpected schema %#v", schema)
+	}
+	properties := schema["properties"].(map[string]interface{})
+	expected := map[string]interface{}{
+		"requestLog": map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{"type": map[string]interface{}{"type": "string"}},
+			"required":   []string{"type"},
+		},
+		"name":    map[string]interface{}{"type": "string"},
+		"port":    map[string]interface{}{"type": "integer", "minimum": 1.0, "maximum": 65535.0},
+		"enabled": map[string]interface{}{"type": "boolean"},
+		"loggers": map[string]interface{}{
+			"type":                 "object",
+			"additionalProperties": map[string]interface{}{"type": "string"},
+		},
+		"appenders": map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{"type": map[string]interface{}{"type": "string"}},
+				"required":   []string{"type"},
+			},
+		},
+		"other": map[string]interface{}{"type": "string"},
+	}
+	if !reflect.DeepEqual(expected, properties) {
+		t.Fatalf("unexpected properties %#v", properties)
+	}
+	if !reflect.DeepEqual([]string{"name"}, schema["required"]) {
+		t.Fatalf("unexpected required %#v", schema["required"])
+	}
+}
+
+type schemaNode struct {
+	Name     string
+	Next     *schemaNode
+	Children []schemaNode
+	Leaf     schemaLeaf
+}
+
+type schemaLeaf struct {
+	Parent *schemaLeaf
+}
+
+func TestSchemaRecursive(t *testing.T) {
+	schema := Schema(&schemaNode{})
+	properties := schema["properties"].(map[string]interface{})
+	root := map[string]interface{}{"$ref": "#"}
+	if !reflect.DeepEqual(root, properties["next"]) {
+		t.Fatalf("unexpected next %#v", properties["next"])
+	}
+	children := map[string]interface{}{"type": "array", "items": root}
+	if !reflect.DeepEqual(children, properties["children"]) {
+		t.Fatalf("unexpected children %#v", properties["children"])
+	}
+	leaf := map[string]interface{}{"$ref": "#/definitions/configuration.schemaLeaf"}
+	if !reflect.DeepEqual(leaf, properties["leaf"]) {
+		t.Fatalf("unexpected leaf %#v", properties["leaf"])
+	}
+	definitions := schema["definitions"].(map[string]interface{})
+	expected := map[string]interface{}{
+		"type":       "object",
+		"properties": map[string]interface{}{"parent": leaf},
+	}
+	if !reflect.DeepEqual(expected, definitions["configuration.schemaLeaf"]) {
+		t.Fatalf("unexpected definitions %#v", definitions)
+	}
+}
@@ -0,0 +1,60 @@
+package gomelon
+
+import (
+	"flag"
+
+	"github.com/goburrow/gomelon/core"
+)
+
+// ConfiguredCommand is a command which loads and validates the application
+// configuration before running Action, e.g.
+//
+//	bootstrap.AddCommand(&gomelon.ConfiguredCommand{
+//		CommandName:        "export",
+//		CommandDescription: "exports data to a file",
+//		Action: func(bootstrap *core.Bootstrap, configuration interface{}) error {
+//			config := configuration.(*MyConfiguration)
+//			...
+//		},
+//	})
+//
+// See also NewConfiguredCommand.
+type ConfiguredCommand struct {
+	ConfigurationCommand
+
+	CommandName        string
+	CommandDescription string
+	// Flags adds flags of the command in addition to --config.
+	Flags func(flags *flag.FlagSet)
+	// Action is called with the validated configuration.
+	Action func(bootstrap *core.Bootstrap, configuration interface{}) error
+}
+
+var _ core.Command = (*ConfiguredCommand)(nil)
+var _ core.CommandFlags = (*ConfiguredCommand)(nil)
+
+// Name returns CommandName.
+func (command *ConfiguredCommand) Name() string {
+	return command.CommandName
+}
+
+// Description returns CommandDescription.
+func (command *ConfiguredCommand) Description() string {
+	return command.CommandDescription
+}
+
+// AddFlags adds flag --config and flags given by Flags.
+func (command *ConfiguredCommand) AddFlags(flags *flag.FlagSet) {
+	command.ConfigurationCommand.AddFlags(flags)
+	if command.Flags != nil {
+		command.Flags(flags)
+	}
+}
+
+// Run loads the configuration and calls Action.
+func (command *ConfiguredCommand) Run(bootstrap *core.Bootstrap) error {
+	if err := command.ConfigurationCommand.Run(bootstrap); err != nil {
+		return err
+	}
+	return command.Action(bootstrap, command.Configuration)
+}
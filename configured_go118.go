@@ -0,0 +1,27 @@
+//go:build go1.18
+// +build go1.18
+
+package gomelon
+
+import (
+	"fmt"
+
+	"github.com/goburrow/gomelon/core"
+)
+
+// NewConfiguredCommand returns a ConfiguredCommand whose action receives the
+// configuration as type T, which is usually the pointer type of the
+// application configuration.
+func NewConfiguredCommand[T any](name, description string, action func(bootstrap *core.Bootstrap, configuration T) error) *ConfiguredCommand {
+	return &ConfiguredCommand{
+		CommandName:        name,
+		CommandDescription: description,
+		Action: func(bootstrap *core.Bootstrap, configuration interface{}) error {
+			c, ok := configuration.(T)
+			if !ok {
+				return fmt.Errorf("configuration: unsupported type %T", configuration)
+			}
+			return action(bootstrap, c)
+		},
+	}
+}
@@ -0,0 +1,52 @@
+//go:build go1.18
+// +build go1.18
+
+package gomelon
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/goburrow/gomelon/core"
+)
+
+type configuredApplication struct {
+	Application
+	command core.Command
+}
+
+func (app *configuredApplication) Initialize(bootstrap *core.Bootstrap) {
+	bootstrap.AddCommand(app.command)
+}
+
+func TestConfiguredCommand(t *testing.T) {
+	dir, err := ioutil.TempDir("", "configured")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "config.yml")
+	if err = ioutil.WriteFile(path, []byte("server:\n  type: simple\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	var config *Configuration
+	command := NewConfiguredCommand("export", "exports data", func(bootstrap *core.Bootstrap, c *Configuration) error {
+		config = c
+		return nil
+	})
+	if err = Run(&configuredApplication{command: command}, []string{"export", "--config", path}); err != nil {
+		t.Fatal(err)
+	}
+	if config == nil {
+		t.Fatal("configuration is not given")
+	}
+	// Configuration is required.
+	command = NewConfiguredCommand("export", "exports data", func(*core.Bootstrap, *Configuration) error {
+		return nil
+	})
+	if err = Run(&configuredApplication{command: command}, []string{"export"}); err == nil {
+		t.Fatal("error expected")
+	}
+}
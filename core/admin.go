@@ -3,30 +3,24 @@ package core
 import (
 	"bytes"
 	"fmt"
+	"html/template"
+	"io"
 	"net/http"
 	"runtime"
-
-	"github.com/goburrow/gol"
-	"github.com/goburrow/health"
+	"runtime/pprof"
+	"strconv"
+	"time"
 )
 
 const (
 	pingURI        = "/ping"
 	runtimeURI     = "/runtime"
 	healthCheckURI = "/healthcheck"
+	threadsURI     = "/threads"
+	liveURI        = "/live"
+	readyURI       = "/ready"
 	tasksURI       = "/tasks"
 
-	adminHTML = `<!DOCTYPE html>
-<html>
-<head>
-	<title>Operational Menu</title>
-</head>
-<body>
-	<h1>Operational Menu</h1>
-	<ul>%[1]s</ul>
-</body>
-</html>
-`
 	noHealthChecksWarning = `
 !!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!
 !    THIS APPLICATION HAS NO HEALTHCHECKS.    !
@@ -35,9 +29,28 @@ const (
 
 	adminLoggerName = "gomelon/admin"
 
-	gcTaskName = "gc"
+	defaultAdminTitle = "Operational Menu"
+
+	gcTaskName       = "gc"
+	shutdownTaskName = "shutdown"
 )
 
+var defaultAdminTemplate = template.Must(template.New("admin").Parse(`<!DOCTYPE html>
+<html>
+<head>
+	<title>{{.Title}}</title>
+</head>
+<body>
+	<h1>{{.Title}}</h1>
+	<ul>{{range .Links}}<li><a href="{{.URL}}">{{.Name}}</a></li>{{end}}</ul>
+{{- range .Groups}}
+	<h2>{{.Name}}</h2>
+	<ul>{{range .Links}}<li><a href="{{.URL}}">{{.Name}}</a></li>{{end}}</ul>
+{{- end}}
+</body>
+</html>
+`))
+
 // AdminHandler is an item listed in the admin homepage.
 type AdminHandler interface {
 	Path() string
@@ -45,20 +58,37 @@ type AdminHandler interface {
 	http.Handler
 }
 
+// AdminHandlerGroup is optionally implemented by AdminHandler to be listed
+// under the group, e.g. name of the bundle, in the admin homepage.
+type AdminHandlerGroup interface {
+	Group() string
+}
+
 type AdminEnvironment struct {
 	ServerHandler ServerHandler
-	HealthChecks  health.Registry
+	HealthChecks  *HealthCheckRegistry
+
+	// Title is the title of the admin homepage.
+	Title string
+	// HomeTemplate overrides the default template of the admin homepage.
+	// It is executed with a value having fields Title, Links and Groups,
+	// where each group has Name and Links and each link has Name and URL.
+	HomeTemplate *template.Template
+	// HomeHandler replaces the admin homepage entirely.
+	HomeHandler http.Handler
 
 	handlers []AdminHandler
+	links    []adminLink
 	tasks    []Task
 }
 
 func NewAdminEnvironment() *AdminEnvironment {
 	env := &AdminEnvironment{
-		HealthChecks: health.NewRegistry(),
+		HealthChecks: NewHealthCheckRegistry(),
+		Title:        defaultAdminTitle,
 	}
 	// Default handlers
-	env.AddHandler(&pingHandler{}, &runtimeHandler{}, &healthCheckHandler{env.HealthChecks})
+	env.AddHandler(&pingHandler{}, &runtimeHandler{}, &healthCheckHandler{env.HealthChecks}, &threadsHandler{}, &liveHandler{})
 	// Default tasks
 	env.AddTask(&gcTask{})
 	return env
@@ -74,12 +104,19 @@ func (env *AdminEnvironment) AddHandler(handler ...AdminHandler) {
 	env.handlers = append(env.handlers, handler...)
 }
 
+// AddLink adds an external link to the admin homepage under the group.
+// Links with empty group are listed along with the default handlers.
+func (env *AdminEnvironment) AddLink(name, url, group string) {
+	env.links = append(env.links, adminLink{Name: name, URL: url, group: group})
+}
+
 // onStarting registers all required HTTP handlers
-func (env *AdminEnvironment) onStarting() {
-	env.ServerHandler.Handle("GET", "/", &adminIndex{
-		handlers:    env.handlers,
-		contextPath: env.ServerHandler.PathPrefix(),
-	})
+func (env *AdminEnvironment) onStarting() error {
+	home := env.HomeHandler
+	if home == nil {
+		home = env.newAdminIndex()
+	}
+	env.ServerHandler.Handle("GET", "/", home)
 	// Registered handlers
 	for _, h := range env.handlers {
 		env.ServerHandler.Handle("*", h.Path(), h)
@@ -87,18 +124,21 @@ func (env *AdminEnvironment) onStarting() {
 	// Registered tasks
 	for _, task := range env.tasks {
 		path := tasksURI + "/" + task.Name()
-		env.ServerHandler.Handle("POST", path, task)
+		env.ServerHandler.Handle("POST", path, &taskHandler{task})
 	}
 	env.logTasks()
 	env.logHealthChecks()
+	env.HealthChecks.start()
+	return nil
 }
 
 func (env *AdminEnvironment) onStopped() {
+	env.HealthChecks.stop()
 }
 
 // logTasks prints all registered tasks to the log
 func (env *AdminEnvironment) logTasks() {
-	logger := gol.GetLogger(adminLoggerName)
+	logger := GetLogger(adminLoggerName)
 	if !logger.InfoEnabled() {
 		return
 	}
@@ -112,7 +152,7 @@ func (env *AdminEnvironment) logTasks() {
 
 // logTasks prints all registered tasks to the log
 func (env *AdminEnvironment) logHealthChecks() {
-	logger := gol.GetLogger(adminLoggerName)
+	logger := GetLogger(adminLoggerName)
 	names := env.HealthChecks.Names()
 	if len(names) <= 0 {
 		logger.Warn(noHealthChecksWarning)
@@ -120,30 +160,80 @@ func (env *AdminEnvironment) logHealthChecks() {
 	logger.Debug("health checks = %v", names)
 }
 
+// adminLink is a link in the admin homepage.
+type adminLink struct {
+	Name  string
+	URL   string
+	group string
+}
+
+// adminLinkGroup contains links of the same group.
+type adminLinkGroup struct {
+	Name  string
+	Links []adminLink
+}
+
 // adminIndex is the home page of admin.
 type adminIndex struct {
-	handlers    []AdminHandler
-	contextPath string
+	template *template.Template
+	data     struct {
+		Title  string
+		Links  []adminLink
+		Groups []*adminLinkGroup
+	}
+}
+
+func (env *AdminEnvironment) newAdminIndex() *adminIndex {
+	index := &adminIndex{
+		template: env.HomeTemplate,
+	}
+	if index.template == nil {
+		index.template = defaultAdminTemplate
+	}
+	index.data.Title = env.Title
+	links := make([]adminLink, 0, len(env.handlers)+len(env.links))
+	for _, h := range env.handlers {
+		link := adminLink{Name: h.Name(), URL: env.ServerHandler.PathPrefix() + h.Path()}
+		if g, ok := h.(AdminHandlerGroup); ok {
+			link.group = g.Group()
+		}
+		links = append(links, link)
+	}
+	links = append(links, env.links...)
+	// Groups are listed in order of their first links.
+	groups := make(map[string]*adminLinkGroup)
+	for _, link := range links {
+		if link.group == "" {
+			index.data.Links = append(index.data.Links, link)
+			continue
+		}
+		group, ok := groups[link.group]
+		if !ok {
+			group = &adminLinkGroup{Name: link.group}
+			groups[link.group] = group
+			index.data.Groups = append(index.data.Groups, group)
+		}
+		group.Links = append(group.Links, link)
+	}
+	return index
 }
 
 // ServeHTTP handles request to the root of Admin page
 func (handler *adminIndex) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	var buf bytes.Buffer
-
-	for _, h := range handler.handlers {
-		fmt.Fprintf(&buf, "<li><a href=\"%[1]s%[2]s\">%[3]s</a></li>",
-			handler.contextPath, h.Path(), h.Name())
+	if err := handler.template.Execute(&buf, &handler.data); err != nil {
+		GetLogger(adminLoggerName).Error("could not render admin page: %v", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
 	}
-
 	w.Header().Set("Cache-Control", "must-revalidate,no-cache,no-store")
 	w.Header().Set("Content-Type", "text/html")
-
-	fmt.Fprintf(w, adminHTML, buf.String())
+	w.Write(buf.Bytes())
 }
 
 // healthCheckHandler is the http handler for /healthcheck page
 type healthCheckHandler struct {
-	registry health.Registry
+	registry *HealthCheckRegistry
 }
 
 func (handler *healthCheckHandler) Name() string {
@@ -154,17 +244,27 @@ func (handler *healthCheckHandler) Path() string {
 	return healthCheckURI
 }
 
+// ServeHTTP runs all health checks or only those given in query parameter
+// name, e.g. /healthcheck?name=database.
 func (handler *healthCheckHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Cache-Control", "must-revalidate,no-cache,no-store")
 
-	results := handler.registry.RunHealthChecks()
+	names := r.URL.Query()["name"]
+	results := handler.registry.Results(names...)
+	for _, name := range names {
+		if _, ok := results[name]; !ok {
+			http.Error(w, "No health check named "+name, http.StatusNotFound)
+			return
+		}
+	}
 	if len(results) == 0 {
 		w.WriteHeader(http.StatusNotImplemented)
 		w.Write([]byte("No health checks registered."))
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
-	if !isAllHealthy(results) {
+	// Failures of non-critical health checks do not change the status.
+	if !isHealthy(results) {
 		w.WriteHeader(http.StatusInternalServerError)
 	}
 	first := true
@@ -182,19 +282,12 @@ func (handler *healthCheckHandler) ServeHTTP(w http.ResponseWriter, r *http.Requ
 		if result.Cause() != nil {
 			fmt.Fprintf(w, ", \"Cause\": %q", result.Cause())
 		}
-		w.Write([]byte("}"))
-	}
-	w.Write([]byte("\n}\n"))
-}
-
-// isAllHealthy checks if all are healthy
-func isAllHealthy(results map[string]health.Result) bool {
-	for _, result := range results {
-		if !result.Healthy() {
-			return false
+		if result.Severity != HealthCheckCritical {
+			fmt.Fprintf(w, ", \"Severity\": %q", result.Severity)
 		}
+		fmt.Fprintf(w, ", \"Timestamp\": %q}", result.Timestamp.Format(time.RFC3339))
 	}
-	return true
+	w.Write([]byte("\n}\n"))
 }
 
 // pingHandler handles ping request to admin /ping
@@ -215,40 +308,83 @@ func (handler *pingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte("pong\n"))
 }
 
-// runtimeHandler displays runtime statistics.
-type runtimeHandler struct {
+// liveHandler responds OK as long as the process is able to serve requests.
+type liveHandler struct {
 }
 
-func (handler *runtimeHandler) Name() string {
-	return "Runtime"
+func (handler *liveHandler) Name() string {
+	return "Liveness"
 }
 
-func (handler *runtimeHandler) Path() string {
-	return runtimeURI
+func (handler *liveHandler) Path() string {
+	return liveURI
 }
 
-func (handler *runtimeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+func (handler *liveHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Cache-Control", "must-revalidate,no-cache,no-store")
 	w.Header().Set("Content-Type", "text/plain")
+	w.Write([]byte("live\n"))
+}
+
+// readyHandler responds OK only when the application is running and all
+// health checks are healthy, otherwise status 503 is returned.
+type readyHandler struct {
+	registry  *HealthCheckRegistry
+	lifecycle *LifecycleEnvironment
+}
+
+func (handler *readyHandler) Name() string {
+	return "Readiness"
+}
+
+func (handler *readyHandler) Path() string {
+	return readyURI
+}
+
+func (handler *readyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "must-revalidate,no-cache,no-store")
+	w.Header().Set("Content-Type", "text/plain")
+
+	if state := handler.lifecycle.State(); state != StateRunning {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprintf(w, "not ready: %s\n", state)
+		return
+	}
+	if !isHealthy(handler.registry.Results()) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte("not ready: unhealthy\n"))
+		return
+	}
+	w.Write([]byte("ready\n"))
+}
+
+// threadsHandler dumps stack traces of all goroutines.
+type threadsHandler struct {
+}
 
-	fmt.Fprintf(w, "GOARCH: %s\nGOOS: %s\nVersion: %s\nNumCPU: %d\nNumCgoCall: %d\nNumGoroutine: %d\n",
-		runtime.GOARCH, runtime.GOOS, runtime.Version(),
-		runtime.NumCPU(), runtime.NumCgoCall(), runtime.NumGoroutine())
-
-	var m runtime.MemStats
-	runtime.ReadMemStats(&m)
-	// General statistics
-	fmt.Fprintf(w, "MemStats:\n\tAlloc: %d\n\tTotalAlloc: %d\n\tSys: %d\n\tLookups: %d\n\tMallocs: %d\n\tFrees: %d\n",
-		m.Alloc, m.TotalAlloc, m.Sys, m.Lookups, m.Mallocs, m.Frees)
-	// Main allocation heap statistics
-	fmt.Fprintf(w, "\tHeapAlloc: %d\n\tHeapSys: %d\n\tHeapIdle: %d\n\tHeapInuse: %d\n\tHeapReleased: %d\n\tHeapObjects: %d\n",
-		m.HeapAlloc, m.HeapSys, m.HeapIdle, m.HeapInuse, m.HeapReleased, m.HeapObjects)
-	// Low-level fixed-size structure allocator statistics
-	fmt.Fprintf(w, "\tStackInuse: %d\n\tStackSys: %d\n\tMSpanInuse: %d\n\tMSpanSys: %d\n\tMCacheInuse: %d\n\tMCacheSys: %d\n\tBuckHashSys: %d\n\tGCSys: %d\n\tOtherSys: %d\n",
-		m.StackInuse, m.StackSys, m.MSpanInuse, m.MSpanSys, m.MCacheInuse, m.MCacheSys, m.BuckHashSys, m.GCSys, m.OtherSys)
-	// Garbage collector statistics
-	fmt.Fprintf(w, "\tNextGC: %d\n\tLastGC: %d\n\tPauseTotalNs: %d\n\tNumGC: %d\n\tEnableGC: %t\n\tDebugGC: %t\n",
-		m.NextGC, m.LastGC, m.PauseTotalNs, m.NumGC, m.EnableGC, m.DebugGC)
+func (handler *threadsHandler) Name() string {
+	return "Threads"
+}
+
+func (handler *threadsHandler) Path() string {
+	return threadsURI
+}
+
+// ServeHTTP writes goroutine profile with the verbosity given in query
+// parameter debug: 1 groups goroutines by stack, 2 (default) prints
+// every goroutine like an unrecovered panic.
+func (handler *threadsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	debug := 2
+	if s := r.URL.Query().Get("debug"); s != "" {
+		var err error
+		if debug, err = strconv.Atoi(s); err != nil || debug < 1 || debug > 2 {
+			http.Error(w, "invalid debug parameter", http.StatusBadRequest)
+			return
+		}
+	}
+	w.Header().Set("Cache-Control", "must-revalidate,no-cache,no-store")
+	w.Header().Set("Content-Type", "text/plain")
+	pprof.Lookup("goroutine").WriteTo(w, debug)
 }
 
 // gcTask performs a garbage collection
@@ -259,8 +395,44 @@ func (*gcTask) Name() string {
 	return gcTaskName
 }
 
-func (*gcTask) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	w.Write([]byte("Running GC...\n"))
+func (*gcTask) Execute(params map[string][]string, out io.Writer) error {
+	io.WriteString(out, "Running GC...\n")
 	runtime.GC()
-	w.Write([]byte("Done!\n"))
+	io.WriteString(out, "Done!\n")
+	return nil
+}
+
+// shutdownTask stops the application gracefully after the delay given in
+// parameter delay, e.g. "5s".
+type shutdownTask struct {
+	lifecycle *LifecycleEnvironment
+}
+
+func (*shutdownTask) Name() string {
+	return shutdownTaskName
+}
+
+func (t *shutdownTask) Execute(params map[string][]string, out io.Writer) error {
+	var delay time.Duration
+	if s := params["delay"]; len(s) > 0 && s[0] != "" {
+		var err error
+		if delay, err = time.ParseDuration(s[0]); err != nil || delay < 0 {
+			return NewTaskError("invalid delay "+s[0], http.StatusBadRequest)
+		}
+	}
+	t.lifecycle.shutdownMu.Lock()
+	available := t.lifecycle.shutdownHandler != nil
+	t.lifecycle.shutdownMu.Unlock()
+	if !available {
+		return NewTaskError("shutdown is not supported", http.StatusNotImplemented)
+	}
+	fmt.Fprintf(out, "Shutting down in %v...\n", delay)
+	// Not ready for new requests while waiting. Listeners are notified
+	// now so they can deregister the application during the delay.
+	t.lifecycle.setStopping()
+	// Response must be sent before the server is stopped.
+	time.AfterFunc(delay, func() {
+		t.lifecycle.Shutdown()
+	})
+	return nil
 }
@@ -0,0 +1,298 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/goburrow/health"
+)
+
+func TestThreadsHandler(t *testing.T) {
+	handler := &threadsHandler{}
+	for _, query := range []string{"", "?debug=1", "?debug=2"} {
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", threadsURI+query, nil)
+		handler.ServeHTTP(w, r)
+		if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "goroutine") {
+			t.Fatalf("unexpected response %v %s", w.Code, w.Body.String())
+		}
+	}
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", threadsURI+"?debug=3", nil)
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("unexpected response %v", w.Code)
+	}
+}
+
+type stubTask struct {
+	err error
+}
+
+func (t *stubTask) Name() string {
+	return "stub"
+}
+
+func (t *stubTask) Execute(params map[string][]string, out io.Writer) error {
+	if p, ok := params["p"]; ok {
+		fmt.Fprintf(out, "%v", p)
+	}
+	return t.err
+}
+
+type stubHTTPTask struct {
+}
+
+func (t *stubHTTPTask) Name() string {
+	return "stubhttp"
+}
+
+func (t *stubHTTPTask) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("p") == "" {
+		http.Error(w, "missing p", http.StatusBadRequest)
+		return
+	}
+	w.Write([]byte(r.URL.Query().Get("p")))
+}
+
+func TestTaskHandler(t *testing.T) {
+	tests := []struct {
+		task Task
+		body string
+		code int
+	}{
+		{&stubTask{}, "[1]", http.StatusOK},
+		{&stubTask{NewTaskError("bad", http.StatusBadRequest)}, "[1]bad\n", http.StatusOK},
+		{FromHTTPTask(&stubHTTPTask{}), "1", http.StatusOK},
+	}
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("POST", "/tasks/stub?p=1", http.NoBody)
+		(&taskHandler{test.task}).ServeHTTP(w, r)
+		if w.Code != test.code || w.Body.String() != test.body {
+			t.Fatalf("unexpected response %v %q", w.Code, w.Body.String())
+		}
+	}
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/tasks/stub", http.NoBody)
+	(&taskHandler{&stubTask{NewTaskError("bad", http.StatusBadRequest)}}).ServeHTTP(w, r)
+	if w.Code != http.StatusBadRequest || w.Body.String() != "bad\n" {
+		t.Fatalf("unexpected response %v %q", w.Code, w.Body.String())
+	}
+}
+
+func TestTaskHandlerLogsParamNames(t *testing.T) {
+	var messages []string
+	SetLoggerFactory(func(name string) Logger {
+		return recordingLogger{name, &messages}
+	})
+	defer SetLoggerFactory(nil)
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/tasks/stub?token=secret&b=1", http.NoBody)
+	(&taskHandler{&stubTask{}}).ServeHTTP(w, r)
+	if len(messages) != 1 || strings.Contains(messages[0], "secret") ||
+		!strings.HasPrefix(messages[0], "INFO gomelon/admin: executed task stub [b token] in ") {
+		t.Fatalf("unexpected messages: %v", messages)
+	}
+}
+
+func TestFromHTTPTask(t *testing.T) {
+	task := FromHTTPTask(&stubHTTPTask{})
+	var buf bytes.Buffer
+	if err := task.Execute(map[string][]string{"p": {"1"}}, &buf); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "1" {
+		t.Fatalf("unexpected output %q", buf.String())
+	}
+	err := task.Execute(nil, &buf)
+	if e, ok := err.(*TaskError); !ok || e.Status != http.StatusBadRequest || e.Message != "missing p" {
+		t.Fatalf("unexpected error %#v", err)
+	}
+}
+
+func TestShutdownTask(t *testing.T) {
+	lifecycle := NewLifecycleEnvironment()
+	task := &shutdownTask{lifecycle}
+	var buf bytes.Buffer
+	if err, ok := task.Execute(nil, &buf).(*TaskError); !ok || err.Status != http.StatusNotImplemented {
+		t.Fatalf("unexpected error %#v", err)
+	}
+	done := make(chan struct{})
+	lifecycle.SetShutdownHandler(func() {
+		close(done)
+	})
+	if err, ok := task.Execute(map[string][]string{"delay": {"a"}}, &buf).(*TaskError); !ok || err.Status != http.StatusBadRequest {
+		t.Fatalf("unexpected error %#v", err)
+	}
+	var stopping int32
+	lifecycle.AddListener(LifecycleListenerFunc(func(event *LifecycleEvent) {
+		if event.Type == LifecycleStopping {
+			atomic.AddInt32(&stopping, 1)
+		}
+	}))
+	if err := task.Execute(map[string][]string{"delay": {"1ms"}}, &buf); err != nil {
+		t.Fatal(err)
+	}
+	<-done
+	lifecycle.onStopped()
+	if n := atomic.LoadInt32(&stopping); n != 1 {
+		t.Fatalf("unexpected stopping events: %d", n)
+	}
+}
+
+func TestReadyHandler(t *testing.T) {
+	registry := NewHealthCheckRegistry()
+	lifecycle := NewLifecycleEnvironment()
+	handler := &readyHandler{registry, lifecycle}
+
+	serve := func() int {
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", readyURI, nil)
+		handler.ServeHTTP(w, r)
+		return w.Code
+	}
+	if code := serve(); code != http.StatusServiceUnavailable {
+		t.Fatalf("unexpected code when starting: %v", code)
+	}
+	lifecycle.onStarting()
+	if code := serve(); code != http.StatusOK {
+		t.Fatalf("unexpected code when running: %v", code)
+	}
+	registry.Register("test", health.CheckerFunc(func() health.Result {
+		return health.ResultUnhealthy("down", nil)
+	}))
+	if code := serve(); code != http.StatusServiceUnavailable {
+		t.Fatalf("unexpected code when unhealthy: %v", code)
+	}
+	registry.Unregister("test")
+	lifecycle.onStopped()
+	if code := serve(); code != http.StatusServiceUnavailable {
+		t.Fatalf("unexpected code when stopped: %v", code)
+	}
+}
+
+func TestInfoHandler(t *testing.T) {
+	env := NewEnvironment()
+	env.Name = "test"
+	env.BuildInfo.Merge(BuildInfo{Version: "1.0", Commit: "abc"})
+	handler := &infoHandler{env}
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", infoURI, nil)
+	handler.ServeHTTP(w, r)
+	var info map[string]string
+	if err := json.Unmarshal(w.Body.Bytes(), &info); err != nil {
+		t.Fatal(err)
+	}
+	if info["Name"] != "test" || info["Version"] != "1.0" || info["Commit"] != "abc" ||
+		info["GoVersion"] == "" || info["StartTime"] == "" || info["Uptime"] == "" {
+		t.Fatalf("unexpected info %v", info)
+	}
+	if _, ok := info["BuildTime"]; ok {
+		t.Fatalf("unexpected info %v", info)
+	}
+}
+
+func TestRuntimeHandler(t *testing.T) {
+	runtime.GC()
+	handler := &runtimeHandler{}
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", runtimeURI, nil)
+	handler.ServeHTTP(w, r)
+	if !strings.Contains(w.Body.String(), "GCPause:") || w.Header().Get("Content-Type") != "text/plain" {
+		t.Fatalf("unexpected response %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r, _ = http.NewRequest("GET", runtimeURI, nil)
+	r.Header.Set("Accept", "application/json")
+	handler.ServeHTTP(w, r)
+	var stats runtimeStats
+	if err := json.Unmarshal(w.Body.Bytes(), &stats); err != nil {
+		t.Fatal(err)
+	}
+	if stats.Process.PID != os.Getpid() || stats.GCPause.Count == 0 || stats.GCPause.Max < stats.GCPause.P50 {
+		t.Fatalf("unexpected response %s", w.Body.String())
+	}
+}
+
+type stubServerHandler struct {
+	prefix string
+}
+
+func (h *stubServerHandler) Handle(method, pattern string, handler interface{}) {
+}
+
+func (h *stubServerHandler) PathPrefix() string {
+	return h.prefix
+}
+
+func (h *stubServerHandler) AddFilter(func(http.Handler) http.Handler) {
+}
+
+type stubGroupHandler struct {
+	liveHandler
+}
+
+func (*stubGroupHandler) Group() string {
+	return "Stub"
+}
+
+func TestAdminIndex(t *testing.T) {
+	env := NewAdminEnvironment()
+	env.ServerHandler = &stubServerHandler{prefix: "/admin"}
+	env.Title = "My Admin"
+	env.AddHandler(&stubGroupHandler{})
+	env.AddLink("Docs", "http://example.com/docs", "")
+	env.AddLink("Dashboard", "http://example.com/dashboard", "Stub")
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/", nil)
+	env.newAdminIndex().ServeHTTP(w, r)
+	body := w.Body.String()
+	for _, s := range []string{
+		"<title>My Admin</title>",
+		`<li><a href="/admin/ping">Ping</a></li>`,
+		`<li><a href="http://example.com/docs">Docs</a></li>`,
+		`<h2>Stub</h2>`,
+		`<ul><li><a href="/admin/live">Liveness</a></li><li><a href="http://example.com/dashboard">Dashboard</a></li></ul>`,
+	} {
+		if !strings.Contains(body, s) {
+			t.Fatalf("%q not found in %s", s, body)
+		}
+	}
+
+	env.HomeTemplate = template.Must(template.New("home").Parse("{{.Title}}"))
+	w = httptest.NewRecorder()
+	env.newAdminIndex().ServeHTTP(w, r)
+	if w.Body.String() != "My Admin" {
+		t.Fatalf("unexpected response %s", w.Body.String())
+	}
+}
+
+func TestRuntimeMetrics(t *testing.T) {
+	env := NewEnvironment()
+	if v := env.Metrics.Gauge("Runtime.Goroutines").Value(); v <= 0 {
+		t.Fatalf("unexpected goroutines %d", v)
+	}
+	if v := env.Metrics.Gauge("Runtime.MemStats.HeapAlloc").Value(); v <= 0 {
+		t.Fatalf("unexpected heap alloc %d", v)
+	}
+	runtime.GC()
+	time.Sleep(memStatsInterval)
+	if v := env.Metrics.Gauge("Runtime.GC.NumGC").Value(); v <= 0 {
+		t.Fatalf("unexpected number of GC %d", v)
+	}
+}
@@ -3,20 +3,32 @@ package core
 // Bootstrap contains everything required to bootstrap a command
 type Bootstrap struct {
 	Application Application
-	Arguments   []string
+	// Arguments are the command name followed by positional arguments.
+	Arguments []string
+	// ConfigurationPath is the configuration file given by flag --config.
+	// The first positional argument is used if it is empty.
+	ConfigurationPath string
 
 	ConfigurationFactory ConfigurationFactory
 	ValidatorFactory     ValidatorFactory
 
+	// LogLevel and LogLayout configure the root logger before the
+	// configuration is loaded, e.g. "DEBUG" and "json". Environment variables
+	// GOMELON_LOG_LEVEL and GOMELON_LOG_LAYOUT take precedence.
+	LogLevel  string
+	LogLayout string
+
 	bundles  []Bundle
 	commands []Command
 }
 
-// NewBootstrap allocates and returns a new Bootstrap.
+// NewBootstrap allocates and returns a new Bootstrap with the version
+// command.
 func NewBootstrap(app Application) *Bootstrap {
 	bootstrap := &Bootstrap{
 		Application: app,
 	}
+	bootstrap.AddCommand(&VersionCommand{})
 	return bootstrap
 }
 
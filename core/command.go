@@ -1,8 +1,28 @@
 package core
 
+import "flag"
+
 // Command is a basic CLI command
 type Command interface {
 	Name() string
 	Description() string
 	Run(bootstrap *Bootstrap) error
 }
+
+// CommandFlags is implemented by commands accepting flags, e.g. --config.
+// AddFlags is called before arguments of the command are parsed. Remaining
+// positional arguments are given in Bootstrap.Arguments after the command
+// name.
+type CommandFlags interface {
+	AddFlags(flags *flag.FlagSet)
+}
+
+// CommandUsage is implemented by commands providing details printed by
+// "help <command>" in addition to the name, description and flags.
+type CommandUsage interface {
+	// Synopsis returns arguments of the command, e.g. "[flags] <file>".
+	Synopsis() string
+	// Examples returns sample invocations of the command without the
+	// program name, e.g. "server config.yml".
+	Examples() []string
+}
@@ -6,6 +6,12 @@ type Configuration interface {
 	MetricsFactory() MetricsFactory
 }
 
+// HealthCheckConfiguration is optionally implemented by Configuration to
+// configure health checks.
+type HealthCheckConfiguration interface {
+	HealthCheckFactory() HealthCheckFactory
+}
+
 // ConfigurationFactory creates a configuration for the application.
 type ConfigurationFactory interface {
 	Build(bootstrap *Bootstrap) (interface{}, error)
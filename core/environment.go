@@ -1,10 +1,16 @@
 package core
 
+import (
+	"github.com/goburrow/gomelon/metrics/registry"
+)
+
 // Environment also implements Managed interface so that it can be initilizen
 // when server starts.
 type Environment struct {
 	// Name is taken from the application name.
 	Name string
+	// BuildInfo is the version information of the application.
+	BuildInfo BuildInfo
 	// Server manages HTTP resources
 	Server *ServerEnvironment
 	// Lifecycle controls managed services, allow them to start and stop
@@ -14,6 +20,10 @@ type Environment struct {
 	Admin *AdminEnvironment
 	// Validator validates communication data structures.
 	Validator Validator
+	// Metrics is the registry of application metrics.
+	Metrics *registry.Registry
+	// Scheduler runs jobs periodically while the application is running.
+	Scheduler *Scheduler
 
 	eventListeners []eventListener
 }
@@ -24,7 +34,16 @@ func NewEnvironment() *Environment {
 		Server:    NewServerEnvironment(),
 		Lifecycle: NewLifecycleEnvironment(),
 		Admin:     NewAdminEnvironment(),
+		BuildInfo: defaultBuildInfo(),
+		Metrics:   registry.NewRegistry(),
 	}
+	registerRuntimeMetrics(env.Metrics)
+	env.Scheduler = NewScheduler(env.Metrics)
+	env.Lifecycle.AddListener(env.Scheduler)
+	env.Admin.HealthChecks.setMetrics(env.Metrics)
+	env.Admin.AddHandler(&infoHandler{env})
+	env.Admin.AddHandler(&readyHandler{env.Admin.HealthChecks, env.Lifecycle})
+	env.Admin.AddTask(&shutdownTask{env.Lifecycle})
 	env.eventListeners = []eventListener{
 		env.Server,
 		env.Admin,
@@ -35,14 +54,26 @@ func NewEnvironment() *Environment {
 
 // eventListener is used internally to intialize/finalize environment.
 type eventListener interface {
-	onStarting()
+	onStarting() error
 	onStopped()
 }
 
-func (env *Environment) SetStarting() {
+// SetStarting prepares the environment and starts managed objects. It
+// returns an error if the lifecycle fails to start in fail-fast mode.
+func (env *Environment) SetStarting() error {
 	for i := range env.eventListeners {
-		env.eventListeners[i].onStarting()
+		if err := env.eventListeners[i].onStarting(); err != nil {
+			return err
+		}
 	}
+	return nil
+}
+
+// StartLifecycle starts managed objects only, without registering handlers
+// of the server and admin environments. It is used when the application is
+// not served, e.g. in one-shot commands. SetStopped must still be called.
+func (env *Environment) StartLifecycle() error {
+	return env.Lifecycle.onStarting()
 }
 
 func (env *Environment) SetStopped() {
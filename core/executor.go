@@ -0,0 +1,175 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/goburrow/gomelon/metrics/registry"
+)
+
+const executorMetricPrefix = "Executor."
+
+var (
+	// ErrExecutorFull is returned when the queue of the executor is full.
+	ErrExecutorFull = errors.New("core: executor queue is full")
+	// ErrExecutorStopped is returned when the executor has been stopped.
+	ErrExecutorStopped = errors.New("core: executor is stopped")
+)
+
+// Executor runs tasks in a bounded pool of goroutines. Submitted tasks are
+// queued until a worker is available. On stop, queued tasks are drained
+// before the executor returns.
+//
+// Executors created by Environment.NewExecutor publish metrics
+// "Executor.<name>.Queued", "Executor.<name>.Active",
+// "Executor.<name>.Rejected" and "Executor.<name>.Duration".
+type Executor struct {
+	name    string
+	workers int
+	tasks   chan func()
+	active  int64
+	wg      sync.WaitGroup
+
+	mu       sync.RWMutex
+	started  bool
+	stopped  bool
+	rejected *registry.Counter
+	duration *registry.Timer
+}
+
+var _ ManagedContext = (*Executor)(nil)
+
+// NewExecutor allocates and returns a new Executor with the given number of
+// workers and queue size. The executor must be started before tasks are run.
+func NewExecutor(name string, workers, queueSize int) *Executor {
+	if workers < 1 {
+		workers = 1
+	}
+	if queueSize < 0 {
+		queueSize = 0
+	}
+	return &Executor{
+		name:     name,
+		workers:  workers,
+		tasks:    make(chan func(), queueSize),
+		rejected: &registry.Counter{},
+		duration: registry.NewTimer(),
+	}
+}
+
+// NewExecutor creates an executor which is started and stopped with the
+// application. The executor is stopped after the server no longer accepts
+// requests, waiting for queued tasks up to the lifecycle stop timeout.
+// Names must be unique within the environment.
+func (env *Environment) NewExecutor(name string, workers, queueSize int) *Executor {
+	e := NewExecutor(name, workers, queueSize)
+	e.registerMetrics(env.Metrics)
+	env.Lifecycle.ManageContext(e, PhaseService)
+	return e
+}
+
+func (e *Executor) registerMetrics(r *registry.Registry) {
+	prefix := executorMetricPrefix + e.name
+	r.Gauge(prefix + ".Queued").SetFunc(func() int64 {
+		return int64(e.Queued())
+	})
+	r.Gauge(prefix + ".Active").SetFunc(e.Active)
+	e.rejected = r.Counter(prefix + ".Rejected")
+	e.duration = r.Timer(prefix + ".Duration")
+}
+
+// Name returns name of the executor.
+func (e *Executor) Name() string {
+	return e.name
+}
+
+// Submit queues the task without blocking. It returns ErrExecutorFull when
+// the queue is full or ErrExecutorStopped after the executor is stopped.
+func (e *Executor) Submit(task func()) error {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.stopped {
+		e.rejected.Inc()
+		return ErrExecutorStopped
+	}
+	select {
+	case e.tasks <- task:
+		return nil
+	default:
+		e.rejected.Inc()
+		return ErrExecutorFull
+	}
+}
+
+// Queued returns number of tasks waiting for a worker.
+func (e *Executor) Queued() int {
+	return len(e.tasks)
+}
+
+// Active returns number of tasks being run.
+func (e *Executor) Active() int64 {
+	return atomic.LoadInt64(&e.active)
+}
+
+// Start starts the workers.
+func (e *Executor) Start(ctx context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.started || e.stopped {
+		return nil
+	}
+	e.started = true
+	e.wg.Add(e.workers)
+	for i := 0; i < e.workers; i++ {
+		go e.run()
+	}
+	return nil
+}
+
+// Stop rejects new tasks and waits until queued tasks are completed or ctx
+// is done.
+func (e *Executor) Stop(ctx context.Context) error {
+	e.mu.Lock()
+	if e.stopped {
+		e.mu.Unlock()
+		return nil
+	}
+	e.stopped = true
+	close(e.tasks)
+	e.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		e.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (e *Executor) run() {
+	defer e.wg.Done()
+	for task := range e.tasks {
+		e.runTask(task)
+	}
+}
+
+func (e *Executor) runTask(task func()) {
+	atomic.AddInt64(&e.active, 1)
+	start := time.Now()
+	defer func() {
+		if r := recover(); r != nil {
+			lifecycleLogger.Error("panic in executor %s: %v", e.name, r)
+		}
+		e.duration.UpdateSince(start)
+		atomic.AddInt64(&e.active, -1)
+	}()
+	task()
+}
@@ -0,0 +1,61 @@
+package core
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/goburrow/gomelon/metrics/registry"
+)
+
+func TestExecutorDrain(t *testing.T) {
+	env := NewEnvironment()
+	e := env.NewExecutor("test", 2, 10)
+	var count int64
+	for i := 0; i < 10; i++ {
+		err := e.Submit(func() {
+			time.Sleep(10 * time.Millisecond)
+			atomic.AddInt64(&count, 1)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if e.Submit(func() {}) != ErrExecutorFull {
+		t.Fatal("expected executor full")
+	}
+	if err := e.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if atomic.LoadInt64(&count) != 10 {
+		t.Fatalf("unexpected completed tasks: %d", count)
+	}
+	if e.Submit(func() {}) != ErrExecutorStopped {
+		t.Fatal("expected executor stopped")
+	}
+	if env.Metrics.Get("Executor.test.Rejected").(*registry.Counter).Count() != 2 {
+		t.Fatal("unexpected rejected count")
+	}
+	if env.Metrics.Get("Executor.test.Duration").(*registry.Timer).Count() != 10 {
+		t.Fatal("unexpected duration count")
+	}
+}
+
+func TestExecutorStopTimeout(t *testing.T) {
+	e := NewExecutor("test", 1, 1)
+	release := make(chan struct{})
+	defer close(release)
+	e.Start(context.Background())
+	e.Submit(func() {
+		<-release
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := e.Stop(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
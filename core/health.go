@@ -0,0 +1,342 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/goburrow/gomelon/metrics/registry"
+	"github.com/goburrow/health"
+)
+
+const (
+	healthCheckMetricPrefix = "HealthCheck."
+)
+
+// HealthCheckFactory is a factory for configuring health checks for the
+// environment.
+type HealthCheckFactory interface {
+	Configure(*Environment) error
+}
+
+// HealthCheckSeverity indicates impact of a failing health check.
+type HealthCheckSeverity int
+
+const (
+	// HealthCheckCritical failures make the application unhealthy.
+	HealthCheckCritical HealthCheckSeverity = iota
+	// HealthCheckNonCritical failures only report the application as
+	// degraded.
+	HealthCheckNonCritical
+)
+
+func (s HealthCheckSeverity) String() string {
+	if s == HealthCheckNonCritical {
+		return "non-critical"
+	}
+	return "critical"
+}
+
+// HealthCheckOptions controls how a health check is run.
+type HealthCheckOptions struct {
+	// Period enables running the check asynchronously in background at the
+	// given interval. The latest result is reported instead of running the
+	// check on every request.
+	Period time.Duration
+	// Timeout is the maximum duration of the check, after which it is
+	// reported as unhealthy. The registry timeout is used if it is zero.
+	Timeout time.Duration
+	// Severity is critical by default.
+	Severity HealthCheckSeverity
+}
+
+// HealthCheckResult is the result of a health check and the time it was
+// produced.
+type HealthCheckResult struct {
+	health.Result
+	Timestamp time.Time
+	Severity  HealthCheckSeverity
+}
+
+// isHealthy returns false if any critical health check is unhealthy.
+func isHealthy(results map[string]*HealthCheckResult) bool {
+	for _, result := range results {
+		if !result.Healthy() && result.Severity == HealthCheckCritical {
+			return false
+		}
+	}
+	return true
+}
+
+// HealthCheckRegistry is a health.Registry which supports asynchronous
+// health checks. Asynchronous checks are only scheduled while the admin
+// environment is running.
+type HealthCheckRegistry struct {
+	mu      sync.RWMutex
+	checks  map[string]*healthCheck
+	running bool
+	timeout int64
+	metrics *registry.Registry
+}
+
+var _ health.Registry = (*HealthCheckRegistry)(nil)
+
+// NewHealthCheckRegistry allocates and returns a new HealthCheckRegistry.
+func NewHealthCheckRegistry() *HealthCheckRegistry {
+	return &HealthCheckRegistry{
+		checks: make(map[string]*healthCheck),
+	}
+}
+
+// SetTimeout sets the default timeout of health checks. Zero means no
+// timeout.
+func (r *HealthCheckRegistry) SetTimeout(timeout time.Duration) {
+	atomic.StoreInt64(&r.timeout, int64(timeout))
+}
+
+// setMetrics makes the registry publish status and duration of each health
+// check as metrics "HealthCheck.<name>.Healthy" and
+// "HealthCheck.<name>.Duration". It must be called before registering
+// health checks.
+func (r *HealthCheckRegistry) setMetrics(metrics *registry.Registry) {
+	r.mu.Lock()
+	r.metrics = metrics
+	r.mu.Unlock()
+}
+
+// Register adds a health check which is run on every request.
+func (r *HealthCheckRegistry) Register(name string, checker health.Checker) {
+	r.RegisterWithOptions(name, checker, HealthCheckOptions{})
+}
+
+// RegisterWithOptions adds a health check with the given options,
+// replacing the existing one with the same name.
+func (r *HealthCheckRegistry) RegisterWithOptions(name string, checker health.Checker, options HealthCheckOptions) {
+	check := &healthCheck{
+		registry: r,
+		checker:  checker,
+		options:  options,
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.metrics != nil {
+		check.healthy = r.metrics.Gauge(healthCheckMetricPrefix + name + ".Healthy")
+		check.duration = r.metrics.Timer(healthCheckMetricPrefix + name + ".Duration")
+	}
+	if old, ok := r.checks[name]; ok {
+		old.stop()
+	}
+	r.checks[name] = check
+	if r.running {
+		check.start()
+	}
+}
+
+// Unregister removes the health check with the given name.
+func (r *HealthCheckRegistry) Unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if check, ok := r.checks[name]; ok {
+		check.stop()
+		delete(r.checks, name)
+		if r.metrics != nil {
+			r.metrics.Remove(healthCheckMetricPrefix + name + ".Healthy")
+			r.metrics.Remove(healthCheckMetricPrefix + name + ".Duration")
+		}
+	}
+}
+
+// Names returns sorted names of all health checks.
+func (r *HealthCheckRegistry) Names() []string {
+	r.mu.RLock()
+	names := make([]string, 0, len(r.checks))
+	for name := range r.checks {
+		names = append(names, name)
+	}
+	r.mu.RUnlock()
+	sort.Strings(names)
+	return names
+}
+
+// RunHealthChecks returns results of all health checks.
+func (r *HealthCheckRegistry) RunHealthChecks() map[string]health.Result {
+	results := r.Results()
+	m := make(map[string]health.Result, len(results))
+	for name, result := range results {
+		m[name] = result.Result
+	}
+	return m
+}
+
+// Results returns results of the health checks with the given names, or all
+// health checks if no names are given, with their timestamps. Unknown names
+// are ignored. Health checks are run concurrently.
+func (r *HealthCheckRegistry) Results(names ...string) map[string]*HealthCheckResult {
+	r.mu.RLock()
+	checks := make(map[string]*healthCheck, len(r.checks))
+	if len(names) == 0 {
+		for name, check := range r.checks {
+			checks[name] = check
+		}
+	} else {
+		for _, name := range names {
+			if check, ok := r.checks[name]; ok {
+				checks[name] = check
+			}
+		}
+	}
+	r.mu.RUnlock()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	results := make(map[string]*HealthCheckResult, len(checks))
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check *healthCheck) {
+			defer wg.Done()
+			result := check.result()
+			mu.Lock()
+			results[name] = result
+			mu.Unlock()
+		}(name, check)
+	}
+	wg.Wait()
+	return results
+}
+
+// start schedules asynchronous health checks.
+func (r *HealthCheckRegistry) start() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.running = true
+	for _, check := range r.checks {
+		check.start()
+	}
+}
+
+// stop cancels all scheduled health checks.
+func (r *HealthCheckRegistry) stop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.running = false
+	for _, check := range r.checks {
+		check.stop()
+	}
+}
+
+type healthCheck struct {
+	registry *HealthCheckRegistry
+	checker  health.Checker
+	options  HealthCheckOptions
+
+	healthy  *registry.Gauge
+	duration *registry.Timer
+
+	mu     sync.Mutex
+	cached *HealthCheckResult
+	done   chan struct{}
+}
+
+// result returns the latest result of an asynchronous check, or runs the
+// check if it is synchronous or has not been run yet.
+func (c *healthCheck) result() *HealthCheckResult {
+	if c.options.Period > 0 {
+		c.mu.Lock()
+		cached := c.cached
+		c.mu.Unlock()
+		if cached != nil {
+			return cached
+		}
+	}
+	return c.run()
+}
+
+func (c *healthCheck) run() *HealthCheckResult {
+	start := time.Now()
+	result := &HealthCheckResult{
+		Result:    c.check(),
+		Timestamp: time.Now(),
+		Severity:  c.options.Severity,
+	}
+	if c.healthy != nil {
+		c.duration.Update(result.Timestamp.Sub(start))
+		if result.Healthy() {
+			c.healthy.Set(1)
+		} else {
+			c.healthy.Set(0)
+		}
+	}
+	if c.options.Period > 0 {
+		c.mu.Lock()
+		c.cached = result
+		c.mu.Unlock()
+	}
+	return result
+}
+
+// check runs the checker within the timeout.
+func (c *healthCheck) check() health.Result {
+	timeout := c.options.Timeout
+	if timeout <= 0 {
+		timeout = time.Duration(atomic.LoadInt64(&c.registry.timeout))
+	}
+	if timeout <= 0 {
+		return checkSafely(c.checker)
+	}
+	resultChan := make(chan health.Result, 1)
+	go func() {
+		resultChan <- checkSafely(c.checker)
+	}()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case result := <-resultChan:
+		return result
+	case <-timer.C:
+		return health.ResultUnhealthy(fmt.Sprintf("timed out after %v", timeout), nil)
+	}
+}
+
+// checkSafely runs the checker, reporting a panic as an unhealthy result as
+// checks are run in their own goroutines.
+func checkSafely(checker health.Checker) (result health.Result) {
+	defer func() {
+		if r := recover(); r != nil {
+			result = health.ResultUnhealthy(fmt.Sprintf("panic: %v", r), nil)
+		}
+	}()
+	return checker.Check()
+}
+
+func (c *healthCheck) start() {
+	if c.options.Period <= 0 || c.done != nil {
+		return
+	}
+	c.done = make(chan struct{})
+	go c.schedule(c.done)
+}
+
+func (c *healthCheck) stop() {
+	if c.done != nil {
+		close(c.done)
+		c.done = nil
+	}
+}
+
+func (c *healthCheck) schedule(done chan struct{}) {
+	ticker := time.NewTicker(c.options.Period)
+	defer ticker.Stop()
+	// A panicking checker is recorded as unhealthy by checkSafely, so it
+	// does not stop the schedule or crash the application.
+	c.run()
+	for {
+		select {
+		case <-ticker.C:
+			c.run()
+		case <-done:
+			return
+		}
+	}
+}
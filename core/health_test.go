@@ -0,0 +1,179 @@
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/goburrow/gomelon/metrics/registry"
+	"github.com/goburrow/health"
+)
+
+func TestHealthCheckRegistry(t *testing.T) {
+	registry := NewHealthCheckRegistry()
+	var count int32
+	checker := health.CheckerFunc(func() health.Result {
+		atomic.AddInt32(&count, 1)
+		return health.ResultHealthy("ok")
+	})
+	registry.Register("sync", checker)
+	registry.RegisterWithOptions("async", checker, HealthCheckOptions{Period: time.Hour})
+	if names := registry.Names(); len(names) != 2 || names[0] != "async" || names[1] != "sync" {
+		t.Fatalf("unexpected names %v", names)
+	}
+	registry.start()
+	defer registry.stop()
+	// Wait for the first scheduled run.
+	for i := 0; i < 100 && atomic.LoadInt32(&count) == 0; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	results := registry.Results()
+	if len(results) != 2 || !results["sync"].Healthy() || !results["async"].Healthy() {
+		t.Fatalf("unexpected results %v", results)
+	}
+	if results["async"].Timestamp.IsZero() {
+		t.Fatalf("unexpected timestamp %v", results["async"].Timestamp)
+	}
+	// Async check is cached.
+	registry.RunHealthChecks()
+	if c := atomic.LoadInt32(&count); c != 3 {
+		t.Fatalf("unexpected number of checks %d", c)
+	}
+	registry.Unregister("async")
+	if names := registry.Names(); len(names) != 1 {
+		t.Fatalf("unexpected names %v", names)
+	}
+}
+
+func TestHealthCheckTimeout(t *testing.T) {
+	registry := NewHealthCheckRegistry()
+	registry.SetTimeout(time.Hour)
+	done := make(chan struct{})
+	defer close(done)
+	registry.RegisterWithOptions("slow", health.CheckerFunc(func() health.Result {
+		<-done
+		return health.ResultHealthy("ok")
+	}), HealthCheckOptions{Timeout: 10 * time.Millisecond})
+	registry.Register("fast", health.CheckerFunc(func() health.Result {
+		return health.ResultHealthy("ok")
+	}))
+	results := registry.Results()
+	if results["slow"].Healthy() || results["slow"].Message() != "timed out after 10ms" {
+		t.Fatalf("unexpected result %+v", results["slow"])
+	}
+	if !results["fast"].Healthy() {
+		t.Fatalf("unexpected result %+v", results["fast"])
+	}
+}
+
+func TestHealthCheckPanic(t *testing.T) {
+	registry := NewHealthCheckRegistry()
+	panicking := health.CheckerFunc(func() health.Result {
+		panic("check")
+	})
+	registry.Register("panic", panicking)
+	registry.RegisterWithOptions("panicWithTimeout", panicking, HealthCheckOptions{Timeout: time.Second})
+	results := registry.Results()
+	for _, name := range []string{"panic", "panicWithTimeout"} {
+		if results[name].Healthy() || results[name].Message() != "panic: check" {
+			t.Fatalf("unexpected result of %s: %+v", name, results[name])
+		}
+	}
+}
+
+func TestScheduledHealthCheckPanic(t *testing.T) {
+	registry := NewHealthCheckRegistry()
+	var count int32
+	registry.RegisterWithOptions("panic", health.CheckerFunc(func() health.Result {
+		atomic.AddInt32(&count, 1)
+		panic("check")
+	}), HealthCheckOptions{Period: time.Millisecond})
+	registry.start()
+	defer registry.stop()
+	for i := 0; i < 100 && atomic.LoadInt32(&count) < 2; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	if atomic.LoadInt32(&count) < 2 {
+		t.Fatal("scheduled check is not run again after panic")
+	}
+	result := registry.Results()["panic"]
+	if result.Healthy() || result.Message() != "panic: check" || result.Timestamp.IsZero() {
+		t.Fatalf("unexpected result %+v", result)
+	}
+}
+
+func TestHealthCheckSeverity(t *testing.T) {
+	registry := NewHealthCheckRegistry()
+	unhealthy := health.CheckerFunc(func() health.Result {
+		return health.ResultUnhealthy("down", nil)
+	})
+	registry.RegisterWithOptions("cache", unhealthy, HealthCheckOptions{Severity: HealthCheckNonCritical})
+
+	handler := &healthCheckHandler{registry}
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", healthCheckURI, nil)
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"Severity": "non-critical"`) {
+		t.Fatalf("unexpected response %v %s", w.Code, w.Body.String())
+	}
+
+	registry.Register("database", unhealthy)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("unexpected response %v %s", w.Code, w.Body.String())
+	}
+}
+
+func TestHealthCheckHandlerFilter(t *testing.T) {
+	registry := NewHealthCheckRegistry()
+	registry.Register("database", health.CheckerFunc(func() health.Result {
+		return health.ResultHealthy("ok")
+	}))
+	registry.Register("remote", health.CheckerFunc(func() health.Result {
+		return health.ResultUnhealthy("down", nil)
+	}))
+	handler := &healthCheckHandler{registry}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", healthCheckURI+"?name=database", nil)
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusOK || strings.Contains(w.Body.String(), "remote") {
+		t.Fatalf("unexpected response %v %s", w.Code, w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	r, _ = http.NewRequest("GET", healthCheckURI+"?name=database&name=none", nil)
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("unexpected response %v %s", w.Code, w.Body.String())
+	}
+}
+
+func TestHealthCheckMetrics(t *testing.T) {
+	metrics := registry.NewRegistry()
+	r := NewHealthCheckRegistry()
+	r.setMetrics(metrics)
+	healthy := true
+	r.Register("test", health.CheckerFunc(func() health.Result {
+		if healthy {
+			return health.Healthy
+		}
+		return health.ResultUnhealthy("failed", nil)
+	}))
+	r.Results()
+	if metrics.Gauge("HealthCheck.test.Healthy").Value() != 1 || metrics.Timer("HealthCheck.test.Duration").Count() != 1 {
+		t.Fatalf("unexpected metrics %v", metrics.Names())
+	}
+	healthy = false
+	r.Results()
+	if metrics.Gauge("HealthCheck.test.Healthy").Value() != 0 {
+		t.Fatalf("unexpected metrics %v", metrics.Names())
+	}
+	r.Unregister("test")
+	if names := metrics.Names(); len(names) != 0 {
+		t.Fatalf("unexpected metrics %v", names)
+	}
+}
@@ -0,0 +1,95 @@
+package core
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"time"
+)
+
+const (
+	infoURI = "/info"
+)
+
+// Build information of the application which can be set at link time, e.g.
+//
+//	go build -ldflags "-X github.com/goburrow/gomelon/core.Version=1.0.0"
+var (
+	Version   string
+	Commit    string
+	BuildTime string
+)
+
+// startTime is the time when the process started.
+var startTime = time.Now()
+
+// BuildInfo contains version information of the application.
+type BuildInfo struct {
+	Version   string
+	Commit    string
+	BuildTime string
+}
+
+// BuildInfoProvider is optionally implemented by Application to provide its
+// build information. Empty fields are taken from the link time variables.
+type BuildInfoProvider interface {
+	BuildInfo() BuildInfo
+}
+
+// defaultBuildInfo returns build information set at link time.
+func defaultBuildInfo() BuildInfo {
+	return BuildInfo{
+		Version:   Version,
+		Commit:    Commit,
+		BuildTime: BuildTime,
+	}
+}
+
+// Merge overrides fields in info with non-empty fields in other.
+func (info *BuildInfo) Merge(other BuildInfo) {
+	if other.Version != "" {
+		info.Version = other.Version
+	}
+	if other.Commit != "" {
+		info.Commit = other.Commit
+	}
+	if other.BuildTime != "" {
+		info.BuildTime = other.BuildTime
+	}
+}
+
+// infoHandler displays name, build information and uptime of the application.
+type infoHandler struct {
+	env *Environment
+}
+
+func (handler *infoHandler) Name() string {
+	return "Info"
+}
+
+func (handler *infoHandler) Path() string {
+	return infoURI
+}
+
+func (handler *infoHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	info := struct {
+		Name      string
+		Version   string `json:",omitempty"`
+		Commit    string `json:",omitempty"`
+		BuildTime string `json:",omitempty"`
+		GoVersion string
+		StartTime string
+		Uptime    string
+	}{
+		Name:      handler.env.Name,
+		Version:   handler.env.BuildInfo.Version,
+		Commit:    handler.env.BuildInfo.Commit,
+		BuildTime: handler.env.BuildInfo.BuildTime,
+		GoVersion: runtime.Version(),
+		StartTime: startTime.Format(time.RFC3339),
+		Uptime:    time.Since(startTime).String(),
+	}
+	w.Header().Set("Cache-Control", "must-revalidate,no-cache,no-store")
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&info)
+}
@@ -1,15 +1,29 @@
 package core
 
 import (
-	"github.com/goburrow/gol"
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultStopTimeout is the default maximum duration of stopping a
+	// managed object.
+	DefaultStopTimeout = 30 * time.Second
+	// DefaultShutdownTimeout is the default maximum duration of stopping
+	// all managed objects.
+	DefaultShutdownTimeout = 2 * time.Minute
 )
 
 var (
-	lifecycleLogger gol.Logger
+	lifecycleLogger Logger
 )
 
 func init() {
-	lifecycleLogger = gol.GetLogger("gomelon/lifecycle")
+	lifecycleLogger = GetLogger("gomelon/lifecycle")
 }
 
 // Managed is an interface for objects which need to be started and stopped as
@@ -23,49 +37,305 @@ type Managed interface {
 	Stop() error
 }
 
+// ManagedContext is a managed object whose Stop can be cancelled when it
+// exceeds the stop timeout of the lifecycle.
+type ManagedContext interface {
+	Start(ctx context.Context) error
+	Stop(ctx context.Context) error
+}
+
+// managedAdapter adapts Managed to ManagedContext.
+type managedAdapter struct {
+	Managed
+}
+
+func (m managedAdapter) Start(context.Context) error {
+	return m.Managed.Start()
+}
+
+func (m managedAdapter) Stop(context.Context) error {
+	return m.Managed.Stop()
+}
+
+// managedObject returns the object given to the lifecycle.
+func managedObject(m ManagedContext) interface{} {
+	if a, ok := m.(managedAdapter); ok {
+		return a.Managed
+	}
+	return m
+}
+
+// Phase controls the order of managed objects across bundles. Objects in
+// lower phases are started before and stopped after those in higher phases.
+// Objects in the same phase are started in the order they are managed.
+type Phase int
+
+const (
+	// PhaseInfrastructure is for objects which others depend on, such as
+	// logging appenders and database pools.
+	PhaseInfrastructure Phase = -100
+	// PhaseDefault is the phase of objects managed without a phase.
+	PhaseDefault Phase = 0
+	// PhaseService is for objects which depend on others, such as message
+	// consumers and schedulers.
+	PhaseService Phase = 100
+)
+
+type phasedManaged struct {
+	ManagedContext
+	phase Phase
+}
+
+// LifecycleState is the state of the application lifecycle.
+type LifecycleState int32
+
+const (
+	// StateStarting is set before managed objects are started.
+	StateStarting LifecycleState = iota
+	// StateRunning is set after all managed objects have been started.
+	StateRunning
+	// StateStopping is set when the application is requested to shutdown.
+	StateStopping
+	// StateStopped is set after managed objects are stopped.
+	StateStopped
+)
+
+var lifecycleStateStrings = [...]string{"starting", "running", "stopping", "stopped"}
+
+func (s LifecycleState) String() string {
+	if s >= 0 && int(s) < len(lifecycleStateStrings) {
+		return lifecycleStateStrings[s]
+	}
+	return "unknown"
+}
+
 type LifecycleEnvironment struct {
-	managedObjects []Managed
+	// StopTimeout is the maximum duration to wait for each managed object
+	// to stop. Zero means no timeout.
+	StopTimeout time.Duration
+	// ShutdownTimeout is the maximum duration to wait for all managed
+	// objects to stop. Remaining objects are not stopped after the timeout.
+	// Zero means no timeout.
+	ShutdownTimeout time.Duration
+	// FailFast aborts starting when a managed object fails to start:
+	// objects already started are stopped in reverse order and the error
+	// is returned to the server command. Otherwise the error is only
+	// logged. It is enabled by default.
+	FailFast bool
+
+	managedObjects []phasedManaged
+	listeners      []LifecycleListener
+	state          int32
+	// aborted is set when starting has failed and started objects have
+	// been stopped.
+	aborted bool
+
+	shutdownMu      sync.Mutex
+	shutdownHandler func()
+
+	reloadMu    sync.Mutex
+	reloadHooks []func() error
 }
 
 // NewLifecycleEnvironment allocates and returns a new LifecycleEnvironment.
 func NewLifecycleEnvironment() *LifecycleEnvironment {
-	return &LifecycleEnvironment{}
+	return &LifecycleEnvironment{
+		StopTimeout:     DefaultStopTimeout,
+		ShutdownTimeout: DefaultShutdownTimeout,
+		FailFast:        true,
+	}
 }
 
 // Manage adds the given object to the list of objects managed by the server's
-// lifecycle. Manage is not concurrent-safe.
-func (env *LifecycleEnvironment) Manage(obj Managed) {
-	env.managedObjects = append(env.managedObjects, obj)
+// lifecycle in the given phase, or PhaseDefault if it is omitted.
+// Manage is not concurrent-safe.
+func (env *LifecycleEnvironment) Manage(obj Managed, phase ...Phase) {
+	env.ManageContext(managedAdapter{obj}, phase...)
+}
+
+// ManageContext is similar to Manage but Stop of the given object receives
+// a context which is cancelled after the stop timeout.
+func (env *LifecycleEnvironment) ManageContext(obj ManagedContext, phase ...Phase) {
+	m := phasedManaged{ManagedContext: obj, phase: PhaseDefault}
+	if len(phase) > 0 {
+		m.phase = phase[0]
+	}
+	env.managedObjects = append(env.managedObjects, m)
+}
+
+// State returns current state of the lifecycle.
+func (env *LifecycleEnvironment) State() LifecycleState {
+	return LifecycleState(atomic.LoadInt32(&env.state))
 }
 
-// onStarting indicates the application is going to start.
-func (env *LifecycleEnvironment) onStarting() {
-	// Starting managed objects in order.
-	for _, m := range env.managedObjects {
+func (env *LifecycleEnvironment) setState(state LifecycleState) {
+	atomic.StoreInt32(&env.state, int32(state))
+}
+
+// SetShutdownHandler sets the function which stops the application
+// gracefully. It is usually set by the command running the server.
+func (env *LifecycleEnvironment) SetShutdownHandler(f func()) {
+	env.shutdownMu.Lock()
+	env.shutdownHandler = f
+	env.shutdownMu.Unlock()
+}
+
+// Shutdown requests the application to stop gracefully. It returns false if
+// no shutdown handler has been set.
+func (env *LifecycleEnvironment) Shutdown() bool {
+	env.shutdownMu.Lock()
+	f := env.shutdownHandler
+	env.shutdownMu.Unlock()
+	if f == nil {
+		return false
+	}
+	lifecycleLogger.Info("shutting down")
+	env.setStopping()
+	f()
+	return true
+}
+
+// AddReloadHook adds a function called when the application is requested
+// to reload, e.g. to re-read configuration or reopen log files. The server
+// command reloads on SIGHUP.
+func (env *LifecycleEnvironment) AddReloadHook(f func() error) {
+	env.reloadMu.Lock()
+	env.reloadHooks = append(env.reloadHooks, f)
+	env.reloadMu.Unlock()
+}
+
+// Reload calls all reload hooks in order and returns the first error.
+// Remaining hooks are still called when one fails.
+func (env *LifecycleEnvironment) Reload() error {
+	env.reloadMu.Lock()
+	hooks := env.reloadHooks
+	env.reloadMu.Unlock()
+	lifecycleLogger.Info("reloading")
+	var firstErr error
+	for _, f := range hooks {
+		if err := f(); err != nil {
+			lifecycleLogger.Error("error reloading: %v", err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+// setStopping changes state to stopping and notifies listeners if it has
+// not been stopping or stopped.
+func (env *LifecycleEnvironment) setStopping() {
+	for {
+		state := atomic.LoadInt32(&env.state)
+		if LifecycleState(state) == StateStopping || LifecycleState(state) == StateStopped {
+			return
+		}
+		if atomic.CompareAndSwapInt32(&env.state, state, int32(StateStopping)) {
+			env.notify(&LifecycleEvent{Type: LifecycleStopping})
+			return
+		}
+	}
+}
+
+// onStarting indicates the application is going to start. It returns the
+// error of the managed object failing to start in fail-fast mode.
+func (env *LifecycleEnvironment) onStarting() error {
+	env.setState(StateStarting)
+	env.notify(&LifecycleEvent{Type: LifecycleStarting})
+	// Starting managed objects in order of phases then registration.
+	sort.SliceStable(env.managedObjects, func(i, j int) bool {
+		return env.managedObjects[i].phase < env.managedObjects[j].phase
+	})
+	ctx := context.Background()
+	for i, m := range env.managedObjects {
+		obj := managedObject(m.ManagedContext)
+		env.notify(&LifecycleEvent{Type: ManagedStarting, Object: obj})
 		// Panic from a managed object will stop the application.
-		if err := m.Start(); err != nil {
-			lifecycleLogger.Error("error starting managed object %#v: %v", m, err)
+		err := m.Start(ctx)
+		if err != nil {
+			lifecycleLogger.Error("error starting managed object %#v: %v", obj, err)
+		}
+		env.notify(&LifecycleEvent{Type: ManagedStarted, Object: obj, Err: err})
+		if err != nil && env.FailFast {
+			env.abort(env.managedObjects[:i])
+			return fmt.Errorf("core: could not start managed object %#v: %w", obj, err)
 		}
 	}
+	env.setState(StateRunning)
+	env.notify(&LifecycleEvent{Type: LifecycleStarted})
+	return nil
+}
+
+// abort stops the started objects when starting has failed.
+func (env *LifecycleEnvironment) abort(started []phasedManaged) {
+	lifecycleLogger.Error("aborting start, stopping %d managed objects", len(started))
+	env.aborted = true
+	env.setStopping()
+	env.stopManagedObjects(started)
 }
 
 // onStopped indicates the application has stopped.
 func (env *LifecycleEnvironment) onStopped() {
-	// Stopping managed objects in reversed order.
-	for i := len(env.managedObjects) - 1; i >= 0; i-- {
+	env.setStopping()
+	defer func() {
+		env.setState(StateStopped)
+		env.notify(&LifecycleEvent{Type: LifecycleStopped})
+	}()
+	if !env.aborted {
+		env.stopManagedObjects(env.managedObjects)
+	}
+}
+
+// stopManagedObjects stops objects in reversed order within the shutdown
+// timeout.
+func (env *LifecycleEnvironment) stopManagedObjects(objects []phasedManaged) {
+	ctx := context.Background()
+	if env.ShutdownTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, env.ShutdownTimeout)
+		defer cancel()
+	}
+	for i := len(objects) - 1; i >= 0; i-- {
+		m := objects[i].ManagedContext
+		if ctx.Err() != nil {
+			lifecycleLogger.Error("shutdown timed out after %v, not stopping managed object %#v", env.ShutdownTimeout, managedObject(m))
+			continue
+		}
 		// Panic from a managed object will NOT stop the application immediately.
-		stopManagedObject(env.managedObjects[i])
+		env.stopManagedObject(ctx, m)
 	}
 }
 
-func stopManagedObject(m Managed) {
+// stopManagedObject waits until m is stopped or the stop timeout exceeds.
+func (env *LifecycleEnvironment) stopManagedObject(ctx context.Context, m ManagedContext) {
+	obj := managedObject(m)
+	env.notify(&LifecycleEvent{Type: ManagedStopping, Object: obj})
 	var err error
 	defer func() {
+		env.notify(&LifecycleEvent{Type: ManagedStopped, Object: obj, Err: err})
+	}()
+	if env.StopTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, env.StopTimeout)
+		defer cancel()
+	}
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("panic: %v", r)
+			}
+		}()
+		done <- m.Stop(ctx)
+	}()
+	select {
+	case err = <-done:
 		if err != nil {
-			lifecycleLogger.Error("error stopping managed object %#v: %v", m, err)
-		} else if r := recover(); r != nil {
-			lifecycleLogger.Error("panic stopping managed object %#v: %v", m, r)
+			lifecycleLogger.Error("error stopping managed object %#v: %v", obj, err)
 		}
-	}()
-	err = m.Stop()
+	case <-ctx.Done():
+		err = ctx.Err()
+		lifecycleLogger.Error("timed out stopping managed object %#v: %v", obj, err)
+	}
 }
@@ -0,0 +1,81 @@
+package core
+
+// LifecycleEventType is the type of lifecycle events.
+type LifecycleEventType int
+
+const (
+	// LifecycleStarting is sent before managed objects are started.
+	LifecycleStarting LifecycleEventType = iota
+	// LifecycleStarted is sent after all managed objects are started.
+	LifecycleStarted
+	// LifecycleStopping is sent when the application is requested to stop,
+	// before it stops accepting requests if possible.
+	LifecycleStopping
+	// LifecycleStopped is sent after all managed objects are stopped.
+	LifecycleStopped
+	// ManagedStarting is sent before a managed object is started.
+	ManagedStarting
+	// ManagedStarted is sent after a managed object is started.
+	ManagedStarted
+	// ManagedStopping is sent before a managed object is stopped.
+	ManagedStopping
+	// ManagedStopped is sent after a managed object is stopped.
+	ManagedStopped
+)
+
+var lifecycleEventTypeStrings = [...]string{
+	"lifecycle-starting", "lifecycle-started", "lifecycle-stopping", "lifecycle-stopped",
+	"managed-starting", "managed-started", "managed-stopping", "managed-stopped",
+}
+
+func (t LifecycleEventType) String() string {
+	if t >= 0 && int(t) < len(lifecycleEventTypeStrings) {
+		return lifecycleEventTypeStrings[t]
+	}
+	return "unknown"
+}
+
+// LifecycleEvent is a state transition of the lifecycle or a managed object.
+type LifecycleEvent struct {
+	Type LifecycleEventType
+	// Object is the managed object of Managed* events.
+	Object interface{}
+	// Err is the error of starting or stopping the managed object in
+	// ManagedStarted and ManagedStopped events.
+	Err error
+}
+
+// LifecycleListener receives lifecycle events.
+type LifecycleListener interface {
+	LifecycleChanged(event *LifecycleEvent)
+}
+
+// LifecycleListenerFunc is an adapter to allow the use of ordinary
+// functions as LifecycleListener.
+type LifecycleListenerFunc func(event *LifecycleEvent)
+
+// LifecycleChanged calls f(event).
+func (f LifecycleListenerFunc) LifecycleChanged(event *LifecycleEvent) {
+	f(event)
+}
+
+// AddListener adds the listener which is called synchronously on lifecycle
+// events. AddListener is not concurrent-safe.
+func (env *LifecycleEnvironment) AddListener(listener LifecycleListener) {
+	env.listeners = append(env.listeners, listener)
+}
+
+func (env *LifecycleEnvironment) notify(event *LifecycleEvent) {
+	for _, listener := range env.listeners {
+		notifyListener(listener, event)
+	}
+}
+
+func notifyListener(listener LifecycleListener, event *LifecycleEvent) {
+	defer func() {
+		if r := recover(); r != nil {
+			lifecycleLogger.Error("panic in lifecycle listener %#v on %v: %v", listener, event.Type, r)
+		}
+	}()
+	listener.LifecycleChanged(event)
+}
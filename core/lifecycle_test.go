@@ -2,8 +2,12 @@ package core
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"io"
+	"strings"
 	"testing"
+	"time"
 )
 
 type writerManaged struct {
@@ -61,3 +65,170 @@ func TestPanicManagedObject(t *testing.T) {
 		t.Fatal("unexpected stopping order %s", buf.String())
 	}
 }
+
+type blockingManaged struct {
+	stopped chan struct{}
+}
+
+func (m *blockingManaged) Start(ctx context.Context) error {
+	return nil
+}
+
+func (m *blockingManaged) Stop(ctx context.Context) error {
+	<-ctx.Done()
+	close(m.stopped)
+	return ctx.Err()
+}
+
+func TestStopTimeout(t *testing.T) {
+	var buf bytes.Buffer
+	lifecycle := NewLifecycleEnvironment()
+	lifecycle.StopTimeout = 10 * time.Millisecond
+	lifecycle.Manage(&writerManaged{"1", &buf})
+	blocking := &blockingManaged{make(chan struct{})}
+	lifecycle.ManageContext(blocking)
+
+	lifecycle.onStopped()
+	if "1" != buf.String() {
+		t.Fatalf("unexpected stopping order %s", buf.String())
+	}
+	select {
+	case <-blocking.stopped:
+	case <-time.After(time.Second):
+		t.Fatal("context is not cancelled")
+	}
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	var buf bytes.Buffer
+	lifecycle := NewLifecycleEnvironment()
+	lifecycle.StopTimeout = 0
+	lifecycle.ShutdownTimeout = 10 * time.Millisecond
+	lifecycle.Manage(&writerManaged{"1", &buf})
+	lifecycle.ManageContext(&blockingManaged{make(chan struct{})})
+
+	lifecycle.onStopped()
+	if buf.Len() != 0 {
+		t.Fatalf("managed object must not be stopped after timeout: %s", buf.String())
+	}
+	if lifecycle.State() != StateStopped {
+		t.Fatalf("unexpected state %v", lifecycle.State())
+	}
+}
+
+func TestLifecycleListener(t *testing.T) {
+	var buf bytes.Buffer
+	var events []string
+	lifecycle := NewLifecycleEnvironment()
+	m := &writerManaged{"1", &buf}
+	lifecycle.Manage(m)
+	lifecycle.AddListener(LifecycleListenerFunc(func(event *LifecycleEvent) {
+		if event.Object != nil && event.Object != m {
+			t.Errorf("unexpected object %#v", event.Object)
+		}
+		events = append(events, event.Type.String())
+	}))
+	lifecycle.onStarting()
+	lifecycle.SetShutdownHandler(func() {})
+	lifecycle.Shutdown()
+	lifecycle.onStopped()
+	expected := []string{
+		"lifecycle-starting", "managed-starting", "managed-started", "lifecycle-started",
+		"lifecycle-stopping", "managed-stopping", "managed-stopped", "lifecycle-stopped",
+	}
+	if len(events) != len(expected) {
+		t.Fatalf("unexpected events %v", events)
+	}
+	for i := range expected {
+		if events[i] != expected[i] {
+			t.Fatalf("unexpected events %v", events)
+		}
+	}
+}
+
+func TestManagePhase(t *testing.T) {
+	var buf bytes.Buffer
+	lifecycle := NewLifecycleEnvironment()
+	lifecycle.Manage(&writerManaged{"1", &buf}, PhaseService)
+	lifecycle.Manage(&writerManaged{"2", &buf})
+	lifecycle.Manage(&writerManaged{"3", &buf}, PhaseInfrastructure)
+	lifecycle.Manage(&writerManaged{"4", &buf})
+
+	lifecycle.onStarting()
+	if "3241" != buf.String() {
+		t.Fatalf("unexpected starting order %s", buf.String())
+	}
+	buf.Reset()
+	lifecycle.onStopped()
+	if "1423" != buf.String() {
+		t.Fatalf("unexpected stopping order %s", buf.String())
+	}
+}
+
+func TestReload(t *testing.T) {
+	lifecycle := NewLifecycleEnvironment()
+	var calls []int
+	lifecycle.AddReloadHook(func() error {
+		calls = append(calls, 1)
+		return errors.New("reload")
+	})
+	lifecycle.AddReloadHook(func() error {
+		calls = append(calls, 2)
+		return nil
+	})
+	if err := lifecycle.Reload(); err == nil || err.Error() != "reload" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(calls) != 2 || calls[0] != 1 || calls[1] != 2 {
+		t.Fatalf("unexpected calls: %v", calls)
+	}
+}
+
+type failingManaged struct {
+	writerManaged
+}
+
+func (m *failingManaged) Start() error {
+	m.writerManaged.Start()
+	return errors.New("start")
+}
+
+func TestFailFast(t *testing.T) {
+	var buf bytes.Buffer
+	lifecycle := NewLifecycleEnvironment()
+	lifecycle.Manage(&writerManaged{"1", &buf})
+	lifecycle.Manage(&writerManaged{"2", &buf})
+	lifecycle.Manage(&failingManaged{writerManaged{"3", &buf}})
+	lifecycle.Manage(&writerManaged{"4", &buf})
+
+	err := lifecycle.onStarting()
+	if err == nil || !strings.Contains(err.Error(), "start") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if "12321" != buf.String() {
+		t.Fatalf("unexpected order %s", buf.String())
+	}
+	if lifecycle.State() != StateStopping {
+		t.Fatalf("unexpected state %v", lifecycle.State())
+	}
+	// Objects are not stopped again.
+	buf.Reset()
+	lifecycle.onStopped()
+	if buf.Len() != 0 || lifecycle.State() != StateStopped {
+		t.Fatalf("unexpected stopping %s %v", buf.String(), lifecycle.State())
+	}
+}
+
+func TestFailFastDisabled(t *testing.T) {
+	var buf bytes.Buffer
+	lifecycle := NewLifecycleEnvironment()
+	lifecycle.FailFast = false
+	lifecycle.Manage(&failingManaged{writerManaged{"1", &buf}})
+	lifecycle.Manage(&writerManaged{"2", &buf})
+	if err := lifecycle.onStarting(); err != nil {
+		t.Fatal(err)
+	}
+	if "12" != buf.String() || lifecycle.State() != StateRunning {
+		t.Fatalf("unexpected start %s %v", buf.String(), lifecycle.State())
+	}
+}
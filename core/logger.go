@@ -0,0 +1,77 @@
+package core
+
+import (
+	"sync/atomic"
+
+	"github.com/goburrow/gol"
+)
+
+// Logger is the logging interface used by gomelon. gol.Logger implements it.
+type Logger interface {
+	Debug(format string, args ...interface{})
+	Info(format string, args ...interface{})
+	Warn(format string, args ...interface{})
+	Error(format string, args ...interface{})
+	DebugEnabled() bool
+	InfoEnabled() bool
+}
+
+// LoggerFactory returns the logger with the given name.
+type LoggerFactory func(name string) Logger
+
+var loggerFactory atomic.Value
+
+func init() {
+	SetLoggerFactory(nil)
+}
+
+// SetLoggerFactory changes the logging backend of gomelon and applications
+// using GetLogger. Loggers of goburrow/gol are used if factory is nil.
+func SetLoggerFactory(factory LoggerFactory) {
+	if factory == nil {
+		factory = golLoggerFactory
+	}
+	loggerFactory.Store(factory)
+}
+
+func golLoggerFactory(name string) Logger {
+	return gol.GetLogger(name)
+}
+
+// GetLogger returns a logger with the given name. The logger is bound to
+// the backend set by SetLoggerFactory when it is used, so it can be
+// obtained before the backend is changed.
+func GetLogger(name string) Logger {
+	return namedLogger(name)
+}
+
+// namedLogger delegates to the logger of the current backend.
+type namedLogger string
+
+func (l namedLogger) logger() Logger {
+	return loggerFactory.Load().(LoggerFactory)(string(l))
+}
+
+func (l namedLogger) Debug(format string, args ...interface{}) {
+	l.logger().Debug(format, args...)
+}
+
+func (l namedLogger) Info(format string, args ...interface{}) {
+	l.logger().Info(format, args...)
+}
+
+func (l namedLogger) Warn(format string, args ...interface{}) {
+	l.logger().Warn(format, args...)
+}
+
+func (l namedLogger) Error(format string, args ...interface{}) {
+	l.logger().Error(format, args...)
+}
+
+func (l namedLogger) DebugEnabled() bool {
+	return l.logger().DebugEnabled()
+}
+
+func (l namedLogger) InfoEnabled() bool {
+	return l.logger().InfoEnabled()
+}
@@ -0,0 +1,38 @@
+package core
+
+import (
+	"fmt"
+	"testing"
+)
+
+type recordingLogger struct {
+	name     string
+	messages *[]string
+}
+
+func (l recordingLogger) record(level, format string, args []interface{}) {
+	*l.messages = append(*l.messages, level+" "+l.name+": "+fmt.Sprintf(format, args...))
+}
+
+func (l recordingLogger) Debug(format string, args ...interface{}) { l.record("DEBUG", format, args) }
+func (l recordingLogger) Info(format string, args ...interface{})  { l.record("INFO", format, args) }
+func (l recordingLogger) Warn(format string, args ...interface{})  { l.record("WARN", format, args) }
+func (l recordingLogger) Error(format string, args ...interface{}) { l.record("ERROR", format, args) }
+func (l recordingLogger) DebugEnabled() bool                       { return true }
+func (l recordingLogger) InfoEnabled() bool                        { return true }
+
+func TestSetLoggerFactory(t *testing.T) {
+	// Logger is obtained before changing the backend.
+	logger := GetLogger("test")
+	var messages []string
+	SetLoggerFactory(func(name string) Logger {
+		return recordingLogger{name, &messages}
+	})
+	defer SetLoggerFactory(nil)
+
+	logger.Info("hello %d", 1)
+	logger.Error("failed")
+	if len(messages) != 2 || messages[0] != "INFO test: hello 1" || messages[1] != "ERROR test: failed" {
+		t.Fatalf("unexpected messages: %v", messages)
+	}
+}
@@ -0,0 +1,231 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goburrow/gomelon/metrics/registry"
+)
+
+// runtimeStats is the runtime and process information displayed in /runtime.
+type runtimeStats struct {
+	GOARCH       string
+	GOOS         string
+	Version      string
+	NumCPU       int
+	NumCgoCall   int64
+	NumGoroutine int
+	Process      processStats
+	MemStats     *runtime.MemStats
+	GCPause      gcPauseStats
+}
+
+type processStats struct {
+	PID       int
+	StartTime string
+	Uptime    string
+	// OpenFDs is -1 when it is not available on the platform.
+	OpenFDs int
+}
+
+// gcPauseStats is the distribution in nanoseconds of recent GC pauses.
+type gcPauseStats struct {
+	Count int
+	Min   uint64
+	Max   uint64
+	Mean  uint64
+	P50   uint64
+	P75   uint64
+	P95   uint64
+	P99   uint64
+}
+
+func newRuntimeStats() *runtimeStats {
+	stats := &runtimeStats{
+		GOARCH:       runtime.GOARCH,
+		GOOS:         runtime.GOOS,
+		Version:      runtime.Version(),
+		NumCPU:       runtime.NumCPU(),
+		NumCgoCall:   runtime.NumCgoCall(),
+		NumGoroutine: runtime.NumGoroutine(),
+		Process: processStats{
+			PID:       os.Getpid(),
+			StartTime: startTime.Format(time.RFC3339),
+			Uptime:    time.Since(startTime).String(),
+			OpenFDs:   openFDs(),
+		},
+		MemStats: &runtime.MemStats{},
+	}
+	runtime.ReadMemStats(stats.MemStats)
+	stats.GCPause = newGCPauseStats(stats.MemStats)
+	return stats
+}
+
+// newGCPauseStats calculates distribution of pauses in the circular buffer
+// PauseNs of m.
+func newGCPauseStats(m *runtime.MemStats) gcPauseStats {
+	n := int(m.NumGC)
+	if n > len(m.PauseNs) {
+		n = len(m.PauseNs)
+	}
+	if n == 0 {
+		return gcPauseStats{}
+	}
+	pauses := make([]uint64, n)
+	copy(pauses, m.PauseNs[:n])
+	sort.Slice(pauses, func(i, j int) bool { return pauses[i] < pauses[j] })
+	var total uint64
+	for _, p := range pauses {
+		total += p
+	}
+	percentile := func(p float64) uint64 {
+		return pauses[int(p*float64(n-1))]
+	}
+	return gcPauseStats{
+		Count: n,
+		Min:   pauses[0],
+		Max:   pauses[n-1],
+		Mean:  total / uint64(n),
+		P50:   percentile(0.5),
+		P75:   percentile(0.75),
+		P95:   percentile(0.95),
+		P99:   percentile(0.99),
+	}
+}
+
+// openFDs returns number of open file descriptors of the process or -1 if
+// /proc file system is not available.
+func openFDs() int {
+	files, err := ioutil.ReadDir("/proc/self/fd")
+	if err != nil {
+		return -1
+	}
+	return len(files)
+}
+
+const (
+	// memStatsInterval limits how often memory statistics are read since
+	// runtime.ReadMemStats stops the world.
+	memStatsInterval = time.Second
+)
+
+// runtimeMetrics provides gauges of Go runtime statistics.
+type runtimeMetrics struct {
+	mu       sync.Mutex
+	memStats runtime.MemStats
+	readAt   time.Time
+}
+
+// registerRuntimeMetrics adds gauges prefixed by "Runtime." to the registry.
+func registerRuntimeMetrics(r *registry.Registry) {
+	m := &runtimeMetrics{}
+	r.Gauge("Runtime.Goroutines").SetFunc(func() int64 {
+		return int64(runtime.NumGoroutine())
+	})
+	r.Gauge("Runtime.CgoCalls").SetFunc(runtime.NumCgoCall)
+	r.Gauge("Runtime.OpenFDs").SetFunc(func() int64 {
+		return int64(openFDs())
+	})
+	memStats := map[string]func(*runtime.MemStats) uint64{
+		"Runtime.MemStats.Alloc":       func(s *runtime.MemStats) uint64 { return s.Alloc },
+		"Runtime.MemStats.Sys":         func(s *runtime.MemStats) uint64 { return s.Sys },
+		"Runtime.MemStats.HeapAlloc":   func(s *runtime.MemStats) uint64 { return s.HeapAlloc },
+		"Runtime.MemStats.HeapInuse":   func(s *runtime.MemStats) uint64 { return s.HeapInuse },
+		"Runtime.MemStats.HeapObjects": func(s *runtime.MemStats) uint64 { return s.HeapObjects },
+		"Runtime.MemStats.StackInuse":  func(s *runtime.MemStats) uint64 { return s.StackInuse },
+		"Runtime.GC.NumGC":             func(s *runtime.MemStats) uint64 { return uint64(s.NumGC) },
+		"Runtime.GC.PauseTotalNs":      func(s *runtime.MemStats) uint64 { return s.PauseTotalNs },
+		"Runtime.GC.LastPauseNs": func(s *runtime.MemStats) uint64 {
+			if s.NumGC == 0 {
+				return 0
+			}
+			return s.PauseNs[(s.NumGC+255)%256]
+		},
+	}
+	for name, f := range memStats {
+		f := f
+		r.Gauge(name).SetFunc(func() int64 {
+			return int64(m.read(f))
+		})
+	}
+}
+
+// read returns the value of memory statistics read within memStatsInterval.
+func (m *runtimeMetrics) read(f func(*runtime.MemStats) uint64) uint64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if now := time.Now(); now.Sub(m.readAt) >= memStatsInterval {
+		runtime.ReadMemStats(&m.memStats)
+		m.readAt = now
+	}
+	return f(&m.memStats)
+}
+
+// runtimeHandler displays runtime statistics.
+type runtimeHandler struct {
+}
+
+func (handler *runtimeHandler) Name() string {
+	return "Runtime"
+}
+
+func (handler *runtimeHandler) Path() string {
+	return runtimeURI
+}
+
+// ServeHTTP writes statistics in JSON if requested in Accept header or query
+// parameter format=json, otherwise in plain text.
+func (handler *runtimeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "must-revalidate,no-cache,no-store")
+	w.Header().Set("Vary", "Accept")
+
+	stats := newRuntimeStats()
+	if r.URL.Query().Get("format") == "json" || strings.Contains(r.Header.Get("Accept"), "application/json") {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(stats)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain")
+
+	fmt.Fprintf(w, "GOARCH: %s\nGOOS: %s\nVersion: %s\nNumCPU: %d\nNumCgoCall: %d\nNumGoroutine: %d\n",
+		stats.GOARCH, stats.GOOS, stats.Version,
+		stats.NumCPU, stats.NumCgoCall, stats.NumGoroutine)
+
+	p := &stats.Process
+	fmt.Fprintf(w, "Process:\n\tPID: %d\n\tStartTime: %s\n\tUptime: %s\n\tOpenFDs: %d\n",
+		p.PID, p.StartTime, p.Uptime, p.OpenFDs)
+
+	m := stats.MemStats
+	// General statistics
+	fmt.Fprintf(w, "MemStats:\n\tAlloc: %d\n\tTotalAlloc: %d\n\tSys: %d\n\tLookups: %d\n\tMallocs: %d\n\tFrees: %d\n",
+		m.Alloc, m.TotalAlloc, m.Sys, m.Lookups, m.Mallocs, m.Frees)
+	// Main allocation heap statistics
+	fmt.Fprintf(w, "\tHeapAlloc: %d\n\tHeapSys: %d\n\tHeapIdle: %d\n\tHeapInuse: %d\n\tHeapReleased: %d\n\tHeapObjects: %d\n",
+		m.HeapAlloc, m.HeapSys, m.HeapIdle, m.HeapInuse, m.HeapReleased, m.HeapObjects)
+	// Low-level fixed-size structure allocator statistics
+	fmt.Fprintf(w, "\tStackInuse: %d\n\tStackSys: %d\n\tMSpanInuse: %d\n\tMSpanSys: %d\n\tMCacheInuse: %d\n\tMCacheSys: %d\n\tBuckHashSys: %d\n\tGCSys: %d\n\tOtherSys: %d\n",
+		m.StackInuse, m.StackSys, m.MSpanInuse, m.MSpanSys, m.MCacheInuse, m.MCacheSys, m.BuckHashSys, m.GCSys, m.OtherSys)
+	// Garbage collector statistics
+	fmt.Fprintf(w, "\tNextGC: %d\n\tLastGC: %d\n\tPauseTotalNs: %d\n\tNumGC: %d\n\tEnableGC: %t\n\tDebugGC: %t\n",
+		m.NextGC, m.LastGC, m.PauseTotalNs, m.NumGC, m.EnableGC, m.DebugGC)
+	// Per-size class allocation statistics
+	fmt.Fprintf(w, "\tBySize:\n")
+	for _, s := range m.BySize {
+		if s.Mallocs == 0 && s.Frees == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\t\t%d: Mallocs: %d Frees: %d\n", s.Size, s.Mallocs, s.Frees)
+	}
+
+	g := &stats.GCPause
+	fmt.Fprintf(w, "GCPause:\n\tCount: %d\n\tMin: %d\n\tMax: %d\n\tMean: %d\n\tP50: %d\n\tP75: %d\n\tP95: %d\n\tP99: %d\n",
+		g.Count, g.Min, g.Max, g.Mean, g.P50, g.P75, g.P95, g.P99)
+}
@@ -0,0 +1,249 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/goburrow/gomelon/metrics/registry"
+)
+
+const schedulerMetricPrefix = "Scheduler."
+
+// Job is a task run by Scheduler. The context is cancelled when the
+// scheduler is stopped.
+type Job interface {
+	Run(ctx context.Context) error
+}
+
+// JobFunc is an adapter to allow the use of ordinary functions as Job.
+type JobFunc func(ctx context.Context) error
+
+// Run calls f(ctx).
+func (f JobFunc) Run(ctx context.Context) error {
+	return f(ctx)
+}
+
+// Scheduler runs jobs periodically. Jobs are started after all managed
+// objects have been started, right before the server accepts requests, and
+// are stopped as soon as the application is requested to shutdown.
+//
+// A job is never run concurrently with itself: an activation is skipped if
+// the previous run has not completed. Each job publishes metrics
+// "Scheduler.<name>.Duration", "Scheduler.<name>.Failures" and
+// "Scheduler.<name>.Skipped".
+type Scheduler struct {
+	// StopTimeout is the maximum duration to wait for running jobs when
+	// stopping. Zero means no timeout.
+	StopTimeout time.Duration
+
+	metrics *registry.Registry
+
+	mu       sync.Mutex
+	jobs     map[string]*scheduledJob
+	running  bool
+	disabled bool
+	ctx      context.Context
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+var _ LifecycleListener = (*Scheduler)(nil)
+
+// NewScheduler allocates and returns a new Scheduler publishing job metrics
+// to the given registry, which can be nil.
+func NewScheduler(metrics *registry.Registry) *Scheduler {
+	if metrics == nil {
+		metrics = registry.NewRegistry()
+	}
+	return &Scheduler{
+		StopTimeout: DefaultStopTimeout,
+		metrics:     metrics,
+		jobs:        make(map[string]*scheduledJob),
+	}
+}
+
+// Schedule adds a job with the given name, replacing the existing one.
+func (s *Scheduler) Schedule(name string, schedule Schedule, job Job) {
+	prefix := schedulerMetricPrefix + name
+	j := &scheduledJob{
+		name:     name,
+		schedule: schedule,
+		job:      job,
+		duration: s.metrics.Timer(prefix + ".Duration"),
+		failures: s.metrics.Counter(prefix + ".Failures"),
+		skipped:  s.metrics.Counter(prefix + ".Skipped"),
+		stop:     make(chan struct{}),
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if old, ok := s.jobs[name]; ok {
+		close(old.stop)
+	}
+	s.jobs[name] = j
+	if s.running {
+		s.start(j)
+	}
+}
+
+// ScheduleCron adds a job run on the given cron expression. See ParseCron.
+func (s *Scheduler) ScheduleCron(name string, spec string, job Job) error {
+	schedule, err := ParseCron(spec)
+	if err != nil {
+		return err
+	}
+	s.Schedule(name, schedule, job)
+	return nil
+}
+
+// Unschedule removes the job with the given name. It does not wait for the
+// job if it is running.
+func (s *Scheduler) Unschedule(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if j, ok := s.jobs[name]; ok {
+		close(j.stop)
+		delete(s.jobs, name)
+	}
+}
+
+// LifecycleChanged starts the scheduler when the lifecycle has started and
+// stops it when the lifecycle is stopping.
+func (s *Scheduler) LifecycleChanged(event *LifecycleEvent) {
+	switch event.Type {
+	case LifecycleStarted:
+		s.Start()
+	case LifecycleStopping:
+		ctx := context.Background()
+		if s.StopTimeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, s.StopTimeout)
+			defer cancel()
+		}
+		if err := s.Stop(ctx); err != nil {
+			lifecycleLogger.Error("error stopping scheduler: %v", err)
+		}
+	}
+}
+
+// Disable prevents the scheduler from being started, e.g. in commands which
+// do not run as a service.
+func (s *Scheduler) Disable() {
+	s.mu.Lock()
+	s.disabled = true
+	s.mu.Unlock()
+}
+
+// Start starts running scheduled jobs unless the scheduler is disabled.
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running || s.disabled {
+		return
+	}
+	s.running = true
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	for _, j := range s.jobs {
+		s.start(j)
+	}
+}
+
+// Stop cancels running jobs and waits until they return or ctx is done.
+func (s *Scheduler) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	if !s.running {
+		s.mu.Unlock()
+		return nil
+	}
+	s.running = false
+	s.cancel()
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *Scheduler) start(j *scheduledJob) {
+	s.wg.Add(1)
+	go func(ctx context.Context) {
+		defer s.wg.Done()
+		j.loop(ctx, &s.wg)
+	}(s.ctx)
+}
+
+type scheduledJob struct {
+	name     string
+	schedule Schedule
+	job      Job
+	busy     int32
+
+	duration *registry.Timer
+	failures *registry.Counter
+	skipped  *registry.Counter
+
+	stop chan struct{}
+}
+
+// loop waits for activations until ctx is done or the job is removed.
+func (j *scheduledJob) loop(ctx context.Context, wg *sync.WaitGroup) {
+	next := j.schedule.Next(time.Now())
+	for !next.IsZero() {
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-j.stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		if atomic.CompareAndSwapInt32(&j.busy, 0, 1) {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer atomic.StoreInt32(&j.busy, 0)
+				j.run(ctx)
+			}()
+		} else {
+			lifecycleLogger.Warn("job %s is still running, skipped", j.name)
+			j.skipped.Inc()
+		}
+		now := time.Now()
+		next = j.schedule.Next(next)
+		if !next.After(now) {
+			// Catch up without running missed activations.
+			next = j.schedule.Next(now)
+		}
+	}
+}
+
+func (j *scheduledJob) run(ctx context.Context) {
+	start := time.Now()
+	err := j.runJob(ctx)
+	j.duration.UpdateSince(start)
+	if err != nil {
+		j.failures.Inc()
+		lifecycleLogger.Error("job %s failed: %v", j.name, err)
+	}
+}
+
+func (j *scheduledJob) runJob(ctx context.Context) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return j.job.Run(ctx)
+}
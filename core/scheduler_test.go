@@ -0,0 +1,87 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/goburrow/gomelon/metrics/registry"
+)
+
+func TestParseCron(t *testing.T) {
+	base := time.Date(2016, 2, 27, 10, 30, 15, 0, time.UTC)
+	data := []struct {
+		spec string
+		next time.Time
+	}{
+		{"* * * * *", time.Date(2016, 2, 27, 10, 31, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2016, 2, 27, 10, 45, 0, 0, time.UTC)},
+		{"0 9-17 * * *", time.Date(2016, 2, 27, 11, 0, 0, 0, time.UTC)},
+		{"0 0 29 feb *", time.Date(2016, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"30 8 * * mon-fri", time.Date(2016, 2, 29, 8, 30, 0, 0, time.UTC)},
+		{"0 0 1 * 0", time.Date(2016, 2, 28, 0, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2016, 2, 28, 0, 0, 0, 0, time.UTC)},
+		{"@every 90s", base.Add(90 * time.Second)},
+	}
+	for _, d := range data {
+		s, err := ParseCron(d.spec)
+		if err != nil {
+			t.Fatalf("%s: %v", d.spec, err)
+		}
+		if next := s.Next(base); !next.Equal(d.next) {
+			t.Fatalf("%s: unexpected next %v, want %v", d.spec, next, d.next)
+		}
+	}
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "5-1 * * * *", "*/0 * * * *", "@every x"} {
+		if _, err := ParseCron(spec); err == nil {
+			t.Fatalf("%s: expected error", spec)
+		}
+	}
+}
+
+func TestEveryNonPositive(t *testing.T) {
+	for _, d := range []time.Duration{0, -time.Second} {
+		func() {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Fatalf("%v: expected panic", d)
+				}
+			}()
+			Every(d)
+		}()
+	}
+}
+
+func TestScheduler(t *testing.T) {
+	metrics := registry.NewRegistry()
+	s := NewScheduler(metrics)
+	var runs int64
+	release := make(chan struct{})
+	s.Schedule("slow", Every(5*time.Millisecond), JobFunc(func(ctx context.Context) error {
+		atomic.AddInt64(&runs, 1)
+		select {
+		case <-release:
+		case <-ctx.Done():
+		}
+		return errors.New("failed")
+	}))
+	s.LifecycleChanged(&LifecycleEvent{Type: LifecycleStarted})
+	time.Sleep(30 * time.Millisecond)
+	close(release)
+	s.LifecycleChanged(&LifecycleEvent{Type: LifecycleStopping})
+
+	if atomic.LoadInt64(&runs) != 1 {
+		t.Fatalf("unexpected runs: %d", runs)
+	}
+	if metrics.Counter("Scheduler.slow.Skipped").Count() == 0 {
+		t.Fatal("expected skipped activations")
+	}
+	if metrics.Counter("Scheduler.slow.Failures").Count() != 1 {
+		t.Fatal("unexpected failures")
+	}
+	if metrics.Timer("Scheduler.slow.Duration").Count() != 1 {
+		t.Fatal("unexpected duration count")
+	}
+}
@@ -2,9 +2,10 @@ package core
 
 import (
 	"bytes"
+	"context"
 	"fmt"
-
-	"github.com/goburrow/gol"
+	"net"
+	"net/http"
 )
 
 const (
@@ -25,6 +26,51 @@ type ServerHandler interface {
 	Handle(method, pattern string, handler interface{})
 	// PathPrefix returns prefix path of this handler.
 	PathPrefix() string
+	// AddFilter adds a middleware to all requests served by this handler.
+	// Middlewares are executed in the order they are added, after the
+	// built-in filters (request log, panic recovery and compression).
+	AddFilter(func(http.Handler) http.Handler)
+}
+
+// ServerConnector describes a connector of the running server.
+type ServerConnector struct {
+	// Type is the connector type, e.g. "http" or "https".
+	Type string
+	// Admin is set for connectors serving the admin handler only.
+	Admin bool
+	// Addr is the address the connector is bound to, which has the actual
+	// port when port 0 is configured.
+	Addr net.Addr
+}
+
+// ServerLifecycleListener is notified when the server has bound all its
+// connectors and is about to accept connections.
+type ServerLifecycleListener interface {
+	ServerStarted(connectors []ServerConnector)
+}
+
+// ServerLifecycleListenerFunc is an adapter to allow the use of ordinary
+// functions as ServerLifecycleListener.
+type ServerLifecycleListenerFunc func(connectors []ServerConnector)
+
+// ServerStarted calls f(connectors).
+func (f ServerLifecycleListenerFunc) ServerStarted(connectors []ServerConnector) {
+	f(connectors)
+}
+
+// pathParamsKey is the request context key of path parameters.
+type pathParamsKey struct{}
+
+// PathParams returns path parameters matched by the router of ServerHandler.
+func PathParams(r *http.Request) map[string]string {
+	params, _ := r.Context().Value(pathParamsKey{}).(map[string]string)
+	return params
+}
+
+// WithPathParams returns a shallow copy of the request with the given path
+// parameters. It is used by router implementations.
+func WithPathParams(r *http.Request, params map[string]string) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), pathParamsKey{}, params))
 }
 
 // ServerFactory builds Server with given configuration and environment.
@@ -40,10 +86,13 @@ type ServerEnvironment struct {
 
 	components       []interface{}
 	resourceHandlers []ResourceHandler
+	serverListeners  []ServerLifecycleListener
 
 	endpointLogger bytes.Buffer
 }
 
+var _ ServerLifecycleListener = (*ServerEnvironment)(nil)
+
 func NewServerEnvironment() *ServerEnvironment {
 	return &ServerEnvironment{}
 }
@@ -58,6 +107,20 @@ func (env *ServerEnvironment) AddResourceHandler(handler ...ResourceHandler) {
 	env.resourceHandlers = append(env.resourceHandlers, handler...)
 }
 
+// AddServerListener adds a listener which is called after the server has
+// bound its connectors. AddServerListener is not concurrent-safe.
+func (env *ServerEnvironment) AddServerListener(listener ServerLifecycleListener) {
+	env.serverListeners = append(env.serverListeners, listener)
+}
+
+// ServerStarted notifies all server listeners. It is called by Server
+// implementations.
+func (env *ServerEnvironment) ServerStarted(connectors []ServerConnector) {
+	for _, listener := range env.serverListeners {
+		listener.ServerStarted(connectors)
+	}
+}
+
 // LogEndpoint records all endpoints to display on application start.
 // FIXME: recording endpoints automatically in ServerHandler or ResourceHandler?
 func (env *ServerEnvironment) LogEndpoint(method, path string, component interface{}) {
@@ -65,12 +128,13 @@ func (env *ServerEnvironment) LogEndpoint(method, path string, component interfa
 		method, env.ServerHandler.PathPrefix(), path, component)
 }
 
-func (env *ServerEnvironment) onStarting() {
+func (env *ServerEnvironment) onStarting() error {
 	for _, component := range env.components {
 		env.handle(component)
 	}
 	env.logResources()
 	env.logEndpoints()
+	return nil
 }
 
 func (env *ServerEnvironment) onStopped() {
@@ -84,7 +148,7 @@ func (env *ServerEnvironment) handle(component interface{}) {
 }
 
 func (env *ServerEnvironment) logResources() {
-	logger := gol.GetLogger(serverLoggerName)
+	logger := GetLogger(serverLoggerName)
 	if !logger.DebugEnabled() {
 		return
 	}
@@ -99,6 +163,6 @@ func (env *ServerEnvironment) logResources() {
 }
 
 func (env *ServerEnvironment) logEndpoints() {
-	gol.GetLogger(serverLoggerName).Info("endpoints =\n\n%s", env.endpointLogger.String())
+	GetLogger(serverLoggerName).Info("endpoints =\n\n%s", env.endpointLogger.String())
 	env.endpointLogger.Reset()
 }
@@ -1,11 +1,159 @@
 package core
 
 import (
+	"bytes"
+	"fmt"
+	"io"
 	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
 )
 
-// Task is simply a HTTP Handler.
+// Task is an admin task which can be executed by POST request to
+// /tasks/{name}. Query and form parameters are given in params.
 type Task interface {
+	Name() string
+	// Execute runs the task and writes result to out. A TaskError can be
+	// returned to respond with a specific HTTP status.
+	Execute(params map[string][]string, out io.Writer) error
+}
+
+// HTTPTask is a task handling HTTP requests directly. It is useful when the
+// task needs to control the response, e.g. setting headers or streaming.
+type HTTPTask interface {
 	Name() string
 	http.Handler
 }
+
+// TaskError is an error with HTTP status code returned by tasks.
+type TaskError struct {
+	Message string
+	Status  int
+}
+
+// NewTaskError allocates and returns a new TaskError.
+func NewTaskError(message string, status int) *TaskError {
+	return &TaskError{
+		Message: message,
+		Status:  status,
+	}
+}
+
+func (e *TaskError) Error() string {
+	return e.Message
+}
+
+// FromHTTPTask converts a HTTPTask to Task. When executed via Execute, the
+// task is given a POST request with params in the URL query.
+func FromHTTPTask(task HTTPTask) Task {
+	return &httpTask{task}
+}
+
+type httpTask struct {
+	HTTPTask
+}
+
+func (t *httpTask) Execute(params map[string][]string, out io.Writer) error {
+	r := &http.Request{
+		Method: "POST",
+		URL: &url.URL{
+			Path:     tasksURI + "/" + t.Name(),
+			RawQuery: url.Values(params).Encode(),
+		},
+		Header: make(http.Header),
+	}
+	w := &taskResponseWriter{writer: out, header: make(http.Header)}
+	t.ServeHTTP(w, r)
+	if w.status >= http.StatusBadRequest {
+		return NewTaskError(strings.TrimSpace(w.body.String()), w.status)
+	}
+	return nil
+}
+
+// taskResponseWriter writes response body to the underlying writer unless
+// the status is an error, in which case the body is kept as error message.
+type taskResponseWriter struct {
+	writer io.Writer
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (w *taskResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *taskResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *taskResponseWriter) Write(b []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	if w.status >= http.StatusBadRequest {
+		return w.body.Write(b)
+	}
+	return w.writer.Write(b)
+}
+
+// taskHandler serves a task over HTTP.
+type taskHandler struct {
+	task Task
+}
+
+func (h *taskHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger := GetLogger(adminLoggerName)
+	start := time.Now()
+	if t, ok := h.task.(*httpTask); ok {
+		t.ServeHTTP(w, r)
+		logger.Info("executed task %s in %v", h.task.Name(), time.Since(start))
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Cache-Control", "must-revalidate,no-cache,no-store")
+	w.Header().Set("Content-Type", "text/plain")
+	out := &countingWriter{writer: w}
+	err := h.task.Execute(r.Form, out)
+	if err == nil {
+		logger.Info("executed task %s %v in %v", h.task.Name(), paramNames(r.Form), time.Since(start))
+		return
+	}
+	status := http.StatusInternalServerError
+	if e, ok := err.(*TaskError); ok {
+		status = e.Status
+	}
+	logger.Warn("error executing task %s %v: %v", h.task.Name(), paramNames(r.Form), err)
+	if out.count == 0 {
+		w.WriteHeader(status)
+	}
+	fmt.Fprintln(w, err.Error())
+}
+
+// paramNames returns sorted names of params. Values are not logged as they
+// may contain secrets.
+func paramNames(params map[string][]string) []string {
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// countingWriter counts number of bytes written.
+type countingWriter struct {
+	writer io.Writer
+	count  int
+}
+
+func (w *countingWriter) Write(b []byte) (int, error) {
+	n, err := w.writer.Write(b)
+	w.count += n
+	return n, err
+}
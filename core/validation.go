@@ -1,5 +1,7 @@
 package core
 
+import "bytes"
+
 // Validator validates objects.
 type Validator interface {
 	Validate(interface{}) error
@@ -9,3 +11,28 @@ type Validator interface {
 type ValidatorFactory interface {
 	Validator() Validator
 }
+
+// Violation is a constraint violation of a field.
+type Violation struct {
+	// Field is the path of the field, e.g. "Address.City".
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationErrors is returned by Validator when fields are invalid.
+type ValidationErrors []Violation
+
+func (e ValidationErrors) Error() string {
+	var buf bytes.Buffer
+	for i, v := range e {
+		if i > 0 {
+			buf.WriteString("; ")
+		}
+		if v.Field != "" {
+			buf.WriteString(v.Field)
+			buf.WriteString(": ")
+		}
+		buf.WriteString(v.Message)
+	}
+	return buf.String()
+}
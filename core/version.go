@@ -0,0 +1,64 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"runtime/debug"
+)
+
+const frameworkModule = "github.com/goburrow/gomelon"
+
+// VersionCommand prints build information of the application, gomelon and
+// Go. It is added by NewBootstrap.
+type VersionCommand struct {
+}
+
+var _ Command = (*VersionCommand)(nil)
+
+func (*VersionCommand) Name() string {
+	return "version"
+}
+
+func (*VersionCommand) Description() string {
+	return "prints version of the application"
+}
+
+func (*VersionCommand) Run(bootstrap *Bootstrap) error {
+	return printVersion(os.Stdout, bootstrap.Application)
+}
+
+func printVersion(w io.Writer, app Application) error {
+	info := defaultBuildInfo()
+	if p, ok := app.(BuildInfoProvider); ok {
+		info.Merge(p.BuildInfo())
+	}
+	_, err := fmt.Fprintf(w, "%s\n  Version:    %s\n  Commit:     %s\n  Build time: %s\n  Gomelon:    %s\n  Go:         %s %s/%s\n",
+		app.Name(), valueOrUnknown(info.Version), valueOrUnknown(info.Commit), valueOrUnknown(info.BuildTime),
+		frameworkVersion(), runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	return err
+}
+
+// frameworkVersion returns the version of gomelon module the application
+// is built with.
+func frameworkVersion() string {
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		if bi.Main.Path == frameworkModule {
+			return valueOrUnknown(bi.Main.Version)
+		}
+		for _, dep := range bi.Deps {
+			if dep.Path == frameworkModule {
+				return valueOrUnknown(dep.Version)
+			}
+		}
+	}
+	return "unknown"
+}
+
+func valueOrUnknown(s string) string {
+	if s == "" {
+		return "unknown"
+	}
+	return s
+}
@@ -0,0 +1,35 @@
+package core
+
+import (
+	"bytes"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+type versionApplication struct {
+}
+
+func (*versionApplication) Name() string                        { return "app" }
+func (*versionApplication) Initialize(*Bootstrap)               {}
+func (*versionApplication) Run(interface{}, *Environment) error { return nil }
+func (*versionApplication) BuildInfo() BuildInfo {
+	return BuildInfo{Version: "1.0.0", Commit: "abc"}
+}
+
+func TestVersionCommand(t *testing.T) {
+	bootstrap := NewBootstrap(&versionApplication{})
+	if commands := bootstrap.Commands(); len(commands) != 1 || commands[0].Name() != "version" {
+		t.Fatalf("unexpected commands %v", commands)
+	}
+	var buf bytes.Buffer
+	if err := printVersion(&buf, bootstrap.Application); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, s := range []string{"app\n", "Version:    1.0.0\n", "Commit:     abc\n", "Build time: unknown\n", runtime.Version()} {
+		if !strings.Contains(out, s) {
+			t.Fatalf("%q not found in %s", s, out)
+		}
+	}
+}
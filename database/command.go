@@ -0,0 +1,110 @@
+package database
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/goburrow/gomelon"
+	"github.com/goburrow/gomelon/core"
+	"github.com/goburrow/gomelon/database/migrations"
+)
+
+// Command applies, reverts or lists migrations. Its first argument is the
+// action: migrate, status or rollback.
+type Command struct {
+	gomelon.ConfigurationCommand
+
+	bundle *Bundle
+	steps  int
+}
+
+var _ core.Command = (*Command)(nil)
+var _ core.CommandFlags = (*Command)(nil)
+var _ core.CommandUsage = (*Command)(nil)
+
+// Name returns name of the Command.
+func (command *Command) Name() string {
+	return "db"
+}
+
+// Description returns description of the Command.
+func (command *Command) Description() string {
+	return "manages database migrations (migrate, status or rollback)"
+}
+
+// Synopsis returns arguments of the Command.
+func (command *Command) Synopsis() string {
+	return "migrate|status|rollback [flags] [config]"
+}
+
+// Examples returns examples of the Command.
+func (command *Command) Examples() []string {
+	return []string{"db migrate config.yml", "db rollback --steps 2 config.yml"}
+}
+
+// AddFlags adds flags --config and --steps.
+func (command *Command) AddFlags(flags *flag.FlagSet) {
+	command.ConfigurationCommand.AddFlags(flags)
+	flags.IntVar(&command.steps, "steps", 1, "number of migrations to roll back")
+}
+
+// Run loads the configuration and runs the action.
+func (command *Command) Run(bootstrap *core.Bootstrap) error {
+	if len(bootstrap.Arguments) < 2 {
+		return fmt.Errorf("database: action is required: migrate, status or rollback")
+	}
+	action := bootstrap.Arguments[1]
+	if action != "migrate" && action != "status" && action != "rollback" {
+		return fmt.Errorf("database: unsupported action %q", action)
+	}
+	// Configuration path is given after the action.
+	if bootstrap.ConfigurationPath == "" && len(bootstrap.Arguments) > 2 {
+		bootstrap.ConfigurationPath = bootstrap.Arguments[2]
+	}
+	if err := command.ConfigurationCommand.Run(bootstrap); err != nil {
+		return err
+	}
+	factory, err := factoryOf(command.Configuration)
+	if err != nil {
+		return err
+	}
+	db, err := factory.Open()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	migrator, err := command.bundle.Migrator(db, factory)
+	if err != nil {
+		return err
+	}
+	return runAction(context.Background(), os.Stdout, migrator, action, command.steps)
+}
+
+func runAction(ctx context.Context, w io.Writer, m *migrations.Migrator, action string, steps int) error {
+	switch action {
+	case "migrate":
+		n, err := m.Migrate(ctx)
+		logger.Info("applied %d migrations", n)
+		return err
+	case "rollback":
+		n, err := m.Rollback(ctx, steps)
+		logger.Info("rolled back %d migrations", n)
+		return err
+	default:
+		status, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, s := range status {
+			state := "pending"
+			if s.Applied {
+				state = "applied"
+			}
+			fmt.Fprintf(w, "%-8s %6d  %s\n", state, s.Version, s.Name)
+		}
+		return nil
+	}
+}
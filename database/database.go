@@ -0,0 +1,176 @@
+/*
+Package database provides a pool of SQL connections and commands managing
+the database schema.
+
+The application configuration implements Configuration:
+
+	database:
+	  driver: postgres
+	  dsn: postgres://localhost/app?sslmode=disable
+	  maxOpenConnections: 10
+	  migrations: db/migrations
+
+The driver must be imported by the application. Bundle adds command "db"
+which applies, reverts or lists migrations:
+
+	app db migrate config.yml
+	app db status config.yml
+	app db rollback --steps 2 config.yml
+*/
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/goburrow/gomelon/core"
+	"github.com/goburrow/gomelon/database/migrations"
+	"github.com/goburrow/health"
+)
+
+const (
+	healthCheckName = "database"
+	pingTimeout     = 5 * time.Second
+)
+
+var logger core.Logger
+
+func init() {
+	logger = core.GetLogger("gomelon/database")
+}
+
+// Configuration is implemented by application configuration which has a
+// database.
+type Configuration interface {
+	DatabaseFactory() *Factory
+}
+
+// Factory is the configuration of the database.
+type Factory struct {
+	Driver string `valid:"nonzero"`
+	DSN    string `valid:"nonzero"`
+
+	MaxOpenConnections int
+	MaxIdleConnections int
+	// ConnectionMaxLifetime is the maximum duration a connection is reused,
+	// e.g. "30m".
+	ConnectionMaxLifetime string
+	// Migrations is the directory of SQL migrations.
+	Migrations string
+}
+
+// Open opens the database and configures its connection pool.
+func (factory *Factory) Open() (*sql.DB, error) {
+	var maxLifetime time.Duration
+	if factory.ConnectionMaxLifetime != "" {
+		var err error
+		if maxLifetime, err = time.ParseDuration(factory.ConnectionMaxLifetime); err != nil {
+			return nil, fmt.Errorf("database: invalid connection max lifetime %v", err)
+		}
+	}
+	db, err := sql.Open(factory.Driver, factory.DSN)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(factory.MaxOpenConnections)
+	if factory.MaxIdleConnections > 0 {
+		db.SetMaxIdleConns(factory.MaxIdleConnections)
+	}
+	db.SetConnMaxLifetime(maxLifetime)
+	return db, nil
+}
+
+// Bundle opens the database when the application runs, closes it when the
+// application stops and adds command "db" for migrations.
+type Bundle struct {
+	// DB is available after the bundle is run.
+	DB *sql.DB
+
+	migrations []migrations.Migration
+}
+
+var _ core.Bundle = (*Bundle)(nil)
+
+// NewBundle allocates and returns a new Bundle with Go migrations which are
+// applied along with SQL migrations in the configured directory.
+func NewBundle(m ...migrations.Migration) *Bundle {
+	return &Bundle{
+		migrations: m,
+	}
+}
+
+// Initialize adds command "db".
+func (b *Bundle) Initialize(bootstrap *core.Bootstrap) {
+	bootstrap.AddCommand(&Command{bundle: b})
+}
+
+// Run opens the database and registers health check "database".
+func (b *Bundle) Run(conf interface{}, env *core.Environment) error {
+	db, err := open(conf)
+	if err != nil {
+		return err
+	}
+	b.DB = db
+	env.Lifecycle.Manage(&closer{db}, core.PhaseInfrastructure)
+	env.Admin.HealthChecks.Register(healthCheckName, &checker{db})
+	return nil
+}
+
+// Migrator returns the migrator of Go migrations and SQL migrations in the
+// configured directory.
+func (b *Bundle) Migrator(db *sql.DB, factory *Factory) (*migrations.Migrator, error) {
+	m := b.migrations
+	if factory.Migrations != "" {
+		files, err := migrations.ReadDir(factory.Migrations)
+		if err != nil {
+			return nil, err
+		}
+		m = append(append([]migrations.Migration(nil), m...), files...)
+	}
+	return migrations.NewMigrator(db, m), nil
+}
+
+func factoryOf(conf interface{}) (*Factory, error) {
+	c, ok := conf.(Configuration)
+	if !ok {
+		return nil, fmt.Errorf("database: configuration %T does not implement database.Configuration", conf)
+	}
+	return c.DatabaseFactory(), nil
+}
+
+func open(conf interface{}) (*sql.DB, error) {
+	factory, err := factoryOf(conf)
+	if err != nil {
+		return nil, err
+	}
+	return factory.Open()
+}
+
+// closer closes the database when the application stops.
+type closer struct {
+	db *sql.DB
+}
+
+func (c *closer) Start() error {
+	return nil
+}
+
+func (c *closer) Stop() error {
+	return c.db.Close()
+}
+
+// checker pings the database.
+type checker struct {
+	db *sql.DB
+}
+
+func (c *checker) Check() health.Result {
+	ctx, cancel := context.WithTimeout(context.Background(), pingTimeout)
+	defer cancel()
+	if err := c.db.PingContext(ctx); err != nil {
+		return health.ResultUnhealthy("could not ping database", err)
+	}
+	return health.ResultHealthy("")
+}
@@ -0,0 +1,27 @@
+package database
+
+import (
+	"testing"
+)
+
+type testConfiguration struct {
+	Database Factory
+}
+
+func (c *testConfiguration) DatabaseFactory() *Factory {
+	return &c.Database
+}
+
+func TestOpen(t *testing.T) {
+	if _, err := open(struct{}{}); err == nil {
+		t.Fatal("error expected")
+	}
+	c := &testConfiguration{Database: Factory{Driver: "unknown", DSN: "x", ConnectionMaxLifetime: "1"}}
+	if _, err := open(c); err == nil {
+		t.Fatal("error expected for invalid lifetime")
+	}
+	c.Database.ConnectionMaxLifetime = "30m"
+	if _, err := open(c); err == nil {
+		t.Fatal("error expected for unknown driver")
+	}
+}
@@ -0,0 +1,263 @@
+/*
+Package migrations manages versions of a database schema.
+
+A migration is either a pair of Go functions or SQL files in a directory
+named by version and name:
+
+	0001_create_users.up.sql
+	0001_create_users.down.sql
+
+Applied versions are recorded in table schema_migrations. Each migration is
+applied in its own transaction.
+*/
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultTable is the table recording applied migrations.
+const DefaultTable = "schema_migrations"
+
+// Migration is a versioned change of the database schema.
+type Migration struct {
+	Version int64
+	Name    string
+	// Up applies the migration.
+	Up func(ctx context.Context, tx *sql.Tx) error
+	// Down reverts the migration. Migrations without Down can not be rolled
+	// back.
+	Down func(ctx context.Context, tx *sql.Tx) error
+}
+
+// Status is the state of a migration.
+type Status struct {
+	Version int64
+	Name    string
+	Applied bool
+}
+
+// SQL returns a migration function executing the given statements.
+func SQL(query string) func(context.Context, *sql.Tx) error {
+	return func(ctx context.Context, tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, query)
+		return err
+	}
+}
+
+// ReadDir loads migrations from SQL files "<version>_<name>.up.sql" and
+// "<version>_<name>.down.sql" in the directory. Other files are ignored.
+func ReadDir(dir string) ([]Migration, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	byVersion := make(map[int64]*Migration)
+	for _, f := range files {
+		name := f.Name()
+		var up bool
+		switch {
+		case f.IsDir():
+			continue
+		case strings.HasSuffix(name, ".up.sql"):
+			up = true
+			name = strings.TrimSuffix(name, ".up.sql")
+		case strings.HasSuffix(name, ".down.sql"):
+			name = strings.TrimSuffix(name, ".down.sql")
+		default:
+			continue
+		}
+		i := strings.IndexByte(name, '_')
+		if i <= 0 {
+			return nil, fmt.Errorf("migrations: invalid file name %q", f.Name())
+		}
+		version, err := strconv.ParseInt(name[:i], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migrations: invalid version of %q", f.Name())
+		}
+		query, err := ioutil.ReadFile(filepath.Join(dir, f.Name()))
+		if err != nil {
+			return nil, err
+		}
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: name[i+1:]}
+			byVersion[version] = m
+		} else if m.Name != name[i+1:] {
+			return nil, fmt.Errorf("migrations: duplicate version %d", version)
+		}
+		if up {
+			m.Up = SQL(string(query))
+		} else {
+			m.Down = SQL(string(query))
+		}
+	}
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == nil {
+			return nil, fmt.Errorf("migrations: missing up file of version %d", m.Version)
+		}
+		migrations = append(migrations, *m)
+	}
+	sortMigrations(migrations)
+	return migrations, nil
+}
+
+// Migrator applies and reverts migrations.
+type Migrator struct {
+	// Table records applied versions. It is DefaultTable if empty.
+	Table string
+
+	db         *sql.DB
+	migrations []Migration
+}
+
+// NewMigrator allocates and returns a new Migrator of the given migrations.
+func NewMigrator(db *sql.DB, migrations []Migration) *Migrator {
+	m := &Migrator{
+		db:         db,
+		migrations: append([]Migration(nil), migrations...),
+	}
+	sortMigrations(m.migrations)
+	return m
+}
+
+func sortMigrations(migrations []Migration) {
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+}
+
+func (m *Migrator) table() string {
+	if m.Table == "" {
+		return DefaultTable
+	}
+	return m.Table
+}
+
+// Migrate applies all pending migrations in order of versions and returns
+// the number of applied migrations.
+func (m *Migrator) Migrate(ctx context.Context) (int, error) {
+	if err := m.validate(); err != nil {
+		return 0, err
+	}
+	applied, err := m.appliedVersions(ctx)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for i := range m.migrations {
+		migration := &m.migrations[i]
+		if applied[migration.Version] {
+			continue
+		}
+		if migration.Up == nil {
+			return n, fmt.Errorf("migrations: version %d can not be applied", migration.Version)
+		}
+		query := fmt.Sprintf("INSERT INTO %s (version, name) VALUES (%d, '%s')",
+			m.table(), migration.Version, strings.Replace(migration.Name, "'", "''", -1))
+		if err = m.run(ctx, migration.Up, query); err != nil {
+			return n, fmt.Errorf("migrations: could not apply version %d: %v", migration.Version, err)
+		}
+		n++
+	}
+	return n, nil
+}
+
+// Rollback reverts the given number of latest applied migrations and returns
+// the number of reverted migrations.
+func (m *Migrator) Rollback(ctx context.Context, steps int) (int, error) {
+	applied, err := m.appliedVersions(ctx)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for i := len(m.migrations) - 1; i >= 0 && n < steps; i-- {
+		migration := &m.migrations[i]
+		if !applied[migration.Version] {
+			continue
+		}
+		if migration.Down == nil {
+			return n, fmt.Errorf("migrations: version %d can not be rolled back", migration.Version)
+		}
+		query := fmt.Sprintf("DELETE FROM %s WHERE version = %d", m.table(), migration.Version)
+		if err = m.run(ctx, migration.Down, query); err != nil {
+			return n, fmt.Errorf("migrations: could not roll back version %d: %v", migration.Version, err)
+		}
+		n++
+	}
+	return n, nil
+}
+
+// Status returns state of all migrations in order of versions.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.appliedVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	status := make([]Status, len(m.migrations))
+	for i, migration := range m.migrations {
+		status[i] = Status{
+			Version: migration.Version,
+			Name:    migration.Name,
+			Applied: applied[migration.Version],
+		}
+	}
+	return status, nil
+}
+
+func (m *Migrator) validate() error {
+	for i := 1; i < len(m.migrations); i++ {
+		if m.migrations[i].Version == m.migrations[i-1].Version {
+			return fmt.Errorf("migrations: duplicate version %d", m.migrations[i].Version)
+		}
+	}
+	return nil
+}
+
+// appliedVersions creates the migration table if needed and returns the
+// recorded versions.
+func (m *Migrator) appliedVersions(ctx context.Context) (map[int64]bool, error) {
+	query := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (version BIGINT PRIMARY KEY, name VARCHAR(255) NOT NULL)", m.table())
+	if _, err := m.db.ExecContext(ctx, query); err != nil {
+		return nil, err
+	}
+	rows, err := m.db.QueryContext(ctx, fmt.Sprintf("SELECT version FROM %s", m.table()))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	applied := make(map[int64]bool)
+	for rows.Next() {
+		var version int64
+		if err = rows.Scan(&version); err != nil {
+			return nil, err
+		}
+		applied[version] = true
+	}
+	return applied, rows.Err()
+}
+
+// run calls f and executes query in a transaction.
+func (m *Migrator) run(ctx context.Context, f func(context.Context, *sql.Tx) error, query string) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err = f(ctx, tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, query); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,233 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// testDriver records versions in memory and statements of migrations.
+type testDriver struct {
+	mu         sync.Mutex
+	versions   map[int64]bool
+	statements []string
+}
+
+func (d *testDriver) Open(string) (driver.Conn, error) {
+	return &testConn{d}, nil
+}
+
+type testConn struct {
+	d *testDriver
+}
+
+func (c *testConn) Prepare(query string) (driver.Stmt, error) {
+	return &testStmt{c.d, query}, nil
+}
+
+func (c *testConn) Close() error {
+	return nil
+}
+
+func (c *testConn) Begin() (driver.Tx, error) {
+	return c, nil
+}
+
+func (c *testConn) Commit() error {
+	return nil
+}
+
+func (c *testConn) Rollback() error {
+	return nil
+}
+
+type testStmt struct {
+	d     *testDriver
+	query string
+}
+
+func (s *testStmt) Close() error {
+	return nil
+}
+
+func (s *testStmt) NumInput() int {
+	return -1
+}
+
+func (s *testStmt) Exec([]driver.Value) (driver.Result, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	var version int64
+	switch {
+	case strings.HasPrefix(s.query, "CREATE TABLE IF NOT EXISTS "+DefaultTable):
+	case strings.HasPrefix(s.query, "INSERT INTO "+DefaultTable):
+		fmt.Sscanf(s.query[strings.Index(s.query, "VALUES (")+8:], "%d", &version)
+		s.d.versions[version] = true
+	case strings.HasPrefix(s.query, "DELETE FROM "+DefaultTable):
+		fmt.Sscanf(s.query, "DELETE FROM "+DefaultTable+" WHERE version = %d", &version)
+		delete(s.d.versions, version)
+	default:
+		s.d.statements = append(s.d.statements, s.query)
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s *testStmt) Query([]driver.Value) (driver.Rows, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	rows := &testRows{}
+	for v := range s.d.versions {
+		rows.versions = append(rows.versions, v)
+	}
+	return rows, nil
+}
+
+type testRows struct {
+	versions []int64
+}
+
+func (r *testRows) Columns() []string {
+	return []string{"version"}
+}
+
+func (r *testRows) Close() error {
+	return nil
+}
+
+func (r *testRows) Next(dest []driver.Value) error {
+	if len(r.versions) == 0 {
+		return io.EOF
+	}
+	dest[0] = r.versions[0]
+	r.versions = r.versions[1:]
+	return nil
+}
+
+var (
+	testDriverMu    sync.Mutex
+	testDriverCount int
+)
+
+// openTestDB returns a database backed by a new testDriver.
+func openTestDB(t *testing.T) (*sql.DB, *testDriver) {
+	testDriverMu.Lock()
+	defer testDriverMu.Unlock()
+	testDriverCount++
+	name := fmt.Sprintf("migrations-test-%d", testDriverCount)
+	d := &testDriver{versions: make(map[int64]bool)}
+	sql.Register(name, d)
+	db, err := sql.Open(name, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, d
+}
+
+func TestMigrator(t *testing.T) {
+	db, d := openTestDB(t)
+	defer db.Close()
+	failed := errors.New("failed")
+	var fail bool
+	migrations := []Migration{
+		{Version: 2, Name: "add_email", Up: SQL("ALTER 2"), Down: SQL("REVERT 2")},
+		{Version: 1, Name: "create_users", Up: SQL("CREATE 1"), Down: SQL("DROP 1")},
+		{Version: 3, Name: "seed", Up: func(ctx context.Context, tx *sql.Tx) error {
+			if fail {
+				return failed
+			}
+			_, err := tx.ExecContext(ctx, "INSERT 3")
+			return err
+		}},
+	}
+	m := NewMigrator(db, migrations)
+	ctx := context.Background()
+
+	fail = true
+	n, err := m.Migrate(ctx)
+	if err == nil || n != 2 {
+		t.Fatalf("unexpected migrate result %v %v", n, err)
+	}
+	fail = false
+	if n, err = m.Migrate(ctx); err != nil || n != 1 {
+		t.Fatalf("unexpected migrate result %v %v", n, err)
+	}
+	if strings.Join(d.statements, ",") != "CREATE 1,ALTER 2,INSERT 3" {
+		t.Fatalf("unexpected statements %v", d.statements)
+	}
+	// Version 3 can not be rolled back.
+	if n, err = m.Rollback(ctx, 1); err == nil || n != 0 {
+		t.Fatalf("unexpected rollback result %v %v", n, err)
+	}
+	delete(d.versions, 3)
+	if n, err = m.Rollback(ctx, 1); err != nil || n != 1 {
+		t.Fatalf("unexpected rollback result %v %v", n, err)
+	}
+	status, err := m.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Status{{1, "create_users", true}, {2, "add_email", false}, {3, "seed", false}}
+	if fmt.Sprint(status) != fmt.Sprint(expected) {
+		t.Fatalf("unexpected status %v", status)
+	}
+}
+
+func TestMigratorDuplicateVersion(t *testing.T) {
+	db, _ := openTestDB(t)
+	defer db.Close()
+	m := NewMigrator(db, []Migration{
+		{Version: 1, Name: "a", Up: SQL("A")},
+		{Version: 1, Name: "b", Up: SQL("B")},
+	})
+	if _, err := m.Migrate(context.Background()); err == nil {
+		t.Fatal("error expected")
+	}
+}
+
+func TestReadDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"0002_add_email.up.sql":      "ALTER",
+		"0001_create_users.up.sql":   "CREATE",
+		"0001_create_users.down.sql": "DROP",
+		"README.md":                  "",
+	}
+	for name, content := range files {
+		if err = ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	migrations, err := ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) != 2 {
+		t.Fatalf("unexpected migrations %+v", migrations)
+	}
+	if migrations[0].Version != 1 || migrations[0].Name != "create_users" || migrations[0].Down == nil {
+		t.Fatalf("unexpected migration %+v", migrations[0])
+	}
+	if migrations[1].Version != 2 || migrations[1].Name != "add_email" || migrations[1].Down != nil {
+		t.Fatalf("unexpected migration %+v", migrations[1])
+	}
+	// Down migration without up.
+	if err = ioutil.WriteFile(filepath.Join(dir, "0003_x.down.sql"), nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = ReadDir(dir); err == nil {
+		t.Fatal("error expected")
+	}
+}
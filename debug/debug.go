@@ -1,5 +1,10 @@
 /*
 Package debug adds debug endpoint to admin page.
+
+Profiling endpoints are only registered when enabled in configuration:
+
+	debug:
+	  pprof: true
 */
 package debug
 
@@ -8,26 +13,44 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/pprof"
+	runtimepprof "runtime/pprof"
+	"strconv"
 	"strings"
+	"time"
 
-	"github.com/goburrow/gol"
 	"github.com/goburrow/gomelon/core"
 )
 
 const (
 	pprofPath  = "/debug/pprof/"
 	expvarPath = "/debug/vars"
+	debugGroup = "Debug"
+
+	cpuProfileTaskName      = "cpuprofile"
+	defaultCPUProfileSecond = 30
 )
 
 var (
-	logger gol.Logger
+	logger core.Logger
 )
 
 func init() {
-	logger = gol.GetLogger("gomelon/debug")
+	logger = core.GetLogger("gomelon/debug")
+}
+
+// Configuration is implemented by application configuration which allows
+// enabling debug endpoints.
+type Configuration interface {
+	DebugFactory() *Factory
+}
+
+// Factory is the configuration of debug endpoints.
+type Factory struct {
+	// Pprof enables /debug/pprof/ endpoints on admin server.
+	Pprof bool
 }
 
-// Bundle adds pprof into admin environment.
+// Bundle adds expvar and pprof into admin environment.
 type Bundle struct {
 }
 
@@ -41,13 +64,19 @@ func NewBundle() *Bundle {
 func (b *Bundle) Initialize(bootstrap *core.Bootstrap) {
 }
 
-// Run registers /debug/vars and /debug/pprof/.
+// Run registers /debug/vars and /debug/pprof/ if it is enabled in
+// configuration.
 func (b *Bundle) Run(conf interface{}, env *core.Environment) error {
 	env.Admin.AddHandler(&expvarHandler{})
 
+	if c, ok := conf.(Configuration); !ok || !c.DebugFactory().Pprof {
+		logger.Debug("pprof is disabled")
+		return nil
+	}
 	pprofIndexHandler := &pprofHandler{}
 	env.Admin.AddHandler(pprofIndexHandler)
 	env.Admin.ServerHandler.Handle("*", pprofPath+"*", pprofIndexHandler)
+	env.Admin.AddTask(core.FromHTTPTask(&cpuProfileTask{}))
 	return nil
 }
 
@@ -59,6 +88,10 @@ func (h *pprofHandler) Name() string {
 	return "Profiling"
 }
 
+func (h *pprofHandler) Group() string {
+	return debugGroup
+}
+
 func (h *pprofHandler) Path() string {
 	return pprofPath
 }
@@ -73,7 +106,8 @@ func (h *pprofHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			pprof.Profile(w, r)
 		case "symbol":
 			pprof.Symbol(w, r)
-		// TODO: pprof.Trace
+		case "trace":
+			pprof.Trace(w, r)
 		default:
 			pprof.Handler(name).ServeHTTP(w, r)
 		}
@@ -83,6 +117,38 @@ func (h *pprofHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	pprof.Index(w, r)
 }
 
+// cpuProfileTask runs CPU profiling for the duration given in query
+// parameter seconds (default 30) and sends back the profile.
+type cpuProfileTask struct {
+}
+
+func (t *cpuProfileTask) Name() string {
+	return cpuProfileTaskName
+}
+
+func (t *cpuProfileTask) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	seconds := defaultCPUProfileSecond
+	if s := r.URL.Query().Get("seconds"); s != "" {
+		var err error
+		if seconds, err = strconv.Atoi(s); err != nil || seconds <= 0 {
+			http.Error(w, "invalid seconds parameter", http.StatusBadRequest)
+			return
+		}
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", `attachment; filename="cpu.pprof"`)
+	if err := runtimepprof.StartCPUProfile(w); err != nil {
+		http.Error(w, "could not enable CPU profiling: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	logger.Info("profiling CPU for %d seconds", seconds)
+	select {
+	case <-time.After(time.Duration(seconds) * time.Second):
+	case <-r.Context().Done():
+	}
+	runtimepprof.StopCPUProfile()
+}
+
 type expvarHandler struct {
 }
 
@@ -90,6 +156,10 @@ func (h *expvarHandler) Name() string {
 	return "Variables"
 }
 
+func (h *expvarHandler) Group() string {
+	return debugGroup
+}
+
 func (h *expvarHandler) Path() string {
 	return expvarPath
 }
@@ -11,15 +11,23 @@ import (
 	"github.com/goburrow/gomelon/server"
 )
 
+type configuration struct {
+	debug Factory
+}
+
+func (c *configuration) DebugFactory() *Factory {
+	return &c.debug
+}
+
 func TestBundle(t *testing.T) {
 	env := core.NewEnvironment()
 	handler := server.NewHandler()
 	env.Admin.ServerHandler = handler
 
 	bundle := NewBundle()
-	bundle.Run(nil, env)
+	bundle.Run(&configuration{Factory{Pprof: true}}, env)
 
-	server := httptest.NewServer(handler.ServeMux)
+	server := httptest.NewServer(handler)
 	defer server.Close()
 
 	res, err := http.Get(server.URL + "/debug/pprof/")
@@ -38,3 +46,40 @@ func TestBundle(t *testing.T) {
 		t.Fatalf("unexpected body %s", body)
 	}
 }
+
+func TestBundlePprofDisabled(t *testing.T) {
+	env := core.NewEnvironment()
+	handler := server.NewHandler()
+	env.Admin.ServerHandler = handler
+
+	bundle := NewBundle()
+	bundle.Run(&configuration{}, env)
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/debug/pprof/", nil)
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("unexpected response code: %v", w.Code)
+	}
+}
+
+func TestCPUProfileTask(t *testing.T) {
+	task := &cpuProfileTask{}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/tasks/cpuprofile?seconds=a", nil)
+	task.ServeHTTP(w, r)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("unexpected response code: %v", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r, _ = http.NewRequest("POST", "/tasks/cpuprofile?seconds=1", nil)
+	task.ServeHTTP(w, r)
+	if w.Code != http.StatusOK || w.Body.Len() == 0 {
+		t.Fatalf("unexpected response: %v %d", w.Code, w.Body.Len())
+	}
+	if w.Header().Get("Content-Disposition") == "" {
+		t.Fatalf("unexpected header: %v", w.Header())
+	}
+}
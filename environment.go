@@ -1,10 +1,12 @@
 package gomelon
 
 import (
+	"github.com/goburrow/gomelon/configuration"
 	"github.com/goburrow/gomelon/core"
 )
 
 // EnvironmentCommand creates a new Environment from provided Bootstrap.
+// Errors configuring the environment are returned as StartupError.
 type EnvironmentCommand struct {
 	ConfigurationCommand
 	Environment *core.Environment
@@ -18,15 +20,25 @@ func (command *EnvironmentCommand) Run(bootstrap *core.Bootstrap) error {
 	// Create environment
 	command.Environment = core.NewEnvironment()
 	command.Environment.Name = bootstrap.Application.Name()
+	if p, ok := bootstrap.Application.(core.BuildInfoProvider); ok {
+		command.Environment.BuildInfo.Merge(p.BuildInfo())
+	}
 	command.Environment.Validator = bootstrap.ValidatorFactory.Validator()
+	command.Environment.Admin.AddHandler(configuration.NewHandler(command.Configuration))
 	// Config other factories that affect this environment.
 	if err := command.configuration.LoggingFactory().Configure(command.Environment); err != nil {
 		command.Environment.SetStopped()
-		return err
+		return &StartupError{Err: err}
 	}
 	if err := command.configuration.MetricsFactory().Configure(command.Environment); err != nil {
 		command.Environment.SetStopped()
-		return err
+		return &StartupError{Err: err}
+	}
+	if c, ok := command.configuration.(core.HealthCheckConfiguration); ok {
+		if err := c.HealthCheckFactory().Configure(command.Environment); err != nil {
+			command.Environment.SetStopped()
+			return &StartupError{Err: err}
+		}
 	}
 	return nil
 }
@@ -0,0 +1,109 @@
+package gomelon
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/goburrow/gomelon/core"
+)
+
+// Exit codes returned by RunAndExit.
+const (
+	// ExitOK is returned when the command completed successfully.
+	ExitOK = 0
+	// ExitFailure is returned for errors not classified below, e.g. the
+	// command itself failed.
+	ExitFailure = 1
+	// ExitUsage is returned for an unknown command or invalid flags.
+	ExitUsage = 2
+	// ExitConfiguration is returned when the configuration could not be
+	// loaded or is invalid.
+	ExitConfiguration = 3
+	// ExitStartup is returned when the environment, server or application
+	// could not be started.
+	ExitStartup = 4
+)
+
+// UnknownCommandError is returned by Run when no command matches the name
+// given in arguments.
+type UnknownCommandError struct {
+	Command string
+}
+
+func (e *UnknownCommandError) Error() string {
+	return fmt.Sprintf("gomelon: unknown command %q", e.Command)
+}
+
+// UsageError is returned by Run when flags of the command are invalid.
+type UsageError struct {
+	Command string
+	Err     error
+}
+
+func (e *UsageError) Error() string {
+	return fmt.Sprintf("gomelon: invalid usage of command %q: %v", e.Command, e.Err)
+}
+
+func (e *UsageError) Unwrap() error {
+	return e.Err
+}
+
+// ConfigurationError is returned when the configuration could not be built
+// or validated.
+type ConfigurationError struct {
+	Err error
+}
+
+func (e *ConfigurationError) Error() string {
+	return "gomelon: invalid configuration: " + e.Err.Error()
+}
+
+func (e *ConfigurationError) Unwrap() error {
+	return e.Err
+}
+
+// StartupError is returned when the environment, server, bundles or
+// application failed to start.
+type StartupError struct {
+	Err error
+}
+
+func (e *StartupError) Error() string {
+	return "gomelon: could not start: " + e.Err.Error()
+}
+
+func (e *StartupError) Unwrap() error {
+	return e.Err
+}
+
+// ExitCode returns the exit code for the error returned by Run.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	var unknownCommand *UnknownCommandError
+	var usage *UsageError
+	var configuration *ConfigurationError
+	var startup *StartupError
+	switch {
+	case errors.As(err, &unknownCommand), errors.As(err, &usage):
+		return ExitUsage
+	case errors.As(err, &configuration):
+		return ExitConfiguration
+	case errors.As(err, &startup):
+		return ExitStartup
+	default:
+		return ExitFailure
+	}
+}
+
+// RunAndExit runs the application with command line arguments and exits
+// the process with the code given by ExitCode.
+func RunAndExit(app core.Application) {
+	err := Run(app, os.Args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	os.Exit(ExitCode(err))
+}
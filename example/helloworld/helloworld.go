@@ -2,7 +2,6 @@ package main
 
 import (
 	"net/http"
-	"os"
 
 	"github.com/goburrow/gomelon"
 	"github.com/goburrow/gomelon/core"
@@ -44,5 +43,5 @@ func (app *application) Run(conf interface{}, env *core.Environment) error {
 //   http://localhost:8080/admin/
 func main() {
 	app := &application{}
-	gomelon.Run(app, os.Args[1:])
+	gomelon.RunAndExit(app)
 }
@@ -1,20 +1,20 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"sync"
 
-	"github.com/goburrow/gol"
 	"github.com/goburrow/gomelon"
 	"github.com/goburrow/gomelon/assets"
 	"github.com/goburrow/gomelon/core"
 	"github.com/goburrow/gomelon/debug"
 	"github.com/goburrow/gomelon/rest"
 	"github.com/goburrow/health"
-	"golang.org/x/net/context"
 )
 
 // User is data model for user.
@@ -31,7 +31,7 @@ var (
 	mu    sync.RWMutex
 	users = make(map[string]*User)
 
-	logger          = gol.GetLogger("example")
+	logger          = core.GetLogger("example")
 	errUserNotFound = rest.NewHTTPError("User not found.", http.StatusNotFound)
 	errUserExisted  = rest.NewHTTPError("User existed.", http.StatusConflict)
 )
@@ -58,7 +58,7 @@ func (r *usersResource) GET(c context.Context) (interface{}, error) {
 
 func (r *usersResource) POST(c context.Context) (interface{}, error) {
 	user := &User{}
-	if err := rest.ValidEntityFromContext(c, user); err != nil {
+	if err := rest.Entity(c, user); err != nil {
 		return nil, err
 	}
 	mu.Lock()
@@ -68,7 +68,7 @@ func (r *usersResource) POST(c context.Context) (interface{}, error) {
 		return nil, errUserExisted
 	}
 	users[user.Name] = user
-	return "Created.", nil
+	return rest.Created("/user/"+user.Name, user), nil
 }
 
 // Consumes indicates that usersResource only accepts JSON.
@@ -90,11 +90,11 @@ func (r *userResource) Path() string {
 }
 
 func (r *userResource) GET(c context.Context) (interface{}, error) {
-	params := rest.ParamsFromContext(c)
+	name := rest.PathParam(c, "name")
 	mu.RLock()
 	defer mu.RUnlock()
 
-	user, ok := users[params["name"]]
+	user, ok := users[name]
 	if !ok {
 		return nil, errUserNotFound
 	}
@@ -102,31 +102,31 @@ func (r *userResource) GET(c context.Context) (interface{}, error) {
 }
 
 func (r *userResource) POST(c context.Context) (interface{}, error) {
-	params := rest.ParamsFromContext(c)
+	name := rest.PathParam(c, "name")
 	mu.Lock()
 	defer mu.Unlock()
 
-	user, ok := users[params["name"]]
+	user, ok := users[name]
 	if !ok {
 		return nil, errUserNotFound
 	}
-	if err := rest.ValidEntityFromContext(c, user); err != nil {
+	if err := rest.Entity(c, user); err != nil {
 		return nil, err
 	}
-	users[params["name"]] = user
+	users[name] = user
 	return "Updated.", nil
 }
 
 func (r *userResource) DELETE(c context.Context) (interface{}, error) {
-	params := rest.ParamsFromContext(c)
+	name := rest.PathParam(c, "name")
 	mu.Lock()
 	defer mu.Unlock()
-	_, ok := users[params["name"]]
+	_, ok := users[name]
 	if !ok {
 		return nil, errUserNotFound
 	}
-	delete(users, params["name"])
-	return "Deleted.", nil
+	delete(users, name)
+	return rest.NoContent(), nil
 }
 
 func (r *userResource) Metrics() string {
@@ -142,11 +142,12 @@ func (*usersTask) Name() string {
 }
 
 // usersTask is a task for management
-func (*usersTask) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+func (*usersTask) Execute(params map[string][]string, out io.Writer) error {
 	mu.Lock()
 	defer mu.Unlock()
 	users = make(map[string]*User)
-	w.Write([]byte("Removed."))
+	io.WriteString(out, "Removed.")
+	return nil
 }
 
 // greetings logs when application starts and stops. It implements core.Managed.
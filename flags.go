@@ -0,0 +1,74 @@
+package gomelon
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/goburrow/gomelon/core"
+)
+
+// newFlagSet returns flags of the command with usage generated from its
+// name, description and flag definitions.
+func newFlagSet(command core.Command) *flag.FlagSet {
+	flags := flag.NewFlagSet(command.Name(), flag.ContinueOnError)
+	if c, ok := command.(core.CommandFlags); ok {
+		c.AddFlags(flags)
+	}
+	flags.Usage = func() {
+		printUsage(flags.Output(), command, flags)
+	}
+	return flags
+}
+
+// printUsage prints synopsis, description, flags and examples of the
+// command.
+func printUsage(w io.Writer, command core.Command, flags *flag.FlagSet) {
+	program := filepath.Base(os.Args[0])
+	synopsis := "[flags] [arguments]"
+	var examples []string
+	if u, ok := command.(core.CommandUsage); ok {
+		synopsis = u.Synopsis()
+		examples = u.Examples()
+	}
+	fmt.Fprintf(w, "Usage: %s %s %s\n\n%s\n", program, command.Name(), synopsis, command.Description())
+	if hasFlags(flags) {
+		fmt.Fprintln(w, "\nFlags:")
+		flags.SetOutput(w)
+		flags.PrintDefaults()
+	}
+	if len(examples) > 0 {
+		fmt.Fprintln(w, "\nExamples:")
+		for _, example := range examples {
+			fmt.Fprintf(w, "  %s %s\n", program, example)
+		}
+	}
+}
+
+func hasFlags(flags *flag.FlagSet) bool {
+	n := 0
+	flags.VisitAll(func(*flag.Flag) {
+		n++
+	})
+	return n > 0
+}
+
+// parseArguments parses flags which can be placed before or after
+// positional arguments, e.g. "config.yml --dry-run", and returns the
+// positional arguments.
+func parseArguments(flags *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, err
+		}
+		args = flags.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
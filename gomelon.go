@@ -4,36 +4,52 @@ Package gomelon provides a lightweight framework for building web services.
 package gomelon
 
 import (
-	"fmt"
+	"flag"
 	"os"
 
 	"github.com/goburrow/gomelon/configuration"
 	"github.com/goburrow/gomelon/core"
+	"github.com/goburrow/gomelon/logging"
 	"github.com/goburrow/gomelon/validation"
 )
 
-func printHelp(bootstrap *core.Bootstrap) {
-	fmt.Fprintln(os.Stdout, "Available commands:")
-	for _, command := range bootstrap.Commands() {
-		fmt.Fprintf(os.Stdout, "  %-20s\t%s\n", command.Name(), command.Description())
-	}
-}
-
-// Run executes application with given arguments
+// Run executes application with given arguments. It prints available
+// commands if no command is given and returns UnknownCommandError if the
+// command is not found. Errors returned by built-in commands can be
+// UsageError, ConfigurationError or StartupError. See ExitCode.
 func Run(app core.Application, args []string) error {
 	bootstrap := core.NewBootstrap(app)
+	bootstrap.AddCommand(&HelpCommand{})
 	bootstrap.Arguments = args
-	bootstrap.ConfigurationFactory = &configuration.Factory{&Configuration{}}
+	bootstrap.ConfigurationFactory = &configuration.Factory{Configuration: &Configuration{}}
 	bootstrap.ValidatorFactory = &validation.Factory{}
 
 	app.Initialize(bootstrap)
+	if err := logging.ConfigureBootstrap(bootstrap); err != nil {
+		return err
+	}
 	if len(args) > 0 {
 		for _, command := range bootstrap.Commands() {
 			if command.Name() == args[0] {
-				return command.Run(bootstrap)
+				return runCommand(bootstrap, command, args)
 			}
 		}
+		printHelp(os.Stderr, bootstrap)
+		return &UnknownCommandError{Command: args[0]}
 	}
-	printHelp(bootstrap)
+	printHelp(os.Stdout, bootstrap)
 	return nil
 }
+
+// runCommand parses flags of the command and runs it.
+func runCommand(bootstrap *core.Bootstrap, command core.Command, args []string) error {
+	positional, err := parseArguments(newFlagSet(command), args[1:])
+	if err != nil {
+		if err == flag.ErrHelp {
+			return nil
+		}
+		return &UsageError{Command: command.Name(), Err: err}
+	}
+	bootstrap.Arguments = append([]string{args[0]}, positional...)
+	return command.Run(bootstrap)
+}
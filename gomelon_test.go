@@ -0,0 +1,85 @@
+package gomelon
+
+import (
+	"errors"
+	"flag"
+	"testing"
+
+	"github.com/goburrow/gomelon/core"
+)
+
+type flagCommand struct {
+	verbose   bool
+	name      string
+	arguments []string
+}
+
+func (c *flagCommand) Name() string {
+	return "test"
+}
+
+func (c *flagCommand) Description() string {
+	return "tests flags"
+}
+
+func (c *flagCommand) AddFlags(flags *flag.FlagSet) {
+	flags.BoolVar(&c.verbose, "verbose", false, "verbose output")
+	flags.StringVar(&c.name, "name", "", "name")
+}
+
+func (c *flagCommand) Run(bootstrap *core.Bootstrap) error {
+	c.arguments = bootstrap.Arguments
+	return nil
+}
+
+type flagApplication struct {
+	Application
+	command *flagCommand
+}
+
+func (app *flagApplication) Initialize(bootstrap *core.Bootstrap) {
+	bootstrap.AddCommand(app.command)
+}
+
+func TestRunCommandFlags(t *testing.T) {
+	app := &flagApplication{command: &flagCommand{}}
+	err := Run(app, []string{"test", "a", "--verbose", "b", "-name=x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := app.command
+	if !c.verbose || c.name != "x" {
+		t.Fatalf("unexpected flags %+v", c)
+	}
+	if len(c.arguments) != 3 || c.arguments[0] != "test" || c.arguments[1] != "a" || c.arguments[2] != "b" {
+		t.Fatalf("unexpected arguments %v", c.arguments)
+	}
+	if err = Run(app, []string{"test", "--unknown"}); err == nil {
+		t.Fatal("error expected")
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	app := &flagApplication{command: &flagCommand{}}
+	err := Run(app, []string{"unknown"})
+	if _, ok := err.(*UnknownCommandError); !ok || ExitCode(err) != ExitUsage {
+		t.Fatalf("unexpected error %#v", err)
+	}
+	err = Run(app, []string{"test", "--unknown"})
+	if _, ok := err.(*UsageError); !ok || ExitCode(err) != ExitUsage {
+		t.Fatalf("unexpected error %#v", err)
+	}
+	err = Run(&Application{}, []string{"check", "not-found.yml"})
+	if _, ok := err.(*ConfigurationError); !ok || ExitCode(err) != ExitConfiguration {
+		t.Fatalf("unexpected error %#v", err)
+	}
+	if code := ExitCode(&StartupError{Err: errors.New("startup")}); code != ExitStartup {
+		t.Fatalf("unexpected exit code %v", code)
+	}
+	if code := ExitCode(errors.New("failure")); code != ExitFailure {
+		t.Fatalf("unexpected exit code %v", code)
+	}
+	if code := ExitCode(nil); code != ExitOK {
+		t.Fatalf("unexpected exit code %v", code)
+	}
+}
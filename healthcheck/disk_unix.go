@@ -0,0 +1,17 @@
+//go:build !windows
+// +build !windows
+
+package healthcheck
+
+import (
+	"syscall"
+)
+
+// freeSpace returns number of bytes available to unprivileged users.
+func freeSpace(path string) (uint64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, err
+	}
+	return uint64(stat.Bavail) * uint64(stat.Bsize), nil
+}
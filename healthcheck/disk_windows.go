@@ -0,0 +1,10 @@
+package healthcheck
+
+import (
+	"errors"
+)
+
+// freeSpace is not supported on Windows.
+func freeSpace(path string) (uint64, error) {
+	return 0, errors.New("healthcheck: disk space is not supported")
+}
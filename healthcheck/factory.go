@@ -0,0 +1,149 @@
+package healthcheck
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/goburrow/gomelon/core"
+	"github.com/goburrow/health"
+	"github.com/goburrow/polytype"
+)
+
+func init() {
+	polytype.Register("TCPHealthCheck", func() interface{} { return &TCPFactory{} })
+	polytype.Register("HTTPHealthCheck", func() interface{} { return &HTTPFactory{} })
+	polytype.Register("DiskSpaceHealthCheck", func() interface{} { return &DiskSpaceFactory{} })
+	polytype.Register("GoroutinesHealthCheck", func() interface{} { return &GoroutinesFactory{} })
+}
+
+// CheckerFactory builds a health check from configuration.
+type CheckerFactory interface {
+	Build() (health.Checker, error)
+	// Common returns settings shared by all types of health check.
+	Common() *CommonConfiguration
+}
+
+// CommonConfiguration is the configuration shared by all types of health
+// check.
+type CommonConfiguration struct {
+	Name string `valid:"nonzero"`
+	// Period runs the check in background at the interval, e.g. "30s".
+	Period string
+	// Timeout overrides the default timeout of the check.
+	Timeout string
+	// NonCritical failures do not make the application unhealthy.
+	NonCritical bool
+}
+
+func (c *CommonConfiguration) Common() *CommonConfiguration {
+	return c
+}
+
+func (c *CommonConfiguration) options() (core.HealthCheckOptions, error) {
+	options := core.HealthCheckOptions{}
+	var err error
+	if c.Period != "" {
+		if options.Period, err = time.ParseDuration(c.Period); err != nil {
+			return options, fmt.Errorf("healthcheck: invalid period of %s %v", c.Name, err)
+		}
+	}
+	if c.Timeout != "" {
+		if options.Timeout, err = time.ParseDuration(c.Timeout); err != nil {
+			return options, fmt.Errorf("healthcheck: invalid timeout of %s %v", c.Name, err)
+		}
+	}
+	if c.NonCritical {
+		options.Severity = core.HealthCheckNonCritical
+	}
+	return options, nil
+}
+
+// TCPFactory is the configuration of TCPChecker.
+type TCPFactory struct {
+	CommonConfiguration
+	Addr string `valid:"nonzero"`
+}
+
+func (f *TCPFactory) Build() (health.Checker, error) {
+	options, err := f.options()
+	if err != nil {
+		return nil, err
+	}
+	return &TCPChecker{Addr: f.Addr, Timeout: options.Timeout}, nil
+}
+
+// HTTPFactory is the configuration of HTTPChecker.
+type HTTPFactory struct {
+	CommonConfiguration
+	URL string `valid:"nonzero"`
+}
+
+func (f *HTTPFactory) Build() (health.Checker, error) {
+	options, err := f.options()
+	if err != nil {
+		return nil, err
+	}
+	return &HTTPChecker{URL: f.URL, Timeout: options.Timeout}, nil
+}
+
+// DiskSpaceFactory is the configuration of DiskSpaceChecker.
+type DiskSpaceFactory struct {
+	CommonConfiguration
+	Path         string `valid:"nonzero"`
+	MinFreeBytes uint64
+}
+
+func (f *DiskSpaceFactory) Build() (health.Checker, error) {
+	return &DiskSpaceChecker{Path: f.Path, MinFreeBytes: f.MinFreeBytes}, nil
+}
+
+// GoroutinesFactory is the configuration of GoroutinesChecker.
+type GoroutinesFactory struct {
+	CommonConfiguration
+	Max int `valid:"nonzero"`
+}
+
+func (f *GoroutinesFactory) Build() (health.Checker, error) {
+	return &GoroutinesChecker{Max: f.Max}, nil
+}
+
+// CheckConfiguration is an union of health check configurations.
+type CheckConfiguration struct {
+	polytype.Type
+}
+
+// Factory configures health checks of the admin environment.
+type Factory struct {
+	// Timeout is the default timeout of all health checks, e.g. "10s".
+	Timeout string
+	Checks  []CheckConfiguration
+}
+
+// Factory implements core.HealthCheckFactory interface.
+var _ core.HealthCheckFactory = (*Factory)(nil)
+
+func (factory *Factory) Configure(env *core.Environment) error {
+	if factory.Timeout != "" {
+		timeout, err := time.ParseDuration(factory.Timeout)
+		if err != nil {
+			return fmt.Errorf("healthcheck: invalid timeout %v", err)
+		}
+		env.Admin.HealthChecks.SetTimeout(timeout)
+	}
+	for _, check := range factory.Checks {
+		f, ok := check.Value().(CheckerFactory)
+		if !ok {
+			return fmt.Errorf("healthcheck: unsupported health check %#v", check.Value())
+		}
+		checker, err := f.Build()
+		if err != nil {
+			return err
+		}
+		options, err := f.Common().options()
+		if err != nil {
+			return err
+		}
+		env.Admin.HealthChecks.RegisterWithOptions(f.Common().Name, checker, options)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+/*
+Package healthcheck provides reusable health checks and their configuration.
+
+Health checks can be registered from code:
+
+	env.Admin.HealthChecks.Register("database", &healthcheck.TCPChecker{Addr: "db:5432"})
+
+or from configuration:
+
+	healthChecks:
+	  timeout: 5s
+	  checks:
+	  - type: TCPHealthCheck
+	    name: database
+	    addr: db:5432
+	  - type: DiskSpaceHealthCheck
+	    name: disk
+	    path: /var/lib/app
+	    minFreeBytes: 1073741824
+	    nonCritical: true
+*/
+package healthcheck
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"runtime"
+	"time"
+
+	"github.com/goburrow/health"
+)
+
+const (
+	defaultTimeout = 5 * time.Second
+)
+
+// TCPChecker checks if a TCP connection can be established to the address.
+type TCPChecker struct {
+	Addr string
+	// Timeout is the maximum duration for connecting. Default is 5s.
+	Timeout time.Duration
+}
+
+var _ health.Checker = (*TCPChecker)(nil)
+
+func (c *TCPChecker) Check() health.Result {
+	conn, err := net.DialTimeout("tcp", c.Addr, timeoutOrDefault(c.Timeout))
+	if err != nil {
+		return health.ResultUnhealthy("could not connect to "+c.Addr, err)
+	}
+	conn.Close()
+	return health.ResultHealthy("")
+}
+
+// HTTPChecker checks if a GET request to the URL responds with a
+// successful (2xx) status.
+type HTTPChecker struct {
+	URL string
+	// Timeout is the maximum duration of the request. Default is 5s.
+	Timeout time.Duration
+}
+
+var _ health.Checker = (*HTTPChecker)(nil)
+
+func (c *HTTPChecker) Check() health.Result {
+	client := &http.Client{
+		Timeout: timeoutOrDefault(c.Timeout),
+	}
+	resp, err := client.Get(c.URL)
+	if err != nil {
+		return health.ResultUnhealthy("could not get "+c.URL, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return health.ResultUnhealthy(fmt.Sprintf("unexpected status %d from %s", resp.StatusCode, c.URL), nil)
+	}
+	return health.ResultHealthy("")
+}
+
+// DiskSpaceChecker checks if free space of the file system containing
+// the path is at least MinFreeBytes.
+type DiskSpaceChecker struct {
+	Path         string
+	MinFreeBytes uint64
+}
+
+var _ health.Checker = (*DiskSpaceChecker)(nil)
+
+func (c *DiskSpaceChecker) Check() health.Result {
+	free, err := freeSpace(c.Path)
+	if err != nil {
+		return health.ResultUnhealthy("could not get free space of "+c.Path, err)
+	}
+	if free < c.MinFreeBytes {
+		return health.ResultUnhealthy(fmt.Sprintf("free space %d bytes is less than %d bytes", free, c.MinFreeBytes), nil)
+	}
+	return health.ResultHealthy(fmt.Sprintf("free space %d bytes", free))
+}
+
+// GoroutinesChecker checks if number of goroutines does not exceed Max.
+type GoroutinesChecker struct {
+	Max int
+}
+
+var _ health.Checker = (*GoroutinesChecker)(nil)
+
+func (c *GoroutinesChecker) Check() health.Result {
+	n := runtime.NumGoroutine()
+	if n > c.Max {
+		return health.ResultUnhealthy(fmt.Sprintf("%d goroutines exceeds %d", n, c.Max), nil)
+	}
+	return health.ResultHealthy(fmt.Sprintf("%d goroutines", n))
+}
+
+func timeoutOrDefault(timeout time.Duration) time.Duration {
+	if timeout > 0 {
+		return timeout
+	}
+	return defaultTimeout
+}
@@ -0,0 +1,106 @@
+package healthcheck
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/goburrow/gomelon/core"
+)
+
+func TestTCPChecker(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	checker := &TCPChecker{Addr: addr}
+	if result := checker.Check(); !result.Healthy() {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	l.Close()
+	if result := checker.Check(); result.Healthy() {
+		t.Fatalf("unexpected result %+v", result)
+	}
+}
+
+func TestHTTPChecker(t *testing.T) {
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	checker := &HTTPChecker{URL: server.URL}
+	if result := checker.Check(); !result.Healthy() {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	status = http.StatusServiceUnavailable
+	if result := checker.Check(); result.Healthy() {
+		t.Fatalf("unexpected result %+v", result)
+	}
+}
+
+func TestDiskSpaceChecker(t *testing.T) {
+	checker := &DiskSpaceChecker{Path: os.TempDir(), MinFreeBytes: 1}
+	if result := checker.Check(); !result.Healthy() {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	checker.MinFreeBytes = 1<<64 - 1
+	if result := checker.Check(); result.Healthy() {
+		t.Fatalf("unexpected result %+v", result)
+	}
+}
+
+func TestGoroutinesChecker(t *testing.T) {
+	checker := &GoroutinesChecker{Max: 1000000}
+	if result := checker.Check(); !result.Healthy() {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	checker.Max = 0
+	if result := checker.Check(); result.Healthy() {
+		t.Fatalf("unexpected result %+v", result)
+	}
+}
+
+func TestFactory(t *testing.T) {
+	data := `{
+		"timeout": "2s",
+		"checks": [
+			{"type": "GoroutinesHealthCheck", "name": "goroutines", "max": 1000000},
+			{"type": "DiskSpaceHealthCheck", "name": "disk", "path": "/", "nonCritical": true, "timeout": "1s"}
+		]
+	}`
+	var factory Factory
+	if err := json.Unmarshal([]byte(data), &factory); err != nil {
+		t.Fatal(err)
+	}
+	env := core.NewEnvironment()
+	if err := factory.Configure(env); err != nil {
+		t.Fatal(err)
+	}
+	names := env.Admin.HealthChecks.Names()
+	if len(names) != 2 || names[0] != "disk" || names[1] != "goroutines" {
+		t.Fatalf("unexpected names %v", names)
+	}
+	results := env.Admin.HealthChecks.Results("disk")
+	if results["disk"].Severity != core.HealthCheckNonCritical {
+		t.Fatalf("unexpected result %+v", results["disk"])
+	}
+}
+
+func TestFactoryInvalidDuration(t *testing.T) {
+	factory := Factory{
+		Checks: []CheckConfiguration{{}},
+	}
+	factory.Checks[0].SetValue(&TCPFactory{
+		CommonConfiguration: CommonConfiguration{Name: "tcp", Period: "x"},
+		Addr:                "localhost:1",
+	})
+	if err := factory.Configure(core.NewEnvironment()); err == nil {
+		t.Fatal("error expected")
+	}
+}
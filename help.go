@@ -0,0 +1,67 @@
+package gomelon
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/goburrow/gomelon/core"
+)
+
+// HelpCommand prints available commands or usage of the given command.
+// Commands can implement core.CommandUsage to provide synopsis and examples.
+type HelpCommand struct {
+}
+
+var _ core.Command = (*HelpCommand)(nil)
+var _ core.CommandUsage = (*HelpCommand)(nil)
+
+// Name returns name of the HelpCommand.
+func (command *HelpCommand) Name() string {
+	return "help"
+}
+
+// Description returns description of the HelpCommand.
+func (command *HelpCommand) Description() string {
+	return "prints usage of a command"
+}
+
+// Synopsis returns arguments of the HelpCommand.
+func (command *HelpCommand) Synopsis() string {
+	return "[command]"
+}
+
+// Examples returns examples of the HelpCommand.
+func (command *HelpCommand) Examples() []string {
+	return []string{"help server"}
+}
+
+// Run prints usage of the command given in the first argument or lists all
+// commands if there is none.
+func (command *HelpCommand) Run(bootstrap *core.Bootstrap) error {
+	if len(bootstrap.Arguments) < 2 {
+		printHelp(os.Stdout, bootstrap)
+		return nil
+	}
+	return printCommandHelp(os.Stdout, bootstrap, bootstrap.Arguments[1])
+}
+
+func printHelp(w io.Writer, bootstrap *core.Bootstrap) {
+	program := filepath.Base(os.Args[0])
+	fmt.Fprintf(w, "Usage: %s <command> [flags] [arguments]\n\nAvailable commands:\n", program)
+	for _, command := range bootstrap.Commands() {
+		fmt.Fprintf(w, "  %-20s\t%s\n", command.Name(), command.Description())
+	}
+	fmt.Fprintf(w, "\nRun '%s help <command>' for usage of a command.\n", program)
+}
+
+func printCommandHelp(w io.Writer, bootstrap *core.Bootstrap, name string) error {
+	for _, command := range bootstrap.Commands() {
+		if command.Name() == name {
+			printUsage(w, command, newFlagSet(command))
+			return nil
+		}
+	}
+	return &UnknownCommandError{Command: name}
+}
@@ -0,0 +1,37 @@
+package gomelon
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/goburrow/gomelon/core"
+)
+
+func TestPrintCommandHelp(t *testing.T) {
+	bootstrap := core.NewBootstrap(&Application{})
+	bootstrap.AddCommand(&ServerCommand{})
+	bootstrap.AddCommand(&flagCommand{})
+
+	var buf bytes.Buffer
+	if err := printCommandHelp(&buf, bootstrap, "server"); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, s := range []string{" server [flags] [config]\n", "runs the application as an HTTP server\n", "\nFlags:\n", "-dry-run", "\nExamples:\n", " server config.yml\n"} {
+		if !strings.Contains(out, s) {
+			t.Fatalf("%q not found in:\n%s", s, out)
+		}
+	}
+	buf.Reset()
+	if err := printCommandHelp(&buf, bootstrap, "test"); err != nil {
+		t.Fatal(err)
+	}
+	out = buf.String()
+	if !strings.Contains(out, " test [flags] [arguments]\n") || strings.Contains(out, "Examples:") {
+		t.Fatalf("unexpected usage:\n%s", out)
+	}
+	if err := printCommandHelp(&buf, bootstrap, "unknown"); err == nil {
+		t.Fatal("error expected")
+	}
+}
@@ -0,0 +1,87 @@
+package gomelon
+
+import (
+	"flag"
+
+	"github.com/goburrow/gomelon/core"
+)
+
+const jobLoggerName = "gomelon/job"
+
+// JobCommand builds the environment as the server command does, i.e. builds
+// the server, runs bundles and the application and starts managed objects,
+// but calls Action instead of starting the server. It is intended for
+// one-shot jobs such as data import or maintenance scripts which share
+// configuration, connection pools and metrics with the application, e.g.
+//
+//	bootstrap.AddCommand(&gomelon.JobCommand{
+//		CommandName:        "import",
+//		CommandDescription: "imports data from a file",
+//		Action: func(bootstrap *core.Bootstrap, configuration interface{}, env *core.Environment) error {
+//			...
+//		},
+//	})
+//
+// Scheduled jobs are not run and managed objects are stopped after Action
+// returns.
+type JobCommand struct {
+	EnvironmentCommand
+
+	CommandName        string
+	CommandDescription string
+	// Flags adds flags of the command in addition to --config.
+	Flags func(flags *flag.FlagSet)
+	// Action is called after managed objects have been started.
+	Action func(bootstrap *core.Bootstrap, configuration interface{}, env *core.Environment) error
+}
+
+var _ core.Command = (*JobCommand)(nil)
+var _ core.CommandFlags = (*JobCommand)(nil)
+
+// Name returns CommandName.
+func (command *JobCommand) Name() string {
+	return command.CommandName
+}
+
+// Description returns CommandDescription.
+func (command *JobCommand) Description() string {
+	return command.CommandDescription
+}
+
+// AddFlags adds flag --config and flags given by Flags.
+func (command *JobCommand) AddFlags(flags *flag.FlagSet) {
+	command.EnvironmentCommand.AddFlags(flags)
+	if command.Flags != nil {
+		command.Flags(flags)
+	}
+}
+
+// Run builds the environment and calls Action.
+func (command *JobCommand) Run(bootstrap *core.Bootstrap) error {
+	if err := command.EnvironmentCommand.Run(bootstrap); err != nil {
+		return err
+	}
+	env := command.Environment
+	defer env.SetStopped()
+	env.Scheduler.Disable()
+	logger := core.GetLogger(jobLoggerName)
+	// The server is built, but never started, so that bundles and the
+	// application can register handlers.
+	if _, err := command.configuration.ServerFactory().Build(env); err != nil {
+		logger.Error("could not create server: %v", err)
+		return &StartupError{Err: err}
+	}
+	if err := bootstrap.Run(command.Configuration, env); err != nil {
+		logger.Error("could not run bootstrap: %v", err)
+		return &StartupError{Err: err}
+	}
+	if err := bootstrap.Application.Run(command.Configuration, env); err != nil {
+		logger.Error("could not run application: %v", err)
+		return &StartupError{Err: err}
+	}
+	if err := env.StartLifecycle(); err != nil {
+		logger.Error("could not start: %v", err)
+		return &StartupError{Err: err}
+	}
+	return command.Action(bootstrap, command.Configuration, env)
+}
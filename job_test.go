@@ -0,0 +1,76 @@
+package gomelon
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/goburrow/gomelon/core"
+)
+
+type jobApplication struct {
+	Application
+	command *JobCommand
+	managed *jobManaged
+}
+
+func (app *jobApplication) Initialize(bootstrap *core.Bootstrap) {
+	bootstrap.AddCommand(app.command)
+}
+
+func (app *jobApplication) Run(_ interface{}, env *core.Environment) error {
+	// Handlers can be registered though the server is not started.
+	env.Admin.ServerHandler.Handle("GET", "/job", http.NotFoundHandler())
+	env.Lifecycle.Manage(app.managed)
+	return nil
+}
+
+type jobManaged struct {
+	started, stopped bool
+}
+
+func (m *jobManaged) Start() error {
+	m.started = true
+	return nil
+}
+
+func (m *jobManaged) Stop() error {
+	m.stopped = true
+	return nil
+}
+
+func TestJobCommand(t *testing.T) {
+	dir, err := ioutil.TempDir("", "job")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "config.yml")
+	if err = ioutil.WriteFile(path, []byte("server:\n  type: simple\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	app := &jobApplication{managed: &jobManaged{}}
+	var started bool
+	app.command = &JobCommand{
+		CommandName:        "import",
+		CommandDescription: "imports data",
+		Action: func(bootstrap *core.Bootstrap, configuration interface{}, env *core.Environment) error {
+			if _, ok := configuration.(*Configuration); !ok {
+				t.Fatalf("unexpected configuration %#v", configuration)
+			}
+			started = app.managed.started
+			return nil
+		},
+	}
+	if err = Run(app, []string{"import", path}); err != nil {
+		t.Fatal(err)
+	}
+	if !started {
+		t.Fatal("managed object is not started before action")
+	}
+	if !app.managed.stopped {
+		t.Fatal("managed object is not stopped")
+	}
+}
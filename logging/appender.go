@@ -4,13 +4,13 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/goburrow/gol"
 	"github.com/goburrow/gomelon/core"
 
-	golfile "github.com/goburrow/gol/file"
-	golrotation "github.com/goburrow/gol/file/rotation"
 	golfilter "github.com/goburrow/gol/filter"
 	golsyslog "github.com/goburrow/gol/syslog"
 )
@@ -62,6 +62,11 @@ type filteredAppenderFactory struct {
 	Threshold string
 	Includes  []string
 	Excludes  []string
+	// MaxEventsPerSecond limits events of each logger. Zero means no limit.
+	MaxEventsPerSecond int `valid:"min=0"`
+	// LimitPerMessage applies MaxEventsPerSecond to each logger and message
+	// format separately.
+	LimitPerMessage bool
 }
 
 func (factory *filteredAppenderFactory) Build(appender gol.Appender) (gol.Appender, error) {
@@ -69,6 +74,11 @@ func (factory *filteredAppenderFactory) Build(appender gol.Appender) (gol.Append
 	if err != nil {
 		return nil, err
 	}
+	if factory.MaxEventsPerSecond > 0 {
+		sampling := NewSamplingAppender(appender, factory.MaxEventsPerSecond)
+		sampling.PerMessage = factory.LimitPerMessage
+		appender = sampling
+	}
 	a := golfilter.NewAppender(appender)
 	a.SetThreshold(threshold)
 	if len(factory.Includes) > 0 {
@@ -83,6 +93,7 @@ func (factory *filteredAppenderFactory) Build(appender gol.Appender) (gol.Append
 // ConsoleAppenderFactory provides an appender that writes logging events to the console.
 type ConsoleAppenderFactory struct {
 	filteredAppenderFactory
+	layoutFactory
 
 	Target string
 }
@@ -99,48 +110,115 @@ func (factory *ConsoleAppenderFactory) Build(environment *core.Environment) (gol
 		return nil, fmt.Errorf("logging: unsupported target %s", factory.Target)
 	}
 
-	return factory.filteredAppenderFactory.Build(gol.NewAppender(writer))
+	appender, err := factory.layoutFactory.Build(writer)
+	if err != nil {
+		return nil, err
+	}
+	return factory.filteredAppenderFactory.Build(appender)
 }
 
 // FileAppenderFactory provides an appender that writes logging events to file system.
 // It also archives older files as needed.
 type FileAppenderFactory struct {
 	filteredAppenderFactory
+	layoutFactory
 
 	CurrentLogFilename string `valid:"nonzero"`
 
-	Archive                    bool
+	// Archive enables rotation of the current log file.
+	Archive bool
+	// ArchivedLogFilenamePattern is the name of archived files, in which
+	// "%d" is replaced by date and "%i" by index, e.g. "app-%d-%i.log".
+	// It must contain "%i" if MaxFileSize is set. By default, it is derived
+	// from the current log file name.
 	ArchivedLogFilenamePattern string
-	ArchivedFileCount          int
+	// ArchivedFileCount is the number of archived files to keep.
+	ArchivedFileCount int
+	// ArchivedFileMaxAge is the duration to keep archived files, e.g. "168h".
+	ArchivedFileMaxAge string
+	// Compress gzips archived files. It is also enabled when
+	// ArchivedLogFilenamePattern ends with ".gz".
+	Compress bool
+	// MaxFileSize triggers rotation when the current file reaches the size,
+	// e.g. "100MB".
+	MaxFileSize string
+	// RotationPeriod is the interval of time-based rotation, e.g. "1h".
+	// Files are rotated daily by default if the pattern contains "%d".
+	RotationPeriod string
 }
 
 func (factory *FileAppenderFactory) Build(environment *core.Environment) (gol.Appender, error) {
-	fa := golfile.NewAppender(factory.CurrentLogFilename)
-	if factory.Archive {
-		triggeringPolicy := golrotation.NewTimeTriggeringPolicy()
-		if err := triggeringPolicy.Start(); err != nil {
-			return nil, err
-		}
-
-		rollingPolicy := golrotation.NewTimeRollingPolicy()
-		rollingPolicy.FilePattern = factory.ArchivedLogFilenamePattern
-		rollingPolicy.FileCount = factory.ArchivedFileCount
-
-		fa.SetTriggeringPolicy(triggeringPolicy)
-		fa.SetRollingPolicy(rollingPolicy)
+	file, err := factory.BuildWriter()
+	if err != nil {
+		return nil, err
 	}
-	appender, err := factory.filteredAppenderFactory.Build(fa)
+	appender, err := factory.layoutFactory.Build(file)
 	if err != nil {
 		return nil, err
 	}
-	// Start file appender early. Its Start method can be called multiple times.
-	if err := fa.Start(); err != nil {
+	appender, err = factory.filteredAppenderFactory.Build(appender)
+	if err != nil {
 		return nil, err
 	}
-	environment.Lifecycle.Manage(fa)
+	// Open file early. Its Start method can be called multiple times.
+	if err := file.Start(); err != nil {
+		return nil, err
+	}
+	environment.Lifecycle.Manage(file, core.PhaseInfrastructure)
+	environment.Lifecycle.AddReloadHook(file.Reopen)
 	return appender, nil
 }
 
+// BuildWriter returns a RotatingFile configured by the factory. The file
+// is opened on the first write or when it is started.
+func (factory *FileAppenderFactory) BuildWriter() (*RotatingFile, error) {
+	file := NewRotatingFile(factory.CurrentLogFilename)
+	if !factory.Archive {
+		return file, nil
+	}
+	file.MaxFiles = factory.ArchivedFileCount
+	file.Compress = factory.Compress
+	if factory.ArchivedFileMaxAge != "" {
+		maxAge, err := time.ParseDuration(factory.ArchivedFileMaxAge)
+		if err != nil || maxAge <= 0 {
+			return nil, fmt.Errorf("logging: invalid archived file max age %s", factory.ArchivedFileMaxAge)
+		}
+		file.MaxAge = maxAge
+	}
+	if factory.MaxFileSize != "" {
+		size, err := parseSize(factory.MaxFileSize)
+		if err != nil {
+			return nil, err
+		}
+		file.MaxSize = size
+	}
+	if factory.RotationPeriod != "" {
+		period, err := time.ParseDuration(factory.RotationPeriod)
+		if err != nil || period <= 0 {
+			return nil, fmt.Errorf("logging: invalid rotation period %s", factory.RotationPeriod)
+		}
+		file.Period = period
+	}
+	file.Pattern = factory.ArchivedLogFilenamePattern
+	if file.Pattern == "" {
+		file.Pattern = defaultArchivedPattern(file.Name, file.MaxSize > 0)
+	} else if file.MaxSize > 0 && !strings.Contains(file.Pattern, patternIndex) {
+		return nil, fmt.Errorf("logging: archived file name pattern %s must contain %s when max file size is set", file.Pattern, patternIndex)
+	}
+	return file, nil
+}
+
+// defaultArchivedPattern returns pattern "name-%d.ext" or "name-%d-%i.ext"
+// if size-based rotation is enabled.
+func defaultArchivedPattern(name string, sized bool) string {
+	ext := filepath.Ext(name)
+	pattern := strings.TrimSuffix(name, ext) + "-" + patternDate
+	if sized {
+		pattern += "-" + patternIndex
+	}
+	return pattern + ext
+}
+
 // SyslogAppenderFactory provides an appender that writes logging events to syslog.
 type SyslogAppenderFactory struct {
 	filteredAppenderFactory
@@ -169,6 +247,6 @@ func (factory *SyslogAppenderFactory) Build(environment *core.Environment) (gol.
 	if err := sa.Start(); err != nil {
 		return nil, err
 	}
-	environment.Lifecycle.Manage(sa)
+	environment.Lifecycle.Manage(sa, core.PhaseInfrastructure)
 	return appender, nil
 }
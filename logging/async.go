@@ -0,0 +1,127 @@
+package logging
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/goburrow/gol"
+	"github.com/goburrow/gomelon/core"
+)
+
+// OverflowPolicy decides what an AsyncAppender does when its queue is full.
+type OverflowPolicy int
+
+const (
+	// OverflowBlock waits until the queue has space.
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDrop discards the event.
+	OverflowDrop
+)
+
+var overflowPolicies = map[string]OverflowPolicy{
+	"":      OverflowBlock,
+	"block": OverflowBlock,
+	"drop":  OverflowDrop,
+}
+
+func getOverflowPolicy(policy string) (OverflowPolicy, error) {
+	p, ok := overflowPolicies[policy]
+	if !ok {
+		return 0, fmt.Errorf("logging: unsupported overflow policy %s", policy)
+	}
+	return p, nil
+}
+
+// AsyncAppender sends logging events to appenders in a background goroutine
+// through a bounded queue. Events are appended synchronously when it is not
+// running.
+type AsyncAppender struct {
+	// Policy is applied when the queue is full.
+	Policy OverflowPolicy
+
+	appenders []gol.Appender
+	queueSize int
+	dropped   int64
+
+	mu      sync.RWMutex
+	running bool
+	events  chan *gol.LoggingEvent
+	done    chan struct{}
+}
+
+var _ gol.Appender = (*AsyncAppender)(nil)
+var _ core.Managed = (*AsyncAppender)(nil)
+
+// NewAsyncAppender allocates and returns a new AsyncAppender.
+func NewAsyncAppender(queueSize int, appenders ...gol.Appender) *AsyncAppender {
+	return &AsyncAppender{
+		queueSize: queueSize,
+		appenders: appenders,
+	}
+}
+
+func (a *AsyncAppender) Append(event *gol.LoggingEvent) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if !a.running {
+		a.appendAll(event)
+		return
+	}
+	if a.Policy == OverflowDrop {
+		select {
+		case a.events <- event:
+		default:
+			atomic.AddInt64(&a.dropped, 1)
+		}
+		return
+	}
+	a.events <- event
+}
+
+// Dropped returns number of events discarded due to the full queue.
+func (a *AsyncAppender) Dropped() int64 {
+	return atomic.LoadInt64(&a.dropped)
+}
+
+// Start starts appending events in background.
+func (a *AsyncAppender) Start() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.running {
+		return nil
+	}
+	a.events = make(chan *gol.LoggingEvent, a.queueSize)
+	a.done = make(chan struct{})
+	a.running = true
+	go a.run(a.events, a.done)
+	return nil
+}
+
+// Stop waits until all queued events are appended.
+func (a *AsyncAppender) Stop() error {
+	a.mu.Lock()
+	if !a.running {
+		a.mu.Unlock()
+		return nil
+	}
+	a.running = false
+	close(a.events)
+	done := a.done
+	a.mu.Unlock()
+	<-done
+	return nil
+}
+
+func (a *AsyncAppender) run(events chan *gol.LoggingEvent, done chan struct{}) {
+	defer close(done)
+	for event := range events {
+		a.appendAll(event)
+	}
+}
+
+func (a *AsyncAppender) appendAll(event *gol.LoggingEvent) {
+	for _, appender := range a.appenders {
+		appender.Append(event)
+	}
+}
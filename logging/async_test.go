@@ -0,0 +1,71 @@
+package logging
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/goburrow/gol"
+)
+
+type blockingAppender struct {
+	mu      sync.Mutex
+	events  []*gol.LoggingEvent
+	release chan struct{}
+}
+
+func (a *blockingAppender) Append(event *gol.LoggingEvent) {
+	if a.release != nil {
+		<-a.release
+	}
+	a.mu.Lock()
+	a.events = append(a.events, event)
+	a.mu.Unlock()
+}
+
+func TestAsyncAppender(t *testing.T) {
+	target := &blockingAppender{}
+	a := NewAsyncAppender(2, target)
+	// Appended synchronously before starting.
+	a.Append(&gol.LoggingEvent{})
+	if len(target.events) != 1 {
+		t.Fatalf("unexpected events %d", len(target.events))
+	}
+	if err := a.Start(); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		a.Append(&gol.LoggingEvent{})
+	}
+	if err := a.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if len(target.events) != 11 || a.Dropped() != 0 {
+		t.Fatalf("unexpected events %d, dropped %d", len(target.events), a.Dropped())
+	}
+}
+
+func TestAsyncAppenderDrop(t *testing.T) {
+	target := &blockingAppender{release: make(chan struct{})}
+	a := NewAsyncAppender(1, target)
+	a.Policy = OverflowDrop
+	a.Start()
+	// The first event is being appended, the second one is queued.
+	a.Append(&gol.LoggingEvent{})
+	for a.Dropped() == 0 {
+		a.Append(&gol.LoggingEvent{})
+	}
+	close(target.release)
+	a.Stop()
+	if len(target.events) < 1 || len(target.events) > 2 {
+		t.Fatalf("unexpected events %d", len(target.events))
+	}
+}
+
+func TestGetOverflowPolicy(t *testing.T) {
+	if p, err := getOverflowPolicy("drop"); err != nil || p != OverflowDrop {
+		t.Fatalf("unexpected policy %v %v", p, err)
+	}
+	if _, err := getOverflowPolicy("wait"); err == nil {
+		t.Fatal("error must be thrown")
+	}
+}
@@ -0,0 +1,71 @@
+package logging
+
+import (
+	"github.com/goburrow/gol"
+	"github.com/goburrow/gomelon/core"
+)
+
+// FormatLogger is a logger with printf-style methods, which is implemented
+// by zap.SugaredLogger and logrus.Logger.
+type FormatLogger interface {
+	Debugf(format string, args ...interface{})
+	Infof(format string, args ...interface{})
+	Warnf(format string, args ...interface{})
+	Errorf(format string, args ...interface{})
+}
+
+// NewFormatLoggerFactory returns a core.LoggerFactory which sends logs to
+// loggers created by newLogger, e.g. with zap:
+//
+//	core.SetLoggerFactory(logging.NewFormatLoggerFactory(func(name string) logging.FormatLogger {
+//		return zapLogger.Named(name).Sugar()
+//	}))
+//
+// Levels of loggers are still managed by gomelon configuration and admin
+// handlers while appenders are not used.
+func NewFormatLoggerFactory(newLogger func(name string) FormatLogger) core.LoggerFactory {
+	return func(name string) core.Logger {
+		return &formatLogger{
+			levels: gol.GetLogger(name),
+			logger: newLogger(name),
+		}
+	}
+}
+
+// formatLogger checks levels with gol logger.
+type formatLogger struct {
+	levels gol.Logger
+	logger FormatLogger
+}
+
+func (l *formatLogger) Debug(format string, args ...interface{}) {
+	if l.levels.DebugEnabled() {
+		l.logger.Debugf(format, args...)
+	}
+}
+
+func (l *formatLogger) Info(format string, args ...interface{}) {
+	if l.levels.InfoEnabled() {
+		l.logger.Infof(format, args...)
+	}
+}
+
+func (l *formatLogger) Warn(format string, args ...interface{}) {
+	if l.levels.WarnEnabled() {
+		l.logger.Warnf(format, args...)
+	}
+}
+
+func (l *formatLogger) Error(format string, args ...interface{}) {
+	if l.levels.ErrorEnabled() {
+		l.logger.Errorf(format, args...)
+	}
+}
+
+func (l *formatLogger) DebugEnabled() bool {
+	return l.levels.DebugEnabled()
+}
+
+func (l *formatLogger) InfoEnabled() bool {
+	return l.levels.InfoEnabled()
+}
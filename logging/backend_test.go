@@ -0,0 +1,44 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/goburrow/gol"
+)
+
+type printfLogger []string
+
+func (l *printfLogger) Debugf(format string, args ...interface{}) {
+	*l = append(*l, "DEBUG "+fmt.Sprintf(format, args...))
+}
+
+func (l *printfLogger) Infof(format string, args ...interface{}) {
+	*l = append(*l, "INFO "+fmt.Sprintf(format, args...))
+}
+
+func (l *printfLogger) Warnf(format string, args ...interface{}) {
+	*l = append(*l, "WARN "+fmt.Sprintf(format, args...))
+}
+
+func (l *printfLogger) Errorf(format string, args ...interface{}) {
+	*l = append(*l, "ERROR "+fmt.Sprintf(format, args...))
+}
+
+func TestFormatLoggerFactory(t *testing.T) {
+	name := "gomelon/test/backend"
+	setLogLevel(name, gol.LevelInfo)
+	var messages printfLogger
+	factory := NewFormatLoggerFactory(func(string) FormatLogger {
+		return &messages
+	})
+	logger := factory(name)
+	logger.Debug("debug")
+	logger.Info("info %d", 1)
+	ctx := WithFields(context.Background(), Fields{"requestId": "1"})
+	WithContext(ctx, logger).Warn("warn")
+	if len(messages) != 2 || messages[0] != "INFO info 1" || messages[1] != "WARN warn [requestId=1]" {
+		t.Fatalf("unexpected messages: %q", messages)
+	}
+}
@@ -0,0 +1,51 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/goburrow/gol"
+	"github.com/goburrow/gomelon/core"
+)
+
+const (
+	logLevelEnv  = "GOMELON_LOG_LEVEL"
+	logLayoutEnv = "GOMELON_LOG_LAYOUT"
+)
+
+// bootstrapAppender is the root appender set by ConfigureBootstrap.
+var bootstrapAppender gol.Appender
+
+// ConfigureBootstrap sets level and layout of the root logger given in the
+// bootstrap or environment variables GOMELON_LOG_LEVEL and
+// GOMELON_LOG_LAYOUT, so that logs are consistent before the logging
+// configuration is applied.
+func ConfigureBootstrap(bootstrap *core.Bootstrap) error {
+	level := bootstrap.LogLevel
+	if s := os.Getenv(logLevelEnv); s != "" {
+		level = s
+	}
+	layout := bootstrap.LogLayout
+	if s := os.Getenv(logLayoutEnv); s != "" {
+		layout = s
+	}
+	if level != "" {
+		logLevel, ok := getLogLevel(level)
+		if !ok {
+			return fmt.Errorf("logging: unsupported level %s", level)
+		}
+		setLogLevel(gol.RootLoggerName, logLevel)
+	}
+	if layout != "" {
+		factory := &layoutFactory{Layout: layout}
+		appender, err := factory.Build(os.Stderr)
+		if err != nil {
+			return err
+		}
+		if err = setAppender(gol.RootLoggerName, appender); err != nil {
+			return err
+		}
+		bootstrapAppender = appender
+	}
+	return nil
+}
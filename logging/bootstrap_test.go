@@ -0,0 +1,36 @@
+package logging
+
+import (
+	"os"
+	"testing"
+
+	"github.com/goburrow/gol"
+	"github.com/goburrow/gomelon/core"
+)
+
+func TestConfigureBootstrap(t *testing.T) {
+	root := gol.GetLogger(gol.RootLoggerName).(*gol.DefaultLogger)
+	level := root.Level()
+	defer func() {
+		root.SetLevel(level)
+		setAppender(gol.RootLoggerName, gol.NewAppender(os.Stderr))
+		bootstrapAppender = nil
+	}()
+
+	os.Setenv(logLevelEnv, "WARN")
+	defer os.Unsetenv(logLevelEnv)
+	bootstrap := &core.Bootstrap{LogLevel: "DEBUG", LogLayout: "json"}
+	if err := ConfigureBootstrap(bootstrap); err != nil {
+		t.Fatal(err)
+	}
+	if root.Level() != gol.LevelWarn {
+		t.Fatalf("unexpected level %v", root.Level())
+	}
+	if _, ok := bootstrapAppender.(*JSONAppender); !ok {
+		t.Fatalf("unexpected appender %T", bootstrapAppender)
+	}
+	bootstrap.LogLayout = "xml"
+	if err := ConfigureBootstrap(bootstrap); err == nil {
+		t.Fatal("error must be thrown")
+	}
+}
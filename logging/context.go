@@ -0,0 +1,113 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"sort"
+
+	"github.com/goburrow/gol"
+	"github.com/goburrow/gomelon/core"
+)
+
+// fieldsFormat is appended to formats of events logged with fields.
+const fieldsFormat = " %v"
+
+type fieldsKey struct{}
+
+// Fields are contextual key/value pairs of logging events, such as request
+// ID and user ID. They are displayed as " [key=value]" after the message in
+// text layout and as additional fields in JSON layout.
+type Fields map[string]string
+
+func (f Fields) String() string {
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	for i, name := range f.names() {
+		if i > 0 {
+			buf.WriteByte(' ')
+		}
+		buf.WriteString(name)
+		buf.WriteByte('=')
+		buf.WriteString(f[name])
+	}
+	buf.WriteByte(']')
+	return buf.String()
+}
+
+func (f Fields) names() []string {
+	names := make([]string, 0, len(f))
+	for name := range f {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// WithFields returns a copy of ctx containing fields in addition to those
+// already in ctx.
+func WithFields(ctx context.Context, fields Fields) context.Context {
+	parent := FieldsFromContext(ctx)
+	merged := make(Fields, len(parent)+len(fields))
+	for k, v := range parent {
+		merged[k] = v
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+	return context.WithValue(ctx, fieldsKey{}, merged)
+}
+
+// FieldsFromContext returns fields in ctx or nil if there is none.
+func FieldsFromContext(ctx context.Context) Fields {
+	fields, _ := ctx.Value(fieldsKey{}).(Fields)
+	return fields
+}
+
+// WithContext returns a logger which includes fields in ctx in all events,
+// e.g.
+//
+//	logging.WithContext(r.Context(), logger).Info("user %s logged in", name)
+func WithContext(ctx context.Context, logger core.Logger) core.Logger {
+	fields := FieldsFromContext(ctx)
+	if len(fields) == 0 {
+		return logger
+	}
+	return &contextLogger{Logger: logger, fields: fields}
+}
+
+// contextLogger passes fields as the last argument of events.
+type contextLogger struct {
+	core.Logger
+	fields Fields
+}
+
+func (l *contextLogger) Debug(format string, args ...interface{}) {
+	l.Logger.Debug(format+fieldsFormat, append(args, l.fields)...)
+}
+
+func (l *contextLogger) Info(format string, args ...interface{}) {
+	l.Logger.Info(format+fieldsFormat, append(args, l.fields)...)
+}
+
+func (l *contextLogger) Warn(format string, args ...interface{}) {
+	l.Logger.Warn(format+fieldsFormat, append(args, l.fields)...)
+}
+
+func (l *contextLogger) Error(format string, args ...interface{}) {
+	l.Logger.Error(format+fieldsFormat, append(args, l.fields)...)
+}
+
+// eventFields separates fields added by contextLogger from the event,
+// returning the original format and arguments.
+func eventFields(event *gol.LoggingEvent) (string, []interface{}, Fields) {
+	n := len(event.Arguments)
+	if n == 0 || len(event.Format) < len(fieldsFormat) ||
+		event.Format[len(event.Format)-len(fieldsFormat):] != fieldsFormat {
+		return event.Format, event.Arguments, nil
+	}
+	fields, ok := event.Arguments[n-1].(Fields)
+	if !ok {
+		return event.Format, event.Arguments, nil
+	}
+	return event.Format[:len(event.Format)-len(fieldsFormat)], event.Arguments[:n-1], fields
+}
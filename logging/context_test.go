@@ -0,0 +1,39 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/goburrow/gol"
+	"github.com/goburrow/gomelon/core"
+)
+
+// eventLogger is a gol.Logger sending all events to an appender.
+type eventLogger struct {
+	gol.Logger
+	appender gol.Appender
+}
+
+func (l *eventLogger) Info(format string, args ...interface{}) {
+	l.appender.Append(&gol.LoggingEvent{Name: "test", Level: gol.LevelInfo, Format: format, Arguments: args})
+}
+
+func TestWithContext(t *testing.T) {
+	ctx := WithFields(context.Background(), Fields{"requestId": "1"})
+	ctx = WithFields(ctx, Fields{"user": "a"})
+	if fields := FieldsFromContext(ctx); fields.String() != "[requestId=1 user=a]" {
+		t.Fatalf("unexpected fields: %v", fields)
+	}
+
+	var buf bytes.Buffer
+	logger := &eventLogger{appender: NewJSONAppender(&buf)}
+	WithContext(ctx, logger).Info("hello %d%%", 1)
+	expected := `"message":"hello 1%","requestId":"1","user":"a"}` + "\n"
+	if !bytes.HasSuffix(buf.Bytes(), []byte(expected)) {
+		t.Fatalf("unexpected output: %s", buf.String())
+	}
+	if WithContext(context.Background(), logger) != core.Logger(logger) {
+		t.Fatal("logger must not be wrapped without fields")
+	}
+}
@@ -0,0 +1,94 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/goburrow/gol"
+)
+
+const (
+	textLayout = "text"
+	jsonLayout = "json"
+)
+
+// layoutFactory selects how logging events are written.
+type layoutFactory struct {
+	// Layout is either "text" (default) or "json".
+	Layout string
+	// AdditionalFields are included in every JSON event, e.g. {service: api}.
+	AdditionalFields map[string]string
+}
+
+func (factory *layoutFactory) Build(w io.Writer) (gol.Appender, error) {
+	switch factory.Layout {
+	case "", textLayout:
+		return gol.NewAppender(w), nil
+	case jsonLayout:
+		a := NewJSONAppender(w)
+		a.Fields = factory.AdditionalFields
+		return a, nil
+	default:
+		return nil, fmt.Errorf("logging: unsupported layout %s", factory.Layout)
+	}
+}
+
+// JSONAppender writes each logging event as a JSON object in a single line,
+// including Fields given by WithContext:
+//
+//	{"timestamp":"2006-01-02T15:04:05.999Z","level":"INFO","logger":"app","message":"started"}
+type JSONAppender struct {
+	// Fields are additional fields of all events.
+	Fields map[string]string
+
+	mu  sync.Mutex
+	w   io.Writer
+	buf bytes.Buffer
+}
+
+var _ gol.Appender = (*JSONAppender)(nil)
+
+// NewJSONAppender allocates and returns a new JSONAppender.
+func NewJSONAppender(w io.Writer) *JSONAppender {
+	return &JSONAppender{w: w}
+}
+
+func (a *JSONAppender) Append(event *gol.LoggingEvent) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.buf.Reset()
+	a.buf.WriteString(`{"timestamp":`)
+	writeJSONString(&a.buf, event.Time.UTC().Format(time.RFC3339Nano))
+	a.buf.WriteString(`,"level":`)
+	writeJSONString(&a.buf, gol.LevelString(event.Level))
+	a.buf.WriteString(`,"logger":`)
+	writeJSONString(&a.buf, event.Name)
+	format, args, fields := eventFields(event)
+	a.buf.WriteString(`,"message":`)
+	writeJSONString(&a.buf, fmt.Sprintf(format, args...))
+	writeJSONFields(&a.buf, a.Fields)
+	writeJSONFields(&a.buf, fields)
+	a.buf.WriteString("}\n")
+	a.w.Write(a.buf.Bytes())
+}
+
+// writeJSONFields writes fields sorted by name.
+func writeJSONFields(buf *bytes.Buffer, fields Fields) {
+	for _, name := range fields.names() {
+		buf.WriteByte(',')
+		writeJSONString(buf, name)
+		buf.WriteByte(':')
+		writeJSONString(buf, fields[name])
+	}
+}
+
+func writeJSONString(buf *bytes.Buffer, s string) {
+	// Marshalling a string never fails.
+	data, _ := json.Marshal(s)
+	buf.Write(data)
+}
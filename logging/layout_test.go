@@ -0,0 +1,41 @@
+package logging
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/goburrow/gol"
+)
+
+func TestJSONAppender(t *testing.T) {
+	var buf bytes.Buffer
+	a := NewJSONAppender(&buf)
+	a.Fields = map[string]string{"service": "api", "env": "prod"}
+	a.Append(&gol.LoggingEvent{
+		Name:      "app",
+		Level:     gol.LevelWarn,
+		Time:      time.Date(2016, 1, 2, 3, 4, 5, 0, time.UTC),
+		Format:    "hello %q",
+		Arguments: []interface{}{"world"},
+	})
+	expected := `{"timestamp":"2016-01-02T03:04:05Z","level":"WARN","logger":"app","message":"hello \"world\"","env":"prod","service":"api"}` + "\n"
+	if buf.String() != expected {
+		t.Fatalf("unexpected output: %s", buf.String())
+	}
+}
+
+func TestLayoutFactory(t *testing.T) {
+	factory := &layoutFactory{Layout: "json"}
+	a, err := factory.Build(&bytes.Buffer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := a.(*JSONAppender); !ok {
+		t.Fatalf("unexpected appender %T", a)
+	}
+	factory.Layout = "xml"
+	if _, err = factory.Build(&bytes.Buffer{}); err == nil {
+		t.Fatal("error must be thrown")
+	}
+}
@@ -0,0 +1,168 @@
+package logging
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/goburrow/gol"
+	"github.com/goburrow/gomelon/core"
+)
+
+const (
+	levelURI = "/loglevel"
+
+	// rootLoggerName is the name of root logger in level handler.
+	rootLoggerName = "ROOT"
+)
+
+var (
+	levelsMu sync.Mutex
+	// knownLoggers contains names of loggers whose levels have been changed.
+	knownLoggers = map[string]struct{}{
+		gol.RootLoggerName: {},
+	}
+	// pendingReverts are scheduled restorations of logger levels.
+	pendingReverts = map[string]*levelRevert{}
+)
+
+type levelRevert struct {
+	timer *time.Timer
+	level gol.Level
+}
+
+// levelHandler lists and changes logger levels.
+//
+//	GET /loglevel
+//	PUT /loglevel {"logger": "name", "level": "DEBUG", "duration": "10m"}
+//
+// The level is restored after duration if it is given.
+type levelHandler struct {
+}
+
+var _ core.AdminHandler = (*levelHandler)(nil)
+
+// levelRequest is the request body of PUT method.
+type levelRequest struct {
+	Logger   string `json:"logger"`
+	Level    string `json:"level"`
+	Duration string `json:"duration"`
+}
+
+func (h *levelHandler) Name() string {
+	return "Log Levels"
+}
+
+func (h *levelHandler) Path() string {
+	return levelURI
+}
+
+func (h *levelHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET", "HEAD":
+		h.list(w, r)
+	case "PUT":
+		h.set(w, r)
+	default:
+		w.Header().Set("Allow", "GET, HEAD, PUT")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	}
+}
+
+// list writes levels of known loggers and those given in query parameter
+// logger.
+func (h *levelHandler) list(w http.ResponseWriter, r *http.Request) {
+	levelsMu.Lock()
+	names := make([]string, 0, len(knownLoggers))
+	for name := range knownLoggers {
+		names = append(names, name)
+	}
+	levelsMu.Unlock()
+	names = append(names, r.URL.Query()["logger"]...)
+	sort.Strings(names)
+
+	levels := make(map[string]string, len(names))
+	for _, name := range names {
+		if logger, ok := gol.GetLogger(loggerNameOf(name)).(*gol.DefaultLogger); ok {
+			levels[displayName(name)] = gol.LevelString(logger.Level())
+		}
+	}
+	w.Header().Set("Cache-Control", "must-revalidate,no-cache,no-store")
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(levels)
+}
+
+func (h *levelHandler) set(w http.ResponseWriter, r *http.Request) {
+	var req levelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	level, ok := getLogLevel(req.Level)
+	if !ok {
+		http.Error(w, "unsupported level "+req.Level, http.StatusBadRequest)
+		return
+	}
+	var duration time.Duration
+	if req.Duration != "" {
+		var err error
+		if duration, err = time.ParseDuration(req.Duration); err != nil || duration <= 0 {
+			http.Error(w, "invalid duration "+req.Duration, http.StatusBadRequest)
+			return
+		}
+	}
+	name := loggerNameOf(req.Logger)
+	logger, ok := gol.GetLogger(name).(*gol.DefaultLogger)
+	if !ok {
+		http.Error(w, "unsupported logger "+req.Logger, http.StatusBadRequest)
+		return
+	}
+	scheduleLevelRevert(name, logger.Level(), duration)
+	setLogLevel(name, level)
+	core.GetLogger(loggerName).Info("changed level of logger %q to %s for %v", displayName(name), gol.LevelString(level), duration)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// scheduleLevelRevert restores logger level after the given duration,
+// replacing the pending one if any. The level before the first temporary
+// change is kept. Zero duration cancels the pending restoration.
+func scheduleLevelRevert(name string, level gol.Level, duration time.Duration) {
+	levelsMu.Lock()
+	defer levelsMu.Unlock()
+	if revert, ok := pendingReverts[name]; ok {
+		revert.timer.Stop()
+		delete(pendingReverts, name)
+		level = revert.level
+	}
+	if duration <= 0 {
+		return
+	}
+	revert := &levelRevert{level: level}
+	revert.timer = time.AfterFunc(duration, func() {
+		levelsMu.Lock()
+		if pendingReverts[name] != revert {
+			levelsMu.Unlock()
+			return
+		}
+		delete(pendingReverts, name)
+		levelsMu.Unlock()
+		setLogLevel(name, level)
+	})
+	pendingReverts[name] = revert
+}
+
+func loggerNameOf(name string) string {
+	if name == rootLoggerName {
+		return gol.RootLoggerName
+	}
+	return name
+}
+
+func displayName(name string) string {
+	if name == gol.RootLoggerName {
+		return rootLoggerName
+	}
+	return name
+}
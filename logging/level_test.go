@@ -0,0 +1,51 @@
+package logging
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/goburrow/gol"
+)
+
+func TestLevelHandler(t *testing.T) {
+	handler := &levelHandler{}
+	logger := gol.GetLogger("gomelon/test/level").(*gol.DefaultLogger)
+	logger.SetLevel(gol.LevelInfo)
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("PUT", levelURI, strings.NewReader(`{"logger":"gomelon/test/level","level":"debug","duration":"50ms"}`))
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("unexpected response %v %s", w.Code, w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	r, _ = http.NewRequest("GET", levelURI, nil)
+	handler.ServeHTTP(w, r)
+	var levels map[string]string
+	if err := json.Unmarshal(w.Body.Bytes(), &levels); err != nil {
+		t.Fatal(err)
+	}
+	if levels["gomelon/test/level"] != "DEBUG" || levels[rootLoggerName] == "" {
+		t.Fatalf("unexpected levels %v", levels)
+	}
+	// Level is restored
+	for i := 0; i < 100 && logger.Level() != gol.LevelInfo; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if logger.Level() != gol.LevelInfo {
+		t.Fatalf("unexpected level %v", logger.Level())
+	}
+
+	for _, body := range []string{`{"logger":"a","level":"x"}`, `{"logger":"a","level":"INFO","duration":"x"}`, `{`} {
+		w = httptest.NewRecorder()
+		r, _ = http.NewRequest("PUT", levelURI, strings.NewReader(body))
+		handler.ServeHTTP(w, r)
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("unexpected response %v for %s", w.Code, body)
+		}
+	}
+}
@@ -4,20 +4,21 @@ Package logging provides logging configuration for applications.
 package logging
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
 	"strings"
 
 	"github.com/goburrow/gol"
 	"github.com/goburrow/gomelon/core"
 	"github.com/goburrow/polytype"
 
-	golasync "github.com/goburrow/gol/async"
 	_ "github.com/goburrow/gol/log"
 )
 
 const (
-	loggerName      = "gomelon/logging"
-	asyncBufferSize = 1024
+	loggerName       = "gomelon/logging"
+	defaultQueueSize = 1024
 )
 
 var (
@@ -33,9 +34,18 @@ var (
 )
 
 func init() {
-	polytype.Register("ConsoleAppender", func() interface{} { return &ConsoleAppenderFactory{} })
-	polytype.Register("FileAppender", func() interface{} { return &FileAppenderFactory{} })
-	polytype.Register("SyslogAppender", func() interface{} { return &SyslogAppenderFactory{} })
+	newConsole := func() interface{} { return &ConsoleAppenderFactory{} }
+	newFile := func() interface{} { return &FileAppenderFactory{} }
+	newSyslog := func() interface{} { return &SyslogAppenderFactory{} }
+	newSentry := func() interface{} { return &SentryAppenderFactory{} }
+	polytype.Register("ConsoleAppender", newConsole)
+	polytype.Register("console", newConsole)
+	polytype.Register("FileAppender", newFile)
+	polytype.Register("file", newFile)
+	polytype.Register("SyslogAppender", newSyslog)
+	polytype.Register("syslog", newSyslog)
+	polytype.Register("SentryAppender", newSentry)
+	polytype.Register("sentry", newSentry)
 }
 
 func getLogLevel(level string) (gol.Level, bool) {
@@ -47,19 +57,65 @@ func setLogLevel(name string, level gol.Level) {
 	logger, ok := gol.GetLogger(name).(*gol.DefaultLogger)
 	if ok {
 		logger.SetLevel(level)
+		levelsMu.Lock()
+		knownLoggers[name] = struct{}{}
+		levelsMu.Unlock()
 	}
 }
 
-// AppenderConfiguration is an union of console, file and syslog configuration.
+// AppenderConfiguration is an union of console, file, syslog, sentry and custom
+// appender configuration. Custom appenders implementing AppenderFactory
+// are registered with polytype.Register.
 type AppenderConfiguration struct {
 	polytype.Type
 }
 
+// LoggerConfiguration is the configuration of a logger. It can be given
+// as either a level, e.g. "DEBUG", or an object:
+//
+//	loggers:
+//	  gomelon/server: INFO
+//	  app/audit:
+//	    level: INFO
+//	    additive: false
+//	    appenders:
+//	    - type: file
+//	      currentLogFilename: /var/log/audit.log
+type LoggerConfiguration struct {
+	Level string
+	// Additive also sends events to appenders of the root logger. It is
+	// true by default.
+	Additive bool
+	// Appenders are specific to the logger.
+	Appenders []AppenderConfiguration
+}
+
+func (c *LoggerConfiguration) UnmarshalJSON(data []byte) error {
+	var level string
+	if err := json.Unmarshal(data, &level); err == nil {
+		*c = LoggerConfiguration{Level: level, Additive: true}
+		return nil
+	}
+	type loggerConfiguration LoggerConfiguration
+	config := loggerConfiguration{Additive: true}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return err
+	}
+	*c = LoggerConfiguration(config)
+	return nil
+}
+
 // Factory configures logging environment.
 type Factory struct {
 	Level     string
-	Loggers   map[string]string
+	Loggers   map[string]LoggerConfiguration
 	Appenders []AppenderConfiguration
+	// QueueSize is the capacity of the queue of logging events of each
+	// logger having appenders. It is 1024 by default.
+	QueueSize int `valid:"min=0"`
+	// OverflowPolicy is either "block" (default) or "drop", which discards
+	// events when the queue is full.
+	OverflowPolicy string
 }
 
 // Factory implements core.LoggingFactory interface.
@@ -69,14 +125,15 @@ func (factory *Factory) Configure(env *core.Environment) error {
 	var err error
 
 	if err = factory.configureLevels(); err != nil {
-		gol.GetLogger(loggerName).Error("%v", err)
+		core.GetLogger(loggerName).Error("%v", err)
 		return err
 	}
 	if err = factory.configureAppenders(env); err != nil {
-		gol.GetLogger(loggerName).Error("%v", err)
+		core.GetLogger(loggerName).Error("%v", err)
 		return err
 	}
 	env.Admin.AddTask(&logTask{})
+	env.Admin.AddHandler(&levelHandler{})
 	return nil
 }
 
@@ -91,9 +148,12 @@ func (factory *Factory) configureLevels() error {
 	}
 	// Change level of other loggers
 	for k, v := range factory.Loggers {
-		logLevel, ok := getLogLevel(v)
+		if v.Level == "" {
+			continue
+		}
+		logLevel, ok := getLogLevel(v.Level)
 		if !ok {
-			return fmt.Errorf("logging: unsupported level %s", v)
+			return fmt.Errorf("logging: unsupported level %s", v.Level)
 		}
 		setLogLevel(k, logLevel)
 	}
@@ -101,29 +161,100 @@ func (factory *Factory) configureLevels() error {
 }
 
 func (factory *Factory) configureAppenders(environment *core.Environment) error {
-	// appenders is a list of appenders for root logger.
-	var appenders []gol.Appender
-
-	for _, appenderFactory := range factory.Appenders {
-		if a, ok := appenderFactory.Value().(AppenderFactory); ok {
-			appender, err := a.Build(environment)
-			if err != nil {
-				return err
-			}
-			appenders = append(appenders, appender)
+	// Override default appender of the root logger
+	root, err := factory.buildAppenders(environment, gol.RootLoggerName, factory.Appenders)
+	if err != nil {
+		return err
+	}
+	if root != nil {
+		if err = setAppender(gol.RootLoggerName, root); err != nil {
+			return err
+		}
+	} else if bootstrapAppender != nil {
+		root = bootstrapAppender
+	} else {
+		// Default appender of gol root logger.
+		root = gol.NewAppender(os.Stderr)
+	}
+	for name, config := range factory.Loggers {
+		if len(config.Appenders) == 0 {
+			continue
+		}
+		appender, err := factory.buildAppenders(environment, name, config.Appenders)
+		if err != nil {
+			return err
+		}
+		if config.Additive {
+			appender = multiAppender{appender, root}
+		}
+		if err = setAppender(name, appender); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// buildAppenders returns an asynchronous appender of the given appenders
+// or nil if there is no appender. Appenders which need the caller context,
+// such as SentryAppender, are called synchronously. Number of dropped
+// events is published as metric "Logging.<logger>.Dropped".
+func (factory *Factory) buildAppenders(environment *core.Environment, name string, configs []AppenderConfiguration) (gol.Appender, error) {
+	policy, err := getOverflowPolicy(factory.OverflowPolicy)
+	if err != nil {
+		return nil, err
+	}
+	var appenders, syncAppenders []gol.Appender
+
+	for _, config := range configs {
+		appenderFactory, ok := config.Value().(AppenderFactory)
+		if !ok {
+			return nil, fmt.Errorf("logging: unsupported appender %#v", config.Value())
+		}
+		appender, err := appenderFactory.Build(environment)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := appender.(synchronousAppender); ok {
+			syncAppenders = append(syncAppenders, appender)
 		} else {
-			return fmt.Errorf("logging: unsupported appender %#v", appenderFactory.Value())
+			appenders = append(appenders, appender)
 		}
 	}
-	// Override default appender of the root logger
 	if len(appenders) > 0 {
-		logger, ok := gol.GetLogger(gol.RootLoggerName).(*gol.DefaultLogger)
-		if !ok {
-			return fmt.Errorf("logging: logger is not gol.DefaultLogger %T", logger)
+		queueSize := factory.QueueSize
+		if queueSize <= 0 {
+			queueSize = defaultQueueSize
 		}
-		a := golasync.NewAppender(asyncBufferSize, appenders...)
-		logger.SetAppender(a)
-		environment.Lifecycle.Manage(a)
+		a := NewAsyncAppender(queueSize, appenders...)
+		a.Policy = policy
+		environment.Lifecycle.Manage(a, core.PhaseInfrastructure)
+		environment.Metrics.Gauge("Logging." + displayName(name) + ".Dropped").SetFunc(a.Dropped)
+		syncAppenders = append(syncAppenders, a)
+	}
+	switch len(syncAppenders) {
+	case 0:
+		return nil, nil
+	case 1:
+		return syncAppenders[0], nil
+	default:
+		return multiAppender(syncAppenders), nil
+	}
+}
+
+func setAppender(name string, appender gol.Appender) error {
+	logger, ok := gol.GetLogger(name).(*gol.DefaultLogger)
+	if !ok {
+		return fmt.Errorf("logging: logger is not gol.DefaultLogger %T", logger)
 	}
+	logger.SetAppender(appender)
 	return nil
 }
+
+// multiAppender sends logging events to all appenders.
+type multiAppender []gol.Appender
+
+func (m multiAppender) Append(event *gol.LoggingEvent) {
+	for _, a := range m {
+		a.Append(event)
+	}
+}
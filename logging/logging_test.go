@@ -1,9 +1,15 @@
 package logging
 
 import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/goburrow/gol"
+	"github.com/goburrow/gomelon/core"
 )
 
 func TestGetLogLevel(t *testing.T) {
@@ -36,3 +42,57 @@ func TestGetLogLevel(t *testing.T) {
 		t.Fatal("Should not found")
 	}
 }
+
+func TestLoggerConfiguration(t *testing.T) {
+	var factory Factory
+	data := `{"loggers": {"a": "DEBUG", "b": {"level": "WARN", "additive": false, "appenders": [{"type": "console"}]}}}`
+	if err := json.Unmarshal([]byte(data), &factory); err != nil {
+		t.Fatal(err)
+	}
+	a := factory.Loggers["a"]
+	if a.Level != "DEBUG" || !a.Additive || len(a.Appenders) != 0 {
+		t.Fatalf("unexpected logger configuration: %+v", a)
+	}
+	b := factory.Loggers["b"]
+	if b.Level != "WARN" || b.Additive || len(b.Appenders) != 1 {
+		t.Fatalf("unexpected logger configuration: %+v", b)
+	}
+	if _, ok := b.Appenders[0].Value().(*ConsoleAppenderFactory); !ok {
+		t.Fatalf("unexpected appender: %#v", b.Appenders[0].Value())
+	}
+}
+
+func TestLoggerAppenders(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	name := filepath.Join(dir, "test.log")
+	file := &AppenderConfiguration{}
+	file.SetValue(&FileAppenderFactory{CurrentLogFilename: name})
+	factory := &Factory{
+		Loggers: map[string]LoggerConfiguration{
+			"gomelon/logging/test": {Level: "INFO", Appenders: []AppenderConfiguration{*file}},
+		},
+	}
+	env := core.NewEnvironment()
+	if err = factory.configureAppenders(env); err != nil {
+		t.Fatal(err)
+	}
+	defer setAppender("gomelon/logging/test", nil)
+	logger, ok := gol.GetLogger("gomelon/logging/test").(*gol.DefaultLogger)
+	if !ok {
+		t.Fatalf("unexpected logger %T", logger)
+	}
+	logger.Info("test")
+	env.SetStopped()
+	data, err := ioutil.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "test") {
+		t.Fatalf("unexpected log: %s", data)
+	}
+}
@@ -0,0 +1,358 @@
+package logging
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goburrow/gomelon/core"
+)
+
+const (
+	// Placeholders in archived file name pattern.
+	patternDate  = "%d"
+	patternIndex = "%i"
+	// patternDateCompat is the date placeholder used by gol rotation.
+	patternDateCompat = "%s"
+
+	defaultRotationPeriod = 24 * time.Hour
+
+	gzipExt = ".gz"
+)
+
+// RotatingFile is a file writer which archives the current file when its
+// size exceeds MaxSize or when the rotation period elapses. Archived files
+// are named after Pattern, in which "%d" is replaced by the date of the
+// period and "%i" by the file index within the period, e.g.
+// "/var/log/app-%d-%i.log". Periods are aligned to UTC. Existing archives
+// are never overwritten: an index is appended if Pattern has no "%i".
+type RotatingFile struct {
+	// Name is the path of the current log file.
+	Name string
+	// Pattern is the name pattern of archived files. Files are not rotated
+	// if it is empty.
+	Pattern string
+	// MaxSize is the maximum size in bytes of the current file. Zero means
+	// no size limit.
+	MaxSize int64
+	// Period is the rotation interval if Pattern contains a date. It is one
+	// day by default.
+	Period time.Duration
+	// MaxFiles is the number of archived files to retain. Zero means all
+	// files are kept.
+	MaxFiles int
+	// MaxAge is the duration to retain archived files. Zero means no limit.
+	MaxAge time.Duration
+	// Compress gzips archived files, appending ".gz" to their names. It is
+	// enabled if Pattern ends with ".gz".
+	Compress bool
+
+	mu    sync.Mutex
+	file  *os.File
+	size  int64
+	start time.Time
+}
+
+var _ core.Managed = (*RotatingFile)(nil)
+
+// NewRotatingFile returns a RotatingFile writing to file name.
+func NewRotatingFile(name string) *RotatingFile {
+	return &RotatingFile{Name: name}
+}
+
+// Start opens the current file for appending. It can be called multiple times.
+func (f *RotatingFile) Start() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file != nil {
+		return nil
+	}
+	return f.open(time.Now())
+}
+
+// Stop closes the current file.
+func (f *RotatingFile) Stop() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+// Reopen closes the current file, which is opened again on the next write.
+// It allows the file to be moved by external tools such as logrotate.
+func (f *RotatingFile) Reopen() error {
+	return f.Stop()
+}
+
+// Write appends b to the current file, rotating it beforehand if needed.
+func (f *RotatingFile) Write(b []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	now := time.Now()
+	if f.file == nil {
+		if err := f.open(now); err != nil {
+			return 0, err
+		}
+	}
+	if f.shouldRotate(now, int64(len(b))) {
+		if err := f.rotate(now); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.file.Write(b)
+	f.size += int64(n)
+	return n, err
+}
+
+func (f *RotatingFile) open(now time.Time) error {
+	if dir := filepath.Dir(f.Name); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	file, err := os.OpenFile(f.Name, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	f.file = file
+	f.size = info.Size()
+	if f.size > 0 {
+		// Existing logs belong to the period they were last written in.
+		now = info.ModTime()
+	}
+	f.start = now.Truncate(f.period())
+	return nil
+}
+
+func (f *RotatingFile) period() time.Duration {
+	if f.Period > 0 {
+		return f.Period
+	}
+	return defaultRotationPeriod
+}
+
+func (f *RotatingFile) timeBased() bool {
+	return strings.Contains(f.Pattern, patternDate) || strings.Contains(f.Pattern, patternDateCompat)
+}
+
+func (f *RotatingFile) shouldRotate(now time.Time, n int64) bool {
+	if f.Pattern == "" || f.size == 0 {
+		return false
+	}
+	if f.MaxSize > 0 && f.size+n > f.MaxSize {
+		return true
+	}
+	return f.timeBased() && !now.Before(f.start.Add(f.period()))
+}
+
+// rotate archives the current file and opens a new one.
+func (f *RotatingFile) rotate(now time.Time) error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+	f.file = nil
+	archived, err := f.archiveName()
+	if err != nil {
+		return err
+	}
+	if f.compressed() {
+		uncompressed := strings.TrimSuffix(archived, gzipExt)
+		if err = os.Rename(f.Name, uncompressed); err != nil {
+			return err
+		}
+		if err = f.open(now); err != nil {
+			return err
+		}
+		if err = gzipFile(uncompressed, archived); err != nil {
+			return err
+		}
+	} else {
+		if err = os.Rename(f.Name, archived); err != nil {
+			return err
+		}
+		if err = f.open(now); err != nil {
+			return err
+		}
+	}
+	return f.prune(now)
+}
+
+func (f *RotatingFile) compressed() bool {
+	return f.Compress || strings.HasSuffix(f.Pattern, gzipExt)
+}
+
+// gzipFile compresses file src to dst and removes src.
+func gzipFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	w := gzip.NewWriter(out)
+	if _, err = io.Copy(w, in); err == nil {
+		err = w.Close()
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(dst)
+		return err
+	}
+	in.Close()
+	return os.Remove(src)
+}
+
+// archiveName returns the first unused file name for the current period.
+func (f *RotatingFile) archiveName() (string, error) {
+	date := f.start.UTC().Format(f.dateLayout())
+	name := strings.NewReplacer(patternDate, date, patternDateCompat, date).Replace(f.Pattern)
+	if f.compressed() && !strings.HasSuffix(name, gzipExt) {
+		name += gzipExt
+	}
+	if !strings.Contains(name, patternIndex) {
+		if _, err := os.Stat(name); os.IsNotExist(err) {
+			return name, nil
+		}
+		// Do not overwrite the archive of the same period, e.g. when the
+		// application is restarted, but append an index to its name.
+		name = indexedPattern(name, f.compressed())
+	}
+	for i := 1; ; i++ {
+		indexed := strings.Replace(name, patternIndex, strconv.Itoa(i), -1)
+		if _, err := os.Stat(indexed); os.IsNotExist(err) {
+			return indexed, nil
+		}
+	}
+}
+
+// indexedPattern inserts "-%i" before the extension of name, e.g.
+// "app-2006-01-02-%i.log.gz".
+func indexedPattern(name string, compressed bool) string {
+	suffix := ""
+	if compressed {
+		suffix = gzipExt
+		name = strings.TrimSuffix(name, gzipExt)
+	}
+	ext := filepath.Ext(name)
+	return strings.TrimSuffix(name, ext) + "-" + patternIndex + ext + suffix
+}
+
+func (f *RotatingFile) dateLayout() string {
+	switch period := f.period(); {
+	case period >= 24*time.Hour:
+		return "2006-01-02"
+	case period >= time.Hour:
+		return "2006-01-02-15"
+	default:
+		return "2006-01-02-15-04"
+	}
+}
+
+// prune removes the oldest archived files exceeding MaxFiles and those
+// older than MaxAge.
+func (f *RotatingFile) prune(now time.Time) error {
+	if f.MaxFiles <= 0 && f.MaxAge <= 0 {
+		return nil
+	}
+	files, err := f.archivedFiles()
+	if err != nil {
+		return err
+	}
+	for i, file := range files {
+		expired := f.MaxAge > 0 && now.Sub(file.modTime) > f.MaxAge
+		exceeded := f.MaxFiles > 0 && i < len(files)-f.MaxFiles
+		if expired || exceeded {
+			if err = os.Remove(file.path); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+type archivedFile struct {
+	path    string
+	modTime time.Time
+}
+
+// archivedFiles returns files matching Pattern, compressed or not, sorted
+// from the oldest.
+func (f *RotatingFile) archivedFiles() ([]archivedFile, error) {
+	glob := strings.NewReplacer(patternDate, "*", patternDateCompat, "*", patternIndex, "*").Replace(f.Pattern)
+	glob = strings.TrimSuffix(glob, gzipExt)
+	paths, err := filepath.Glob(glob)
+	if err != nil {
+		return nil, err
+	}
+	compressed, err := filepath.Glob(glob + gzipExt)
+	if err != nil {
+		return nil, err
+	}
+	paths = append(paths, compressed...)
+	files := make([]archivedFile, 0, len(paths))
+	for _, path := range paths {
+		if path == f.Name {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		files = append(files, archivedFile{path, info.ModTime()})
+	}
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].modTime.Equal(files[j].modTime) {
+			return files[i].path < files[j].path
+		}
+		return files[i].modTime.Before(files[j].modTime)
+	})
+	return files, nil
+}
+
+// parseSize parses size such as "512", "10KB", "100MB" or "1GB" in bytes.
+func parseSize(s string) (int64, error) {
+	units := []struct {
+		suffix string
+		size   int64
+	}{
+		{"KB", 1 << 10},
+		{"MB", 1 << 20},
+		{"GB", 1 << 30},
+		{"B", 1},
+	}
+	str := strings.ToUpper(strings.TrimSpace(s))
+	multiplier := int64(1)
+	for _, unit := range units {
+		if strings.HasSuffix(str, unit.suffix) {
+			str = strings.TrimSpace(strings.TrimSuffix(str, unit.suffix))
+			multiplier = unit.size
+			break
+		}
+	}
+	n, err := strconv.ParseInt(str, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("logging: invalid size %s", s)
+	}
+	return n * multiplier, nil
+}
@@ -0,0 +1,199 @@
+package logging
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRotatingFileSize(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	f := NewRotatingFile(filepath.Join(dir, "app.log"))
+	f.Pattern = filepath.Join(dir, "app-%i.log")
+	f.MaxSize = 10
+	f.MaxFiles = 2
+	if err = f.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer f.Stop()
+	for _, s := range []string{"0123456\n", "abcdefg\n", "hijklmn\n", "opqrstu\n"} {
+		if _, err = f.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	data, err := ioutil.ReadFile(f.Name)
+	if err != nil || string(data) != "opqrstu\n" {
+		t.Fatalf("unexpected current file: %q %v", data, err)
+	}
+	// The first archive is removed as only 2 files are kept.
+	if _, err = os.Stat(filepath.Join(dir, "app-1.log")); !os.IsNotExist(err) {
+		t.Fatalf("expected archive to be removed: %v", err)
+	}
+	data, err = ioutil.ReadFile(filepath.Join(dir, "app-3.log"))
+	if err != nil || string(data) != "hijklmn\n" {
+		t.Fatalf("unexpected archived file: %q %v", data, err)
+	}
+}
+
+func TestRotatingFileTime(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	name := filepath.Join(dir, "app.log")
+	if err = ioutil.WriteFile(name, []byte("old\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	yesterday := time.Now().Add(-24 * time.Hour)
+	if err = os.Chtimes(name, yesterday, yesterday); err != nil {
+		t.Fatal(err)
+	}
+	factory := &FileAppenderFactory{
+		CurrentLogFilename: name,
+		Archive:            true,
+	}
+	f, err := factory.BuildWriter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Stop()
+	if _, err = f.Write([]byte("new\n")); err != nil {
+		t.Fatal(err)
+	}
+	archived := filepath.Join(dir, "app-"+yesterday.UTC().Format("2006-01-02")+".log")
+	data, err := ioutil.ReadFile(archived)
+	if err != nil || string(data) != "old\n" {
+		t.Fatalf("unexpected archived file: %q %v", data, err)
+	}
+}
+
+func TestRotatingFileTimeExists(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	yesterday := time.Now().Add(-24 * time.Hour)
+	archived := filepath.Join(dir, "app-"+yesterday.UTC().Format("2006-01-02")+".log")
+	if err = ioutil.WriteFile(archived, []byte("archived\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	name := filepath.Join(dir, "app.log")
+	if err = ioutil.WriteFile(name, []byte("old\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.Chtimes(name, yesterday, yesterday); err != nil {
+		t.Fatal(err)
+	}
+	f := NewRotatingFile(name)
+	f.Pattern = filepath.Join(dir, "app-%d.log")
+	defer f.Stop()
+	if _, err = f.Write([]byte("new\n")); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(archived)
+	if err != nil || string(data) != "archived\n" {
+		t.Fatalf("unexpected archived file: %q %v", data, err)
+	}
+	data, err = ioutil.ReadFile(filepath.Join(dir, "app-"+yesterday.UTC().Format("2006-01-02")+"-1.log"))
+	if err != nil || string(data) != "old\n" {
+		t.Fatalf("unexpected archived file: %q %v", data, err)
+	}
+}
+
+func TestRotatingFileSizeWithoutIndex(t *testing.T) {
+	factory := &FileAppenderFactory{
+		CurrentLogFilename:         "app.log",
+		Archive:                    true,
+		ArchivedLogFilenamePattern: "app-%d.log",
+		MaxFileSize:                "10MB",
+	}
+	if _, err := factory.BuildWriter(); err == nil {
+		t.Fatal("error expected")
+	}
+}
+
+func TestRotatingFileCompress(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Expired archive
+	expired := filepath.Join(dir, "app-9.log.gz")
+	if err = ioutil.WriteFile(expired, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	lastWeek := time.Now().Add(-7 * 24 * time.Hour)
+	if err = os.Chtimes(expired, lastWeek, lastWeek); err != nil {
+		t.Fatal(err)
+	}
+
+	f := NewRotatingFile(filepath.Join(dir, "app.log"))
+	f.Pattern = filepath.Join(dir, "app-%i.log")
+	f.MaxSize = 4
+	f.MaxAge = 24 * time.Hour
+	f.Compress = true
+	defer f.Stop()
+	for _, s := range []string{"abc\n", "def\n"} {
+		if _, err = f.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err = os.Stat(expired); !os.IsNotExist(err) {
+		t.Fatalf("expected archive to be removed: %v", err)
+	}
+	if _, err = os.Stat(filepath.Join(dir, "app-1.log")); !os.IsNotExist(err) {
+		t.Fatalf("expected uncompressed archive to be removed: %v", err)
+	}
+	file, err := os.Open(filepath.Join(dir, "app-1.log.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	r, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadAll(r)
+	if err != nil || string(data) != "abc\n" {
+		t.Fatalf("unexpected archived data: %q %v", data, err)
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := map[string]int64{
+		"512":   512,
+		"10KB":  10 << 10,
+		"1 mb":  1 << 20,
+		"2GB":   2 << 30,
+		"100B":  100,
+		"":      -1,
+		"-1":    -1,
+		"10 XB": -1,
+	}
+	for s, expected := range tests {
+		size, err := parseSize(s)
+		if expected < 0 {
+			if err == nil {
+				t.Fatalf("expected error for %q", s)
+			}
+			continue
+		}
+		if err != nil || size != expected {
+			t.Fatalf("unexpected size of %q: %d %v", s, size, err)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package logging
+
+import (
+	"sync"
+	"time"
+
+	"github.com/goburrow/gol"
+)
+
+// maxSamplingWindows is the number of windows after which stale ones are
+// removed.
+const maxSamplingWindows = 1024
+
+// SamplingAppender passes at most Max events of each logger within Period
+// to the appender. When events are suppressed, a warning "suppressed N
+// messages" is appended at the beginning of the next period in which the
+// logger logs.
+type SamplingAppender struct {
+	Max    int
+	Period time.Duration
+	// PerMessage limits events of each logger and format separately, so
+	// only identical messages are suppressed.
+	PerMessage bool
+
+	appender gol.Appender
+
+	mu      sync.Mutex
+	windows map[string]*samplingWindow
+}
+
+var _ gol.Appender = (*SamplingAppender)(nil)
+
+type samplingWindow struct {
+	start      time.Time
+	count      int
+	suppressed int
+}
+
+// NewSamplingAppender allocates and returns a new SamplingAppender which
+// allows max events per second.
+func NewSamplingAppender(appender gol.Appender, max int) *SamplingAppender {
+	return &SamplingAppender{
+		Max:      max,
+		Period:   time.Second,
+		appender: appender,
+		windows:  make(map[string]*samplingWindow),
+	}
+}
+
+func (a *SamplingAppender) Append(event *gol.LoggingEvent) {
+	key := event.Name
+	if a.PerMessage {
+		key += "\x00" + event.Format
+	}
+	now := event.Time
+	if now.IsZero() {
+		now = time.Now()
+	}
+	a.mu.Lock()
+	w, ok := a.windows[key]
+	if !ok {
+		if len(a.windows) >= maxSamplingWindows {
+			a.removeStale(now)
+		}
+		w = &samplingWindow{start: now}
+		a.windows[key] = w
+	}
+	suppressed := 0
+	if now.Sub(w.start) >= a.Period {
+		suppressed = w.suppressed
+		w.start = now
+		w.count = 0
+		w.suppressed = 0
+	}
+	w.count++
+	allowed := w.count <= a.Max
+	if !allowed {
+		w.suppressed++
+	}
+	a.mu.Unlock()
+
+	if suppressed > 0 {
+		a.appender.Append(&gol.LoggingEvent{
+			Name:      event.Name,
+			Level:     gol.LevelWarn,
+			Time:      now,
+			Format:    "suppressed %d messages",
+			Arguments: []interface{}{suppressed},
+		})
+	}
+	if allowed {
+		a.appender.Append(event)
+	}
+}
+
+// removeStale removes windows which have ended without suppressed events.
+func (a *SamplingAppender) removeStale(now time.Time) {
+	for key, w := range a.windows {
+		if w.suppressed == 0 && now.Sub(w.start) >= a.Period {
+			delete(a.windows, key)
+		}
+	}
+}
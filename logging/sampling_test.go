@@ -0,0 +1,48 @@
+package logging
+
+import (
+	"testing"
+	"time"
+
+	"github.com/goburrow/gol"
+)
+
+type eventsAppender []*gol.LoggingEvent
+
+func (a *eventsAppender) Append(event *gol.LoggingEvent) {
+	*a = append(*a, event)
+}
+
+func TestSamplingAppender(t *testing.T) {
+	var events eventsAppender
+	a := NewSamplingAppender(&events, 2)
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		a.Append(&gol.LoggingEvent{Name: "a", Time: start, Format: "a"})
+	}
+	a.Append(&gol.LoggingEvent{Name: "b", Time: start, Format: "b"})
+	if len(events) != 3 {
+		t.Fatalf("unexpected events: %d", len(events))
+	}
+	a.Append(&gol.LoggingEvent{Name: "a", Time: start.Add(time.Second), Format: "a"})
+	if len(events) != 5 {
+		t.Fatalf("unexpected events: %d", len(events))
+	}
+	summary := events[3]
+	if summary.Name != "a" || summary.Level != gol.LevelWarn || summary.Arguments[0] != 3 {
+		t.Fatalf("unexpected summary: %+v", summary)
+	}
+}
+
+func TestSamplingAppenderPerMessage(t *testing.T) {
+	var events eventsAppender
+	a := NewSamplingAppender(&events, 1)
+	a.PerMessage = true
+	now := time.Now()
+	a.Append(&gol.LoggingEvent{Name: "a", Time: now, Format: "x"})
+	a.Append(&gol.LoggingEvent{Name: "a", Time: now, Format: "x"})
+	a.Append(&gol.LoggingEvent{Name: "a", Time: now, Format: "y"})
+	if len(events) != 2 || events[1].Format != "y" {
+		t.Fatalf("unexpected events: %d", len(events))
+	}
+}
@@ -0,0 +1,318 @@
+package logging
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goburrow/gol"
+	"github.com/goburrow/gomelon/core"
+)
+
+const (
+	sentryClient         = "gomelon/1.0"
+	sentryQueueSize      = 100
+	defaultSentryTimeout = 5 * time.Second
+	maxStackFrames       = 64
+)
+
+var sentryLevels = map[gol.Level]string{
+	gol.LevelTrace: "debug",
+	gol.LevelDebug: "debug",
+	gol.LevelInfo:  "info",
+	gol.LevelWarn:  "warning",
+	gol.LevelError: "error",
+}
+
+// stackSkipPrefixes are functions of logging libraries which are excluded
+// from stack traces.
+var stackSkipPrefixes = []string{
+	"runtime.",
+	"github.com/goburrow/gol.",
+	"github.com/goburrow/gol/",
+	"github.com/goburrow/gomelon/logging.",
+	"github.com/goburrow/gomelon/core.namedLogger.",
+}
+
+// SentryAppenderFactory provides an appender that reports logging events
+// to Sentry or a compatible service. Only WARN and ERROR events are sent
+// unless Threshold is given.
+type SentryAppenderFactory struct {
+	filteredAppenderFactory
+
+	// DSN is the Sentry client key, e.g. "https://key@sentry.io/1".
+	DSN string `valid:"nonzero" secret:"true"`
+	// Environment is the deployment environment, e.g. "production".
+	Environment string
+	// Release is the application version, which is the build version by
+	// default.
+	Release string
+	// Timeout of sending an event. Default is 5s.
+	Timeout string
+}
+
+func (factory *SentryAppenderFactory) Build(environment *core.Environment) (gol.Appender, error) {
+	a, err := NewSentryAppender(factory.DSN)
+	if err != nil {
+		return nil, err
+	}
+	a.Environment = factory.Environment
+	a.Release = factory.Release
+	if a.Release == "" {
+		a.Release = environment.BuildInfo.Version
+	}
+	if factory.Timeout != "" {
+		timeout, err := time.ParseDuration(factory.Timeout)
+		if err != nil {
+			return nil, err
+		}
+		a.Client.Timeout = timeout
+	}
+	filtered := factory.filteredAppenderFactory
+	if filtered.Threshold == "" {
+		filtered.Threshold = "WARN"
+	}
+	appender, err := filtered.Build(a)
+	if err != nil {
+		return nil, err
+	}
+	environment.Lifecycle.Manage(a, core.PhaseInfrastructure)
+	return &sentryFilteredAppender{appender}, nil
+}
+
+// sentryFilteredAppender keeps the filtered appender synchronous.
+type sentryFilteredAppender struct {
+	gol.Appender
+}
+
+func (*sentryFilteredAppender) synchronous() {}
+
+// synchronousAppender is implemented by appenders which must be called in
+// the goroutine logging the event, e.g. to capture stack traces.
+type synchronousAppender interface {
+	synchronous()
+}
+
+// SentryAppender sends logging events with stack traces to Sentry using its
+// store API. Events are sent in background and dropped when the queue is
+// full or the appender is not started.
+type SentryAppender struct {
+	Environment string
+	Release     string
+	ServerName  string
+	Client      *http.Client
+
+	storeURL string
+	auth     string
+
+	mu      sync.Mutex
+	running bool
+	events  chan *sentryEvent
+	done    chan struct{}
+}
+
+var _ gol.Appender = (*SentryAppender)(nil)
+var _ core.Managed = (*SentryAppender)(nil)
+
+// NewSentryAppender returns a SentryAppender for the given DSN.
+func NewSentryAppender(dsn string) (*SentryAppender, error) {
+	u, err := url.Parse(dsn)
+	if err != nil || u.User == nil || u.Host == "" {
+		return nil, fmt.Errorf("logging: invalid sentry dsn %q", dsn)
+	}
+	path := strings.TrimSuffix(u.Path, "/")
+	i := strings.LastIndex(path, "/")
+	project := path[i+1:]
+	if project == "" {
+		return nil, fmt.Errorf("logging: invalid sentry dsn %q", dsn)
+	}
+	auth := "Sentry sentry_version=7, sentry_client=" + sentryClient + ", sentry_key=" + u.User.Username()
+	if secret, ok := u.User.Password(); ok {
+		auth += ", sentry_secret=" + secret
+	}
+	hostname, _ := os.Hostname()
+	return &SentryAppender{
+		ServerName: hostname,
+		Client:     &http.Client{Timeout: defaultSentryTimeout},
+		storeURL:   u.Scheme + "://" + u.Host + path[:i] + "/api/" + project + "/store/",
+		auth:       auth,
+	}, nil
+}
+
+func (a *SentryAppender) synchronous() {}
+
+type sentryEvent struct {
+	EventID     string            `json:"event_id"`
+	Timestamp   string            `json:"timestamp"`
+	Level       string            `json:"level"`
+	Logger      string            `json:"logger"`
+	Platform    string            `json:"platform"`
+	Message     string            `json:"message"`
+	Environment string            `json:"environment,omitempty"`
+	Release     string            `json:"release,omitempty"`
+	ServerName  string            `json:"server_name,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	Stacktrace  *sentryStacktrace `json:"stacktrace,omitempty"`
+}
+
+type sentryStacktrace struct {
+	Frames []sentryFrame `json:"frames"`
+}
+
+type sentryFrame struct {
+	Function string `json:"function"`
+	Filename string `json:"filename"`
+	AbsPath  string `json:"abs_path"`
+	Lineno   int    `json:"lineno"`
+}
+
+// Append captures the stack trace of the caller and queues the event.
+func (a *SentryAppender) Append(event *gol.LoggingEvent) {
+	format, args, fields := eventFields(event)
+	e := &sentryEvent{
+		EventID:     newEventID(),
+		Timestamp:   event.Time.UTC().Format("2006-01-02T15:04:05"),
+		Level:       sentryLevels[event.Level],
+		Logger:      event.Name,
+		Platform:    "go",
+		Message:     fmt.Sprintf(format, args...),
+		Environment: a.Environment,
+		Release:     a.Release,
+		ServerName:  a.ServerName,
+		Tags:        fields,
+		Stacktrace:  captureStacktrace(),
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if !a.running {
+		return
+	}
+	select {
+	case a.events <- e:
+	default:
+	}
+}
+
+// Start starts sending events in background.
+func (a *SentryAppender) Start() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.running {
+		return nil
+	}
+	a.events = make(chan *sentryEvent, sentryQueueSize)
+	a.done = make(chan struct{})
+	a.running = true
+	go a.run(a.events, a.done)
+	return nil
+}
+
+// Stop waits until queued events are sent.
+func (a *SentryAppender) Stop() error {
+	a.mu.Lock()
+	if !a.running {
+		a.mu.Unlock()
+		return nil
+	}
+	a.running = false
+	close(a.events)
+	done := a.done
+	a.mu.Unlock()
+	<-done
+	return nil
+}
+
+func (a *SentryAppender) run(events chan *sentryEvent, done chan struct{}) {
+	defer close(done)
+	for e := range events {
+		if err := a.send(e); err != nil {
+			// Logging the error would be sent to Sentry again.
+			fmt.Fprintf(os.Stderr, "logging: could not send event to sentry: %v\n", err)
+		}
+	}
+}
+
+func (a *SentryAppender) send(e *sentryEvent) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", a.storeURL, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Sentry-Auth", a.auth)
+	resp, err := a.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// captureStacktrace returns frames of the caller, oldest first.
+func captureStacktrace() *sentryStacktrace {
+	pcs := make([]uintptr, maxStackFrames)
+	n := runtime.Callers(1, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	var stack []sentryFrame
+	for {
+		frame, more := frames.Next()
+		if !skipFrame(frame.Function) {
+			stack = append(stack, sentryFrame{
+				Function: frame.Function,
+				Filename: trimGoPath(frame.File),
+				AbsPath:  frame.File,
+				Lineno:   frame.Line,
+			})
+		}
+		if !more {
+			break
+		}
+	}
+	if len(stack) == 0 {
+		return nil
+	}
+	for i, j := 0, len(stack)-1; i < j; i, j = i+1, j-1 {
+		stack[i], stack[j] = stack[j], stack[i]
+	}
+	return &sentryStacktrace{Frames: stack}
+}
+
+func skipFrame(function string) bool {
+	for _, prefix := range stackSkipPrefixes {
+		if strings.HasPrefix(function, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// trimGoPath returns the file path relative to its module or GOPATH.
+func trimGoPath(file string) string {
+	for _, dir := range []string{"/pkg/mod/", "/src/"} {
+		if i := strings.LastIndex(file, dir); i >= 0 {
+			return file[i+len(dir):]
+		}
+	}
+	return file
+}
+
+func newEventID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,82 @@
+//go:build go1.21
+
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/goburrow/gol"
+	"github.com/goburrow/gomelon/core"
+)
+
+// NewSlogLoggerFactory returns a core.LoggerFactory which sends logs to
+// logger with attribute "logger" being the logger name. Fields given by
+// WithContext are added as attributes:
+//
+//	core.SetLoggerFactory(logging.NewSlogLoggerFactory(slog.Default()))
+//
+// Levels of loggers are still managed by gomelon configuration and admin
+// handlers while appenders are not used.
+func NewSlogLoggerFactory(logger *slog.Logger) core.LoggerFactory {
+	return func(name string) core.Logger {
+		return &slogLogger{
+			levels: gol.GetLogger(name),
+			logger: logger.With("logger", name),
+		}
+	}
+}
+
+type slogLogger struct {
+	levels gol.Logger
+	logger *slog.Logger
+}
+
+func (l *slogLogger) log(level slog.Level, format string, args []interface{}) {
+	msg, fields := formatMessage(format, args)
+	attrs := make([]slog.Attr, 0, len(fields))
+	for _, name := range fields.names() {
+		attrs = append(attrs, slog.String(name, fields[name]))
+	}
+	l.logger.LogAttrs(context.Background(), level, msg, attrs...)
+}
+
+func (l *slogLogger) Debug(format string, args ...interface{}) {
+	if l.levels.DebugEnabled() {
+		l.log(slog.LevelDebug, format, args)
+	}
+}
+
+func (l *slogLogger) Info(format string, args ...interface{}) {
+	if l.levels.InfoEnabled() {
+		l.log(slog.LevelInfo, format, args)
+	}
+}
+
+func (l *slogLogger) Warn(format string, args ...interface{}) {
+	if l.levels.WarnEnabled() {
+		l.log(slog.LevelWarn, format, args)
+	}
+}
+
+func (l *slogLogger) Error(format string, args ...interface{}) {
+	if l.levels.ErrorEnabled() {
+		l.log(slog.LevelError, format, args)
+	}
+}
+
+func (l *slogLogger) DebugEnabled() bool {
+	return l.levels.DebugEnabled()
+}
+
+func (l *slogLogger) InfoEnabled() bool {
+	return l.levels.InfoEnabled()
+}
+
+// formatMessage formats the message and separates fields added by
+// WithContext if any.
+func formatMessage(format string, args []interface{}) (string, Fields) {
+	format, args, fields := eventFields(&gol.LoggingEvent{Format: format, Arguments: args})
+	return fmt.Sprintf(format, args...), fields
+}
@@ -0,0 +1,29 @@
+//go:build go1.21
+
+package logging
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/goburrow/gol"
+)
+
+func TestSlogLoggerFactory(t *testing.T) {
+	name := "gomelon/test/slog"
+	setLogLevel(name, gol.LevelInfo)
+	var buf bytes.Buffer
+	handler := slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})
+	logger := NewSlogLoggerFactory(slog.New(handler))(name)
+	logger.Debug("debug")
+	ctx := WithFields(context.Background(), Fields{"requestId": "1"})
+	WithContext(ctx, logger).Info("hello %s", "world")
+	output := buf.String()
+	if strings.Contains(output, "debug") ||
+		!strings.Contains(output, `level=INFO msg="hello world" logger=gomelon/test/slog requestId=1`) {
+		t.Fatalf("unexpected output: %s", output)
+	}
+}
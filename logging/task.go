@@ -2,9 +2,12 @@ package logging
 
 import (
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
 
 	"github.com/goburrow/gol"
+	"github.com/goburrow/gomelon/core"
 )
 
 const (
@@ -19,20 +22,18 @@ func (*logTask) Name() string {
 	return logTaskName
 }
 
-func (*logTask) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	query := r.URL.Query()
+func (*logTask) Execute(params map[string][]string, out io.Writer) error {
 	// Can have multiple loggers
-	loggers, ok := query["logger"]
+	loggers, ok := params["logger"]
 	if !ok || len(loggers) == 0 {
-		return
+		return nil
 	}
 	// But only one level
-	level := query.Get("level")
+	level := url.Values(params).Get("level")
 	if level != "" {
 		logLevel, ok := getLogLevel(level)
 		if !ok {
-			http.Error(w, "Unsupported level "+level, http.StatusBadRequest)
-			return
+			return core.NewTaskError("Unsupported level "+level, http.StatusBadRequest)
 		}
 		for _, name := range loggers {
 			setLogLevel(name, logLevel)
@@ -42,7 +43,8 @@ func (*logTask) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	for _, name := range loggers {
 		logger, ok := gol.GetLogger(name).(*gol.DefaultLogger)
 		if ok {
-			fmt.Fprintf(w, "%s: %s\n", name, gol.LevelString(logger.Level()))
+			fmt.Fprintf(out, "%s: %s\n", name, gol.LevelString(logger.Level()))
 		}
 	}
+	return nil
 }
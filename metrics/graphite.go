@@ -0,0 +1,150 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goburrow/gomelon/core"
+	"github.com/goburrow/gomelon/metrics/registry"
+	"github.com/goburrow/polytype"
+)
+
+const (
+	defaultGraphitePort = 2003
+	graphiteDialTimeout = 10 * time.Second
+)
+
+func init() {
+	newGraphiteFactory := func() interface{} { return &GraphiteReporterFactory{} }
+	polytype.Register("GraphiteReporter", newGraphiteFactory)
+	polytype.Register("graphite", newGraphiteFactory)
+}
+
+// GraphiteReporterFactory is the configuration of GraphiteReporter.
+type GraphiteReporterFactory struct {
+	CommonReporterConfiguration
+
+	Host string `valid:"nonzero"`
+	// Port defaults to 2003.
+	Port   int
+	Prefix string
+}
+
+func (factory *GraphiteReporterFactory) Build(env *core.Environment) (Reporter, error) {
+	port := factory.Port
+	if port == 0 {
+		port = defaultGraphitePort
+	}
+	return &GraphiteReporter{
+		Addr:   net.JoinHostPort(factory.Host, strconv.Itoa(port)),
+		Prefix: factory.Prefix,
+	}, nil
+}
+
+// GraphiteReporter sends metrics to Graphite using plaintext protocol.
+// Durations of timers are reported in milliseconds.
+type GraphiteReporter struct {
+	// Addr is the TCP address of Graphite (carbon) server.
+	Addr   string
+	Prefix string
+	// Tags are sent in Graphite 1.1 tagged series format if it is not empty.
+	Tags map[string]string
+}
+
+var _ Reporter = (*GraphiteReporter)(nil)
+
+func (reporter *GraphiteReporter) addPrefixAndTags(prefix string, tags map[string]string) {
+	reporter.Prefix = joinPrefix(prefix, reporter.Prefix)
+	reporter.Tags = mergeTags(tags, reporter.Tags)
+}
+
+func (reporter *GraphiteReporter) Report(r *registry.Registry) error {
+	conn, err := net.DialTimeout("tcp", reporter.Addr, graphiteDialTimeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	w := bufio.NewWriter(conn)
+	reporter.write(w, r, time.Now().Unix())
+	return w.Flush()
+}
+
+func (reporter *GraphiteReporter) write(w io.Writer, r *registry.Registry, timestamp int64) {
+	tags := reporter.formatTags()
+	r.Each(func(name string, metric interface{}) {
+		name = graphiteName(reporter.Prefix, name)
+		send := func(field string, value interface{}) {
+			fmt.Fprintf(w, "%s.%s%s %v %d\n", name, field, tags, value, timestamp)
+		}
+		switch m := metric.(type) {
+		case *registry.Counter:
+			send("count", m.Count())
+		case *registry.Gauge:
+			send("value", m.Value())
+		case *registry.Histogram:
+			writeGraphiteHistogram(send, m.Snapshot(), 1)
+		case *registry.Meter:
+			s := m.Snapshot()
+			send("count", s.Count)
+			writeGraphiteRates(send, s.M1Rate, s.M5Rate, s.M15Rate, s.MeanRate)
+		case *registry.Timer:
+			s := m.Snapshot()
+			writeGraphiteHistogram(send, s.HistogramSnapshot, float64(time.Millisecond))
+			writeGraphiteRates(send, s.M1Rate, s.M5Rate, s.M15Rate, s.MeanRate)
+		}
+	})
+}
+
+func writeGraphiteHistogram(send func(string, interface{}), s registry.HistogramSnapshot, scale float64) {
+	send("count", s.Count)
+	send("min", float64(s.Min)/scale)
+	send("max", float64(s.Max)/scale)
+	send("mean", s.Mean/scale)
+	send("stddev", s.StdDev/scale)
+	send("p50", s.P50/scale)
+	send("p75", s.P75/scale)
+	send("p95", s.P95/scale)
+	send("p98", s.P98/scale)
+	send("p99", s.P99/scale)
+	send("p999", s.P999/scale)
+}
+
+func writeGraphiteRates(send func(string, interface{}), m1, m5, m15, mean float64) {
+	send("m1_rate", m1)
+	send("m5_rate", m5)
+	send("m15_rate", m15)
+	send("mean_rate", mean)
+}
+
+// formatTags returns tags sorted by name in Graphite format ";tag=value".
+func (reporter *GraphiteReporter) formatTags() string {
+	if len(reporter.Tags) == 0 {
+		return ""
+	}
+	tags := make([]string, 0, len(reporter.Tags))
+	for name, value := range reporter.Tags {
+		tags = append(tags, ";"+name+"="+value)
+	}
+	sort.Strings(tags)
+	return strings.Join(tags, "")
+}
+
+// graphiteName replaces characters which are not allowed in Graphite paths.
+func graphiteName(prefix, name string) string {
+	if prefix != "" {
+		name = prefix + "." + name
+	}
+	b := []byte(name)
+	for i, c := range b {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '.' || c == '_' || c == '-') {
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
@@ -0,0 +1,101 @@
+package metrics
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/goburrow/gomelon/core"
+	"github.com/goburrow/gomelon/metrics/registry"
+)
+
+func TestGraphiteReporterWrite(t *testing.T) {
+	r := registry.NewRegistry()
+	r.Counter("HTTP.Panics").Add(2)
+	r.Gauge("Endpoint.GET./users").Set(3)
+	r.Timer("t").Update(2 * time.Millisecond)
+
+	var buf bytes.Buffer
+	reporter := &GraphiteReporter{Prefix: "app"}
+	reporter.write(&buf, r, 100)
+	lines := strings.Split(buf.String(), "\n")
+	expected := []string{
+		"app.Endpoint.GET._users.value 3 100",
+		"app.HTTP.Panics.count 2 100",
+		"app.t.count 1 100",
+		"app.t.min 2 100",
+	}
+	for i, line := range expected {
+		if lines[i] != line {
+			t.Fatalf("unexpected line %d: %q", i, lines[i])
+		}
+	}
+}
+
+func TestGraphiteReporterPrefixAndTags(t *testing.T) {
+	r := registry.NewRegistry()
+	r.Counter("c").Inc()
+
+	var buf bytes.Buffer
+	reporter := &GraphiteReporter{Prefix: "app", Tags: map[string]string{"dc": "1"}}
+	reporter.addPrefixAndTags("prod", map[string]string{"env": "prod", "dc": "0"})
+	reporter.write(&buf, r, 100)
+	if buf.String() != "prod.app.c.count;dc=1;env=prod 1 100\n" {
+		t.Fatalf("unexpected output: %q", buf.String())
+	}
+}
+
+func TestGraphiteReporter(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	received := make(chan string, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		scanner := bufio.NewScanner(conn)
+		for scanner.Scan() {
+			if strings.HasPrefix(scanner.Text(), "c.") {
+				received <- scanner.Text()
+				return
+			}
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(l.Addr().String())
+	var factory Factory
+	data := `{"frequency": "1h", "reporters": [{"type": "graphite", "host": "` + host + `", "port": ` + port + `}]}`
+	if err = json.Unmarshal([]byte(data), &factory); err != nil {
+		t.Fatal(err)
+	}
+	env := core.NewEnvironment()
+	if err = factory.Configure(env); err != nil {
+		t.Fatal(err)
+	}
+	reporter, err := factory.Reporters[0].Value().(ReporterFactory).Build(env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	env.Metrics.Counter("c").Inc()
+	// Metrics are reported when stopping.
+	scheduled := NewScheduledReporter(reporter, env.Metrics, time.Hour)
+	scheduled.Start()
+	scheduled.Stop()
+	select {
+	case line := <-received:
+		if !strings.HasPrefix(line, "c.count 1 ") {
+			t.Fatalf("unexpected line %q", line)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out")
+	}
+}
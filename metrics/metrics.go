@@ -4,25 +4,65 @@ Package metrics provides metrics configuration for applications.
 package metrics
 
 import (
-	"expvar"
+	"encoding/json"
+	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
-	_ "github.com/codahale/metrics"
-	_ "github.com/codahale/metrics/runtime"
 	"github.com/goburrow/gomelon/core"
+	"github.com/goburrow/gomelon/metrics/registry"
 )
 
 const (
 	metricsURI = "/metrics"
-	metricsVar = "metrics"
 )
 
-// metricsHandler displays expvars.
+// metricsHandler displays metrics in the registry.
+//
+//	GET /metrics?name=HTTP.&name=Endpoint.GET&pretty=true
+//	GET /metrics?rates=true
+//
+// Only metrics whose names start with one of the given name prefixes are
+// included. With rates, counts of counters, histograms, meters and timers
+// are displayed along with their changes and rates per second since the
+// previous rates request including them.
 type metricsHandler struct {
+	registry *registry.Registry
+	now      func() time.Time
+
+	mu        sync.Mutex
+	startTime time.Time
+	last      map[string]metricSample
 }
 
 var _ core.AdminHandler = (*metricsHandler)(nil)
 
+// metricRate is the rate view of a metric.
+type metricRate struct {
+	Count int64   `json:"count"`
+	Delta int64   `json:"delta"`
+	Rate  float64 `json:"rate"`
+}
+
+// metricSample is the count of a metric when its rate was last requested.
+type metricSample struct {
+	count int64
+	time  time.Time
+}
+
+func newMetricsHandler(r *registry.Registry) *metricsHandler {
+	return &metricsHandler{
+		registry:  r,
+		now:       time.Now,
+		startTime: time.Now(),
+		last:      make(map[string]metricSample),
+	}
+}
+
 func (handler *metricsHandler) Name() string {
 	return "Metrics"
 }
@@ -31,28 +71,148 @@ func (handler *metricsHandler) Path() string {
 	return metricsURI
 }
 
-func (*metricsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+func (handler *metricsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Cache-Control", "must-revalidate,no-cache,no-store")
 
-	val := expvar.Get(metricsVar)
-	if val == nil {
-		w.WriteHeader(http.StatusNotImplemented)
-		w.Write([]byte("No metrics."))
+	query := r.URL.Query()
+	filtered := handler.filter(query["name"])
+	var output interface{} = filtered
+	if queryBool(query, "rates") {
+		output = handler.rates(filtered)
+	}
+	var data []byte
+	var err error
+	if queryBool(query, "pretty") {
+		data, err = json.MarshalIndent(output, "", "  ")
+	} else {
+		data, err = json.Marshal(output)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
-	w.Write([]byte(val.String()))
+	w.Write(data)
 }
 
+// filter returns a registry containing metrics whose names start with one
+// of the given prefixes, or the original registry if there is no prefix.
+func (handler *metricsHandler) filter(prefixes []string) *registry.Registry {
+	if len(prefixes) == 0 {
+		return handler.registry
+	}
+	filtered := registry.NewRegistry()
+	handler.registry.Each(func(name string, metric interface{}) {
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(name, prefix) {
+				filtered.Register(name, metric)
+				return
+			}
+		}
+	})
+	return filtered
+}
+
+// rates returns counts and rates of metrics since the last call including
+// each metric, or since the handler was created for new metrics.
+func (handler *metricsHandler) rates(r *registry.Registry) map[string]*metricRate {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+
+	now := handler.now()
+	rates := make(map[string]*metricRate)
+	r.Each(func(name string, metric interface{}) {
+		var count int64
+		switch m := metric.(type) {
+		case *registry.Counter:
+			count = m.Count()
+		case *registry.Histogram:
+			count = m.Count()
+		case *registry.Meter:
+			count = m.Count()
+		case *registry.Timer:
+			count = m.Count()
+		default:
+			return
+		}
+		last, ok := handler.last[name]
+		if !ok {
+			last.time = handler.startTime
+		}
+		rate := &metricRate{
+			Count: count,
+			Delta: count - last.count,
+		}
+		if elapsed := now.Sub(last.time).Seconds(); elapsed > 0 {
+			rate.Rate = float64(rate.Delta) / elapsed
+		}
+		handler.last[name] = metricSample{count: count, time: now}
+		rates[name] = rate
+	})
+	return rates
+}
+
+// queryBool returns true if the parameter is given without value or with
+// a true value.
+func queryBool(query url.Values, key string) bool {
+	values, ok := query[key]
+	if !ok {
+		return false
+	}
+	if len(values) == 0 || values[0] == "" {
+		return true
+	}
+	b, _ := strconv.ParseBool(values[0])
+	return b
+}
+
+// Factory is the metrics configuration.
 type Factory struct {
+	// Frequency is the default interval of reporting, e.g. "1m".
 	Frequency string
+	// Reporters send metrics to monitoring backends periodically.
+	Reporters []ReporterConfiguration
+	// Prefix is prepended to names of all reported metrics, e.g. "myapp".
+	Prefix string
+	// Tags are added to all reported metrics, e.g. {env: prod}, by
+	// reporters which support tags.
+	Tags map[string]string
+	// Prometheus configures metrics exposed in admin page /prometheus.
+	Prometheus PrometheusConfiguration
 }
 
 // Factory implements core.MetricsFactory interface.
 var _ core.MetricsFactory = (*Factory)(nil)
 
 func (factory *Factory) Configure(env *core.Environment) error {
-	env.Admin.AddHandler(&metricsHandler{})
-	// TODO: configure frequency in metrics.
+	prometheus := factory.Prometheus
+	if prometheus.Namespace == "" {
+		prometheus.Namespace = factory.Prefix
+	}
+	prometheus.Labels = mergeTags(factory.Tags, prometheus.Labels)
+	env.Admin.AddHandler(newMetricsHandler(env.Metrics),
+		newPrometheusHandler(env.Metrics, &prometheus))
+	frequency, err := parseFrequency(factory.Frequency, defaultFrequency)
+	if err != nil {
+		return err
+	}
+	for _, config := range factory.Reporters {
+		f, ok := config.Value().(ReporterFactory)
+		if !ok {
+			return fmt.Errorf("metrics: unsupported reporter %#v", config.Value())
+		}
+		reporterFrequency, err := parseFrequency(f.Common().Frequency, frequency)
+		if err != nil {
+			return err
+		}
+		reporter, err := f.Build(env)
+		if err != nil {
+			return err
+		}
+		if t, ok := reporter.(tagger); ok {
+			t.addPrefixAndTags(factory.Prefix, factory.Tags)
+		}
+		env.Lifecycle.Manage(NewScheduledReporter(reporter, env.Metrics, reporterFrequency))
+	}
 	return nil
 }
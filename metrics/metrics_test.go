@@ -0,0 +1,109 @@
+package metrics
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/goburrow/gomelon/metrics/registry"
+)
+
+func TestMetricsHandlerFilter(t *testing.T) {
+	r := registry.NewRegistry()
+	r.Counter("HTTP.Panics").Inc()
+	r.Counter("Endpoint.GET./.2xx").Inc()
+	r.Gauge("Runtime.Goroutines").Set(1)
+
+	handler := newMetricsHandler(r)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", metricsURI+"?name=HTTP.&name=Runtime&pretty", nil)
+	handler.ServeHTTP(w, req)
+	if !strings.Contains(w.Body.String(), "\n  ") {
+		t.Fatalf("expected indented output: %s", w.Body.String())
+	}
+	var output struct {
+		Counters map[string]interface{}
+		Gauges   map[string]interface{}
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &output); err != nil {
+		t.Fatal(err)
+	}
+	if len(output.Counters) != 1 || output.Counters["HTTP.Panics"] == nil {
+		t.Fatalf("unexpected counters: %v", output.Counters)
+	}
+	if len(output.Gauges) != 1 || output.Gauges["Runtime.Goroutines"] == nil {
+		t.Fatalf("unexpected gauges: %v", output.Gauges)
+	}
+}
+
+func TestMetricsHandlerRates(t *testing.T) {
+	r := registry.NewRegistry()
+	c := r.Counter("c")
+	c.Add(2)
+	r.Gauge("g").Set(1)
+
+	handler := newMetricsHandler(r)
+	rates := func() map[string]*metricRate {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", metricsURI+"?rates=true", nil)
+		handler.ServeHTTP(w, req)
+		var output map[string]*metricRate
+		if err := json.Unmarshal(w.Body.Bytes(), &output); err != nil {
+			t.Fatal(err)
+		}
+		return output
+	}
+	output := rates()
+	if len(output) != 1 || output["c"].Count != 2 || output["c"].Delta != 2 {
+		t.Fatalf("unexpected rates: %+v", output["c"])
+	}
+	c.Add(3)
+	output = rates()
+	if output["c"].Count != 5 || output["c"].Delta != 3 || output["c"].Rate <= 0 {
+		t.Fatalf("unexpected rates: %+v", output["c"])
+	}
+}
+
+func TestMetricsHandlerRatesFiltered(t *testing.T) {
+	r := registry.NewRegistry()
+	a := r.Counter("A")
+	b := r.Counter("B")
+
+	handler := newMetricsHandler(r)
+	start := time.Unix(1000, 0)
+	clock := start
+	handler.now = func() time.Time { return clock }
+	handler.startTime = start
+	rates := func(name string) *metricRate {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", metricsURI+"?rates&name="+name, nil)
+		handler.ServeHTTP(w, req)
+		var output map[string]*metricRate
+		if err := json.Unmarshal(w.Body.Bytes(), &output); err != nil {
+			t.Fatal(err)
+		}
+		if len(output) != 1 || output[name] == nil {
+			t.Fatalf("unexpected rates: %+v", output)
+		}
+		return output[name]
+	}
+	a.Add(10)
+	b.Add(10)
+	clock = start.Add(10 * time.Second)
+	if rate := rates("A"); rate.Delta != 10 || rate.Rate != 1 {
+		t.Fatalf("unexpected rate of A: %+v", rate)
+	}
+	b.Add(10)
+	clock = start.Add(20 * time.Second)
+	if rate := rates("B"); rate.Delta != 20 || rate.Rate != 1 {
+		t.Fatalf("unexpected rate of B: %+v", rate)
+	}
+	a.Add(20)
+	clock = start.Add(30 * time.Second)
+	if rate := rates("A"); rate.Delta != 20 || rate.Rate != 1 {
+		t.Fatalf("unexpected rate of A: %+v", rate)
+	}
+}
@@ -0,0 +1,270 @@
+package metrics
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/goburrow/gomelon/core"
+	"github.com/goburrow/gomelon/metrics/registry"
+	"github.com/goburrow/polytype"
+)
+
+const (
+	defaultOTLPEndpoint = "http://localhost:4318/v1/metrics"
+	defaultOTLPTimeout  = 10 * time.Second
+
+	otlpScopeName = "gomelon"
+	// otlpCumulative is AGGREGATION_TEMPORALITY_CUMULATIVE.
+	otlpCumulative = 2
+)
+
+func init() {
+	newOTLPFactory := func() interface{} { return &OTLPReporterFactory{} }
+	polytype.Register("OTLPReporter", newOTLPFactory)
+	polytype.Register("otlp", newOTLPFactory)
+}
+
+// OTLPReporterFactory is the configuration of OTLPReporter.
+type OTLPReporterFactory struct {
+	CommonReporterConfiguration
+
+	// Endpoint is the URL of OpenTelemetry collector.
+	// Default is http://localhost:4318/v1/metrics.
+	Endpoint string
+	// Headers are added to export requests, e.g. for authentication.
+	Headers map[string]string `secret:"true"`
+	// Timeout of export requests. Default is 10s.
+	Timeout string
+	// ResourceAttributes are added to service.name and service.version
+	// which are taken from the application.
+	ResourceAttributes map[string]string
+}
+
+func (factory *OTLPReporterFactory) Build(env *core.Environment) (Reporter, error) {
+	timeout := defaultOTLPTimeout
+	if factory.Timeout != "" {
+		var err error
+		if timeout, err = time.ParseDuration(factory.Timeout); err != nil {
+			return nil, fmt.Errorf("metrics: invalid otlp timeout %v", err)
+		}
+	}
+	reporter := NewOTLPReporter(factory.Endpoint)
+	reporter.Client.Timeout = timeout
+	reporter.Headers = factory.Headers
+	reporter.ResourceAttributes["service.name"] = env.Name
+	if env.BuildInfo.Version != "" {
+		reporter.ResourceAttributes["service.version"] = env.BuildInfo.Version
+	}
+	for k, v := range factory.ResourceAttributes {
+		reporter.ResourceAttributes[k] = v
+	}
+	return reporter, nil
+}
+
+// OTLPReporter exports metrics to OpenTelemetry collector using OTLP over
+// HTTP with JSON encoding. Counters are exported as non-monotonic sums,
+// meters as monotonic sums and histograms and timers (in seconds) as
+// summaries.
+type OTLPReporter struct {
+	Endpoint           string
+	Headers            map[string]string
+	ResourceAttributes map[string]string
+	Client             *http.Client
+	// Prefix is prepended to metric names.
+	Prefix string
+	// Attributes are added to all data points.
+	Attributes map[string]string
+
+	startTime time.Time
+}
+
+var _ Reporter = (*OTLPReporter)(nil)
+
+func (reporter *OTLPReporter) addPrefixAndTags(prefix string, tags map[string]string) {
+	reporter.Prefix = joinPrefix(prefix, reporter.Prefix)
+	reporter.Attributes = mergeTags(tags, reporter.Attributes)
+}
+
+// NewOTLPReporter allocates and returns a new OTLPReporter.
+func NewOTLPReporter(endpoint string) *OTLPReporter {
+	if endpoint == "" {
+		endpoint = defaultOTLPEndpoint
+	}
+	return &OTLPReporter{
+		Endpoint:           endpoint,
+		ResourceAttributes: make(map[string]string),
+		Client:             &http.Client{Timeout: defaultOTLPTimeout},
+		startTime:          time.Now(),
+	}
+}
+
+func (reporter *OTLPReporter) Report(r *registry.Registry) error {
+	data, err := json.Marshal(reporter.export(r, time.Now()))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", reporter.Endpoint, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range reporter.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := reporter.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("metrics: otlp export failed with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// OTLP JSON structures. 64-bit integers are encoded as strings.
+type (
+	otlpRequest struct {
+		ResourceMetrics []otlpResourceMetrics `json:"resourceMetrics"`
+	}
+	otlpResourceMetrics struct {
+		Resource     otlpResource       `json:"resource"`
+		ScopeMetrics []otlpScopeMetrics `json:"scopeMetrics"`
+	}
+	otlpResource struct {
+		Attributes []otlpAttribute `json:"attributes"`
+	}
+	otlpAttribute struct {
+		Key   string `json:"key"`
+		Value struct {
+			StringValue string `json:"stringValue"`
+		} `json:"value"`
+	}
+	otlpScopeMetrics struct {
+		Scope struct {
+			Name string `json:"name"`
+		} `json:"scope"`
+		Metrics []otlpMetric `json:"metrics"`
+	}
+	otlpMetric struct {
+		Name    string       `json:"name"`
+		Unit    string       `json:"unit,omitempty"`
+		Gauge   *otlpGauge   `json:"gauge,omitempty"`
+		Sum     *otlpSum     `json:"sum,omitempty"`
+		Summary *otlpSummary `json:"summary,omitempty"`
+	}
+	otlpGauge struct {
+		DataPoints []otlpNumberDataPoint `json:"dataPoints"`
+	}
+	otlpSum struct {
+		DataPoints             []otlpNumberDataPoint `json:"dataPoints"`
+		AggregationTemporality int                   `json:"aggregationTemporality"`
+		IsMonotonic            bool                  `json:"isMonotonic"`
+	}
+	otlpNumberDataPoint struct {
+		Attributes        []otlpAttribute `json:"attributes,omitempty"`
+		StartTimeUnixNano string          `json:"startTimeUnixNano,omitempty"`
+		TimeUnixNano      string          `json:"timeUnixNano"`
+		AsInt             string          `json:"asInt"`
+	}
+	otlpSummary struct {
+		DataPoints []otlpSummaryDataPoint `json:"dataPoints"`
+	}
+	otlpSummaryDataPoint struct {
+		Attributes        []otlpAttribute     `json:"attributes,omitempty"`
+		StartTimeUnixNano string              `json:"startTimeUnixNano"`
+		TimeUnixNano      string              `json:"timeUnixNano"`
+		Count             string              `json:"count"`
+		Sum               float64             `json:"sum"`
+		QuantileValues    []otlpQuantileValue `json:"quantileValues"`
+	}
+	otlpQuantileValue struct {
+		Quantile float64 `json:"quantile"`
+		Value    float64 `json:"value"`
+	}
+)
+
+func (reporter *OTLPReporter) export(r *registry.Registry, now time.Time) *otlpRequest {
+	start := strconv.FormatInt(reporter.startTime.UnixNano(), 10)
+	timestamp := strconv.FormatInt(now.UnixNano(), 10)
+	attributes := otlpAttributes(reporter.Attributes)
+	number := func(v int64) []otlpNumberDataPoint {
+		return []otlpNumberDataPoint{{
+			Attributes:        attributes,
+			StartTimeUnixNano: start,
+			TimeUnixNano:      timestamp,
+			AsInt:             strconv.FormatInt(v, 10),
+		}}
+	}
+	summary := func(s registry.HistogramSnapshot, scale float64) *otlpSummary {
+		return &otlpSummary{
+			DataPoints: []otlpSummaryDataPoint{{
+				Attributes:        attributes,
+				StartTimeUnixNano: start,
+				TimeUnixNano:      timestamp,
+				Count:             strconv.FormatInt(s.Count, 10),
+				Sum:               s.Mean * float64(s.Count) / scale,
+				QuantileValues: []otlpQuantileValue{
+					{0, float64(s.Min) / scale},
+					{0.5, s.P50 / scale},
+					{0.75, s.P75 / scale},
+					{0.95, s.P95 / scale},
+					{0.99, s.P99 / scale},
+					{0.999, s.P999 / scale},
+					{1, float64(s.Max) / scale},
+				},
+			}},
+		}
+	}
+	var metrics []otlpMetric
+	r.Each(func(name string, metric interface{}) {
+		m := otlpMetric{Name: joinPrefix(reporter.Prefix, name)}
+		switch v := metric.(type) {
+		case *registry.Counter:
+			m.Sum = &otlpSum{DataPoints: number(v.Count()), AggregationTemporality: otlpCumulative}
+		case *registry.Gauge:
+			points := number(v.Value())
+			points[0].StartTimeUnixNano = ""
+			m.Gauge = &otlpGauge{DataPoints: points}
+		case *registry.Meter:
+			m.Sum = &otlpSum{DataPoints: number(v.Count()), AggregationTemporality: otlpCumulative, IsMonotonic: true}
+		case *registry.Histogram:
+			m.Summary = summary(v.Snapshot(), 1)
+		case *registry.Timer:
+			m.Unit = "s"
+			m.Summary = summary(v.Snapshot().HistogramSnapshot, float64(time.Second))
+		default:
+			return
+		}
+		metrics = append(metrics, m)
+	})
+
+	scope := otlpScopeMetrics{Metrics: metrics}
+	scope.Scope.Name = otlpScopeName
+	resource := otlpResourceMetrics{ScopeMetrics: []otlpScopeMetrics{scope}}
+	resource.Resource.Attributes = otlpAttributes(reporter.ResourceAttributes)
+	return &otlpRequest{ResourceMetrics: []otlpResourceMetrics{resource}}
+}
+
+// otlpAttributes returns attributes sorted by key.
+func otlpAttributes(m map[string]string) []otlpAttribute {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	attributes := make([]otlpAttribute, len(keys))
+	for i, k := range keys {
+		attributes[i].Key = k
+		attributes[i].Value.StringValue = m[k]
+	}
+	return attributes
+}
@@ -0,0 +1,76 @@
+package metrics
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/goburrow/gomelon/core"
+	"github.com/goburrow/gomelon/metrics/registry"
+)
+
+func TestOTLPReporter(t *testing.T) {
+	var body map[string]interface{}
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		data, _ := ioutil.ReadAll(r.Body)
+		json.Unmarshal(data, &body)
+	}))
+	defer server.Close()
+
+	env := core.NewEnvironment()
+	env.Name = "app"
+	env.BuildInfo.Version = "1.0"
+	factory := &OTLPReporterFactory{
+		Endpoint: server.URL,
+		Headers:  map[string]string{"Authorization": "Bearer token"},
+	}
+	reporter, err := factory.Build(env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := registry.NewRegistry()
+	r.Counter("c").Add(3)
+	r.Timer("t").Update(time.Second)
+	if err = reporter.Report(r); err != nil {
+		t.Fatal(err)
+	}
+	if header.Get("Authorization") != "Bearer token" || header.Get("Content-Type") != "application/json" {
+		t.Fatalf("unexpected header %v", header)
+	}
+	resource := body["resourceMetrics"].([]interface{})[0].(map[string]interface{})
+	attrs, _ := json.Marshal(resource["resource"])
+	expected := `{"attributes":[{"key":"service.name","value":{"stringValue":"app"}},{"key":"service.version","value":{"stringValue":"1.0"}}]}`
+	if string(attrs) != expected {
+		t.Fatalf("unexpected resource %s", attrs)
+	}
+	metrics := resource["scopeMetrics"].([]interface{})[0].(map[string]interface{})["metrics"].([]interface{})
+	if len(metrics) != 2 {
+		t.Fatalf("unexpected metrics %v", metrics)
+	}
+	c := metrics[0].(map[string]interface{})
+	point := c["sum"].(map[string]interface{})["dataPoints"].([]interface{})[0].(map[string]interface{})
+	if c["name"] != "c" || point["asInt"] != "3" {
+		t.Fatalf("unexpected metric %v", c)
+	}
+	s := metrics[1].(map[string]interface{})
+	summary := s["summary"].(map[string]interface{})["dataPoints"].([]interface{})[0].(map[string]interface{})
+	if s["unit"] != "s" || summary["count"] != "1" || summary["sum"] != 1.0 {
+		t.Fatalf("unexpected metric %v", s)
+	}
+}
+
+func TestOTLPReporterError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+	reporter := NewOTLPReporter(server.URL)
+	if err := reporter.Report(registry.NewRegistry()); err == nil {
+		t.Fatal("error expected")
+	}
+}
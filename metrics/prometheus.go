@@ -0,0 +1,149 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/goburrow/gomelon/core"
+	"github.com/goburrow/gomelon/metrics/registry"
+)
+
+const (
+	prometheusURI         = "/prometheus"
+	prometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+)
+
+// PrometheusConfiguration is the configuration of Prometheus exposition
+// handler in admin page.
+type PrometheusConfiguration struct {
+	// Namespace is prepended to names of all metrics.
+	Namespace string
+	// Labels are added to all metrics.
+	Labels map[string]string
+}
+
+// prometheusHandler exposes metrics in Prometheus text format.
+// Counters and gauges are exported as gauges, meters as counters, and
+// histograms and timers (in seconds) as summaries.
+type prometheusHandler struct {
+	registry  *registry.Registry
+	namespace string
+	labels    string
+}
+
+var _ core.AdminHandler = (*prometheusHandler)(nil)
+
+func newPrometheusHandler(r *registry.Registry, config *PrometheusConfiguration) *prometheusHandler {
+	return &prometheusHandler{
+		registry:  r,
+		namespace: config.Namespace,
+		labels:    formatLabels(config.Labels),
+	}
+}
+
+func (handler *prometheusHandler) Name() string {
+	return "Prometheus"
+}
+
+func (handler *prometheusHandler) Path() string {
+	return prometheusURI
+}
+
+func (handler *prometheusHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "must-revalidate,no-cache,no-store")
+	w.Header().Set("Content-Type", prometheusContentType)
+	bw := bufio.NewWriter(w)
+	handler.write(bw)
+	bw.Flush()
+}
+
+func (handler *prometheusHandler) write(w io.Writer) {
+	handler.registry.Each(func(name string, metric interface{}) {
+		name = prometheusName(handler.namespace, name)
+		switch m := metric.(type) {
+		case *registry.Counter:
+			fmt.Fprintf(w, "# TYPE %s gauge\n", name)
+			fmt.Fprintf(w, "%s%s %d\n", name, handler.labels, m.Count())
+		case *registry.Gauge:
+			fmt.Fprintf(w, "# TYPE %s gauge\n", name)
+			fmt.Fprintf(w, "%s%s %d\n", name, handler.labels, m.Value())
+		case *registry.Meter:
+			fmt.Fprintf(w, "# TYPE %s_total counter\n", name)
+			fmt.Fprintf(w, "%s_total%s %d\n", name, handler.labels, m.Count())
+		case *registry.Histogram:
+			handler.writeSummary(w, name, m.Snapshot(), 1)
+		case *registry.Timer:
+			handler.writeSummary(w, name+"_seconds", m.Snapshot().HistogramSnapshot, float64(time.Second))
+		}
+	})
+}
+
+func (handler *prometheusHandler) writeSummary(w io.Writer, name string, s registry.HistogramSnapshot, scale float64) {
+	fmt.Fprintf(w, "# TYPE %s summary\n", name)
+	quantiles := []struct {
+		name  string
+		value float64
+	}{
+		{"0.5", s.P50},
+		{"0.75", s.P75},
+		{"0.95", s.P95},
+		{"0.98", s.P98},
+		{"0.99", s.P99},
+		{"0.999", s.P999},
+	}
+	for _, q := range quantiles {
+		fmt.Fprintf(w, "%s%s %g\n", name, handler.withLabel("quantile", q.name), q.value/scale)
+	}
+	fmt.Fprintf(w, "%s_count%s %d\n", name, handler.labels, s.Count)
+}
+
+// withLabel returns constant labels with the additional label.
+func (handler *prometheusHandler) withLabel(name, value string) string {
+	label := name + "=" + quoteLabel(value)
+	if handler.labels == "" {
+		return "{" + label + "}"
+	}
+	return handler.labels[:len(handler.labels)-1] + "," + label + "}"
+}
+
+// prometheusName converts the metric name to a valid Prometheus name.
+func prometheusName(namespace, name string) string {
+	if namespace != "" {
+		name = namespace + "_" + name
+	}
+	b := []byte(name)
+	for i, c := range b {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' || c >= '0' && c <= '9' && i > 0) {
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
+
+// formatLabels returns labels sorted by name in Prometheus format.
+func formatLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = prometheusName("", name) + "=" + quoteLabel(labels[name])
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+var labelReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func quoteLabel(value string) string {
+	return `"` + labelReplacer.Replace(value) + `"`
+}
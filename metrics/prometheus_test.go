@@ -0,0 +1,59 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/goburrow/gomelon/metrics/registry"
+)
+
+func TestPrometheusHandler(t *testing.T) {
+	r := registry.NewRegistry()
+	r.Counter("HTTP.Panics").Add(2)
+	r.Gauge("Connector.http.Active").Set(3)
+	r.Meter("events").Mark(4)
+	r.Histogram("sizes").Update(10)
+
+	handler := newPrometheusHandler(r, &PrometheusConfiguration{
+		Namespace: "app",
+		Labels:    map[string]string{"env": "prod", "dc": `a"b`},
+	})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", prometheusURI, nil)
+	handler.ServeHTTP(w, req)
+	if w.Header().Get("Content-Type") != prometheusContentType {
+		t.Fatalf("unexpected content type %s", w.Header().Get("Content-Type"))
+	}
+	expected := `# TYPE app_Connector_http_Active gauge
+app_Connector_http_Active{dc="a\"b",env="prod"} 3
+# TYPE app_HTTP_Panics gauge
+app_HTTP_Panics{dc="a\"b",env="prod"} 2
+# TYPE app_events_total counter
+app_events_total{dc="a\"b",env="prod"} 4
+# TYPE app_sizes summary
+app_sizes{dc="a\"b",env="prod",quantile="0.5"} 10
+app_sizes{dc="a\"b",env="prod",quantile="0.75"} 10
+app_sizes{dc="a\"b",env="prod",quantile="0.95"} 10
+app_sizes{dc="a\"b",env="prod",quantile="0.98"} 10
+app_sizes{dc="a\"b",env="prod",quantile="0.99"} 10
+app_sizes{dc="a\"b",env="prod",quantile="0.999"} 10
+app_sizes_count{dc="a\"b",env="prod"} 1
+`
+	if w.Body.String() != expected {
+		t.Fatalf("unexpected response:\n%s", w.Body.String())
+	}
+}
+
+func TestPrometheusTimer(t *testing.T) {
+	r := registry.NewRegistry()
+	r.Timer("Endpoint.GET./users/:name.Requests").Update(1500000000)
+	handler := newPrometheusHandler(r, &PrometheusConfiguration{})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", prometheusURI, nil)
+	handler.ServeHTTP(w, req)
+	if !strings.Contains(w.Body.String(), `Endpoint_GET__users__name_Requests_seconds{quantile="0.5"} 1.5`+"\n") {
+		t.Fatalf("unexpected response:\n%s", w.Body.String())
+	}
+}
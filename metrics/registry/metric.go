@@ -0,0 +1,344 @@
+package registry
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Counter is an incrementing and decrementing value.
+type Counter struct {
+	count int64
+}
+
+// Inc increments the counter by one.
+func (c *Counter) Inc() {
+	atomic.AddInt64(&c.count, 1)
+}
+
+// Dec decrements the counter by one.
+func (c *Counter) Dec() {
+	atomic.AddInt64(&c.count, -1)
+}
+
+// Add adds n to the counter.
+func (c *Counter) Add(n int64) {
+	atomic.AddInt64(&c.count, n)
+}
+
+// Count returns current value of the counter.
+func (c *Counter) Count() int64 {
+	return atomic.LoadInt64(&c.count)
+}
+
+// Gauge is an instantaneous value which is either set or computed by a
+// function when it is read.
+type Gauge struct {
+	mu    sync.RWMutex
+	value int64
+	f     func() int64
+}
+
+// Set sets value of the gauge.
+func (g *Gauge) Set(value int64) {
+	g.mu.Lock()
+	g.value = value
+	g.f = nil
+	g.mu.Unlock()
+}
+
+// SetFunc sets the function computing value of the gauge.
+func (g *Gauge) SetFunc(f func() int64) {
+	g.mu.Lock()
+	g.f = f
+	g.mu.Unlock()
+}
+
+// Value returns current value of the gauge.
+func (g *Gauge) Value() int64 {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	if g.f != nil {
+		return g.f()
+	}
+	return g.value
+}
+
+const (
+	defaultReservoirSize = 1028
+)
+
+// Histogram tracks distribution of values. Statistics are calculated from
+// a sliding window of the most recent values.
+type Histogram struct {
+	mu     sync.Mutex
+	count  int64
+	values []int64
+	next   int
+}
+
+// HistogramSnapshot contains statistics of a histogram.
+type HistogramSnapshot struct {
+	Count  int64   `json:"count"`
+	Min    int64   `json:"min"`
+	Max    int64   `json:"max"`
+	Mean   float64 `json:"mean"`
+	StdDev float64 `json:"stddev"`
+	P50    float64 `json:"p50"`
+	P75    float64 `json:"p75"`
+	P95    float64 `json:"p95"`
+	P98    float64 `json:"p98"`
+	P99    float64 `json:"p99"`
+	P999   float64 `json:"p999"`
+}
+
+// NewHistogram allocates and returns a new Histogram.
+func NewHistogram() *Histogram {
+	return &Histogram{
+		values: make([]int64, 0, defaultReservoirSize),
+	}
+}
+
+// Update adds a value to the histogram.
+func (h *Histogram) Update(value int64) {
+	h.mu.Lock()
+	h.count++
+	if len(h.values) < cap(h.values) {
+		h.values = append(h.values, value)
+	} else {
+		h.values[h.next] = value
+		h.next = (h.next + 1) % len(h.values)
+	}
+	h.mu.Unlock()
+}
+
+// Count returns total number of values added.
+func (h *Histogram) Count() int64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.count
+}
+
+// Snapshot calculates statistics of the histogram.
+func (h *Histogram) Snapshot() HistogramSnapshot {
+	h.mu.Lock()
+	values := make([]int64, len(h.values))
+	copy(values, h.values)
+	s := HistogramSnapshot{Count: h.count}
+	h.mu.Unlock()
+
+	if len(values) == 0 {
+		return s
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+	s.Min = values[0]
+	s.Max = values[len(values)-1]
+	var sum float64
+	for _, v := range values {
+		sum += float64(v)
+	}
+	s.Mean = sum / float64(len(values))
+	if len(values) > 1 {
+		var variance float64
+		for _, v := range values {
+			d := float64(v) - s.Mean
+			variance += d * d
+		}
+		s.StdDev = math.Sqrt(variance / float64(len(values)-1))
+	}
+	s.P50 = percentile(values, 0.5)
+	s.P75 = percentile(values, 0.75)
+	s.P95 = percentile(values, 0.95)
+	s.P98 = percentile(values, 0.98)
+	s.P99 = percentile(values, 0.99)
+	s.P999 = percentile(values, 0.999)
+	return s
+}
+
+// percentile returns the quantile q of sorted values using linear
+// interpolation.
+func percentile(values []int64, q float64) float64 {
+	pos := q * float64(len(values)+1)
+	switch {
+	case pos < 1:
+		return float64(values[0])
+	case pos >= float64(len(values)):
+		return float64(values[len(values)-1])
+	}
+	lower := float64(values[int(pos)-1])
+	upper := float64(values[int(pos)])
+	return lower + (pos-math.Floor(pos))*(upper-lower)
+}
+
+const (
+	meterTickInterval = 5 * time.Second
+)
+
+// ewma is an exponentially-weighted moving average.
+type ewma struct {
+	alpha       float64
+	rate        float64
+	uncounted   int64
+	initialized bool
+}
+
+func newEWMA(minutes float64) *ewma {
+	return &ewma{
+		alpha: 1 - math.Exp(-meterTickInterval.Seconds()/60/minutes),
+	}
+}
+
+func (e *ewma) tick() {
+	instantRate := float64(e.uncounted) / meterTickInterval.Seconds()
+	e.uncounted = 0
+	if e.initialized {
+		e.rate += e.alpha * (instantRate - e.rate)
+	} else {
+		e.rate = instantRate
+		e.initialized = true
+	}
+}
+
+// Meter measures rate of events: mean rate and one-, five- and
+// fifteen-minute exponentially-weighted moving average rates.
+type Meter struct {
+	mu       sync.Mutex
+	count    int64
+	start    time.Time
+	lastTick time.Time
+	m1       *ewma
+	m5       *ewma
+	m15      *ewma
+}
+
+// MeterSnapshot contains rates per second of a meter.
+type MeterSnapshot struct {
+	Count    int64   `json:"count"`
+	M1Rate   float64 `json:"m1_rate"`
+	M5Rate   float64 `json:"m5_rate"`
+	M15Rate  float64 `json:"m15_rate"`
+	MeanRate float64 `json:"mean_rate"`
+}
+
+// NewMeter allocates and returns a new Meter.
+func NewMeter() *Meter {
+	t := now()
+	return &Meter{
+		start:    t,
+		lastTick: t,
+		m1:       newEWMA(1),
+		m5:       newEWMA(5),
+		m15:      newEWMA(15),
+	}
+}
+
+// Mark records n events.
+func (m *Meter) Mark(n int64) {
+	m.mu.Lock()
+	m.tickIfNecessary()
+	m.count += n
+	m.m1.uncounted += n
+	m.m5.uncounted += n
+	m.m15.uncounted += n
+	m.mu.Unlock()
+}
+
+// Count returns total number of events.
+func (m *Meter) Count() int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.count
+}
+
+// Snapshot returns current rates of the meter.
+func (m *Meter) Snapshot() MeterSnapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.tickIfNecessary()
+	s := MeterSnapshot{
+		Count:   m.count,
+		M1Rate:  m.m1.rate,
+		M5Rate:  m.m5.rate,
+		M15Rate: m.m15.rate,
+	}
+	if elapsed := now().Sub(m.start).Seconds(); elapsed > 0 {
+		s.MeanRate = float64(m.count) / elapsed
+	}
+	return s
+}
+
+// tickIfNecessary updates moving averages for every elapsed tick interval.
+func (m *Meter) tickIfNecessary() {
+	t := now()
+	ticks := int(t.Sub(m.lastTick) / meterTickInterval)
+	if ticks <= 0 {
+		return
+	}
+	m.lastTick = m.lastTick.Add(time.Duration(ticks) * meterTickInterval)
+	for i := 0; i < ticks; i++ {
+		m.m1.tick()
+		m.m5.tick()
+		m.m15.tick()
+	}
+}
+
+// Timer measures both rate and duration distribution of events.
+type Timer struct {
+	histogram *Histogram
+	meter     *Meter
+}
+
+// TimerSnapshot contains duration statistics in nanoseconds and rates per
+// second of a timer.
+type TimerSnapshot struct {
+	HistogramSnapshot
+	M1Rate   float64 `json:"m1_rate"`
+	M5Rate   float64 `json:"m5_rate"`
+	M15Rate  float64 `json:"m15_rate"`
+	MeanRate float64 `json:"mean_rate"`
+}
+
+// NewTimer allocates and returns a new Timer.
+func NewTimer() *Timer {
+	return &Timer{
+		histogram: NewHistogram(),
+		meter:     NewMeter(),
+	}
+}
+
+// Update records an event with the given duration.
+func (t *Timer) Update(d time.Duration) {
+	t.histogram.Update(int64(d))
+	t.meter.Mark(1)
+}
+
+// UpdateSince records an event started at the given time.
+func (t *Timer) UpdateSince(start time.Time) {
+	t.Update(now().Sub(start))
+}
+
+// Time records duration of f.
+func (t *Timer) Time(f func()) {
+	start := now()
+	f()
+	t.UpdateSince(start)
+}
+
+// Count returns total number of events.
+func (t *Timer) Count() int64 {
+	return t.meter.Count()
+}
+
+// Snapshot returns statistics of the timer.
+func (t *Timer) Snapshot() TimerSnapshot {
+	m := t.meter.Snapshot()
+	return TimerSnapshot{
+		HistogramSnapshot: t.histogram.Snapshot(),
+		M1Rate:            m.M1Rate,
+		M5Rate:            m.M5Rate,
+		M15Rate:           m.M15Rate,
+		MeanRate:          m.MeanRate,
+	}
+}
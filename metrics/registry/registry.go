@@ -0,0 +1,183 @@
+/*
+Package registry provides a registry of application metrics: counters,
+gauges, histograms, meters and timers.
+*/
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// now is used to get current time and can be overridden in tests.
+var now = time.Now
+
+// Registry holds metrics by their names. It is safe for concurrent use.
+type Registry struct {
+	mu      sync.RWMutex
+	metrics map[string]interface{}
+}
+
+// NewRegistry allocates and returns a new Registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		metrics: make(map[string]interface{}),
+	}
+}
+
+// Register adds the metric with the given name. An error is returned if the
+// name has already been registered.
+func (r *Registry) Register(name string, metric interface{}) error {
+	switch metric.(type) {
+	case *Counter, *Gauge, *Histogram, *Meter, *Timer:
+	default:
+		return fmt.Errorf("registry: unsupported metric %T", metric)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.metrics[name]; ok {
+		return fmt.Errorf("registry: metric %s is already registered", name)
+	}
+	r.metrics[name] = metric
+	return nil
+}
+
+// Get returns the metric with the given name or nil if it does not exist.
+func (r *Registry) Get(name string) interface{} {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.metrics[name]
+}
+
+// Remove deletes the metric with the given name.
+func (r *Registry) Remove(name string) {
+	r.mu.Lock()
+	delete(r.metrics, name)
+	r.mu.Unlock()
+}
+
+// Names returns sorted names of all metrics.
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	names := make([]string, 0, len(r.metrics))
+	for name := range r.metrics {
+		names = append(names, name)
+	}
+	r.mu.RUnlock()
+	sort.Strings(names)
+	return names
+}
+
+// Each calls f for all metrics in order of their names.
+func (r *Registry) Each(f func(name string, metric interface{})) {
+	for _, name := range r.Names() {
+		if metric := r.Get(name); metric != nil {
+			f(name, metric)
+		}
+	}
+}
+
+// Counter returns the counter with the given name, creating it if needed.
+// It panics if the name is used by other type of metric.
+func (r *Registry) Counter(name string) *Counter {
+	m := r.getOrAdd(name, func() interface{} { return &Counter{} })
+	c, ok := m.(*Counter)
+	if !ok {
+		panic(fmt.Sprintf("registry: metric %s is %T, not a counter", name, m))
+	}
+	return c
+}
+
+// Gauge returns the gauge with the given name, creating it if needed.
+// It panics if the name is used by other type of metric.
+func (r *Registry) Gauge(name string) *Gauge {
+	m := r.getOrAdd(name, func() interface{} { return &Gauge{} })
+	g, ok := m.(*Gauge)
+	if !ok {
+		panic(fmt.Sprintf("registry: metric %s is %T, not a gauge", name, m))
+	}
+	return g
+}
+
+// Histogram returns the histogram with the given name, creating it if needed.
+// It panics if the name is used by other type of metric.
+func (r *Registry) Histogram(name string) *Histogram {
+	m := r.getOrAdd(name, func() interface{} { return NewHistogram() })
+	h, ok := m.(*Histogram)
+	if !ok {
+		panic(fmt.Sprintf("registry: metric %s is %T, not a histogram", name, m))
+	}
+	return h
+}
+
+// Meter returns the meter with the given name, creating it if needed.
+// It panics if the name is used by other type of metric.
+func (r *Registry) Meter(name string) *Meter {
+	m := r.getOrAdd(name, func() interface{} { return NewMeter() })
+	meter, ok := m.(*Meter)
+	if !ok {
+		panic(fmt.Sprintf("registry: metric %s is %T, not a meter", name, m))
+	}
+	return meter
+}
+
+// Timer returns the timer with the given name, creating it if needed.
+// It panics if the name is used by other type of metric.
+func (r *Registry) Timer(name string) *Timer {
+	m := r.getOrAdd(name, func() interface{} { return NewTimer() })
+	t, ok := m.(*Timer)
+	if !ok {
+		panic(fmt.Sprintf("registry: metric %s is %T, not a timer", name, m))
+	}
+	return t
+}
+
+func (r *Registry) getOrAdd(name string, create func() interface{}) interface{} {
+	r.mu.RLock()
+	m, ok := r.metrics[name]
+	r.mu.RUnlock()
+	if ok {
+		return m
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if m, ok = r.metrics[name]; !ok {
+		m = create()
+		r.metrics[name] = m
+	}
+	return m
+}
+
+// MarshalJSON encodes all metrics grouped by their types.
+func (r *Registry) MarshalJSON() ([]byte, error) {
+	var output struct {
+		Gauges     map[string]interface{} `json:"gauges"`
+		Counters   map[string]interface{} `json:"counters"`
+		Histograms map[string]interface{} `json:"histograms"`
+		Meters     map[string]interface{} `json:"meters"`
+		Timers     map[string]interface{} `json:"timers"`
+	}
+	output.Gauges = make(map[string]interface{})
+	output.Counters = make(map[string]interface{})
+	output.Histograms = make(map[string]interface{})
+	output.Meters = make(map[string]interface{})
+	output.Timers = make(map[string]interface{})
+	r.Each(func(name string, metric interface{}) {
+		switch m := metric.(type) {
+		case *Counter:
+			output.Counters[name] = map[string]int64{"count": m.Count()}
+		case *Gauge:
+			output.Gauges[name] = map[string]int64{"value": m.Value()}
+		case *Histogram:
+			output.Histograms[name] = m.Snapshot()
+		case *Meter:
+			output.Meters[name] = m.Snapshot()
+		case *Timer:
+			output.Timers[name] = m.Snapshot()
+		}
+	})
+	return json.Marshal(&output)
+}
@@ -0,0 +1,110 @@
+package registry
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+	r.Counter("c").Inc()
+	r.Counter("c").Add(2)
+	if r.Counter("c").Count() != 3 {
+		t.Fatalf("unexpected count %d", r.Counter("c").Count())
+	}
+	r.Gauge("g").Set(5)
+	if err := r.Register("c", &Counter{}); err == nil {
+		t.Fatal("error expected")
+	}
+	if err := r.Register("t", NewTimer()); err != nil {
+		t.Fatal(err)
+	}
+	if names := r.Names(); len(names) != 3 || names[0] != "c" || names[1] != "g" || names[2] != "t" {
+		t.Fatalf("unexpected names %v", names)
+	}
+	r.Remove("t")
+	if r.Get("t") != nil {
+		t.Fatal("metric is not removed")
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("panic expected")
+			}
+		}()
+		r.Gauge("c")
+	}()
+
+	data, err := json.Marshal(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"gauges":{"g":{"value":5}},"counters":{"c":{"count":3}},"histograms":{},"meters":{},"timers":{}}`
+	if string(data) != expected {
+		t.Fatalf("unexpected json %s", data)
+	}
+}
+
+func TestGauge(t *testing.T) {
+	g := &Gauge{}
+	g.SetFunc(func() int64 { return 7 })
+	if g.Value() != 7 {
+		t.Fatalf("unexpected value %d", g.Value())
+	}
+	g.Set(1)
+	if g.Value() != 1 {
+		t.Fatalf("unexpected value %d", g.Value())
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	h := NewHistogram()
+	for i := 1; i <= 100; i++ {
+		h.Update(int64(i))
+	}
+	s := h.Snapshot()
+	if s.Count != 100 || s.Min != 1 || s.Max != 100 || s.Mean != 50.5 || s.P50 != 50.5 || s.P99 != 99.99 {
+		t.Fatalf("unexpected snapshot %+v", s)
+	}
+	// Only recent values are kept.
+	for i := 0; i < defaultReservoirSize; i++ {
+		h.Update(1000)
+	}
+	s = h.Snapshot()
+	if s.Count != 100+defaultReservoirSize || s.Min != 1000 || s.StdDev != 0 {
+		t.Fatalf("unexpected snapshot %+v", s)
+	}
+}
+
+func TestMeter(t *testing.T) {
+	current := time.Unix(0, 0)
+	now = func() time.Time { return current }
+	defer func() { now = time.Now }()
+
+	m := NewMeter()
+	m.Mark(50)
+	current = current.Add(meterTickInterval)
+	s := m.Snapshot()
+	if s.Count != 50 || s.M1Rate != 10 || s.M15Rate != 10 || s.MeanRate != 10 {
+		t.Fatalf("unexpected snapshot %+v", s)
+	}
+	// Rates decay without events.
+	current = current.Add(time.Minute)
+	s = m.Snapshot()
+	expected := 10 * math.Exp(-1)
+	if math.Abs(s.M1Rate-expected) > 1e-9 || s.M5Rate <= s.M1Rate {
+		t.Fatalf("unexpected snapshot %+v", s)
+	}
+}
+
+func TestTimer(t *testing.T) {
+	timer := NewTimer()
+	timer.Update(time.Second)
+	timer.Update(3 * time.Second)
+	s := timer.Snapshot()
+	if timer.Count() != 2 || s.Count != 2 || s.Min != int64(time.Second) || s.Mean != float64(2*time.Second) {
+		t.Fatalf("unexpected snapshot %+v", s)
+	}
+}
@@ -0,0 +1,191 @@
+package metrics
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/goburrow/gomelon/core"
+	"github.com/goburrow/gomelon/metrics/registry"
+	"github.com/goburrow/polytype"
+)
+
+const (
+	loggerName = "gomelon/metrics"
+
+	defaultFrequency = time.Minute
+)
+
+// Reporter sends metrics in the registry to a monitoring backend.
+type Reporter interface {
+	Report(*registry.Registry) error
+}
+
+// ReporterFactory builds a reporter from configuration.
+type ReporterFactory interface {
+	Build(*core.Environment) (Reporter, error)
+	// Common returns settings shared by all types of reporter.
+	Common() *CommonReporterConfiguration
+}
+
+// CommonReporterConfiguration is the configuration shared by all types of
+// reporter.
+type CommonReporterConfiguration struct {
+	// Frequency overrides frequency of the metrics configuration.
+	Frequency string
+}
+
+func (c *CommonReporterConfiguration) Common() *CommonReporterConfiguration {
+	return c
+}
+
+// ReporterConfiguration is an union of reporter configurations.
+type ReporterConfiguration struct {
+	polytype.Type
+}
+
+// tagger is implemented by reporters supporting global prefix and tags
+// given in metrics configuration.
+type tagger interface {
+	addPrefixAndTags(prefix string, tags map[string]string)
+}
+
+// joinPrefix returns names of parent and child prefix separated by a dot.
+func joinPrefix(parent, child string) string {
+	if parent == "" {
+		return child
+	}
+	if child == "" {
+		return parent
+	}
+	return parent + "." + child
+}
+
+// mergeTags returns a new map containing tags from both maps, values in
+// overrides take precedence.
+func mergeTags(tags, overrides map[string]string) map[string]string {
+	if len(tags) == 0 {
+		return overrides
+	}
+	m := make(map[string]string, len(tags)+len(overrides))
+	for k, v := range tags {
+		m[k] = v
+	}
+	for k, v := range overrides {
+		m[k] = v
+	}
+	return m
+}
+
+// ReporterFunc is an adapter to allow the use of ordinary functions as
+// reporters.
+type ReporterFunc func(*registry.Registry) error
+
+func (f ReporterFunc) Report(r *registry.Registry) error {
+	return f(r)
+}
+
+// ScheduledReporter reports metrics periodically while the application
+// is running. It is started and stopped by the lifecycle environment, e.g.
+//
+//	env.Lifecycle.Manage(metrics.NewScheduledReporter(reporter, env.Metrics, time.Minute))
+//
+// Reporters given in configuration are scheduled automatically.
+type ScheduledReporter struct {
+	reporter  Reporter
+	registry  *registry.Registry
+	frequency time.Duration
+
+	mu     sync.Mutex
+	stop   chan struct{}
+	wg     sync.WaitGroup
+	failed bool
+}
+
+var _ core.Managed = (*ScheduledReporter)(nil)
+
+// NewScheduledReporter allocates and returns a new ScheduledReporter.
+func NewScheduledReporter(reporter Reporter, r *registry.Registry, frequency time.Duration) *ScheduledReporter {
+	if frequency <= 0 {
+		frequency = defaultFrequency
+	}
+	return &ScheduledReporter{
+		reporter:  reporter,
+		registry:  r,
+		frequency: frequency,
+	}
+}
+
+// Start starts reporting in background.
+func (s *ScheduledReporter) Start() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		return nil
+	}
+	core.GetLogger(loggerName).Debug("reporting metrics every %v (%T)", s.frequency, s.reporter)
+	s.stop = make(chan struct{})
+	s.wg.Add(1)
+	go s.run(s.stop)
+	return nil
+}
+
+// Stop stops reporting after reporting the last time, so that metrics
+// recorded during shutdown are not lost.
+func (s *ScheduledReporter) Stop() error {
+	s.mu.Lock()
+	if s.stop == nil {
+		s.mu.Unlock()
+		return nil
+	}
+	close(s.stop)
+	s.stop = nil
+	s.mu.Unlock()
+
+	s.wg.Wait()
+	s.report()
+	return nil
+}
+
+func (s *ScheduledReporter) run(stop chan struct{}) {
+	defer s.wg.Done()
+	ticker := time.NewTicker(s.frequency)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.report()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// report logs failures only once until the reporter succeeds again.
+func (s *ScheduledReporter) report() {
+	err := s.reporter.Report(s.registry)
+	logger := core.GetLogger(loggerName)
+	if err != nil {
+		if !s.failed {
+			logger.Warn("could not report metrics (%T): %v", s.reporter, err)
+		}
+	} else if s.failed {
+		logger.Info("reporting metrics recovered (%T)", s.reporter)
+	}
+	s.failed = err != nil
+}
+
+// parseFrequency returns the duration of s or def if s is empty.
+func parseFrequency(s string, def time.Duration) (time.Duration, error) {
+	if s == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("metrics: invalid frequency %v", err)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("metrics: invalid frequency %v", d)
+	}
+	return d, nil
+}
@@ -0,0 +1,47 @@
+package metrics
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/goburrow/gomelon/core"
+	"github.com/goburrow/gomelon/metrics/registry"
+)
+
+func TestScheduledReporter(t *testing.T) {
+	var count int32
+	reporter := ReporterFunc(func(r *registry.Registry) error {
+		atomic.AddInt32(&count, 1)
+		return errors.New("failed")
+	})
+	s := NewScheduledReporter(reporter, registry.NewRegistry(), time.Millisecond)
+	// Not started
+	s.Stop()
+	if atomic.LoadInt32(&count) != 0 {
+		t.Fatalf("unexpected count %d", count)
+	}
+	s.Start()
+	for i := 0; i < 100 && atomic.LoadInt32(&count) < 2; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	s.Stop()
+	n := atomic.LoadInt32(&count)
+	if n < 3 {
+		t.Fatalf("unexpected count %d", n)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if atomic.LoadInt32(&count) != n {
+		t.Fatal("reporter is not stopped")
+	}
+}
+
+func TestFactoryFrequency(t *testing.T) {
+	for _, frequency := range []string{"1", "-1s"} {
+		factory := Factory{Frequency: frequency}
+		if err := factory.Configure(core.NewEnvironment()); err == nil {
+			t.Fatalf("error expected for %q", frequency)
+		}
+	}
+}
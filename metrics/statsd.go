@@ -0,0 +1,175 @@
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goburrow/gomelon/core"
+	"github.com/goburrow/gomelon/metrics/registry"
+	"github.com/goburrow/polytype"
+)
+
+const (
+	defaultStatsDPort = 8125
+	// statsDMaxPacketSize keeps packets within a common MTU.
+	statsDMaxPacketSize = 1432
+)
+
+func init() {
+	newStatsDFactory := func() interface{} { return &StatsDReporterFactory{} }
+	polytype.Register("StatsDReporter", newStatsDFactory)
+	polytype.Register("statsd", newStatsDFactory)
+}
+
+// StatsDReporterFactory is the configuration of StatsDReporter.
+type StatsDReporterFactory struct {
+	CommonReporterConfiguration
+
+	Host string `valid:"nonzero"`
+	// Port defaults to 8125.
+	Port   int
+	Prefix string
+	// Tags are added to all metrics using DogStatsD format.
+	Tags map[string]string
+}
+
+func (factory *StatsDReporterFactory) Build(env *core.Environment) (Reporter, error) {
+	port := factory.Port
+	if port == 0 {
+		port = defaultStatsDPort
+	}
+	return &StatsDReporter{
+		Addr:   net.JoinHostPort(factory.Host, strconv.Itoa(port)),
+		Prefix: factory.Prefix,
+		Tags:   factory.Tags,
+	}, nil
+}
+
+// StatsDReporter sends metrics to StatsD or DogStatsD over UDP.
+// Counters, meters and counts of timers are sent as counters of their
+// changes since the last report. Gauges and statistics of histograms and
+// timers (in milliseconds) are sent as gauges.
+type StatsDReporter struct {
+	// Addr is the UDP address of StatsD server.
+	Addr   string
+	Prefix string
+	// Tags are added to all metrics if it is not empty. Only DogStatsD
+	// supports tags.
+	Tags map[string]string
+
+	mu     sync.Mutex
+	counts map[string]int64
+}
+
+var _ Reporter = (*StatsDReporter)(nil)
+
+func (reporter *StatsDReporter) addPrefixAndTags(prefix string, tags map[string]string) {
+	reporter.Prefix = joinPrefix(prefix, reporter.Prefix)
+	reporter.Tags = mergeTags(tags, reporter.Tags)
+}
+
+func (reporter *StatsDReporter) Report(r *registry.Registry) error {
+	conn, err := net.Dial("udp", reporter.Addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	for _, packet := range reporter.packets(r) {
+		if _, err = conn.Write(packet); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// packets returns metrics in StatsD format split into packets.
+func (reporter *StatsDReporter) packets(r *registry.Registry) [][]byte {
+	reporter.mu.Lock()
+	defer reporter.mu.Unlock()
+	if reporter.counts == nil {
+		reporter.counts = make(map[string]int64)
+	}
+	tags := reporter.formatTags()
+
+	var packets [][]byte
+	var buf bytes.Buffer
+	send := func(name string, value interface{}, typ string) {
+		line := fmt.Sprintf("%s:%v|%s%s", name, value, typ, tags)
+		if buf.Len() > 0 && buf.Len()+1+len(line) > statsDMaxPacketSize {
+			packets = append(packets, append([]byte(nil), buf.Bytes()...))
+			buf.Reset()
+		}
+		if buf.Len() > 0 {
+			buf.WriteByte('\n')
+		}
+		buf.WriteString(line)
+	}
+	count := func(name string, value int64) {
+		delta := value - reporter.counts[name]
+		reporter.counts[name] = value
+		send(name, delta, "c")
+	}
+	r.Each(func(name string, metric interface{}) {
+		name = statsDName(reporter.Prefix, name)
+		switch m := metric.(type) {
+		case *registry.Counter:
+			count(name, m.Count())
+		case *registry.Gauge:
+			send(name, m.Value(), "g")
+		case *registry.Histogram:
+			sendStatsDHistogram(send, name, m.Snapshot(), 1)
+		case *registry.Meter:
+			count(name, m.Count())
+		case *registry.Timer:
+			s := m.Snapshot()
+			count(name+".count", s.Count)
+			sendStatsDHistogram(send, name, s.HistogramSnapshot, float64(time.Millisecond))
+		}
+	})
+	if buf.Len() > 0 {
+		packets = append(packets, buf.Bytes())
+	}
+	return packets
+}
+
+func sendStatsDHistogram(send func(string, interface{}, string), name string, s registry.HistogramSnapshot, scale float64) {
+	send(name+".min", float64(s.Min)/scale, "g")
+	send(name+".max", float64(s.Max)/scale, "g")
+	send(name+".mean", s.Mean/scale, "g")
+	send(name+".p50", s.P50/scale, "g")
+	send(name+".p95", s.P95/scale, "g")
+	send(name+".p99", s.P99/scale, "g")
+}
+
+// formatTags returns tags sorted by name in DogStatsD format.
+func (reporter *StatsDReporter) formatTags() string {
+	if len(reporter.Tags) == 0 {
+		return ""
+	}
+	tags := make([]string, 0, len(reporter.Tags))
+	for name, value := range reporter.Tags {
+		tags = append(tags, name+":"+value)
+	}
+	sort.Strings(tags)
+	return "|#" + strings.Join(tags, ",")
+}
+
+// statsDName replaces characters which are reserved in StatsD protocol.
+func statsDName(prefix, name string) string {
+	if prefix != "" {
+		name = prefix + "." + name
+	}
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ':', '|', '@', '#', ' ', '\n':
+			return '_'
+		}
+		return r
+	}, name)
+}
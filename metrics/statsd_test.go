@@ -0,0 +1,73 @@
+package metrics
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/goburrow/gomelon/metrics/registry"
+)
+
+func TestStatsDReporterPackets(t *testing.T) {
+	r := registry.NewRegistry()
+	r.Counter("requests").Add(5)
+	r.Gauge("Endpoint.GET./users/:name").Set(3)
+
+	reporter := &StatsDReporter{
+		Prefix: "app",
+		Tags:   map[string]string{"env": "prod", "dc": "a"},
+	}
+	packets := reporter.packets(r)
+	expected := "app.Endpoint.GET./users/_name:3|g|#dc:a,env:prod\napp.requests:5|c|#dc:a,env:prod"
+	if len(packets) != 1 || string(packets[0]) != expected {
+		t.Fatalf("unexpected packets %q", packets)
+	}
+	// Counters are sent as changes.
+	r.Counter("requests").Add(2)
+	packets = reporter.packets(r)
+	if !strings.Contains(string(packets[0]), "app.requests:2|c") {
+		t.Fatalf("unexpected packets %q", packets)
+	}
+}
+
+func TestStatsDReporterPacketSize(t *testing.T) {
+	r := registry.NewRegistry()
+	for i := 0; i < 100; i++ {
+		r.Timer(strings.Repeat("t", i+1)).Update(time.Millisecond)
+	}
+	reporter := &StatsDReporter{}
+	packets := reporter.packets(r)
+	if len(packets) < 2 {
+		t.Fatalf("unexpected number of packets %d", len(packets))
+	}
+	for _, p := range packets {
+		if len(p) > statsDMaxPacketSize {
+			t.Fatalf("packet too large %d", len(p))
+		}
+	}
+}
+
+func TestStatsDReporter(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	r := registry.NewRegistry()
+	r.Counter("c").Inc()
+	reporter := &StatsDReporter{Addr: conn.LocalAddr().String()}
+	if err = reporter.Report(r); err != nil {
+		t.Fatal(err)
+	}
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	buf := make([]byte, statsDMaxPacketSize)
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(buf[:n]) != "c:1|c" {
+		t.Fatalf("unexpected packet %q", buf[:n])
+	}
+}
@@ -0,0 +1,90 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+const bearerPrefix = "Bearer "
+
+var errUnauthorized = NewHTTPError(http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+
+// Principal is the authenticated user of a request.
+type Principal interface {
+	Name() string
+}
+
+// Authenticator validates credentials of the request. It returns nil
+// principal and nil error when the credentials are missing or invalid.
+// Errors are only returned when the credentials could not be checked.
+type Authenticator interface {
+	Authenticate(r *http.Request) (Principal, error)
+}
+
+// BasicAuthenticator is an Authenticator validating HTTP basic credentials.
+type BasicAuthenticator func(username, password string) (Principal, error)
+
+// Authenticate calls f with the credentials of the request.
+func (f BasicAuthenticator) Authenticate(r *http.Request) (Principal, error) {
+	username, password, ok := r.BasicAuth()
+	if !ok {
+		return nil, nil
+	}
+	return f(username, password)
+}
+
+// Scheme returns "Basic".
+func (f BasicAuthenticator) Scheme() string {
+	return "Basic"
+}
+
+// BearerAuthenticator is an Authenticator validating bearer tokens.
+type BearerAuthenticator func(token string) (Principal, error)
+
+// Authenticate calls f with the token of the request.
+func (f BearerAuthenticator) Authenticate(r *http.Request) (Principal, error) {
+	s := r.Header.Get("Authorization")
+	if !strings.HasPrefix(s, bearerPrefix) {
+		return nil, nil
+	}
+	return f(strings.TrimSpace(s[len(bearerPrefix):]))
+}
+
+// Scheme returns "Bearer".
+func (f BearerAuthenticator) Scheme() string {
+	return "Bearer"
+}
+
+// AuthFilter returns a Filter which authenticates requests before the
+// resource method runs. Requests without a principal are rejected with
+// status 401 and header WWW-Authenticate if the authenticator has a
+// Scheme() string method. The principal is given by PrincipalFrom, e.g:
+//
+//	bundle.Filters.Add("/users", rest.AuthFilter(rest.BearerAuthenticator(findUser), "users"))
+func AuthFilter(authenticator Authenticator, realm string) Filter {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c context.Context) (interface{}, error) {
+			principal, err := authenticator.Authenticate(RequestFromContext(c))
+			if err != nil {
+				return nil, err
+			}
+			if principal == nil {
+				if s, ok := authenticator.(interface {
+					Scheme() string
+				}); ok {
+					ResponseWriterFromContext(c).Header().Set("WWW-Authenticate", s.Scheme()+` realm="`+realm+`"`)
+				}
+				return nil, errUnauthorized
+			}
+			return next(context.WithValue(c, principalKey, principal))
+		}
+	}
+}
+
+// PrincipalFrom returns the principal authenticated by AuthFilter or nil if
+// the request has not been authenticated.
+func PrincipalFrom(c context.Context) Principal {
+	principal, _ := c.Value(principalKey).(Principal)
+	return principal
+}
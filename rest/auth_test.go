@@ -0,0 +1,77 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+type testPrincipal string
+
+func (p testPrincipal) Name() string {
+	return string(p)
+}
+
+type principalResource struct {
+}
+
+func (*principalResource) Path() string {
+	return "/me"
+}
+
+func (*principalResource) GET(c context.Context) (interface{}, error) {
+	return map[string]string{"name": PrincipalFrom(c).Name()}, nil
+}
+
+func TestAuthFilter(t *testing.T) {
+	bearer := BearerAuthenticator(func(token string) (Principal, error) {
+		switch token {
+		case "token":
+			return testPrincipal("alice"), nil
+		case "error":
+			return nil, errors.New("unavailable")
+		}
+		return nil, nil
+	})
+	h := newTestHandlerWith(func(h *ResourceHandler) {
+		h.AddFilter("/me", AuthFilter(bearer, "test"))
+	}, &principalResource{})
+
+	w := serve(h, "GET", "/me", nil, "Authorization", "Bearer token")
+	if w.Code != http.StatusOK || w.Body.String() != `{"name":"alice"}`+"\n" {
+		t.Fatalf("unexpected response %v %q", w.Code, w.Body)
+	}
+	w = serve(h, "GET", "/me", nil, "Authorization", "Bearer wrong")
+	if w.Code != http.StatusUnauthorized || w.Header().Get("WWW-Authenticate") != `Bearer realm="test"` {
+		t.Fatalf("unexpected response %v %v", w.Code, w.Header())
+	}
+	w = serve(h, "GET", "/me", nil)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("unexpected response %v", w.Code)
+	}
+	w = serve(h, "GET", "/me", nil, "Authorization", "Bearer error")
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("unexpected response %v", w.Code)
+	}
+}
+
+func TestBasicAuthenticator(t *testing.T) {
+	a := BasicAuthenticator(func(username, password string) (Principal, error) {
+		if username == "alice" && password == "secret" {
+			return testPrincipal(username), nil
+		}
+		return nil, nil
+	})
+	r, _ := http.NewRequest("GET", "/", nil)
+	if p, err := a.Authenticate(r); p != nil || err != nil {
+		t.Fatalf("unexpected principal %v %v", p, err)
+	}
+	r.SetBasicAuth("alice", "secret")
+	if p, err := a.Authenticate(r); err != nil || p.Name() != "alice" {
+		t.Fatalf("unexpected principal %v %v", p, err)
+	}
+	if PrincipalFrom(context.Background()) != nil {
+		t.Fatal("no principal expected")
+	}
+}
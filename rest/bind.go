@@ -0,0 +1,155 @@
+package rest
+
+import (
+	"context"
+	"encoding"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	queryTag   = "query"
+	formTag    = "form"
+	defaultTag = "default"
+)
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// BindQuery sets fields of the struct pointed to by v from the query string
+// of the request. The parameter name is given by tag "query", or the field
+// name if there is no tag, and "-" skips the field. Tag "default" is used
+// when the parameter is absent, e.g.
+//
+//	type listParams struct {
+//		Limit  int      `query:"limit" default:"20"`
+//		Sort   string   `query:"sort"`
+//		Tags   []string `query:"tag"`
+//	}
+//
+// Supported field types are strings, booleans, numbers, time.Duration,
+// encoding.TextUnmarshaler and slices of them. Invalid values result in a
+// HTTPError with status 400.
+func BindQuery(c context.Context, v interface{}) error {
+	return bindValues(RequestFromContext(c).URL.Query(), v, queryTag)
+}
+
+// BindForm is similar to BindQuery but binds values of the request form,
+// which includes both query string and url-encoded body, using tag "form".
+func BindForm(c context.Context, v interface{}) error {
+	r := RequestFromContext(c)
+	if err := r.ParseForm(); err != nil {
+		return NewHTTPError(err.Error(), http.StatusBadRequest)
+	}
+	return bindValues(r.Form, v, formTag)
+}
+
+func bindValues(values url.Values, v interface{}, tag string) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		panic(fmt.Sprintf("rest: binding requires a pointer to struct, got %T", v))
+	}
+	rv = rv.Elem()
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if field.PkgPath != "" {
+			// Unexported
+			continue
+		}
+		name := field.Tag.Get(tag)
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		params, ok := values[name]
+		if !ok || len(params) == 0 {
+			d, hasDefault := field.Tag.Lookup(defaultTag)
+			if !hasDefault {
+				continue
+			}
+			params = []string{d}
+		}
+		if err := setValue(rv.Field(i), params); err != nil {
+			return NewHTTPError(fmt.Sprintf("invalid parameter %s: %v", name, err), http.StatusBadRequest)
+		}
+	}
+	return nil
+}
+
+// setValue sets v from the given parameters. Only slices use all of them.
+func setValue(v reflect.Value, params []string) error {
+	if v.Kind() == reflect.Slice && !v.Type().Implements(textUnmarshalerType) {
+		s := reflect.MakeSlice(v.Type(), 0, len(params))
+		for _, p := range params {
+			e := reflect.New(v.Type().Elem()).Elem()
+			if err := setScalar(e, p); err != nil {
+				return err
+			}
+			s = reflect.Append(s, e)
+		}
+		v.Set(s)
+		return nil
+	}
+	return setScalar(v, params[0])
+}
+
+func setScalar(v reflect.Value, s string) error {
+	if v.CanAddr() && v.Addr().Type().Implements(textUnmarshalerType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Ptr:
+		e := reflect.New(v.Type().Elem())
+		if err := setScalar(e.Elem(), s); err != nil {
+			return err
+		}
+		v.Set(e)
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(strings.TrimSpace(s), 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(strings.TrimSpace(s), 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(strings.TrimSpace(s), v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(n)
+	default:
+		return fmt.Errorf("unsupported type %v", v.Type())
+	}
+	return nil
+}
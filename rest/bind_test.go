@@ -0,0 +1,63 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+type listParams struct {
+	Limit   int           `query:"limit" default:"20"`
+	Offset  uint          `query:"offset"`
+	Sort    string        `query:"sort" default:"name"`
+	Tags    []string      `query:"tag"`
+	Active  *bool         `query:"active"`
+	Timeout time.Duration `query:"timeout"`
+	Ratio   float64
+	Ignored string `query:"-"`
+	hidden  string
+}
+
+func TestBindValues(t *testing.T) {
+	values, _ := url.ParseQuery("limit=5&tag=a&tag=b&active=true&timeout=2s&Ratio=0.5&Ignored=x&hidden=x")
+	var p listParams
+	if err := bindValues(values, &p, queryTag); err != nil {
+		t.Fatal(err)
+	}
+	if p.Limit != 5 || p.Offset != 0 || p.Sort != "name" || len(p.Tags) != 2 || p.Tags[1] != "b" ||
+		p.Active == nil || !*p.Active || p.Timeout != 2*time.Second || p.Ratio != 0.5 || p.Ignored != "" || p.hidden != "" {
+		t.Fatalf("unexpected params %+v", p)
+	}
+	values, _ = url.ParseQuery("limit=x")
+	err := bindValues(values, &p, queryTag)
+	if e, ok := err.(*HTTPError); !ok || e.Code != http.StatusBadRequest {
+		t.Fatalf("unexpected error %#v", err)
+	}
+}
+
+type queryResource struct {
+}
+
+func (*queryResource) Path() string {
+	return "/query"
+}
+
+func (*queryResource) GET(c context.Context) (interface{}, error) {
+	var p listParams
+	if err := BindQuery(c, &p); err != nil {
+		return nil, err
+	}
+	return p.Limit, nil
+}
+
+func TestBindQuery(t *testing.T) {
+	h := newTestHandler(&queryResource{})
+	if w := serve(h, "GET", "/query?limit=3", nil); w.Code != http.StatusOK || w.Body.String() != "3\n" {
+		t.Fatalf("unexpected response %v %s", w.Code, w.Body)
+	}
+	if w := serve(h, "GET", "/query?limit=a", nil); w.Code != http.StatusBadRequest {
+		t.Fatalf("unexpected response %v %s", w.Code, w.Body)
+	}
+}
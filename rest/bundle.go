@@ -6,20 +6,65 @@ import (
 
 // Bundle adds support for RESTful application.
 type Bundle struct {
+	// Providers is shared by all resources. It has JSONProvider as default
+	// and XMLProvider. Applications and bundles can add their providers,
+	// e.g. for CSV or vendor media types, before the bundle is run.
+	Providers *Providers
+	// ErrorMappers translates errors returned by all resources.
+	ErrorMappers *ErrorMappers
+	// Filters runs around resource methods by path prefix, e.g:
+	//
+	//	bundle.Filters.Add("/admin", rest.RequestFilter(checkAdmin))
+	Filters *Filters
 }
 
 var _ core.Bundle = (*Bundle)(nil)
 
+// NewBundle allocates and returns a new Bundle with default providers,
+// error mappers and no filters.
+func NewBundle() *Bundle {
+	bundle := &Bundle{}
+	bundle.init()
+	return bundle
+}
+
+// Initialize creates default providers, error mappers and filters if they
+// have not been set.
 func (bundle *Bundle) Initialize(bootstrap *core.Bootstrap) {
+	bundle.init()
+}
+
+func (bundle *Bundle) init() {
+	if bundle.Providers == nil {
+		bundle.Providers = newDefaultProviders()
+	}
+	if bundle.ErrorMappers == nil {
+		bundle.ErrorMappers = NewErrorMappers()
+	}
+	if bundle.Filters == nil {
+		bundle.Filters = NewFilters()
+	}
 }
 
-// Run registers the RESTful handler and set JSONProvider as default.
-// To support other providers (like XML), use core.Server.Register(), e.g:
-//   environment.Server.Register(&rest.XMLProvider{})
+// Run registers the RESTful handler using Providers, ErrorMappers and
+// Filters.
+// Resources implementing Provider are also added to Providers when
+// registered with core.Server.Register(), e.g:
+//
+//	environment.Server.Register(&myProvider{})
 func (bundle *Bundle) Run(conf interface{}, env *core.Environment) error {
+	bundle.init()
 	restHandler := NewResourceHandler(env)
-	restHandler.AddProvider(&JSONProvider{})
-	//restHandler.Providers.AddProvider(&XMLProvider{})
+	restHandler.SetProviders(bundle.Providers)
+	restHandler.SetErrorMapper(bundle.ErrorMappers)
+	restHandler.SetFilters(bundle.Filters)
 	env.Server.AddResourceHandler(restHandler)
 	return nil
 }
+
+func newDefaultProviders() *Providers {
+	providers := NewProviders()
+	providers.AddProvider(&JSONProvider{})
+	providers.AddProvider(&XMLProvider{})
+	return providers
+}
@@ -0,0 +1,14 @@
+package rest
+
+import (
+	"context"
+
+	netcontext "golang.org/x/net/context"
+)
+
+// Resources declared with golang.org/x/net/context still implement the
+// resource interfaces, since its Context is an alias of context.Context.
+// This fails to compile if the alias is removed.
+var _ func(context.Context) (interface{}, error) = func(netcontext.Context) (interface{}, error) {
+	return nil, nil
+}
@@ -1,13 +1,14 @@
 package rest
 
 import (
+	"context"
+	"mime"
 	"net/http"
 	"strings"
 	"time"
 
-	"github.com/codahale/metrics"
-	"github.com/zenazn/goji/web"
-	"golang.org/x/net/context"
+	"github.com/goburrow/gomelon/core"
+	"github.com/goburrow/gomelon/metrics/registry"
 )
 
 const (
@@ -27,34 +28,35 @@ const (
 
 const (
 	pathParamsKey contextKey = iota + 10
+	principalKey
 )
 
 var (
 	errInternalServerError  = NewHTTPError(http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 	errNotAcceptable        = NewHTTPError(http.StatusText(http.StatusNotAcceptable), http.StatusNotAcceptable)
 	errUnsupportedMediaType = NewHTTPError(http.StatusText(http.StatusUnsupportedMediaType), http.StatusUnsupportedMediaType)
-)
 
-type contextFunc func(context.Context) (interface{}, error)
+	errRequestEntityTooLarge = NewHTTPError(http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+)
 
 // contextHandler is a HTTP handler for a resource giving user a request/response context.
-// It implements web.Handler.
+// It implements http.Handler.
 type contextHandler struct {
 	providers providerMap
-	handle    contextFunc
+	handle    HandlerFunc
 
 	resourceHandler *ResourceHandler
 
 	metrics        bool
-	metricRequests metrics.Counter
-	metricLatency  *metrics.Histogram
+	metricRequests *registry.Counter
+	metricLatency  *registry.Timer
 }
 
-// ServeHTTPC converts web.C to context.Context
-func (h *contextHandler) ServeHTTPC(c web.C, w http.ResponseWriter, r *http.Request) {
+// ServeHTTP creates context.Context for the request.
+func (h *contextHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	if h.metrics {
-		h.metricRequests.Add()
-		defer h.recordLatency(time.Now())
+		h.metricRequests.Inc()
+		defer h.metricLatency.UpdateSince(time.Now())
 	}
 
 	responseWriters := h.getResponseWriters(r)
@@ -63,13 +65,15 @@ func (h *contextHandler) ServeHTTPC(c web.C, w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	ctx, cancel := context.WithCancel(context.Background())
+	// The context is cancelled when the client goes away or the request
+	// is completed, and has deadlines set by server filters.
+	ctx, cancel := context.WithCancel(r.Context())
 	defer cancel()
 
 	ctx = context.WithValue(ctx, responseWriterKey, w)
 	ctx = context.WithValue(ctx, requestKey, r)
 	ctx = context.WithValue(ctx, contextHandlerKey, h)
-	ctx = context.WithValue(ctx, pathParamsKey, c.URLParams)
+	ctx = context.WithValue(ctx, pathParamsKey, core.PathParams(r))
 
 	response, err := h.handle(ctx)
 	if err != nil {
@@ -80,9 +84,36 @@ func (h *contextHandler) ServeHTTPC(c web.C, w http.ResponseWriter, r *http.Requ
 	if response == nil {
 		return
 	}
+	if res, ok := response.(*Response); ok {
+		for k, v := range res.Header {
+			w.Header()[k] = v
+		}
+		status := res.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		if res.Entity == nil {
+			w.WriteHeader(status)
+			return
+		}
+		w = &statusResponseWriter{ResponseWriter: w, status: status}
+		response = res.Entity
+	}
+	if p, ok := response.(pageHeaderWriter); ok {
+		p.writePageHeader(r, h.pathPrefix(), w.Header())
+	}
+	if h.writeStream(w, r, responseWriters, response) {
+		return
+	}
+	h.writeResponse(w, r, responseWriters, response)
+}
+
+// writeResponse writes the response entity using the first writeable
+// writer.
+func (h *contextHandler) writeResponse(w http.ResponseWriter, r *http.Request, responseWriters []ResponseWriter, response interface{}) {
 	for i := len(responseWriters) - 1; i >= 0; i-- {
 		if responseWriters[i].IsWriteable(r, response, w) {
-			err = responseWriters[i].Write(r, response, w)
+			err := responseWriters[i].Write(r, response, w)
 			if err != nil {
 				h.resourceHandler.logger.Warn("response writer: %v", err)
 				h.resourceHandler.errorMapper.MapError(errInternalServerError, w, r)
@@ -96,48 +127,38 @@ func (h *contextHandler) ServeHTTPC(c web.C, w http.ResponseWriter, r *http.Requ
 
 // getResponseWriters returns a list of ResponseWriter according Accept in the request header.
 func (h *contextHandler) getResponseWriters(r *http.Request) []ResponseWriter {
-	accept := r.Header.Get("Accept")
-	if accept == "" || accept == "*/*" {
-		return h.providers.GetResponseWriters("*/*")
-	}
-	acceptMIMETypes := strings.Split(accept, ",")
-	// Return providers that support the first mime type
-	for _, mime := range acceptMIMETypes {
-		// TODO: support priority
-		idx := strings.Index(mime, ";")
-		if idx >= 0 {
-			mime = mime[:idx]
-		}
-		writers := h.providers.GetResponseWriters(strings.TrimSpace(mime))
-		if len(writers) > 0 {
-			return writers
-		}
-	}
-	return nil
+	return negotiateWriters(h.providers, r.Header.Get("Accept"))
 }
 
 // getRequestReaders returns a list of RequestReader according Content-Type in the request header.
+// Parameters of the media type are ignored and the default readers are used when
+// Content-Type is not set.
 func (h *contextHandler) getRequestReaders(r *http.Request) []RequestReader {
-	mime := r.Header.Get("Content-Type")
-	return h.providers.GetRequestReaders(strings.TrimSpace(mime))
+	contentType := r.Header.Get("Content-Type")
+	if contentType == "" {
+		return h.providers.GetRequestReaders("*/*")
+	}
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		contentType = mediaType
+	}
+	return h.providers.GetRequestReaders(strings.TrimSpace(contentType))
+}
+
+// pathPrefix returns the context path of the application.
+func (h *contextHandler) pathPrefix() string {
+	if h.resourceHandler.serverHandler == nil {
+		return ""
+	}
+	return strings.TrimSuffix(h.resourceHandler.serverHandler.PathPrefix(), "/")
 }
 
 func (h *contextHandler) setMetrics(name string) {
+	metrics := h.resourceHandler.metrics
 	h.metricRequests = metrics.Counter("HTTP.Requests." + name)
-	// 5 min window tracking
-	h.metricLatency = metrics.NewHistogram("HTTP.Latency."+name,
-		1,         // 1ms
-		1000*60*3, // 3min
-		3)         // precision
+	h.metricLatency = metrics.Timer("HTTP.Latency." + name)
 	h.metrics = true
 }
 
-// recordLatency is taken from codahale/http-handlers.
-func (h *contextHandler) recordLatency(start time.Time) {
-	elapsedMS := time.Now().Sub(start).Seconds() * 1000.0
-	_ = h.metricLatency.RecordValue(int64(elapsedMS))
-}
-
 // ResponseWriterFromContext returns http.ResponseWriter.
 // Panic if http.ResponseWriter is not in the given context.
 func ResponseWriterFromContext(c context.Context) http.ResponseWriter {
@@ -158,6 +179,16 @@ func RequestFromContext(c context.Context) *http.Request {
 	return v
 }
 
+// contextHandlerFromContext returns the contextHandler serving the request.
+// Panic if it is not in the given context.
+func contextHandlerFromContext(c context.Context) *contextHandler {
+	v, ok := c.Value(contextHandlerKey).(*contextHandler)
+	if !ok {
+		panic("rest: no handler in context")
+	}
+	return v
+}
+
 // ParamsFromContext returns path params.
 func ParamsFromContext(c context.Context) map[string]string {
 	v, ok := c.Value(pathParamsKey).(map[string]string)
@@ -167,42 +198,23 @@ func ParamsFromContext(c context.Context) map[string]string {
 	return v
 }
 
+// PathParam returns value of the path parameter with the given name, e.g.
+// "id" of path "/users/:id", or empty string if it does not exist.
+func PathParam(c context.Context, name string) string {
+	return ParamsFromContext(c)[name]
+}
+
 // EntityFromContext returns marshalled http.Request.Body.
+//
+// Deprecated: use Entity which also limits size of the entity and validates
+// it.
 func EntityFromContext(c context.Context, v interface{}) error {
-	request := c.Value(requestKey).(*http.Request)
-	contextHandler, ok := c.Value(contextHandlerKey).(*contextHandler)
-	if !ok {
-		panic("rest: no handler in context")
-	}
-	requestReaders := contextHandler.getRequestReaders(request)
-	if len(requestReaders) == 0 {
-		return errUnsupportedMediaType
-	}
-	for i := len(requestReaders) - 1; i >= 0; i-- {
-		if requestReaders[i].IsReadable(request, v) {
-			err := requestReaders[i].Read(request, v)
-			if err != nil {
-				return NewHTTPError(err.Error(), http.StatusBadRequest)
-			}
-			return nil
-		}
-	}
-	return errUnsupportedMediaType
+	return Entity(c, v)
 }
 
 // ValidEntityFromContext is similar to EntityFromContext but also validate the entity.
+//
+// Deprecated: use Entity which validates the entity.
 func ValidEntityFromContext(c context.Context, v interface{}) error {
-	err := EntityFromContext(c, v)
-	if err != nil {
-		return err
-	}
-	contextHandler, ok := c.Value(contextHandlerKey).(*contextHandler)
-	if !ok {
-		panic("rest: no handler in context")
-	}
-	err = contextHandler.resourceHandler.validator.Validate(v)
-	if err != nil {
-		return NewHTTPError(err.Error(), statusUnprocessableEntity)
-	}
-	return nil
+	return Entity(c, v)
 }
@@ -0,0 +1,98 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/goburrow/gomelon/core"
+)
+
+// DefaultMaxEntitySize is the default limit of request entities.
+const DefaultMaxEntitySize = 10 << 20 // 10MB
+
+// EntityError is the details of HTTPError returned by Entity when the
+// request entity is malformed.
+type EntityError struct {
+	// Field is the path of the field having invalid value if known.
+	Field string `json:"field,omitempty"`
+	// Offset is the position in the entity where the error occurred if known.
+	Offset int64  `json:"offset,omitempty"`
+	Reason string `json:"reason"`
+}
+
+// Entity decodes the request body into v using the provider of the request
+// Content-Type, or the default provider if it is not set, then validates it
+// with the environment validator. It returns HTTPError with status 415 for
+// unsupported media types, 413 when the body exceeds
+// ResourceHandler.MaxEntitySize, 400 with EntityError details when the body
+// is malformed and 422 with core.Violation list when it is invalid.
+func Entity(c context.Context, v interface{}) error {
+	request := RequestFromContext(c)
+	h := contextHandlerFromContext(c)
+	requestReaders := h.getRequestReaders(request)
+	if len(requestReaders) == 0 {
+		return errUnsupportedMediaType
+	}
+	if limit := h.resourceHandler.MaxEntitySize; limit > 0 && request.Body != nil {
+		request.Body = http.MaxBytesReader(ResponseWriterFromContext(c), request.Body, limit)
+	}
+	for i := len(requestReaders) - 1; i >= 0; i-- {
+		if requestReaders[i].IsReadable(request, v) {
+			if err := requestReaders[i].Read(request, v); err != nil {
+				return entityError(err)
+			}
+			return validateEntity(h.resourceHandler.validator, v)
+		}
+	}
+	return errUnsupportedMediaType
+}
+
+func entityError(err error) error {
+	var maxBytesError *http.MaxBytesError
+	if errors.As(err, &maxBytesError) {
+		return errRequestEntityTooLarge
+	}
+	details := &EntityError{Reason: err.Error()}
+	var syntaxError *json.SyntaxError
+	var typeError *json.UnmarshalTypeError
+	switch {
+	case err == io.EOF:
+		details.Reason = "empty entity"
+	case err == io.ErrUnexpectedEOF:
+		details.Reason = "unexpected end of entity"
+	case errors.As(err, &syntaxError):
+		details.Offset = syntaxError.Offset
+	case errors.As(err, &typeError):
+		details.Field = typeError.Field
+		details.Offset = typeError.Offset
+		details.Reason = fmt.Sprintf("cannot use %s as %v", typeError.Value, typeError.Type)
+	}
+	return &HTTPError{
+		Message: "malformed entity",
+		Code:    http.StatusBadRequest,
+		Details: details,
+	}
+}
+
+func validateEntity(validator core.Validator, v interface{}) error {
+	if validator == nil {
+		return nil
+	}
+	err := validator.Validate(v)
+	if err == nil {
+		return nil
+	}
+	violations, ok := err.(core.ValidationErrors)
+	if !ok {
+		violations = core.ValidationErrors{{Message: err.Error()}}
+	}
+	return &HTTPError{
+		Message: "invalid entity",
+		Code:    statusUnprocessableEntity,
+		Details: []core.Violation(violations),
+	}
+}
@@ -0,0 +1,81 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/goburrow/gomelon/core"
+)
+
+type entityResource struct {
+}
+
+func (*entityResource) Path() string {
+	return "/entity"
+}
+
+func (*entityResource) POST(c context.Context) (interface{}, error) {
+	var v struct {
+		Name string
+		Age  int
+	}
+	if err := Entity(c, &v); err != nil {
+		return nil, err
+	}
+	return v.Name, nil
+}
+
+func TestEntity(t *testing.T) {
+	h := newTestHandlerWith(func(h *ResourceHandler) {
+		h.MaxEntitySize = 100
+	}, &entityResource{})
+	tests := []struct {
+		body        string
+		contentType string
+		code        int
+		response    string
+	}{
+		{`{"Name":"a"}`, "application/json; charset=utf-8", http.StatusOK, `"a"`},
+		{`{"Name":"b"}`, "", http.StatusOK, `"b"`},
+		{`{"Name":"c"}`, "text/csv", http.StatusUnsupportedMediaType, ""},
+		{`{"Name":`, "application/json", http.StatusBadRequest, `{"message":"malformed entity","code":400,"details":{"reason":"unexpected end of entity"}}`},
+		{`{"Age":"x"}`, "application/json", http.StatusBadRequest, `{"message":"malformed entity","code":400,"details":{"field":"Age","offset":10,"reason":"cannot use string as int"}}`},
+		{`{"Name":"` + strings.Repeat("x", 100) + `"}`, "application/json", http.StatusRequestEntityTooLarge, ""},
+	}
+	for _, test := range tests {
+		w := serve(h, "POST", "/entity", strings.NewReader(test.body), "Content-Type", test.contentType)
+		if w.Code != test.code {
+			t.Fatalf("%s: unexpected status %v %s", test.body, w.Code, w.Body)
+		}
+		if test.response != "" && strings.TrimSpace(w.Body.String()) != test.response {
+			t.Fatalf("%s: unexpected response %s", test.body, w.Body)
+		}
+	}
+}
+
+type testValidator struct {
+}
+
+func (*testValidator) Validate(v interface{}) error {
+	if u, ok := v.(*xmlUser); ok && u.Name == "" {
+		return core.ValidationErrors{{Field: "Name", Message: "zero value"}}
+	}
+	return errors.New("unsupported")
+}
+
+func TestEntityValidation(t *testing.T) {
+	h := newTestHandlerWith(func(h *ResourceHandler) {
+		h.validator = &testValidator{}
+	}, &xmlResource{})
+	w := serve(h, "POST", "/xml", strings.NewReader(`{"Name":""}`))
+	if w.Code != statusUnprocessableEntity {
+		t.Fatalf("unexpected status %v %s", w.Code, w.Body)
+	}
+	expected := `{"message":"invalid entity","code":422,"details":[{"field":"Name","message":"zero value"}]}`
+	if strings.TrimSpace(w.Body.String()) != expected {
+		t.Fatalf("unexpected response %s", w.Body)
+	}
+}
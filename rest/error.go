@@ -1,20 +1,26 @@
 package rest
 
 import (
+	"encoding/json"
+	"errors"
 	"net/http"
 
-	"github.com/goburrow/gol"
+	"github.com/goburrow/gomelon/core"
 )
 
-var errorLogger gol.Logger
+var errorLogger core.Logger
 
 func init() {
-	errorLogger = gol.GetLogger("gomelon/rest/error")
+	errorLogger = core.GetLogger("gomelon/rest/error")
 }
 
+// HTTPError is an error with HTTP status code. It is written as plain text,
+// or as JSON object {"code", "message", "details"} when Details is set.
 type HTTPError struct {
-	Message string
-	Code    int
+	Message string `json:"message"`
+	Code    int    `json:"code"`
+	// Details describes the error, e.g. *EntityError.
+	Details interface{} `json:"details,omitempty"`
 }
 
 func NewHTTPError(msg string, code int) *HTTPError {
@@ -33,21 +39,80 @@ type ErrorMapper interface {
 	MapError(error, http.ResponseWriter, *http.Request)
 }
 
-// defaultErrorHandler implements ErrorHandler interface.
-type defaultErrorMapper struct {
+// ErrorMapperFunc converts err to HTTPError. It returns nil if err is not
+// handled.
+type ErrorMapperFunc func(err error) *HTTPError
+
+// ErrorMappers is the registry of error mappers which translate errors
+// returned by resources into HTTP errors, e.g.
+//
+//	mappers.AddError(sql.ErrNoRows, http.StatusNotFound)
+//	mappers.Add(func(err error) *rest.HTTPError {
+//		var e *ValidationError
+//		if errors.As(err, &e) {
+//			return &rest.HTTPError{Message: "invalid entity", Code: 422, Details: e.Fields}
+//		}
+//		return nil
+//	})
+//
+// Mappers added later take precedence. Errors not handled by any mapper
+// are written as is if they are HTTPError, or with status 500 otherwise.
+// ErrorMappers is not concurrent-safe and must be completed before the
+// server starts.
+type ErrorMappers struct {
+	mappers []ErrorMapperFunc
+}
+
+var _ ErrorMapper = (*ErrorMappers)(nil)
+
+// NewErrorMappers allocates and returns a new empty ErrorMappers.
+func NewErrorMappers() *ErrorMappers {
+	return &ErrorMappers{}
+}
+
+// Add adds the error mapper.
+func (m *ErrorMappers) Add(f ErrorMapperFunc) {
+	m.mappers = append(m.mappers, f)
 }
 
-func newErrorMapper() *defaultErrorMapper {
-	return &defaultErrorMapper{}
+// AddError maps errors matching target, as reported by errors.Is, to the
+// given status code with the error message.
+func (m *ErrorMappers) AddError(target error, code int) {
+	m.Add(func(err error) *HTTPError {
+		if errors.Is(err, target) {
+			return NewHTTPError(err.Error(), code)
+		}
+		return nil
+	})
 }
 
-func (h *defaultErrorMapper) MapError(err error, w http.ResponseWriter, r *http.Request) {
+// MapError writes the HTTP error converted from err.
+func (m *ErrorMappers) MapError(err error, w http.ResponseWriter, r *http.Request) {
 	errorLogger.Debug("%v: %#v", r.URL, err)
-	// TODO: log error
-	switch v := err.(type) {
-	case *HTTPError:
-		http.Error(w, v.Message, v.Code)
-	default:
+	httpError, ok := err.(*HTTPError)
+	for i := len(m.mappers) - 1; i >= 0; i-- {
+		if e := m.mappers[i](err); e != nil {
+			httpError, ok = e, true
+			break
+		}
+	}
+	if !ok {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if httpError.Details != nil {
+		writeJSONError(w, httpError)
+	} else {
+		http.Error(w, httpError.Message, httpError.Code)
+	}
+}
+
+// writeJSONError writes err as JSON to the response.
+func writeJSONError(w http.ResponseWriter, err *HTTPError) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(err.Code)
+	if e := json.NewEncoder(w).Encode(err); e != nil {
+		errorLogger.Warn("could not write error: %v", e)
 	}
 }
@@ -0,0 +1,71 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+var errTestNotFound = errors.New("not found")
+
+type testValidationError struct {
+	fields []string
+}
+
+func (e *testValidationError) Error() string {
+	return "invalid " + strings.Join(e.fields, ",")
+}
+
+type errorResource struct {
+}
+
+func (*errorResource) Path() string {
+	return "/errors/:name"
+}
+
+func (*errorResource) GET(c context.Context) (interface{}, error) {
+	switch PathParam(c, "name") {
+	case "notfound":
+		return nil, fmt.Errorf("user: %w", errTestNotFound)
+	case "validation":
+		return nil, &testValidationError{[]string{"name"}}
+	case "http":
+		return nil, NewHTTPError("conflict", http.StatusConflict)
+	default:
+		return nil, errors.New("internal")
+	}
+}
+
+func TestErrorMappers(t *testing.T) {
+	mappers := NewErrorMappers()
+	mappers.AddError(errTestNotFound, http.StatusNotFound)
+	mappers.Add(func(err error) *HTTPError {
+		var e *testValidationError
+		if errors.As(err, &e) {
+			return &HTTPError{Message: "invalid entity", Code: statusUnprocessableEntity, Details: e.fields}
+		}
+		return nil
+	})
+	h := newTestHandlerWith(func(h *ResourceHandler) {
+		h.SetErrorMapper(mappers)
+	}, &errorResource{})
+	tests := []struct {
+		name string
+		code int
+		body string
+	}{
+		{"notfound", http.StatusNotFound, "user: not found"},
+		{"validation", statusUnprocessableEntity, `{"message":"invalid entity","code":422,"details":["name"]}`},
+		{"http", http.StatusConflict, "conflict"},
+		{"other", http.StatusInternalServerError, "internal"},
+	}
+	for _, test := range tests {
+		w := serve(h, "GET", "/errors/"+test.name, nil)
+		if w.Code != test.code || strings.TrimSpace(w.Body.String()) != test.body {
+			t.Fatalf("%s: unexpected response %v %s", test.name, w.Code, w.Body)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package rest
+
+import (
+	"context"
+	"strings"
+)
+
+// HandlerFunc is the signature of resource methods, e.g. GET.
+type HandlerFunc func(context.Context) (interface{}, error)
+
+// Filter runs around a resource method. It returns a HandlerFunc which
+// usually calls next, e.g:
+//
+//	func requireJSON(next rest.HandlerFunc) rest.HandlerFunc {
+//		return func(c context.Context) (interface{}, error) {
+//			if rest.RequestFromContext(c).Header.Get("Content-Type") != "application/json" {
+//				return nil, rest.NewHTTPError("JSON required", http.StatusUnsupportedMediaType)
+//			}
+//			return next(c)
+//		}
+//	}
+//
+// Unlike server filters, it has access to the resource context and the
+// response entity before it is written.
+type Filter func(next HandlerFunc) HandlerFunc
+
+// FilterResource is implemented by resources which have their own filters.
+// They run after filters added to the resource handler.
+type FilterResource interface {
+	Filters() []Filter
+}
+
+// RequestFilter returns a Filter which calls f before the resource method.
+// The method is not called if f returns an error.
+func RequestFilter(f func(context.Context) error) Filter {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c context.Context) (interface{}, error) {
+			if err := f(c); err != nil {
+				return nil, err
+			}
+			return next(c)
+		}
+	}
+}
+
+// ResponseFilter returns a Filter which calls f with the response and error
+// returned by the resource method. The result of f is written instead.
+func ResponseFilter(f func(c context.Context, response interface{}, err error) (interface{}, error)) Filter {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c context.Context) (interface{}, error) {
+			response, err := next(c)
+			return f(c, response, err)
+		}
+	}
+}
+
+type prefixFilter struct {
+	prefix string
+	filter Filter
+}
+
+// Filters is a registry of filters applied to resources by path prefix.
+type Filters struct {
+	filters []prefixFilter
+}
+
+// NewFilters allocates and returns a new Filters.
+func NewFilters() *Filters {
+	return &Filters{}
+}
+
+// Add adds filters to resources whose path is prefix or under prefix,
+// e.g. prefix "/users" matches "/users" and "/users/:id" but not "/usersx".
+// Empty prefix matches all resources. Filters run in the order they are
+// added. They must be added before resources are handled, i.e. in
+// Application.Run.
+func (f *Filters) Add(prefix string, filter ...Filter) {
+	prefix = strings.TrimSuffix(prefix, "/")
+	for _, ft := range filter {
+		f.filters = append(f.filters, prefixFilter{prefix, ft})
+	}
+}
+
+// Match returns filters applied to the given resource path.
+func (f *Filters) Match(path string) []Filter {
+	var filters []Filter
+	for _, ft := range f.filters {
+		if matchPrefix(ft.prefix, path) {
+			filters = append(filters, ft.filter)
+		}
+	}
+	return filters
+}
+
+func matchPrefix(prefix, path string) bool {
+	if prefix == "" || path == prefix {
+		return true
+	}
+	return strings.HasPrefix(path, prefix) && path[len(prefix)] == '/'
+}
+
+// applyFilters returns f wrapped by the given filters, the first being the
+// outermost.
+func applyFilters(f HandlerFunc, filters []Filter) HandlerFunc {
+	for i := len(filters) - 1; i >= 0; i-- {
+		f = filters[i](f)
+	}
+	return f
+}
@@ -0,0 +1,74 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type filterResource struct {
+	path  string
+	trace *[]string
+}
+
+func (r *filterResource) Path() string {
+	return r.path
+}
+
+func (r *filterResource) GET(c context.Context) (interface{}, error) {
+	*r.trace = append(*r.trace, "GET")
+	return map[string]string{"path": r.path}, nil
+}
+
+func (r *filterResource) Filters() []Filter {
+	return []Filter{traceFilter(r.trace, "resource")}
+}
+
+func traceFilter(trace *[]string, name string) Filter {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c context.Context) (interface{}, error) {
+			*trace = append(*trace, name)
+			return next(c)
+		}
+	}
+}
+
+func TestFilters(t *testing.T) {
+	var trace []string
+	h := newTestHandlerWith(func(h *ResourceHandler) {
+		h.AddFilter("/users", traceFilter(&trace, "users"))
+		h.AddFilter("", traceFilter(&trace, "all"))
+		h.AddFilter("/users/", ResponseFilter(func(c context.Context, response interface{}, err error) (interface{}, error) {
+			ResponseWriterFromContext(c).Header().Set("X-Filtered", "true")
+			return response, err
+		}))
+		h.AddFilter("/admin", RequestFilter(func(c context.Context) error {
+			return NewHTTPError("forbidden", http.StatusForbidden)
+		}))
+	}, &filterResource{"/users/:id", &trace}, &filterResource{"/usersx", &trace}, &filterResource{"/admin", &trace})
+
+	w := serve(h, "GET", "/users/1", nil)
+	if w.Code != http.StatusOK || w.Header().Get("X-Filtered") != "true" {
+		t.Fatalf("unexpected response %v %v", w.Code, w.Header())
+	}
+	if strings.Join(trace, ",") != "users,all,resource,GET" {
+		t.Fatalf("unexpected trace %v", trace)
+	}
+	trace = nil
+	w = serve(h, "GET", "/usersx", nil)
+	if w.Code != http.StatusOK || w.Header().Get("X-Filtered") != "" {
+		t.Fatalf("unexpected response %v %v", w.Code, w.Header())
+	}
+	if strings.Join(trace, ",") != "all,resource,GET" {
+		t.Fatalf("unexpected trace %v", trace)
+	}
+	trace = nil
+	w = serve(h, "GET", "/admin", nil)
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("unexpected response %v %v", w.Code, w.Body)
+	}
+	if strings.Join(trace, ",") != "all" {
+		t.Fatalf("unexpected trace %v", trace)
+	}
+}
@@ -0,0 +1,53 @@
+package rest
+
+import (
+	"strings"
+)
+
+// ResourceGroup is a group of resources mounted under a path prefix with
+// shared filters. Paths of the resources are relative to the prefix, e.g:
+//
+//	environment.Server.Register(rest.Group("/v1/users", &usersResource{}, &userOrdersResource{}).
+//		WithFilters(rest.AuthFilter(authenticator, "users")))
+//
+// where usersResource has path "/" and userOrdersResource has path
+// "/:id/orders". Groups can be nested.
+type ResourceGroup struct {
+	prefix    string
+	resources []interface{}
+	filters   []Filter
+}
+
+// Group returns a group of the given resources under prefix.
+func Group(prefix string, resources ...interface{}) *ResourceGroup {
+	return &ResourceGroup{
+		prefix:    prefix,
+		resources: resources,
+	}
+}
+
+// WithFilters adds filters to all resources of the group. They run after
+// filters added to the resource handler and before filters of the
+// resources.
+func (g *ResourceGroup) WithFilters(filter ...Filter) *ResourceGroup {
+	g.filters = append(g.filters, filter...)
+	return g
+}
+
+// joinPath returns path under prefix.
+func joinPath(prefix, path string) string {
+	if prefix == "" {
+		return path
+	}
+	prefix = strings.TrimSuffix(prefix, "/")
+	if !strings.HasPrefix(prefix, "/") {
+		prefix = "/" + prefix
+	}
+	if path == "" || path == "/" {
+		return prefix
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return prefix + path
+}
@@ -0,0 +1,44 @@
+package rest
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestGroup(t *testing.T) {
+	var trace []string
+	group := Group("/v1/",
+		Group("users",
+			&filterResource{"/", &trace},
+			&filterResource{"/:id", &trace},
+		).WithFilters(traceFilter(&trace, "users")),
+		&filterResource{"items", &trace},
+	).WithFilters(traceFilter(&trace, "v1"))
+	h := newTestHandlerWith(func(h *ResourceHandler) {
+		h.AddFilter("/v1/users", traceFilter(&trace, "prefix"))
+	}, group)
+
+	tests := []struct {
+		target string
+		body   string
+		trace  string
+	}{
+		{"/v1/users", `{"path":"/"}`, "prefix,v1,users,resource,GET"},
+		{"/v1/users/1", `{"path":"/:id"}`, "prefix,v1,users,resource,GET"},
+		{"/v1/items", `{"path":"items"}`, "v1,resource,GET"},
+	}
+	for _, test := range tests {
+		trace = nil
+		w := serve(h, "GET", test.target, nil)
+		if w.Code != http.StatusOK || w.Body.String() != test.body+"\n" {
+			t.Fatalf("unexpected response %v %s for %s", w.Code, w.Body, test.target)
+		}
+		if strings.Join(trace, ",") != test.trace {
+			t.Fatalf("unexpected trace %v for %s", trace, test.target)
+		}
+	}
+	if w := serve(h, "GET", "/users/1", nil); w.Code != http.StatusNotFound {
+		t.Fatalf("unexpected response %v", w.Code)
+	}
+}
@@ -0,0 +1,98 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Link is a hypermedia link embedded in responses.
+type Link struct {
+	Rel  string `json:"rel" xml:"rel,attr"`
+	Href string `json:"href" xml:"href,attr"`
+}
+
+// LinkBuilder resolves path templates of resources to absolute URLs of the
+// application serving the request.
+type LinkBuilder struct {
+	request *http.Request
+	// base is scheme, host and context path of the application.
+	base string
+}
+
+// Links returns the link builder of the request. The base URL has the
+// scheme and host of the request, which are those given by trusted proxies
+// when the forwarded filter is enabled, and the application context path,
+// e.g:
+//
+//	links := rest.Links(c)
+//	user.Links = []rest.Link{
+//		links.Self(),
+//		links.Link("orders", "/users/:id/orders", "id", user.ID),
+//	}
+func Links(c context.Context) *LinkBuilder {
+	r := RequestFromContext(c)
+	scheme := r.URL.Scheme
+	if scheme == "" {
+		if r.TLS != nil {
+			scheme = "https"
+		} else {
+			scheme = "http"
+		}
+	}
+	prefix := ""
+	if h, ok := c.Value(contextHandlerKey).(*contextHandler); ok {
+		prefix = h.pathPrefix()
+	}
+	return &LinkBuilder{
+		request: r,
+		base:    scheme + "://" + r.Host + prefix,
+	}
+}
+
+// URL returns the absolute URL of the path template, e.g. "/users/:id",
+// with parameters given in name and value pairs. Values are escaped. It
+// panics if a parameter of the template is not given.
+func (b *LinkBuilder) URL(template string, params ...string) string {
+	if len(params)%2 != 0 {
+		panic("rest: path parameters must be name and value pairs")
+	}
+	segments := strings.Split(template, "/")
+	for i, segment := range segments {
+		if !strings.HasPrefix(segment, ":") {
+			continue
+		}
+		name := segment[1:]
+		found := false
+		for j := 0; j < len(params); j += 2 {
+			if params[j] == name {
+				segments[i] = url.PathEscape(params[j+1])
+				found = true
+				break
+			}
+		}
+		if !found {
+			panic("rest: missing path parameter " + name)
+		}
+	}
+	path := strings.Join(segments, "/")
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return b.base + path
+}
+
+// Link returns the link with the given relation to the path template.
+func (b *LinkBuilder) Link(rel, template string, params ...string) Link {
+	return Link{Rel: rel, Href: b.URL(template, params...)}
+}
+
+// Self returns the link to the requested resource, including its query.
+func (b *LinkBuilder) Self() Link {
+	href := b.request.URL.EscapedPath()
+	if b.request.URL.RawQuery != "" {
+		href += "?" + b.request.URL.RawQuery
+	}
+	return Link{Rel: "self", Href: b.base + href}
+}
@@ -0,0 +1,54 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+type linkResource struct {
+}
+
+func (*linkResource) Path() string {
+	return "/users/:id"
+}
+
+func (*linkResource) GET(c context.Context) (interface{}, error) {
+	links := Links(c)
+	return []Link{
+		links.Self(),
+		links.Link("orders", "/users/:id/orders", "id", PathParam(c, "id")),
+		links.Link("search", "/search/:q", "q", "a b/c"),
+	}, nil
+}
+
+func TestLinks(t *testing.T) {
+	h := newTestHandlerWith(func(h *ResourceHandler) {
+		h.serverHandler = &prefixServerHandler{h.serverHandler, "/api/"}
+	}, &linkResource{})
+	w := serve(h, "GET", "http://example.com/users/1?x=y", nil)
+	expected := `[{"rel":"self","href":"http://example.com/api/users/1?x=y"},` +
+		`{"rel":"orders","href":"http://example.com/api/users/1/orders"},` +
+		`{"rel":"search","href":"http://example.com/api/search/a%20b%2Fc"}]` + "\n"
+	if w.Code != http.StatusOK || w.Body.String() != expected {
+		t.Fatalf("unexpected response %v %s", w.Code, w.Body)
+	}
+}
+
+func TestLinksForwarded(t *testing.T) {
+	r, _ := http.NewRequest("GET", "/users/1", nil)
+	// Set by the forwarded filter.
+	r.URL.Scheme = "https"
+	r.Host = "api.example.com"
+	c := context.WithValue(context.Background(), requestKey, r)
+	links := Links(c)
+	if s := links.URL("/users/:id", "id", "2"); s != "https://api.example.com/users/2" {
+		t.Fatalf("unexpected url %v", s)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatal("panic expected")
+		}
+	}()
+	links.URL("/users/:id")
+}
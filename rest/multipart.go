@@ -0,0 +1,156 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+)
+
+const (
+	// DefaultMaxPartSize is the default maximum size in bytes of a file
+	// part.
+	DefaultMaxPartSize = 32 << 20
+	// DefaultMaxFieldsSize is the default maximum size in bytes of all
+	// regular fields.
+	DefaultMaxFieldsSize = 1 << 20
+)
+
+// MultipartReader reads a multipart/form-data request entity part by part,
+// so that files are not buffered in memory or on disk.
+type MultipartReader struct {
+	// MaxPartSize is the maximum size in bytes of each file part. Reading
+	// a larger part returns a HTTPError with status 413.
+	MaxPartSize int64
+	// MaxFieldsSize is the maximum total size in bytes of regular fields.
+	MaxFieldsSize int64
+
+	reader *multipart.Reader
+	values url.Values
+}
+
+// FilePart is a file in a multipart request. Reading it returns a HTTPError
+// with status 413 when it is larger than the maximum part size.
+type FilePart struct {
+	FieldName string
+	FileName  string
+	Header    textproto.MIMEHeader
+
+	part      *multipart.Part
+	remaining int64
+}
+
+// Multipart returns the reader of the multipart/form-data request entity.
+// It returns a HTTPError with status 415 if the request is not multipart.
+func Multipart(c context.Context) (*MultipartReader, error) {
+	r := RequestFromContext(c)
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/form-data" {
+		return nil, errUnsupportedMediaType
+	}
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, NewHTTPError(err.Error(), http.StatusBadRequest)
+	}
+	return &MultipartReader{
+		MaxPartSize:   DefaultMaxPartSize,
+		MaxFieldsSize: DefaultMaxFieldsSize,
+		reader:        reader,
+		values:        make(url.Values),
+	}, nil
+}
+
+// Read reads all parts of the request. Regular fields are stored for Value
+// and Bind, while file parts are given to f in order. File parts which are
+// not read by f, or all of them if f is nil, are discarded. The first error
+// returned by f is returned.
+func (m *MultipartReader) Read(f func(part *FilePart) error) error {
+	fieldsRemaining := m.MaxFieldsSize
+	for {
+		part, err := m.reader.NextPart()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return NewHTTPError(fmt.Sprintf("invalid multipart entity: %v", err), http.StatusBadRequest)
+		}
+		name := part.FormName()
+		if name == "" {
+			part.Close()
+			continue
+		}
+		if part.FileName() == "" {
+			value, err := ioutil.ReadAll(io.LimitReader(part, fieldsRemaining+1))
+			part.Close()
+			if err != nil {
+				return NewHTTPError(fmt.Sprintf("invalid multipart entity: %v", err), http.StatusBadRequest)
+			}
+			fieldsRemaining -= int64(len(value))
+			if fieldsRemaining < 0 {
+				return errRequestEntityTooLarge
+			}
+			m.values.Add(name, string(value))
+			continue
+		}
+		if f != nil {
+			err = f(&FilePart{
+				FieldName: name,
+				FileName:  part.FileName(),
+				Header:    part.Header,
+				part:      part,
+				remaining: m.MaxPartSize,
+			})
+		}
+		part.Close()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Value returns the first value of the regular field with the given name.
+func (m *MultipartReader) Value(name string) string {
+	return m.values.Get(name)
+}
+
+// Values returns regular fields which have been read.
+func (m *MultipartReader) Values() url.Values {
+	return m.values
+}
+
+// Bind sets fields of the struct pointed to by v from regular fields which
+// have been read, using tag "form". See BindQuery.
+func (m *MultipartReader) Bind(v interface{}) error {
+	return bindValues(m.values, v, formTag)
+}
+
+// Read reads the content of the file part.
+func (p *FilePart) Read(b []byte) (int, error) {
+	if p.remaining < 0 {
+		return 0, p.tooLarge()
+	}
+	// Read one more byte to detect parts exceeding the limit.
+	if int64(len(b)) > p.remaining+1 {
+		b = b[:p.remaining+1]
+	}
+	n, err := p.part.Read(b)
+	if int64(n) > p.remaining {
+		n = int(p.remaining)
+		p.remaining = -1
+		return n, p.tooLarge()
+	}
+	p.remaining -= int64(n)
+	if err != nil && err != io.EOF {
+		err = NewHTTPError(fmt.Sprintf("invalid multipart entity: %v", err), http.StatusBadRequest)
+	}
+	return n, err
+}
+
+func (p *FilePart) tooLarge() error {
+	return NewHTTPError(fmt.Sprintf("part %s is too large", p.FieldName), http.StatusRequestEntityTooLarge)
+}
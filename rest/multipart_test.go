@@ -0,0 +1,101 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type uploadResource struct {
+}
+
+func (*uploadResource) Path() string {
+	return "/upload"
+}
+
+func (*uploadResource) POST(c context.Context) (interface{}, error) {
+	m, err := Multipart(c)
+	if err != nil {
+		return nil, err
+	}
+	m.MaxPartSize = 8
+	m.MaxFieldsSize = 16
+	files := make(map[string]string)
+	err = m.Read(func(part *FilePart) error {
+		data, err := ioutil.ReadAll(part)
+		if err != nil {
+			return err
+		}
+		files[part.FieldName+":"+part.FileName] = string(data)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	var fields struct {
+		Title string   `form:"title"`
+		Tags  []string `form:"tag"`
+		Count int      `form:"count" default:"1"`
+	}
+	if err = m.Bind(&fields); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"fields": fields, "files": files}, nil
+}
+
+func newMultipart(t *testing.T, fields ...string) (io.Reader, string) {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	for i := 0; i+1 < len(fields); i += 2 {
+		var err error
+		if strings.Contains(fields[i], ":") {
+			s := strings.SplitN(fields[i], ":", 2)
+			var fw io.Writer
+			if fw, err = w.CreateFormFile(s[0], s[1]); err == nil {
+				_, err = io.WriteString(fw, fields[i+1])
+			}
+		} else {
+			err = w.WriteField(fields[i], fields[i+1])
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	w.Close()
+	return &buf, w.FormDataContentType()
+}
+
+func TestMultipart(t *testing.T) {
+	h := newTestHandler(&uploadResource{})
+	body, contentType := newMultipart(t, "title", "hello", "file:a.txt", "content", "tag", "x", "tag", "y")
+	w := serve(h, "POST", "/upload", body, "Content-Type", contentType)
+	expected := `{"fields":{"Title":"hello","Tags":["x","y"],"Count":1},"files":{"file:a.txt":"content"}}` + "\n"
+	if w.Code != http.StatusOK || w.Body.String() != expected {
+		t.Fatalf("unexpected response %v %s", w.Code, w.Body)
+	}
+
+	tests := []struct {
+		fields []string
+		code   int
+	}{
+		{[]string{"file:a.txt", "123456789"}, http.StatusRequestEntityTooLarge},
+		{[]string{"title", "1234567890", "tag", "1234567"}, http.StatusRequestEntityTooLarge},
+		{[]string{"count", "x"}, http.StatusBadRequest},
+	}
+	for _, test := range tests {
+		body, contentType = newMultipart(t, test.fields...)
+		w = serve(h, "POST", "/upload", body, "Content-Type", contentType)
+		if w.Code != test.code {
+			t.Fatalf("unexpected response %v %s for %v", w.Code, w.Body, test.fields)
+		}
+	}
+	w = serve(h, "POST", "/upload", strings.NewReader("{}"), "Content-Type", "application/json")
+	if w.Code != http.StatusUnsupportedMediaType {
+		t.Fatalf("unexpected response %v", w.Code)
+	}
+}
@@ -0,0 +1,108 @@
+package rest
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// acceptRange is a media range of the Accept header.
+type acceptRange struct {
+	mediaType string
+	quality   float64
+}
+
+// specificity returns 2 for "type/subtype", 1 for "type/*" and 0 for "*/*".
+func (a *acceptRange) specificity() int {
+	switch {
+	case a.mediaType == "*/*":
+		return 0
+	case strings.HasSuffix(a.mediaType, "/*"):
+		return 1
+	default:
+		return 2
+	}
+}
+
+// matches reports whether the media type is in the range.
+func (a *acceptRange) matches(mediaType string) bool {
+	switch a.specificity() {
+	case 0:
+		return true
+	case 1:
+		return strings.HasPrefix(mediaType, a.mediaType[:len(a.mediaType)-1])
+	default:
+		return mediaType == a.mediaType
+	}
+}
+
+// parseAccept returns media ranges of the Accept header in order of
+// preference: quality, then specificity, then position in the header.
+// Ranges with zero quality are excluded.
+func parseAccept(accept string) []acceptRange {
+	var ranges []acceptRange
+	for _, item := range strings.Split(accept, ",") {
+		params := strings.Split(item, ";")
+		a := acceptRange{
+			mediaType: strings.ToLower(strings.TrimSpace(params[0])),
+			quality:   1,
+		}
+		if a.mediaType == "" {
+			continue
+		}
+		if a.mediaType == "*" {
+			a.mediaType = "*/*"
+		}
+		for _, p := range params[1:] {
+			p = strings.TrimSpace(p)
+			if strings.HasPrefix(p, "q=") {
+				q, err := strconv.ParseFloat(p[2:], 64)
+				if err != nil || q < 0 {
+					q = 0
+				} else if q > 1 {
+					q = 1
+				}
+				a.quality = q
+			}
+		}
+		if a.quality > 0 {
+			ranges = append(ranges, a)
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool {
+		if ranges[i].quality != ranges[j].quality {
+			return ranges[i].quality > ranges[j].quality
+		}
+		return ranges[i].specificity() > ranges[j].specificity()
+	})
+	return ranges
+}
+
+// negotiateWriters returns response writers of the most preferred media
+// type in the Accept header which is supported by the providers.
+func negotiateWriters(providers providerMap, accept string) []ResponseWriter {
+	if accept == "" {
+		return providers.GetResponseWriters("*/*")
+	}
+	for _, a := range parseAccept(accept) {
+		switch a.specificity() {
+		case 0:
+			if writers := providers.GetResponseWriters("*/*"); len(writers) > 0 {
+				return writers
+			}
+		case 1:
+			for _, mediaType := range providers.ContentTypes() {
+				if a.matches(mediaType) {
+					if writers := providers.GetResponseWriters(mediaType); len(writers) > 0 {
+						return writers
+					}
+				}
+			}
+		default:
+			if writers := providers.GetResponseWriters(a.mediaType); len(writers) > 0 {
+				return writers
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package rest
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestParseAccept(t *testing.T) {
+	ranges := parseAccept("text/*;q=0.5, application/json;q=0.9, */*;q=0.1, application/xml, text/html;q=0.5, image/png;q=0")
+	expected := "[{application/xml 1} {application/json 0.9} {text/html 0.5} {text/* 0.5} {*/* 0.1}]"
+	if fmt.Sprint(ranges) != expected {
+		t.Fatalf("unexpected ranges %v", ranges)
+	}
+}
+
+func TestNegotiateWriters(t *testing.T) {
+	p := NewProviders()
+	jsonProvider := &JSONProvider{}
+	xmlProvider := &XMLProvider{}
+	p.AddProvider(jsonProvider)
+	p.AddProvider(xmlProvider)
+
+	tests := []struct {
+		accept   string
+		expected ResponseWriter
+	}{
+		{"", jsonProvider},
+		{"*/*", jsonProvider},
+		{"application/xml", xmlProvider},
+		{"application/json;q=0.5, application/xml", xmlProvider},
+		{"text/*", jsonProvider},
+		{"text/html, text/xml;q=0.8, application/json;q=0.7", xmlProvider},
+		{"image/png, */*;q=0.1", jsonProvider},
+		{"image/png", nil},
+		{"application/json;q=0", nil},
+	}
+	for _, test := range tests {
+		writers := negotiateWriters(p, test.accept)
+		if test.expected == nil {
+			if len(writers) != 0 {
+				t.Fatalf("%q: unexpected writers %#v", test.accept, writers)
+			}
+			continue
+		}
+		if len(writers) != 1 || writers[0] != test.expected {
+			t.Fatalf("%q: unexpected writers %#v", test.accept, writers)
+		}
+	}
+	// Restricted providers only negotiate produced types.
+	r := &restrictedProviders{parent: p, produces: []string{"application/xml"}}
+	if writers := negotiateWriters(r, "*/*"); len(writers) != 1 || writers[0] != xmlProvider {
+		t.Fatalf("unexpected writers %#v", writers)
+	}
+	if writers := negotiateWriters(r, "application/json"); len(writers) != 0 {
+		t.Fatalf("unexpected writers %#v", writers)
+	}
+}
@@ -0,0 +1,170 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Query parameters of paging.
+const (
+	LimitParam  = "limit"
+	OffsetParam = "offset"
+	CursorParam = "cursor"
+)
+
+// Paging parses paging parameters of collection resources.
+type Paging struct {
+	// DefaultLimit is used when the request has no limit.
+	DefaultLimit int
+	// MaxLimit is the maximum limit. Larger limits are reduced to it.
+	MaxLimit int
+}
+
+// DefaultPaging is used by PageParams.
+var DefaultPaging = Paging{
+	DefaultLimit: 20,
+	MaxLimit:     100,
+}
+
+// PageRequest is a page requested by a client, either by offset or by
+// cursor.
+type PageRequest struct {
+	Limit  int
+	Offset int
+	// Cursor is an opaque position given by the previous page. Offset is
+	// zero when it is set.
+	Cursor string
+}
+
+// PageParams returns paging parameters "limit" and either "offset" or
+// "cursor" of the request using DefaultPaging.
+func PageParams(c context.Context) (PageRequest, error) {
+	return DefaultPaging.Parse(c)
+}
+
+// Parse returns paging parameters of the request. Invalid parameters
+// result in a HTTPError with status 400.
+func (p Paging) Parse(c context.Context) (PageRequest, error) {
+	var params struct {
+		Limit  *int   `query:"limit"`
+		Offset int    `query:"offset"`
+		Cursor string `query:"cursor"`
+	}
+	if err := BindQuery(c, &params); err != nil {
+		return PageRequest{}, err
+	}
+	req := PageRequest{
+		Limit:  p.DefaultLimit,
+		Offset: params.Offset,
+		Cursor: params.Cursor,
+	}
+	if params.Limit != nil {
+		if *params.Limit <= 0 {
+			return PageRequest{}, NewHTTPError("invalid parameter limit: must be positive", http.StatusBadRequest)
+		}
+		req.Limit = *params.Limit
+	}
+	if p.MaxLimit > 0 && req.Limit > p.MaxLimit {
+		req.Limit = p.MaxLimit
+	}
+	if req.Offset < 0 {
+		return PageRequest{}, NewHTTPError("invalid parameter offset: must not be negative", http.StatusBadRequest)
+	}
+	if req.Cursor != "" && req.Offset > 0 {
+		return PageRequest{}, NewHTTPError("parameters offset and cursor are exclusive", http.StatusBadRequest)
+	}
+	return req, nil
+}
+
+// PageInfo describes a page of a collection. Responses embedding it have
+// header X-Total-Count when the total is known and header Link with
+// relations first, prev, next and last where applicable.
+type PageInfo struct {
+	Limit      int    `json:"limit" xml:"limit"`
+	Offset     int    `json:"offset" xml:"offset"`
+	Total      *int64 `json:"total,omitempty" xml:"total,omitempty"`
+	NextCursor string `json:"nextCursor,omitempty" xml:"nextCursor,omitempty"`
+
+	// count is the number of items in the page.
+	count int
+	// cursor is set for pages requested by cursor.
+	cursor bool
+}
+
+var _ pageHeaderWriter = (*PageInfo)(nil)
+
+// pageHeaderWriter is implemented by responses embedding PageInfo.
+type pageHeaderWriter interface {
+	writePageHeader(r *http.Request, prefix string, header http.Header)
+}
+
+func newPageInfo(req PageRequest, count int, total int64, nextCursor string) PageInfo {
+	info := PageInfo{
+		Limit:      req.Limit,
+		Offset:     req.Offset,
+		NextCursor: nextCursor,
+		count:      count,
+		cursor:     req.Cursor != "" || nextCursor != "",
+	}
+	if total >= 0 {
+		info.Total = &total
+	}
+	return info
+}
+
+// writePageHeader writes the page headers. Prefix is the context path of
+// the application, which has been removed from the request path.
+func (p *PageInfo) writePageHeader(r *http.Request, prefix string, header http.Header) {
+	if p.Total != nil {
+		header.Set("X-Total-Count", strconv.FormatInt(*p.Total, 10))
+	}
+	var links []string
+	addLink := func(rel string, params map[string]string) {
+		links = append(links, fmt.Sprintf("<%s>; rel=%q", pageURL(prefix, r.URL, params), rel))
+	}
+	limit := strconv.Itoa(p.Limit)
+	if p.cursor {
+		if p.NextCursor != "" {
+			addLink("next", map[string]string{LimitParam: limit, CursorParam: p.NextCursor})
+		}
+	} else if p.Limit > 0 {
+		addLink("first", map[string]string{LimitParam: limit, OffsetParam: ""})
+		if p.Offset > 0 {
+			prev := p.Offset - p.Limit
+			if prev < 0 {
+				prev = 0
+			}
+			addLink("prev", map[string]string{LimitParam: limit, OffsetParam: strconv.Itoa(prev)})
+		}
+		next := p.Offset + p.Limit
+		if (p.Total != nil && int64(next) < *p.Total) || (p.Total == nil && p.count >= p.Limit) {
+			addLink("next", map[string]string{LimitParam: limit, OffsetParam: strconv.Itoa(next)})
+		}
+		if p.Total != nil && *p.Total > 0 {
+			last := (*p.Total - 1) / int64(p.Limit) * int64(p.Limit)
+			addLink("last", map[string]string{LimitParam: limit, OffsetParam: strconv.FormatInt(last, 10)})
+		}
+	}
+	if len(links) > 0 {
+		header.Set("Link", strings.Join(links, ", "))
+	}
+}
+
+// pageURL returns the request URI with the given query parameters replaced.
+// Empty values remove the parameters.
+func pageURL(prefix string, u *url.URL, params map[string]string) string {
+	query := u.Query()
+	for k, v := range params {
+		if v == "" {
+			query.Del(k)
+		} else {
+			query.Set(k, v)
+		}
+	}
+	uri := url.URL{Path: prefix + u.Path, RawQuery: query.Encode()}
+	return uri.String()
+}
@@ -0,0 +1,49 @@
+//go:build go1.18
+// +build go1.18
+
+package rest
+
+// Page is a page of a collection returned by resources, e.g:
+//
+//	func (r *usersResource) GET(c context.Context) (interface{}, error) {
+//		req, err := rest.PageParams(c)
+//		if err != nil {
+//			return nil, err
+//		}
+//		users, total := r.store.List(req.Offset, req.Limit)
+//		return rest.NewPage(req, users, total), nil
+//	}
+type Page[T any] struct {
+	Items []T `json:"items" xml:"items"`
+	PageInfo
+}
+
+// NewPage returns a page of items requested by offset. Total is the number
+// of items in the collection, or negative if it is unknown.
+func NewPage[T any](req PageRequest, items []T, total int64) *Page[T] {
+	return &Page[T]{
+		Items:    nonNilItems(items),
+		PageInfo: newPageInfo(req, len(items), total, ""),
+	}
+}
+
+// NewCursorPage returns a page of items requested by cursor. Next is the
+// cursor of the next page, or empty if this is the last page.
+func NewCursorPage[T any](req PageRequest, items []T, next string) *Page[T] {
+	req.Offset = 0
+	page := &Page[T]{
+		Items:    nonNilItems(items),
+		PageInfo: newPageInfo(req, len(items), -1, next),
+	}
+	page.cursor = true
+	return page
+}
+
+// nonNilItems returns an empty slice instead of nil so that items are
+// encoded as an empty array.
+func nonNilItems[T any](items []T) []T {
+	if items == nil {
+		return []T{}
+	}
+	return items
+}
@@ -0,0 +1,86 @@
+//go:build go1.18
+// +build go1.18
+
+package rest
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+type pageResource struct {
+}
+
+func (*pageResource) Path() string {
+	return "/items"
+}
+
+func (*pageResource) GET(c context.Context) (interface{}, error) {
+	req, err := PageParams(c)
+	if err != nil {
+		return nil, err
+	}
+	if req.Cursor != "" {
+		return NewCursorPage(req, []string{"c"}, "next-"+req.Cursor), nil
+	}
+	var items []int
+	for i := req.Offset; i < req.Offset+req.Limit && i < 45; i++ {
+		items = append(items, i)
+	}
+	return NewPage(req, items, 45), nil
+}
+
+func TestPage(t *testing.T) {
+	h := newTestHandler(&pageResource{})
+	tests := []struct {
+		target string
+		body   string
+		link   string
+	}{
+		{
+			"/items?limit=2&offset=2&sort=name",
+			`{"items":[2,3],"limit":2,"offset":2,"total":45}`,
+			`</items?limit=2&sort=name>; rel="first", </items?limit=2&offset=0&sort=name>; rel="prev", ` +
+				`</items?limit=2&offset=4&sort=name>; rel="next", </items?limit=2&offset=44&sort=name>; rel="last"`,
+		},
+		{
+			"/items?limit=1000&offset=40",
+			`{"items":[40,41,42,43,44],"limit":100,"offset":40,"total":45}`,
+			`</items?limit=100>; rel="first", </items?limit=100&offset=0>; rel="prev", </items?limit=100&offset=0>; rel="last"`,
+		},
+		{
+			"/items?limit=5&cursor=abc",
+			`{"items":["c"],"limit":5,"offset":0,"nextCursor":"next-abc"}`,
+			`</items?cursor=next-abc&limit=5>; rel="next"`,
+		},
+	}
+	for _, test := range tests {
+		w := serve(h, "GET", test.target, nil)
+		if w.Code != http.StatusOK || w.Body.String() != test.body+"\n" {
+			t.Fatalf("unexpected response %v %q", w.Code, w.Body)
+		}
+		if w.Header().Get("Link") != test.link {
+			t.Fatalf("unexpected link %q", w.Header().Get("Link"))
+		}
+	}
+	w := serve(h, "GET", "/items", nil)
+	if w.Header().Get("X-Total-Count") != "45" {
+		t.Fatalf("unexpected total %v", w.Header())
+	}
+	for _, target := range []string{"/items?limit=0", "/items?offset=-1", "/items?offset=1&cursor=a", "/items?limit=x"} {
+		if w = serve(h, "GET", target, nil); w.Code != http.StatusBadRequest {
+			t.Fatalf("unexpected response %v for %s", w.Code, target)
+		}
+	}
+}
+
+func TestPageContextPath(t *testing.T) {
+	h := newTestHandlerWith(func(h *ResourceHandler) {
+		h.serverHandler = &prefixServerHandler{h.serverHandler, "/api"}
+	}, &pageResource{})
+	w := serve(h, "GET", "/items?limit=40", nil)
+	if w.Header().Get("Link") != `</api/items?limit=40>; rel="first", </api/items?limit=40&offset=40>; rel="next", </api/items?limit=40&offset=40>; rel="last"` {
+		t.Fatalf("unexpected link %q", w.Header().Get("Link"))
+	}
+}
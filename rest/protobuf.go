@@ -0,0 +1,122 @@
+package rest
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+)
+
+var protobufMIMETypes = []string{
+	"application/x-protobuf",
+	"application/protobuf",
+}
+
+// ProtobufCodec encodes and decodes Protocol Buffers messages. Applications
+// using google.golang.org/protobuf can implement it with package proto:
+//
+//	type protoCodec struct{}
+//
+//	func (protoCodec) IsMessage(v interface{}) bool {
+//		_, ok := v.(proto.Message)
+//		return ok
+//	}
+//
+//	func (protoCodec) Marshal(v interface{}) ([]byte, error) {
+//		return proto.Marshal(v.(proto.Message))
+//	}
+//
+//	func (protoCodec) Unmarshal(data []byte, v interface{}) error {
+//		return proto.Unmarshal(data, v.(proto.Message))
+//	}
+type ProtobufCodec interface {
+	// IsMessage returns true if v can be encoded and decoded.
+	IsMessage(v interface{}) bool
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// ProtobufProvider reads and writes Protocol Buffers entities of media type
+// application/x-protobuf or application/protobuf. It is not added to the
+// bundle by default, e.g:
+//
+//	bundle.Providers.AddProvider(rest.NewProtobufProvider(protoCodec{}))
+type ProtobufProvider struct {
+	codec ProtobufCodec
+}
+
+var _ Provider = (*ProtobufProvider)(nil)
+
+// NewProtobufProvider allocates and returns a new ProtobufProvider. If codec
+// is nil, messages must have methods Marshal() ([]byte, error) and
+// Unmarshal([]byte) error, as generated by gogo/protobuf.
+func NewProtobufProvider(codec ProtobufCodec) *ProtobufProvider {
+	if codec == nil {
+		codec = marshalerCodec{}
+	}
+	return &ProtobufProvider{codec: codec}
+}
+
+func (p *ProtobufProvider) ContentTypes() []string {
+	return protobufMIMETypes
+}
+
+func (p *ProtobufProvider) IsReadable(r *http.Request, v interface{}) bool {
+	return p.codec.IsMessage(v)
+}
+
+func (p *ProtobufProvider) Read(r *http.Request, v interface{}) error {
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	return p.codec.Unmarshal(data, v)
+}
+
+func (p *ProtobufProvider) IsWriteable(r *http.Request, v interface{}, w http.ResponseWriter) bool {
+	return p.codec.IsMessage(v)
+}
+
+func (p *ProtobufProvider) Write(r *http.Request, v interface{}, w http.ResponseWriter) error {
+	data, err := p.codec.Marshal(v)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", protobufMIMETypes[0])
+	_, err = w.Write(data)
+	return err
+}
+
+type protoMarshaler interface {
+	Marshal() ([]byte, error)
+}
+
+type protoUnmarshaler interface {
+	Unmarshal([]byte) error
+}
+
+// marshalerCodec uses methods of generated messages.
+type marshalerCodec struct{}
+
+func (marshalerCodec) IsMessage(v interface{}) bool {
+	_, ok := v.(protoMarshaler)
+	if !ok {
+		_, ok = v.(protoUnmarshaler)
+	}
+	return ok
+}
+
+func (marshalerCodec) Marshal(v interface{}) ([]byte, error) {
+	m, ok := v.(protoMarshaler)
+	if !ok {
+		return nil, fmt.Errorf("rest: %T is not a protobuf message", v)
+	}
+	return m.Marshal()
+}
+
+func (marshalerCodec) Unmarshal(data []byte, v interface{}) error {
+	m, ok := v.(protoUnmarshaler)
+	if !ok {
+		return fmt.Errorf("rest: %T is not a protobuf message", v)
+	}
+	return m.Unmarshal(data)
+}
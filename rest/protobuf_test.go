@@ -0,0 +1,73 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// protoUser is encoded as field 1 of type string.
+type protoUser struct {
+	Name string
+}
+
+func (u *protoUser) Marshal() ([]byte, error) {
+	return append([]byte{0x0a, byte(len(u.Name))}, u.Name...), nil
+}
+
+func (u *protoUser) Unmarshal(data []byte) error {
+	if len(data) < 2 || data[0] != 0x0a || int(data[1]) != len(data)-2 {
+		return errors.New("invalid message")
+	}
+	u.Name = string(data[2:])
+	return nil
+}
+
+type protobufResource struct {
+}
+
+func (*protobufResource) Path() string {
+	return "/proto"
+}
+
+func (*protobufResource) POST(c context.Context) (interface{}, error) {
+	var u protoUser
+	if err := Entity(c, &u); err != nil {
+		return nil, err
+	}
+	u.Name = strings.ToUpper(u.Name)
+	return &u, nil
+}
+
+func (*protobufResource) GET(c context.Context) (interface{}, error) {
+	return map[string]string{"name": "a"}, nil
+}
+
+func TestProtobufProvider(t *testing.T) {
+	h := newTestHandlerWith(func(h *ResourceHandler) {
+		h.AddProvider(NewProtobufProvider(nil))
+	}, &protobufResource{})
+	w := serve(h, "POST", "/proto", strings.NewReader("\x0a\x03abc"),
+		"Content-Type", "application/x-protobuf", "Accept", "application/protobuf")
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/x-protobuf" {
+		t.Fatalf("unexpected response %v %v %s", w.Code, w.Header(), w.Body)
+	}
+	if w.Body.String() != "\x0a\x03ABC" {
+		t.Fatalf("unexpected body %q", w.Body)
+	}
+	// JSON is still supported.
+	w = serve(h, "POST", "/proto", strings.NewReader(`{"Name":"abc"}`), "Content-Type", "application/json")
+	if w.Code != http.StatusOK || w.Body.String() != `{"Name":"ABC"}`+"\n" {
+		t.Fatalf("unexpected response %v %s", w.Code, w.Body)
+	}
+	w = serve(h, "POST", "/proto", strings.NewReader("\x0a"), "Content-Type", "application/x-protobuf")
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("unexpected response %v %s", w.Code, w.Body)
+	}
+	w = serve(h, "GET", "/proto", nil, "Accept", "application/x-protobuf")
+	if w.Code != http.StatusNotAcceptable {
+		t.Fatalf("unexpected response %v %s", w.Code, w.Body)
+	}
+}
@@ -1,6 +1,9 @@
 package rest
 
-import "net/http"
+import (
+	"net/http"
+	"strings"
+)
 
 // RequestReader reads entity from message body.
 type RequestReader interface {
@@ -23,46 +26,94 @@ type Provider interface {
 }
 
 // providerMap is used to look up providers by MIME type.
-// TODO: Error mapper.
 type providerMap interface {
 	GetRequestReaders(string) []RequestReader
 	GetResponseWriters(string) []ResponseWriter
+	// ContentTypes returns MIME types of response writers in order of
+	// registration.
+	ContentTypes() []string
 }
 
-// defaultProviders implement Providers interface.
-type defaultProviders struct {
+// Providers is the registry of request readers and response writers keyed
+// by media type, which is used for both decoding request entities and
+// encoding responses. Vendor media types with a structured syntax suffix,
+// e.g. "application/vnd.example+json", fall back to the providers of the
+// suffix type ("application/json") if they have none registered.
+// Providers is not concurrent-safe and must be completed before the server
+// starts.
+type Providers struct {
 	readers map[string][]RequestReader
 	writers map[string][]ResponseWriter
+	// contentTypes are keys of writers in order of registration.
+	contentTypes []string
 }
 
-func newProviders() *defaultProviders {
-	return &defaultProviders{
+// NewProviders allocates and returns a new empty Providers.
+func NewProviders() *Providers {
+	return &Providers{
 		readers: make(map[string][]RequestReader),
 		writers: make(map[string][]ResponseWriter),
 	}
 }
 
-func (p *defaultProviders) AddProvider(provider Provider) {
+// AddProvider adds the provider for all of its content types.
+func (p *Providers) AddProvider(provider Provider) {
 	for _, m := range provider.ContentTypes() {
 		p.AddRequestReader(m, provider)
 		p.AddResponseWriter(m, provider)
 	}
 }
 
-func (p *defaultProviders) AddRequestReader(mime string, reader ...RequestReader) {
+// AddRequestReader adds readers for the given media type.
+func (p *Providers) AddRequestReader(mime string, reader ...RequestReader) {
 	p.readers[mime] = append(p.readers[mime], reader...)
 }
 
-func (p *defaultProviders) AddResponseWriter(mime string, writer ...ResponseWriter) {
+// AddResponseWriter adds writers for the given media type.
+func (p *Providers) AddResponseWriter(mime string, writer ...ResponseWriter) {
+	if _, ok := p.writers[mime]; !ok {
+		p.contentTypes = append(p.contentTypes, mime)
+	}
 	p.writers[mime] = append(p.writers[mime], writer...)
 }
 
-func (p *defaultProviders) GetRequestReaders(mime string) []RequestReader {
-	return p.readers[mime]
+// ContentTypes returns media types of response writers in order of
+// registration.
+func (p *Providers) ContentTypes() []string {
+	return p.contentTypes
 }
 
-func (p *defaultProviders) GetResponseWriters(mime string) []ResponseWriter {
-	return p.writers[mime]
+// GetRequestReaders returns readers of the given media type.
+func (p *Providers) GetRequestReaders(mime string) []RequestReader {
+	if readers, ok := p.readers[mime]; ok {
+		return readers
+	}
+	if suffix := suffixType(mime); suffix != "" {
+		return p.readers[suffix]
+	}
+	return nil
+}
+
+// GetResponseWriters returns writers of the given media type.
+func (p *Providers) GetResponseWriters(mime string) []ResponseWriter {
+	if writers, ok := p.writers[mime]; ok {
+		return writers
+	}
+	if suffix := suffixType(mime); suffix != "" {
+		return p.writers[suffix]
+	}
+	return nil
+}
+
+// suffixType returns the media type of the structured syntax suffix, e.g.
+// "application/json" of "application/vnd.example+json".
+func suffixType(mime string) string {
+	slash := strings.IndexByte(mime, '/')
+	plus := strings.LastIndexByte(mime, '+')
+	if slash < 0 || plus < slash {
+		return ""
+	}
+	return mime[:slash+1] + mime[plus+1:]
 }
 
 type restrictedProviders struct {
@@ -100,6 +151,13 @@ func (p *restrictedProviders) GetRequestReaders(mime string) []RequestReader {
 	return nil
 }
 
+func (p *restrictedProviders) ContentTypes() []string {
+	if len(p.produces) == 0 {
+		return p.parent.ContentTypes()
+	}
+	return p.produces
+}
+
 func (p *restrictedProviders) GetResponseWriters(mime string) []ResponseWriter {
 	if len(p.produces) == 0 {
 		return p.parent.GetResponseWriters(mime)
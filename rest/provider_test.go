@@ -2,11 +2,11 @@ package rest
 
 import "testing"
 
-var _ providerMap = (*defaultProviders)(nil)
+var _ providerMap = (*Providers)(nil)
 var _ providerMap = (*restrictedProviders)(nil)
 
 func TestDefaultProviders(t *testing.T) {
-	p := newProviders()
+	p := NewProviders()
 
 	jsonProvider := &JSONProvider{}
 	p.AddProvider(jsonProvider)
@@ -46,7 +46,7 @@ func TestDefaultProviders(t *testing.T) {
 }
 
 func TestRestrictedProviders(t *testing.T) {
-	parent := newProviders()
+	parent := NewProviders()
 
 	parent.AddProvider(&JSONProvider{})
 
@@ -89,3 +89,35 @@ func TestRestrictedProviders(t *testing.T) {
 		t.Fatalf("providers does not support text/xml %#v", p)
 	}
 }
+
+func TestProvidersSuffixType(t *testing.T) {
+	p := NewProviders()
+	jsonProvider := &JSONProvider{}
+	p.AddProvider(jsonProvider)
+	if readers := p.GetRequestReaders("application/vnd.example+json"); len(readers) != 1 || readers[0] != jsonProvider {
+		t.Fatalf("unexpected readers %#v", readers)
+	}
+	if writers := p.GetResponseWriters("application/problem+json"); len(writers) != 1 || writers[0] != jsonProvider {
+		t.Fatalf("unexpected writers %#v", writers)
+	}
+	vendorWriter := &JSONProvider{}
+	p.AddResponseWriter("application/vnd.example+json", vendorWriter)
+	if writers := p.GetResponseWriters("application/vnd.example+json"); len(writers) != 1 || writers[0] != vendorWriter {
+		t.Fatalf("unexpected writers %#v", writers)
+	}
+	if writers := p.GetResponseWriters("text/csv"); len(writers) != 0 {
+		t.Fatalf("unexpected writers %#v", writers)
+	}
+}
+
+func TestBundleProviders(t *testing.T) {
+	bundle := NewBundle()
+	if writers := bundle.Providers.GetResponseWriters("application/xml"); len(writers) != 1 {
+		t.Fatalf("unexpected writers %#v", writers)
+	}
+	bundle = &Bundle{}
+	bundle.Initialize(nil)
+	if writers := bundle.Providers.GetResponseWriters("*/*"); len(writers) != 1 {
+		t.Fatalf("unexpected writers %#v", writers)
+	}
+}
@@ -0,0 +1,146 @@
+package rest
+
+import (
+	"context"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// rateLimitSweepInterval is how often idle clients are removed.
+const rateLimitSweepInterval = time.Minute
+
+// KeyFunc returns the client key of a request which is limited separately.
+type KeyFunc func(c context.Context) string
+
+// KeyByIP returns the IP address of the client. It is the address given by
+// trusted proxies when the forwarded filter is enabled.
+func KeyByIP(c context.Context) string {
+	addr := RequestFromContext(c).RemoteAddr
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+// KeyByPrincipal returns the name of the principal authenticated by
+// AuthFilter, or the IP address of unauthenticated clients.
+func KeyByPrincipal(c context.Context) string {
+	if p := PrincipalFrom(c); p != nil {
+		return "principal:" + p.Name()
+	}
+	return KeyByIP(c)
+}
+
+// KeyByHeader returns a KeyFunc using value of the given request header,
+// e.g. an API key, or the IP address if the header is absent.
+func KeyByHeader(name string) KeyFunc {
+	return func(c context.Context) string {
+		if v := RequestFromContext(c).Header.Get(name); v != "" {
+			return "header:" + v
+		}
+		return KeyByIP(c)
+	}
+}
+
+// RateLimiter is implemented by resources limiting their request rate.
+// The limit applies after all filters of the resource, so KeyByPrincipal
+// can be used with AuthFilter.
+type RateLimiter interface {
+	RateLimit() *RateLimit
+}
+
+// RateLimit limits requests per second of each client using token buckets.
+// Requests exceeding the limit are rejected with status 429 and header
+// Retry-After. It can be shared by multiple resources or added to a path
+// prefix using its Filter.
+type RateLimit struct {
+	rate  float64
+	burst float64
+	key   KeyFunc
+	now   func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimit allocates and returns a new RateLimit allowing rate requests
+// per second with bursts of the given size for each client key. Burst is
+// at least 1 and key is KeyByIP if nil.
+func NewRateLimit(rate float64, burst int, key KeyFunc) *RateLimit {
+	if burst < 1 {
+		burst = 1
+	}
+	if key == nil {
+		key = KeyByIP
+	}
+	return &RateLimit{
+		rate:    rate,
+		burst:   float64(burst),
+		key:     key,
+		now:     time.Now,
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// Allow takes a token of the given client key. It returns false and the
+// duration until a token is available if the limit is exceeded.
+func (l *RateLimit) Allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	l.sweep(now)
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+}
+
+// sweep removes buckets which have been refilled.
+func (l *RateLimit) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < rateLimitSweepInterval {
+		return
+	}
+	l.lastSweep = now
+	full := time.Duration(l.burst / l.rate * float64(time.Second))
+	for key, b := range l.buckets {
+		if now.Sub(b.last) >= full {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// Filter returns a Filter which rejects requests exceeding the limit.
+func (l *RateLimit) Filter() Filter {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c context.Context) (interface{}, error) {
+			ok, retryAfter := l.Allow(l.key(c))
+			if !ok {
+				seconds := int(math.Ceil(retryAfter.Seconds()))
+				if seconds < 1 {
+					seconds = 1
+				}
+				ResponseWriterFromContext(c).Header().Set("Retry-After", strconv.Itoa(seconds))
+				return nil, NewHTTPError(http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+			}
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+type limitedResource struct {
+	limit *RateLimit
+}
+
+func (*limitedResource) Path() string {
+	return "/limited"
+}
+
+func (*limitedResource) GET(c context.Context) (interface{}, error) {
+	return map[string]string{}, nil
+}
+
+func (r *limitedResource) RateLimit() *RateLimit {
+	return r.limit
+}
+
+func TestRateLimit(t *testing.T) {
+	now := time.Unix(0, 0)
+	limit := NewRateLimit(0.5, 2, KeyByHeader("X-API-Key"))
+	limit.now = func() time.Time {
+		return now
+	}
+	h := newTestHandler(&limitedResource{limit})
+	for i := 0; i < 2; i++ {
+		if w := serve(h, "GET", "/limited", nil, "X-API-Key", "a"); w.Code != http.StatusOK {
+			t.Fatalf("unexpected response %v", w.Code)
+		}
+	}
+	w := serve(h, "GET", "/limited", nil, "X-API-Key", "a")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "2" {
+		t.Fatalf("unexpected response %v %v", w.Code, w.Header())
+	}
+	// Other clients have their own limits.
+	if w = serve(h, "GET", "/limited", nil, "X-API-Key", "b"); w.Code != http.StatusOK {
+		t.Fatalf("unexpected response %v", w.Code)
+	}
+	now = now.Add(1500 * time.Millisecond)
+	if w = serve(h, "GET", "/limited", nil, "X-API-Key", "a"); w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "1" {
+		t.Fatalf("unexpected response %v %v", w.Code, w.Header())
+	}
+	now = now.Add(500 * time.Millisecond)
+	if w = serve(h, "GET", "/limited", nil, "X-API-Key", "a"); w.Code != http.StatusOK {
+		t.Fatalf("unexpected response %v", w.Code)
+	}
+	// Idle clients are removed.
+	now = now.Add(time.Hour)
+	limit.Allow("c")
+	if len(limit.buckets) != 1 {
+		t.Fatalf("unexpected buckets %v", limit.buckets)
+	}
+}
+
+func TestRateLimitKeys(t *testing.T) {
+	r, _ := http.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "192.0.2.1:1234"
+	c := context.WithValue(context.Background(), requestKey, r)
+	if k := KeyByPrincipal(c); k != "192.0.2.1" {
+		t.Fatalf("unexpected key %v", k)
+	}
+	if k := KeyByPrincipal(context.WithValue(c, principalKey, testPrincipal("alice"))); k != "principal:alice" {
+		t.Fatalf("unexpected key %v", k)
+	}
+}
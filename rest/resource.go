@@ -1,8 +1,8 @@
 package rest
 
 import (
-	"github.com/goburrow/gol"
 	"github.com/goburrow/gomelon/core"
+	"github.com/goburrow/gomelon/metrics/registry"
 )
 
 const (
@@ -11,71 +11,149 @@ const (
 
 // ResourceHandler implements core.ResourceHandler
 type ResourceHandler struct {
+	// MaxEntitySize is the maximum size in bytes of request entities read by
+	// Entity. Zero means no limit. It is DefaultMaxEntitySize by default.
+	MaxEntitySize int64
+
 	// providers contains all supported Provider.
-	providers *defaultProviders
+	providers *Providers
+	// filters are applied to resources by path prefix.
+	filters *Filters
 
 	serverHandler  core.ServerHandler
 	endpointLogger core.EndpointLogger
 
 	errorMapper ErrorMapper
 	validator   core.Validator
-	logger      gol.Logger
+	metrics     *registry.Registry
+	logger      core.Logger
 }
 
 var _ core.ResourceHandler = (*ResourceHandler)(nil)
 
 func NewResourceHandler(env *core.Environment) *ResourceHandler {
 	return &ResourceHandler{
-		providers:      newProviders(),
+		MaxEntitySize:  DefaultMaxEntitySize,
+		providers:      NewProviders(),
+		filters:        NewFilters(),
 		serverHandler:  env.Server.ServerHandler,
 		endpointLogger: env.Server,
 
-		// TODO: configuable error mapper
-		errorMapper: newErrorMapper(),
+		errorMapper: NewErrorMappers(),
 		validator:   env.Validator,
-		logger:      gol.GetLogger(resourceLoggerName),
+		metrics:     env.Metrics,
+		logger:      core.GetLogger(resourceLoggerName),
 	}
 }
 
 // Handle must only be called after all providers are added.
 func (h *ResourceHandler) HandleResource(v interface{}) {
+	h.handleResource(v, "", nil)
+}
+
+// handleResource handles v whose path is under prefix with filters of its
+// groups.
+func (h *ResourceHandler) handleResource(v interface{}, prefix string, filters []Filter) {
+	if g, ok := v.(*ResourceGroup); ok {
+		prefix = joinPath(prefix, g.prefix)
+		filters = append(filters[:len(filters):len(filters)], g.filters...)
+		for _, r := range g.resources {
+			h.handleResource(r, prefix, filters)
+		}
+		return
+	}
 	// Also supports additional Provider
 	if r, ok := v.(Provider); ok {
 		h.providers.AddProvider(r)
 	}
-	// FIXME: share Providers
 	if r, ok := v.(GET); ok {
-		h.handle(v, "GET", r.Path(), r.GET)
+		h.handle(v, "GET", joinPath(prefix, r.Path()), r.GET, filters)
 	}
 	if r, ok := v.(POST); ok {
-		h.handle(v, "POST", r.Path(), r.POST)
+		h.handle(v, "POST", joinPath(prefix, r.Path()), r.POST, filters)
 	}
 	if r, ok := v.(PUT); ok {
-		h.handle(v, "PUT", r.Path(), r.PUT)
+		h.handle(v, "PUT", joinPath(prefix, r.Path()), r.PUT, filters)
 	}
 	if r, ok := v.(DELETE); ok {
-		h.handle(v, "DELETE", r.Path(), r.DELETE)
+		h.handle(v, "DELETE", joinPath(prefix, r.Path()), r.DELETE, filters)
 	}
 	if r, ok := v.(HEAD); ok {
-		h.handle(v, "HEAD", r.Path(), r.HEAD)
+		h.handle(v, "HEAD", joinPath(prefix, r.Path()), r.HEAD, filters)
+	}
+	if r, ok := v.(PATCH); ok {
+		h.handle(v, "PATCH", joinPath(prefix, r.Path()), r.PATCH, filters)
+	}
+	if r, ok := v.(OPTIONS); ok {
+		h.handle(v, "OPTIONS", joinPath(prefix, r.Path()), r.OPTIONS, filters)
 	}
 }
 
+// Providers returns the registry of providers used by the resource handler.
+func (h *ResourceHandler) Providers() *Providers {
+	return h.providers
+}
+
+// SetProviders replaces the registry of providers. It must be called before
+// resources are added.
+func (h *ResourceHandler) SetProviders(providers *Providers) {
+	h.providers = providers
+}
+
+// Filters returns the registry of filters applied to resources by path
+// prefix.
+func (h *ResourceHandler) Filters() *Filters {
+	return h.filters
+}
+
+// SetFilters replaces the registry of filters. It must be called before
+// resources are added.
+func (h *ResourceHandler) SetFilters(filters *Filters) {
+	h.filters = filters
+}
+
+// AddFilter adds filters to resources under the given path prefix.
+func (h *ResourceHandler) AddFilter(prefix string, filter ...Filter) {
+	h.filters.Add(prefix, filter...)
+}
+
+// SetErrorMapper replaces the error mapper which writes errors returned by
+// resources.
+func (h *ResourceHandler) SetErrorMapper(mapper ErrorMapper) {
+	h.errorMapper = mapper
+}
+
 // AddProvider adds the given provider to the resource handler.
 func (h *ResourceHandler) AddProvider(provider Provider) {
 	h.providers.AddProvider(provider)
 }
 
-func (h *ResourceHandler) handle(v interface{}, method, path string, f contextFunc) {
+func (h *ResourceHandler) handle(v interface{}, method, path string, f HandlerFunc, groupFilters []Filter) {
 	providers := h.getProviders(v)
+	f = applyFilters(f, h.getFilters(v, path, groupFilters))
 	context := &contextHandler{providers: providers, handle: f, resourceHandler: h}
-	if r, hasMetrics := v.(Metrics); hasMetrics {
+	if r, hasMetrics := v.(Metrics); hasMetrics && h.metrics != nil {
 		context.setMetrics(method + "." + r.Metrics())
 	}
 	h.serverHandler.Handle(method, path, context)
 	h.endpointLogger.LogEndpoint(method, path, v)
 }
 
+// getFilters returns filters of the path prefix, filters of the groups,
+// then filters and rate limit of the resource.
+func (h *ResourceHandler) getFilters(v interface{}, path string, groupFilters []Filter) []Filter {
+	filters := append(h.filters.Match(path), groupFilters...)
+	if r, ok := v.(FilterResource); ok {
+		filters = append(filters, r.Filters()...)
+	}
+	if r, ok := v.(RateLimiter); ok {
+		if limit := r.RateLimit(); limit != nil {
+			filters = append(filters, limit.Filter())
+		}
+	}
+	return filters
+}
+
 func (h *ResourceHandler) getProviders(v interface{}) providerMap {
 	// If v does implement Consumes nor Produces interfaces, the provider
 	// is from this resource handler.
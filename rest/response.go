@@ -0,0 +1,83 @@
+package rest
+
+import (
+	"net/http"
+)
+
+// Response is returned by resources to set status code and headers of the
+// response. Entity is written by the provider negotiated for the request,
+// or no body is written if it is nil, e.g.
+//
+//	return rest.Created("/users/"+id, user), nil
+type Response struct {
+	Status int
+	Header http.Header
+	Entity interface{}
+}
+
+// NewResponse returns a response with the given status and entity.
+func NewResponse(status int, entity interface{}) *Response {
+	return &Response{
+		Status: status,
+		Header: make(http.Header),
+		Entity: entity,
+	}
+}
+
+// OK returns a response with status 200.
+func OK(entity interface{}) *Response {
+	return NewResponse(http.StatusOK, entity)
+}
+
+// Created returns a response with status 201 and Location header.
+func Created(location string, entity interface{}) *Response {
+	return NewResponse(http.StatusCreated, entity).SetHeader("Location", location)
+}
+
+// Accepted returns a response with status 202.
+func Accepted(entity interface{}) *Response {
+	return NewResponse(http.StatusAccepted, entity)
+}
+
+// NoContent returns a response with status 204.
+func NoContent() *Response {
+	return NewResponse(http.StatusNoContent, nil)
+}
+
+// SetHeader sets the response header and returns the response.
+func (r *Response) SetHeader(key, value string) *Response {
+	if r.Header == nil {
+		r.Header = make(http.Header)
+	}
+	r.Header.Set(key, value)
+	return r
+}
+
+// statusResponseWriter writes status code before the body written by
+// response writers.
+type statusResponseWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (w *statusResponseWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		w.ResponseWriter.WriteHeader(code)
+	}
+}
+
+func (w *statusResponseWriter) Write(b []byte) (int, error) {
+	w.WriteHeader(w.status)
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush writes the status code if it has not been written and flushes the
+// response.
+func (w *statusResponseWriter) Flush() {
+	w.WriteHeader(w.status)
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
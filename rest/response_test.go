@@ -0,0 +1,62 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+type responseResource struct {
+}
+
+func (*responseResource) Path() string {
+	return "/response"
+}
+
+func (*responseResource) POST(c context.Context) (interface{}, error) {
+	return Created("/response/1", map[string]int{"id": 1}).SetHeader("Cache-Control", "no-store"), nil
+}
+
+func (*responseResource) DELETE(c context.Context) (interface{}, error) {
+	return NoContent(), nil
+}
+
+func TestResponse(t *testing.T) {
+	h := newTestHandler(&responseResource{})
+	w := serve(h, "POST", "/response", nil)
+	if w.Code != http.StatusCreated || w.Header().Get("Location") != "/response/1" ||
+		w.Header().Get("Cache-Control") != "no-store" || w.Body.String() != "{\"id\":1}\n" {
+		t.Fatalf("unexpected response %v %v %s", w.Code, w.Header(), w.Body)
+	}
+	w = serve(h, "DELETE", "/response", nil)
+	if w.Code != http.StatusNoContent || w.Body.Len() != 0 {
+		t.Fatalf("unexpected response %v %s", w.Code, w.Body)
+	}
+}
+
+type patchResource struct {
+}
+
+func (*patchResource) Path() string {
+	return "/patch"
+}
+
+func (*patchResource) PATCH(c context.Context) (interface{}, error) {
+	return "patched", nil
+}
+
+func (*patchResource) OPTIONS(c context.Context) (interface{}, error) {
+	return NoContent().SetHeader("Allow", "PATCH, OPTIONS"), nil
+}
+
+func TestPatchOptions(t *testing.T) {
+	h := newTestHandler(&patchResource{})
+	w := serve(h, "PATCH", "/patch", nil)
+	if w.Code != http.StatusOK || w.Body.String() != "\"patched\"\n" {
+		t.Fatalf("unexpected response %v %s", w.Code, w.Body)
+	}
+	w = serve(h, "OPTIONS", "/patch", nil)
+	if w.Code != http.StatusNoContent || w.Header().Get("Allow") != "PATCH, OPTIONS" {
+		t.Fatalf("unexpected response %v %v", w.Code, w.Header())
+	}
+}
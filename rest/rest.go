@@ -19,11 +19,22 @@ Package rest provides RESTful support for applications.
   func (*MyResource) DELETE(c context.Context) (interface{}, error) {
   	return &myModel{}, nil
   }
+
+Paths can have parameters, e.g. "/my/path/:id", whose values are given by
+PathParam(c, "id").
+
+Filters run around resource methods, either for resources under a path
+prefix added to the bundle or for a resource implementing FilterResource.
+Related resources can be mounted under a common prefix with shared filters
+using Group.
+The context given to resource methods is derived from the context of the
+request, so it is done when the client disconnects. Values of the request
+context are also available.
 */
 package rest
 
 import (
-	"golang.org/x/net/context"
+	"context"
 )
 
 type GET interface {
@@ -51,6 +62,19 @@ type HEAD interface {
 	HEAD(context.Context) (interface{}, error)
 }
 
+// PATCH is implemented by resources supporting partial updates.
+type PATCH interface {
+	Path() string
+	PATCH(context.Context) (interface{}, error)
+}
+
+// OPTIONS is implemented by resources describing their communication
+// options, e.g. responding to CORS preflight requests.
+type OPTIONS interface {
+	Path() string
+	OPTIONS(context.Context) (interface{}, error)
+}
+
 // Consumes defines the MIME Types that a resource can accept.
 type Consumes interface {
 	Consumes() []string
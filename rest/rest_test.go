@@ -0,0 +1,114 @@
+package rest
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/goburrow/gomelon/core"
+	"github.com/goburrow/gomelon/server"
+	netcontext "golang.org/x/net/context"
+)
+
+// newTestHandler returns a HTTP handler serving the given resources with
+// the JSON provider.
+func newTestHandler(resources ...interface{}) http.Handler {
+	return newTestHandlerWith(nil, resources...)
+}
+
+// newTestHandlerWith is similar to newTestHandler but calls configure with
+// the resource handler before resources are added.
+func newTestHandlerWith(configure func(*ResourceHandler), resources ...interface{}) http.Handler {
+	env := core.NewEnvironment()
+	handler := server.NewHandler()
+	env.Server.ServerHandler = handler
+	h := NewResourceHandler(env)
+	h.AddProvider(&JSONProvider{})
+	if configure != nil {
+		configure(h)
+	}
+	for _, r := range resources {
+		h.HandleResource(r)
+	}
+	return handler
+}
+
+func serve(h http.Handler, method, target string, body io.Reader, header ...string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, body)
+	for i := 0; i+1 < len(header); i += 2 {
+		r.Header.Set(header[i], header[i+1])
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+type userResource struct {
+}
+
+func (*userResource) Path() string {
+	return "/users/:id"
+}
+
+func (*userResource) GET(c context.Context) (interface{}, error) {
+	return map[string]string{"id": PathParam(c, "id"), "none": PathParam(c, "none")}, nil
+}
+
+func TestPathParam(t *testing.T) {
+	h := newTestHandler(&userResource{})
+	w := serve(h, "GET", "/users/123", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status %v", w.Code)
+	}
+	if body := strings.TrimSpace(w.Body.String()); body != `{"id":"123","none":""}` {
+		t.Fatalf("unexpected body %s", body)
+	}
+}
+
+// prefixServerHandler is a ServerHandler mounted at a context path.
+type prefixServerHandler struct {
+	core.ServerHandler
+	prefix string
+}
+
+func (h *prefixServerHandler) PathPrefix() string {
+	return h.prefix
+}
+
+type requestContextKey struct{}
+
+// netContextResource is declared with golang.org/x/net/context.
+type netContextResource struct {
+}
+
+func (*netContextResource) Path() string {
+	return "/context"
+}
+
+func (*netContextResource) GET(c netcontext.Context) (interface{}, error) {
+	if c.Err() != nil {
+		return nil, c.Err()
+	}
+	return map[string]interface{}{"value": c.Value(requestContextKey{})}, nil
+}
+
+func TestRequestContext(t *testing.T) {
+	h := newTestHandler(&netContextResource{})
+	r := httptest.NewRequest("GET", "/context", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestContextKey{}, "x")))
+	if w.Code != http.StatusOK || w.Body.String() != `{"value":"x"}`+"\n" {
+		t.Fatalf("unexpected response %v %s", w.Code, w.Body)
+	}
+	// Request is cancelled, e.g. the client has gone away.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r.WithContext(ctx))
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("unexpected response %v %s", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,155 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultEventHeartbeat is the recommended interval of heartbeats which
+// keep idle event streams open through proxies.
+const DefaultEventHeartbeat = 15 * time.Second
+
+// Event is a server-sent event.
+type Event struct {
+	// ID sets the last event ID of the client.
+	ID string
+	// Event is the event type. Empty means "message".
+	Event string
+	// Data is written as is if it is a string or []byte, or encoded as
+	// JSON otherwise.
+	Data interface{}
+	// Retry is the reconnection time of the client.
+	Retry time.Duration
+}
+
+// EventStream sends server-sent events to a client. It is safe for
+// concurrent use.
+type EventStream struct {
+	mu  sync.Mutex
+	w   io.Writer
+	ctx context.Context
+}
+
+// Done is closed when the client disconnects.
+func (s *EventStream) Done() <-chan struct{} {
+	return s.ctx.Done()
+}
+
+// Send writes the event and flushes it to the client.
+func (s *EventStream) Send(e *Event) error {
+	var buf bytes.Buffer
+	if e.ID != "" {
+		fmt.Fprintf(&buf, "id: %s\n", singleLine(e.ID))
+	}
+	if e.Event != "" {
+		fmt.Fprintf(&buf, "event: %s\n", singleLine(e.Event))
+	}
+	if e.Retry > 0 {
+		fmt.Fprintf(&buf, "retry: %d\n", e.Retry/time.Millisecond)
+	}
+	var data string
+	switch v := e.Data.(type) {
+	case nil:
+	case string:
+		data = v
+	case []byte:
+		data = string(v)
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		data = string(b)
+	}
+	for _, line := range strings.Split(data, "\n") {
+		fmt.Fprintf(&buf, "data: %s\n", line)
+	}
+	buf.WriteByte('\n')
+	return s.write(buf.Bytes())
+}
+
+// Comment writes a comment line which is ignored by clients.
+func (s *EventStream) Comment(comment string) error {
+	return s.write([]byte(": " + singleLine(comment) + "\n\n"))
+}
+
+func (s *EventStream) write(b []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	select {
+	case <-s.ctx.Done():
+		return s.ctx.Err()
+	default:
+	}
+	_, err := s.w.Write(b)
+	return err
+}
+
+func singleLine(s string) string {
+	return strings.NewReplacer("\r", "", "\n", " ").Replace(s)
+}
+
+// Events returns a response streaming server-sent events sent by f, e.g.
+//
+//	func (*feed) GET(c context.Context) (interface{}, error) {
+//		return rest.Events(c, rest.DefaultEventHeartbeat, func(stream *rest.EventStream) error {
+//			for {
+//				select {
+//				case <-stream.Done():
+//					return nil
+//				case msg := <-updates:
+//					if err := stream.Send(&rest.Event{Data: msg}); err != nil {
+//						return err
+//					}
+//				}
+//			}
+//		}), nil
+//	}
+//
+// The stream ends when f returns. A heartbeat comment is sent at the given
+// interval unless it is not positive.
+func Events(c context.Context, heartbeat time.Duration, f func(stream *EventStream) error) *Response {
+	ctx := RequestFromContext(c).Context()
+	output := StreamingOutput(func(w io.Writer) error {
+		stream := &EventStream{w: w, ctx: ctx}
+		if heartbeat > 0 {
+			// The heartbeat must be stopped before the response is completed.
+			var wg sync.WaitGroup
+			done := make(chan struct{})
+			defer func() {
+				close(done)
+				wg.Wait()
+			}()
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ticker := time.NewTicker(heartbeat)
+				defer ticker.Stop()
+				for {
+					select {
+					case <-done:
+						return
+					case <-ctx.Done():
+						return
+					case <-ticker.C:
+						if stream.Comment("heartbeat") != nil {
+							return
+						}
+					}
+				}
+			}()
+		}
+		return f(stream)
+	})
+	return NewResponse(http.StatusOK, output).
+		SetHeader("Content-Type", "text/event-stream").
+		SetHeader("Cache-Control", "no-cache").
+		SetHeader("X-Accel-Buffering", "no")
+}
@@ -0,0 +1,55 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+type eventResource struct {
+}
+
+func (*eventResource) Path() string {
+	return "/events"
+}
+
+func (*eventResource) GET(c context.Context) (interface{}, error) {
+	return Events(c, time.Millisecond, func(stream *EventStream) error {
+		if err := stream.Send(&Event{ID: "1", Event: "user", Data: map[string]string{"name": "a"}}); err != nil {
+			return err
+		}
+		time.Sleep(10 * time.Millisecond)
+		return stream.Send(&Event{Data: "line 1\nline 2", Retry: time.Second})
+	}), nil
+}
+
+func TestEvents(t *testing.T) {
+	h := newTestHandler(&eventResource{})
+	w := serve(h, "GET", "/events", nil)
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("unexpected response %v %v", w.Code, w.Header())
+	}
+	body := w.Body.String()
+	if !strings.HasPrefix(body, "id: 1\nevent: user\ndata: {\"name\":\"a\"}\n\n") {
+		t.Fatalf("unexpected body %q", body)
+	}
+	if !strings.Contains(body, "\n\nretry: 1000\ndata: line 1\ndata: line 2\n\n") {
+		t.Fatalf("unexpected body %q", body)
+	}
+	if !strings.Contains(body, ": heartbeat\n\n") {
+		t.Fatalf("heartbeat expected %q", body)
+	}
+}
+
+func TestEventStreamDisconnected(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var buf strings.Builder
+	stream := &EventStream{w: &buf, ctx: ctx}
+	cancel()
+	<-stream.Done()
+	if err := stream.Send(&Event{Data: "x"}); err == nil {
+		t.Fatal("error expected")
+	}
+}
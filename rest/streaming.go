@@ -0,0 +1,103 @@
+package rest
+
+import (
+	"io"
+	"net/http"
+	"reflect"
+)
+
+const defaultStreamContentType = "application/octet-stream"
+
+// StreamingOutput is returned by resources to write the response body
+// progressively, e.g. large exports which should not be buffered in memory.
+// Data written is flushed to the client immediately. Content-Type is
+// "application/octet-stream" unless it is set in Response.Header.
+//
+// Resources can also return an io.Reader, which is copied to the response
+// and closed if it is an io.Closer, or a receive channel whose elements are
+// written by the negotiated provider until the channel is closed or the
+// client disconnects.
+type StreamingOutput func(w io.Writer) error
+
+// flushWriter flushes the response after every write.
+type flushWriter struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+}
+
+func newFlushWriter(w http.ResponseWriter) *flushWriter {
+	f, _ := w.(http.Flusher)
+	return &flushWriter{w: w, flusher: f}
+}
+
+func (w *flushWriter) Write(b []byte) (int, error) {
+	n, err := w.w.Write(b)
+	w.Flush()
+	return n, err
+}
+
+func (w *flushWriter) Flush() {
+	if w.flusher != nil {
+		w.flusher.Flush()
+	}
+}
+
+// writeStream writes the response if it is a stream and reports whether it
+// has been handled.
+func (h *contextHandler) writeStream(w http.ResponseWriter, r *http.Request, responseWriters []ResponseWriter, response interface{}) bool {
+	switch v := response.(type) {
+	case StreamingOutput:
+		setDefaultContentType(w, defaultStreamContentType)
+		fw := newFlushWriter(w)
+		fw.Flush()
+		if err := v(fw); err != nil {
+			h.resourceHandler.logger.Warn("streaming output: %v", err)
+		}
+		return true
+	case io.Reader:
+		if c, ok := v.(io.Closer); ok {
+			defer c.Close()
+		}
+		setDefaultContentType(w, defaultStreamContentType)
+		if _, err := io.Copy(newFlushWriter(w), v); err != nil {
+			h.resourceHandler.logger.Warn("streaming reader: %v", err)
+		}
+		return true
+	}
+	ch := reflect.ValueOf(response)
+	if ch.Kind() != reflect.Chan || ch.Type().ChanDir()&reflect.RecvDir == 0 {
+		return false
+	}
+	fw := newFlushWriter(w)
+	cases := []reflect.SelectCase{
+		{Dir: reflect.SelectRecv, Chan: ch},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(r.Context().Done())},
+	}
+	for {
+		chosen, elem, ok := reflect.Select(cases)
+		if chosen != 0 || !ok {
+			return true
+		}
+		if err := h.writeElement(fw, r, responseWriters, elem.Interface()); err != nil {
+			h.resourceHandler.logger.Warn("streaming channel: %v", err)
+			return true
+		}
+		fw.Flush()
+	}
+}
+
+// writeElement writes an element of a streamed channel.
+func (h *contextHandler) writeElement(w *flushWriter, r *http.Request, responseWriters []ResponseWriter, elem interface{}) error {
+	for i := len(responseWriters) - 1; i >= 0; i-- {
+		if responseWriters[i].IsWriteable(r, elem, w.w) {
+			return responseWriters[i].Write(r, elem, w.w)
+		}
+	}
+	return errNotAcceptable
+}
+
+func setDefaultContentType(w http.ResponseWriter, contentType string) {
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", contentType)
+	}
+}
@@ -0,0 +1,61 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type streamingResource struct {
+}
+
+func (*streamingResource) Path() string {
+	return "/stream/:type"
+}
+
+func (*streamingResource) GET(c context.Context) (interface{}, error) {
+	switch PathParam(c, "type") {
+	case "output":
+		return NewResponse(http.StatusAccepted, StreamingOutput(func(w io.Writer) error {
+			for i := 0; i < 3; i++ {
+				fmt.Fprintf(w, "%d,", i)
+			}
+			return nil
+		})).SetHeader("Content-Type", "text/csv"), nil
+	case "reader":
+		return ioutil.NopCloser(strings.NewReader("data")), nil
+	default:
+		ch := make(chan int, 3)
+		ch <- 1
+		ch <- 2
+		close(ch)
+		return (<-chan int)(ch), nil
+	}
+}
+
+func TestStreaming(t *testing.T) {
+	h := newTestHandler(&streamingResource{})
+	tests := []struct {
+		path        string
+		code        int
+		contentType string
+		body        string
+	}{
+		{"/stream/output", http.StatusAccepted, "text/csv", "0,1,2,"},
+		{"/stream/reader", http.StatusOK, "application/octet-stream", "data"},
+		{"/stream/channel", http.StatusOK, "application/json", "1\n2\n"},
+	}
+	for _, test := range tests {
+		w := serve(h, "GET", test.path, nil)
+		if w.Code != test.code || w.Header().Get("Content-Type") != test.contentType || w.Body.String() != test.body {
+			t.Fatalf("%s: unexpected response %v %v %q", test.path, w.Code, w.Header(), w.Body)
+		}
+		if !w.Flushed {
+			t.Fatalf("%s: response is not flushed", test.path)
+		}
+	}
+}
@@ -2,7 +2,9 @@ package rest
 
 import (
 	"encoding/xml"
+	"io"
 	"net/http"
+	"reflect"
 )
 
 var xmlMIMETypes = []string{
@@ -27,12 +29,21 @@ func (p *XMLProvider) Read(r *http.Request, v interface{}) error {
 	return decoder.Decode(v)
 }
 
+// IsWriteable returns false for maps which are not supported by
+// encoding/xml.
 func (p *XMLProvider) IsWriteable(r *http.Request, v interface{}, w http.ResponseWriter) bool {
-	return true
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t != nil && t.Kind() != reflect.Map
 }
 
 func (p *XMLProvider) Write(r *http.Request, v interface{}, w http.ResponseWriter) error {
-	w.Header().Set("Content-Type", "application/xml")
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
 	encoder := xml.NewEncoder(w)
 	return encoder.Encode(v)
 }
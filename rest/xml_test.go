@@ -0,0 +1,52 @@
+package rest
+
+import (
+	"context"
+	"encoding/xml"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type xmlUser struct {
+	XMLName struct{} `xml:"user"`
+	Name    string   `xml:"name"`
+}
+
+type xmlResource struct {
+}
+
+func (*xmlResource) Path() string {
+	return "/xml"
+}
+
+func (*xmlResource) POST(c context.Context) (interface{}, error) {
+	var u xmlUser
+	if err := Entity(c, &u); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+func (*xmlResource) GET(c context.Context) (interface{}, error) {
+	return map[string]string{"name": "a"}, nil
+}
+
+func TestXMLProvider(t *testing.T) {
+	h := newTestHandlerWith(func(h *ResourceHandler) {
+		h.AddProvider(&XMLProvider{})
+	}, &xmlResource{})
+	w := serve(h, "POST", "/xml", strings.NewReader("<user><name>a</name></user>"),
+		"Content-Type", "application/xml", "Accept", "application/xml")
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/xml; charset=utf-8" {
+		t.Fatalf("unexpected response %v %v", w.Code, w.Header())
+	}
+	if w.Body.String() != xml.Header+"<user><name>a</name></user>" {
+		t.Fatalf("unexpected body %s", w.Body)
+	}
+	// Maps can not be written as XML.
+	w = serve(h, "GET", "/xml", nil, "Accept", "text/xml")
+	if w.Code != http.StatusNotAcceptable {
+		t.Fatalf("unexpected response %v %s", w.Code, w.Body)
+	}
+}
@@ -1,9 +1,9 @@
 package gomelon
 
 import (
+	"flag"
 	"os"
 
-	"github.com/goburrow/gol"
 	"github.com/goburrow/gomelon/core"
 )
 
@@ -16,8 +16,14 @@ const (
 type ServerCommand struct {
 	EnvironmentCommand
 	Server core.Server
+	// DryRun builds the server and runs the application without starting
+	// it, which verifies the configuration and wiring of the application.
+	DryRun bool
 }
 
+var _ core.CommandFlags = (*ServerCommand)(nil)
+var _ core.CommandUsage = (*ServerCommand)(nil)
+
 // Name returns name of the ServerCommand.
 func (command *ServerCommand) Name() string {
 	return "server"
@@ -28,6 +34,22 @@ func (command *ServerCommand) Description() string {
 	return "runs the application as an HTTP server"
 }
 
+// Synopsis returns arguments of the ServerCommand.
+func (command *ServerCommand) Synopsis() string {
+	return "[flags] [config]"
+}
+
+// Examples returns examples of the ServerCommand.
+func (command *ServerCommand) Examples() []string {
+	return []string{"server config.yml", "server --dry-run --config config.yml"}
+}
+
+// AddFlags adds flags --config and --dry-run.
+func (command *ServerCommand) AddFlags(flags *flag.FlagSet) {
+	command.EnvironmentCommand.AddFlags(flags)
+	flags.BoolVar(&command.DryRun, "dry-run", false, "build the server without starting it")
+}
+
 // Run runs the command with the given bootstrap.
 func (command *ServerCommand) Run(bootstrap *core.Bootstrap) error {
 	var err error
@@ -37,34 +59,46 @@ func (command *ServerCommand) Run(bootstrap *core.Bootstrap) error {
 	}
 	// Always run Stop() method on managed objects.
 	defer command.Environment.SetStopped()
-	logger := gol.GetLogger(serverLoggerName)
+	logger := core.GetLogger(serverLoggerName)
 	// Build server
 	if command.Server, err = command.configuration.ServerFactory().Build(command.Environment); err != nil {
 		logger.Error("could not create server: %v", err)
-		return err
+		return &StartupError{Err: err}
 	}
 	// Now can start everything
 	printBanner(logger, command.Environment.Name)
 	// Run all bundles in bootstrap
 	if err = bootstrap.Run(command.Configuration, command.Environment); err != nil {
 		logger.Error("could not run bootstrap: %v", err)
-		return err
+		return &StartupError{Err: err}
 	}
 	// Run application
 	if err = bootstrap.Application.Run(command.Configuration, command.Environment); err != nil {
 		logger.Error("could not run application: %v", err)
-		return err
+		return &StartupError{Err: err}
+	}
+	if command.DryRun {
+		logger.Info("dry run, not starting server")
+		return nil
+	}
+	command.Environment.Lifecycle.SetShutdownHandler(func() {
+		command.Server.Stop()
+	})
+	defer handleSignals(command.Environment)()
+	if err = command.Environment.SetStarting(); err != nil {
+		logger.Error("could not start: %v", err)
+		return &StartupError{Err: err}
 	}
-	command.Environment.SetStarting()
 	defer command.Server.Stop()
 	if err = command.Server.Start(); err != nil {
 		logger.Error("could not start server: %v", err)
+		return &StartupError{Err: err}
 	}
-	return err
+	return nil
 }
 
 // printBanner prints application banner to the given logger
-func printBanner(logger gol.Logger, name string) {
+func printBanner(logger core.Logger, name string) {
 	banner := readBanner()
 	if banner != "" {
 		logger.Info("starting %s\n%s", name, banner)
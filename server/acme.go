@@ -0,0 +1,53 @@
+package server
+
+import (
+	"crypto/tls"
+	"errors"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// ACMEConfiguration is the configuration for obtaining certificates
+// automatically from an ACME certificate authority, e.g. Let's Encrypt.
+type ACMEConfiguration struct {
+	// Hosts are the domain names allowed to obtain certificates for.
+	Hosts []string
+	// CacheDir is the directory where certificates are stored.
+	// Certificates are only kept in memory if it is empty.
+	CacheDir string
+	// Email is the contact email address of the account.
+	Email string
+	// DirectoryURL is the ACME directory endpoint.
+	// Let's Encrypt production is used if it is empty.
+	DirectoryURL string
+}
+
+// acmeTLSConfig creates TLS configuration with certificates managed by
+// autocert.Manager. The tls-alpn-01 challenge is used so no extra HTTP
+// connector is required.
+func (connector *Connector) acmeTLSConfig() (*tls.Config, error) {
+	if len(connector.ACME.Hosts) == 0 {
+		return nil, errors.New("server: no hosts specified for ACME connector")
+	}
+	config, err := connector.baseTLSConfig()
+	if err != nil {
+		return nil, err
+	}
+	manager := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(connector.ACME.Hosts...),
+		Email:      connector.ACME.Email,
+	}
+	if connector.ACME.CacheDir != "" {
+		manager.Cache = autocert.DirCache(connector.ACME.CacheDir)
+	}
+	if connector.ACME.DirectoryURL != "" {
+		manager.Client = &acme.Client{
+			DirectoryURL: connector.ACME.DirectoryURL,
+		}
+	}
+	config.GetCertificate = manager.GetCertificate
+	config.NextProtos = append(config.NextProtos, acme.ALPNProto)
+	return config, nil
+}
@@ -0,0 +1,89 @@
+/*
+Package auth provides a filter which protects handlers with HTTP basic or
+bearer token authentication.
+*/
+package auth
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/goburrow/gomelon/server/filter"
+)
+
+const (
+	filterName = "auth"
+
+	bearerPrefix = "Bearer "
+)
+
+// Filter rejects requests without valid credentials with status 401.
+// Both basic authentication and bearer token are accepted if they are set.
+type Filter struct {
+	// Realm is sent to clients in WWW-Authenticate header.
+	Realm string
+
+	username string
+	password string
+	token    string
+}
+
+var _ filter.Filter = (*Filter)(nil)
+
+// NewBasicFilter allocates and returns a new Filter using basic
+// authentication.
+func NewBasicFilter(username, password string) *Filter {
+	return &Filter{
+		username: username,
+		password: password,
+	}
+}
+
+// NewTokenFilter allocates and returns a new Filter using bearer token.
+func NewTokenFilter(token string) *Filter {
+	return &Filter{
+		token: token,
+	}
+}
+
+// SetToken allows also bearer token for a basic authentication filter.
+func (f *Filter) SetToken(token string) {
+	f.token = token
+}
+
+func (f *Filter) Name() string {
+	return filterName
+}
+
+func (f *Filter) ServeHTTP(w http.ResponseWriter, r *http.Request, chain []filter.Filter) {
+	if !f.authenticate(r) {
+		if f.username != "" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="`+f.Realm+`"`)
+		} else {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="`+f.Realm+`"`)
+		}
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+	chain[0].ServeHTTP(w, r, chain[1:])
+}
+
+func (f *Filter) authenticate(r *http.Request) bool {
+	if f.username != "" {
+		if username, password, ok := r.BasicAuth(); ok {
+			return equal(username, f.username) && equal(password, f.password)
+		}
+	}
+	if f.token != "" {
+		if s := r.Header.Get("Authorization"); strings.HasPrefix(s, bearerPrefix) {
+			return equal(s[len(bearerPrefix):], f.token)
+		}
+	}
+	return false
+}
+
+// equal compares strings in constant time.
+func equal(s1, s2 string) bool {
+	return subtle.ConstantTimeCompare([]byte(s1), []byte(s2)) == 1
+}
@@ -0,0 +1,49 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/goburrow/gomelon/server/filter"
+)
+
+func TestFilter(t *testing.T) {
+	f := NewBasicFilter("admin", "secret")
+	f.SetToken("token")
+	builder := filter.NewChain()
+	builder.Add(f)
+	chain := builder.Build(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+
+	tests := []struct {
+		username string
+		password string
+		token    string
+		code     int
+	}{
+		{"admin", "secret", "", http.StatusOK},
+		{"", "", "token", http.StatusOK},
+		{"admin", "wrong", "", http.StatusUnauthorized},
+		{"", "", "wrong", http.StatusUnauthorized},
+		{"", "", "", http.StatusUnauthorized},
+	}
+	for _, test := range tests {
+		r, _ := http.NewRequest("GET", "/", nil)
+		if test.username != "" {
+			r.SetBasicAuth(test.username, test.password)
+		}
+		if test.token != "" {
+			r.Header.Set("Authorization", "Bearer "+test.token)
+		}
+		w := httptest.NewRecorder()
+		chain.ServeHTTP(w, r)
+		if w.Code != test.code {
+			t.Fatalf("unexpected code %v for %+v", w.Code, test)
+		}
+		if w.Code == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") == "" {
+			t.Fatalf("missing WWW-Authenticate header for %+v", test)
+		}
+	}
+}
@@ -2,9 +2,17 @@ package server
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/goburrow/gomelon/core"
+	"github.com/goburrow/gomelon/server/auth"
+	"github.com/goburrow/gomelon/server/cors"
 	"github.com/goburrow/gomelon/server/filter"
+	"github.com/goburrow/gomelon/server/forwarded"
+	"github.com/goburrow/gomelon/server/gzip"
+	"github.com/goburrow/gomelon/server/headers"
+	"github.com/goburrow/gomelon/server/limit"
+	"github.com/goburrow/gomelon/server/logcontext"
 	"github.com/goburrow/gomelon/server/recovery"
 	"github.com/goburrow/polytype"
 )
@@ -14,24 +22,254 @@ type RequestLogConfiguration struct {
 	polytype.Type
 }
 
+const (
+	defaultShutdownGracePeriod = 30 * time.Second
+	defaultReadHeaderTimeout   = 10 * time.Second
+	defaultIdleTimeout         = 60 * time.Second
+)
+
+// GzipConfiguration is the configuration of response compression.
+type GzipConfiguration struct {
+	Enabled bool
+	// MinimumEntitySize is the minimum size of responses to be compressed.
+	// Default is 256 bytes.
+	MinimumEntitySize int
+	// CompressedMimeTypes are content types to be compressed. All types are
+	// compressed if it is empty.
+	CompressedMimeTypes []string
+}
+
+// ForwardedConfiguration is the configuration of handling forwarded headers
+// from proxies.
+type ForwardedConfiguration struct {
+	Enabled bool
+	// TrustedProxies are IP addresses or CIDR ranges of proxies whose
+	// Forwarded and X-Forwarded-* headers are accepted. Only loopback
+	// addresses are trusted if it is empty.
+	TrustedProxies []string
+}
+
+// AdminAuthConfiguration is the configuration of admin authentication.
+// Admin handlers and tasks are protected when either basic authentication
+// or bearer token is set.
+type AdminAuthConfiguration struct {
+	Username string
+	Password string `secret:"true"`
+	Token    string `secret:"true"`
+}
+
+// CORSConfiguration is the configuration of cross-origin resource sharing.
+// It is enabled when AllowedOrigins is not empty.
+type CORSConfiguration struct {
+	// AllowedOrigins are origins allowed to make requests, "*" allows all.
+	AllowedOrigins []string
+	// AllowedMethods defaults to GET, HEAD and POST.
+	AllowedMethods []string
+	// AllowedHeaders defaults to Authorization and Content-Type.
+	AllowedHeaders   []string
+	AllowCredentials bool
+	// MaxAge is the number of seconds preflight responses can be cached.
+	MaxAge int
+}
+
+// SecurityHeadersConfiguration is the configuration of security headers
+// (X-Content-Type-Options, X-Frame-Options, Referrer-Policy and
+// Cache-Control) which are added to admin responses by default.
+type SecurityHeadersConfiguration struct {
+	Disabled bool
+	// Headers are added to the default headers or override them.
+	// An empty value removes the header.
+	Headers map[string]string
+}
+
 // commonFactory is the shared configuration of DefaultFactory and
 // SimpleFactory.
 type commonFactory struct {
 	RequestLog RequestLogConfiguration
+	// ShutdownGracePeriod is the maximum duration to wait for in-flight
+	// requests when the server is stopping, e.g. "30s".
+	ShutdownGracePeriod string
+	// Gzip compresses responses of the application.
+	Gzip GzipConfiguration
+	// MaxRequestBodySize is the maximum size in bytes of request bodies.
+	// Default is no limit.
+	MaxRequestBodySize int64
+	// Router is the name of the router for application handler,
+	// see RegisterRouter. Default is "goji".
+	Router string
+	// Forwarded rewrites client address, scheme and host of requests
+	// from trusted proxies.
+	Forwarded ForwardedConfiguration
+	// LogContextHeaders maps request headers to fields of logging context,
+	// e.g. {X-Request-Id: requestId}. See logging.WithContext.
+	LogContextHeaders map[string]string
+	// AdminAuth protects admin handlers and tasks.
+	AdminAuth AdminAuthConfiguration
+	// AdminCORS allows dashboards hosted elsewhere to call admin handlers.
+	AdminCORS CORSConfiguration
+	// AdminSecurityHeaders are headers added to admin responses.
+	AdminSecurityHeaders SecurityHeadersConfiguration
+
+	// ReadTimeout is the maximum duration for reading the entire request,
+	// including the body. Default is no timeout.
+	ReadTimeout string
+	// ReadHeaderTimeout is the amount of time allowed to read request
+	// headers. Default is 10s.
+	ReadHeaderTimeout string
+	// WriteTimeout is the maximum duration before timing out writes of the
+	// response. Default is no timeout.
+	WriteTimeout string
+	// IdleTimeout is the maximum amount of time to wait for the next request
+	// when keep-alives are enabled. Default is 60s.
+	IdleTimeout string
+
+	// GracefulRestart allows the server to be upgraded without dropping
+	// connections: on SIGUSR2, the executable is started again taking over
+	// the listening sockets and this process stops gracefully once the new
+	// one is listening.
+	GracefulRestart bool
+}
+
+// newServer creates a new server with common settings.
+func (f *commonFactory) newServer(env *core.Environment) (*Server, error) {
+	server := NewServer()
+	server.Metrics = env.Metrics
+	server.Listener = env.Server
+	server.Lifecycle = env.Lifecycle
+	durations := []struct {
+		name  string
+		value string
+		def   time.Duration
+		out   *time.Duration
+	}{
+		{"shutdown grace period", f.ShutdownGracePeriod, defaultShutdownGracePeriod, &server.ShutdownGracePeriod},
+		{"read timeout", f.ReadTimeout, 0, &server.ReadTimeout},
+		{"read header timeout", f.ReadHeaderTimeout, defaultReadHeaderTimeout, &server.ReadHeaderTimeout},
+		{"write timeout", f.WriteTimeout, 0, &server.WriteTimeout},
+		{"idle timeout", f.IdleTimeout, defaultIdleTimeout, &server.IdleTimeout},
+	}
+	for _, d := range durations {
+		*d.out = d.def
+		if d.value != "" {
+			v, err := time.ParseDuration(d.value)
+			if err != nil {
+				return nil, fmt.Errorf("server: invalid %s %v", d.name, err)
+			}
+			*d.out = v
+		}
+	}
+	server.GracefulRestart = f.GracefulRestart
+	return server, nil
 }
 
-// AddFilters adds request log and panic recovery to the filter chain
-// of the given handlers.
+// newApplicationHandler creates a handler with the configured router which
+// records metrics of all endpoints.
+// Admin handler always uses the default router.
+func (f *commonFactory) newApplicationHandler(env *core.Environment) (*Handler, error) {
+	router, err := newRouter(f.Router)
+	if err != nil {
+		return nil, err
+	}
+	handler := NewRouterHandler(router)
+	handler.Metrics = env.Metrics
+	return handler, nil
+}
+
+// AddFilters adds forwarded headers handling, logging context, request log,
+// panic recovery and request body limit to the filter chain of the given
+// handlers.
 func (f *commonFactory) AddFilters(env *core.Environment, handlers ...*Handler) error {
+	if f.Forwarded.Enabled {
+		forwardedFilter, err := forwarded.NewFilter(f.Forwarded.TrustedProxies)
+		if err != nil {
+			return err
+		}
+		for _, h := range handlers {
+			h.FilterChain.Add(forwardedFilter)
+		}
+	}
+	if len(f.LogContextHeaders) > 0 {
+		logContextFilter := logcontext.NewFilter(f.LogContextHeaders)
+		for _, h := range handlers {
+			h.FilterChain.Add(logContextFilter)
+		}
+	}
 	requestLogFilter, err := f.getRequestLog(env)
 	if err != nil {
 		return err
 	}
 	recoveryFilter := recovery.NewFilter()
+	recoveryFilter.Panics = env.Metrics.Counter("HTTP.Panics")
 	for _, h := range handlers {
 		h.FilterChain.Add(requestLogFilter)
 		h.FilterChain.Add(recoveryFilter)
 	}
+	if f.MaxRequestBodySize > 0 {
+		bodyFilter := limit.NewBodyFilter(f.MaxRequestBodySize)
+		for _, h := range handlers {
+			h.FilterChain.Add(bodyFilter)
+		}
+	}
+	return nil
+}
+
+// AddApplicationFilters adds filters which are only used by application
+// handlers.
+func (f *commonFactory) AddApplicationFilters(env *core.Environment, handlers ...*Handler) error {
+	if !f.Gzip.Enabled {
+		return nil
+	}
+	gzipFilter := gzip.NewFilter()
+	if f.Gzip.MinimumEntitySize > 0 {
+		gzipFilter.MinimumEntitySize = f.Gzip.MinimumEntitySize
+	}
+	gzipFilter.MIMETypes = f.Gzip.CompressedMimeTypes
+	for _, h := range handlers {
+		h.FilterChain.Add(gzipFilter)
+	}
+	return nil
+}
+
+// AddAdminFilters adds filters which are only used by admin handlers.
+func (f *commonFactory) AddAdminFilters(env *core.Environment, handlers ...*Handler) error {
+	var filters []filter.Filter
+	if !f.AdminSecurityHeaders.Disabled {
+		headersFilter := headers.NewSecurityFilter()
+		for name, value := range f.AdminSecurityHeaders.Headers {
+			headersFilter.Set(name, value)
+		}
+		filters = append(filters, headersFilter)
+	}
+	// Preflight requests do not have credentials so CORS must come before
+	// authentication.
+	if len(f.AdminCORS.AllowedOrigins) > 0 {
+		corsFilter := cors.NewFilter(f.AdminCORS.AllowedOrigins)
+		if len(f.AdminCORS.AllowedMethods) > 0 {
+			corsFilter.AllowedMethods = f.AdminCORS.AllowedMethods
+		}
+		if len(f.AdminCORS.AllowedHeaders) > 0 {
+			corsFilter.AllowedHeaders = f.AdminCORS.AllowedHeaders
+		}
+		corsFilter.AllowCredentials = f.AdminCORS.AllowCredentials
+		corsFilter.MaxAge = f.AdminCORS.MaxAge
+		filters = append(filters, corsFilter)
+	}
+	var authFilter *auth.Filter
+	if f.AdminAuth.Username != "" {
+		authFilter = auth.NewBasicFilter(f.AdminAuth.Username, f.AdminAuth.Password)
+		authFilter.SetToken(f.AdminAuth.Token)
+	} else if f.AdminAuth.Token != "" {
+		authFilter = auth.NewTokenFilter(f.AdminAuth.Token)
+	}
+	if authFilter != nil {
+		authFilter.Realm = "admin"
+		filters = append(filters, authFilter)
+	}
+	for _, h := range handlers {
+		for _, adminFilter := range filters {
+			h.FilterChain.Add(adminFilter)
+		}
+	}
 	return nil
 }
 
@@ -2,6 +2,7 @@ package server
 
 import (
 	"testing"
+	"time"
 
 	"github.com/goburrow/gomelon/core"
 )
@@ -15,4 +16,97 @@ func TestCommonFactory(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
+	factory.MaxRequestBodySize = 1024
+	handler = NewHandler()
+	if err = factory.AddFilters(env, handler); err != nil {
+		t.Fatal(err)
+	}
+	// Filter can be found by name
+	handler.FilterChain.Insert(&noRequestLog{}, "bodylimit")
+}
+
+func TestApplicationFilters(t *testing.T) {
+	env := core.NewEnvironment()
+	factory := commonFactory{}
+
+	handler := NewHandler()
+	if err := factory.AddApplicationFilters(env, handler); err != nil {
+		t.Fatal(err)
+	}
+	factory.Gzip.Enabled = true
+	if err := factory.AddApplicationFilters(env, handler); err != nil {
+		t.Fatal(err)
+	}
+	// Filter can be found by name
+	handler.FilterChain.Insert(&noRequestLog{}, "gzip")
+}
+
+func TestAdminFilters(t *testing.T) {
+	env := core.NewEnvironment()
+	factory := commonFactory{}
+
+	handler := NewHandler()
+	if err := factory.AddAdminFilters(env, handler); err != nil {
+		t.Fatal(err)
+	}
+	// Filter can be found by name
+	handler.FilterChain.Insert(&noRequestLog{}, "headers")
+
+	factory.AdminAuth.Token = "token"
+	factory.AdminCORS.AllowedOrigins = []string{"*"}
+	if err := factory.AddAdminFilters(env, handler); err != nil {
+		t.Fatal(err)
+	}
+	handler.FilterChain.Insert(&noRequestLog{}, "auth")
+	handler.FilterChain.Insert(&noRequestLog{}, "cors")
+}
+
+func TestShutdownGracePeriod(t *testing.T) {
+	factory := commonFactory{}
+	server, err := factory.newServer(core.NewEnvironment())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if server.ShutdownGracePeriod != defaultShutdownGracePeriod {
+		t.Fatalf("unexpected shutdown grace period %v", server.ShutdownGracePeriod)
+	}
+	factory.ShutdownGracePeriod = "5s"
+	server, err = factory.newServer(core.NewEnvironment())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if server.ShutdownGracePeriod != 5*time.Second {
+		t.Fatalf("unexpected shutdown grace period %v", server.ShutdownGracePeriod)
+	}
+	factory.ShutdownGracePeriod = "5"
+	_, err = factory.newServer(core.NewEnvironment())
+	if err == nil {
+		t.Fatal("error expected")
+	}
+}
+
+func TestServerTimeouts(t *testing.T) {
+	factory := commonFactory{
+		ReadTimeout:  "5s",
+		WriteTimeout: "10s",
+		IdleTimeout:  "1m",
+	}
+	server, err := factory.newServer(core.NewEnvironment())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if server.ReadTimeout != 5*time.Second || server.ReadHeaderTimeout != defaultReadHeaderTimeout ||
+		server.WriteTimeout != 10*time.Second || server.IdleTimeout != time.Minute {
+		t.Fatalf("unexpected timeouts %+v", server)
+	}
+	server.addConnectors(nil, []Connector{{Type: "http"}})
+	s := server.Connectors[0].server
+	if s.ReadTimeout != 5*time.Second || s.ReadHeaderTimeout != defaultReadHeaderTimeout ||
+		s.WriteTimeout != 10*time.Second || s.IdleTimeout != time.Minute {
+		t.Fatalf("unexpected connector timeouts %+v", s)
+	}
+	factory.ReadHeaderTimeout = "1"
+	if _, err = factory.newServer(core.NewEnvironment()); err == nil {
+		t.Fatal("error expected")
+	}
 }
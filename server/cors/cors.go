@@ -0,0 +1,116 @@
+/*
+Package cors provides a filter which allows browsers to make cross-origin
+requests.
+*/
+package cors
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/goburrow/gomelon/server/filter"
+)
+
+const (
+	filterName = "cors"
+
+	allowOriginHeader      = "Access-Control-Allow-Origin"
+	allowMethodsHeader     = "Access-Control-Allow-Methods"
+	allowHeadersHeader     = "Access-Control-Allow-Headers"
+	allowCredentialsHeader = "Access-Control-Allow-Credentials"
+	maxAgeHeader           = "Access-Control-Max-Age"
+	requestMethodHeader    = "Access-Control-Request-Method"
+)
+
+var (
+	defaultAllowedMethods = []string{"GET", "HEAD", "POST"}
+	defaultAllowedHeaders = []string{"Authorization", "Content-Type"}
+)
+
+// Filter adds CORS headers to responses of requests from allowed origins
+// and responds to preflight requests.
+type Filter struct {
+	// AllowedMethods are methods allowed in preflight requests.
+	// Default is GET, HEAD and POST.
+	AllowedMethods []string
+	// AllowedHeaders are request headers allowed in preflight requests.
+	// Default is Authorization and Content-Type.
+	AllowedHeaders []string
+	// AllowCredentials allows cookies and authorization headers to be sent.
+	AllowCredentials bool
+	// MaxAge is the number of seconds preflight responses can be cached.
+	MaxAge int
+
+	allowedOrigins []string
+	allowAll       bool
+}
+
+var _ filter.Filter = (*Filter)(nil)
+
+// NewFilter allocates and returns a new Filter for the given origins.
+// Origin "*" allows all origins.
+func NewFilter(allowedOrigins []string) *Filter {
+	f := &Filter{
+		AllowedMethods: defaultAllowedMethods,
+		AllowedHeaders: defaultAllowedHeaders,
+	}
+	for _, origin := range allowedOrigins {
+		if origin == "*" {
+			f.allowAll = true
+		} else {
+			f.allowedOrigins = append(f.allowedOrigins, origin)
+		}
+	}
+	return f
+}
+
+func (f *Filter) Name() string {
+	return filterName
+}
+
+func (f *Filter) ServeHTTP(w http.ResponseWriter, r *http.Request, chain []filter.Filter) {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		chain[0].ServeHTTP(w, r, chain[1:])
+		return
+	}
+	w.Header().Add("Vary", "Origin")
+	if !f.isAllowed(origin) {
+		chain[0].ServeHTTP(w, r, chain[1:])
+		return
+	}
+	if f.allowAll && !f.AllowCredentials {
+		w.Header().Set(allowOriginHeader, "*")
+	} else {
+		w.Header().Set(allowOriginHeader, origin)
+	}
+	if f.AllowCredentials {
+		w.Header().Set(allowCredentialsHeader, "true")
+	}
+	if r.Method == "OPTIONS" && r.Header.Get(requestMethodHeader) != "" {
+		// Preflight request
+		w.Header().Set(allowMethodsHeader, strings.Join(f.AllowedMethods, ", "))
+		if len(f.AllowedHeaders) > 0 {
+			w.Header().Set(allowHeadersHeader, strings.Join(f.AllowedHeaders, ", "))
+		}
+		if f.MaxAge > 0 {
+			w.Header().Set(maxAgeHeader, strconv.Itoa(f.MaxAge))
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	chain[0].ServeHTTP(w, r, chain[1:])
+}
+
+func (f *Filter) isAllowed(origin string) bool {
+	if f.allowAll {
+		return true
+	}
+	for _, o := range f.allowedOrigins {
+		if strings.EqualFold(o, origin) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+package cors
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/goburrow/gomelon/server/filter"
+)
+
+func newChain(f *Filter) http.Handler {
+	builder := filter.NewChain()
+	builder.Add(f)
+	return builder.Build(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+}
+
+func TestFilter(t *testing.T) {
+	chain := newChain(NewFilter([]string{"http://example.com"}))
+
+	tests := []struct {
+		method string
+		origin string
+		code   int
+		allow  string
+	}{
+		{"GET", "", http.StatusOK, ""},
+		{"GET", "http://example.com", http.StatusOK, "http://example.com"},
+		{"GET", "http://other.com", http.StatusOK, ""},
+		{"OPTIONS", "http://example.com", http.StatusNoContent, "http://example.com"},
+	}
+	for _, test := range tests {
+		r, _ := http.NewRequest(test.method, "/metrics", nil)
+		if test.origin != "" {
+			r.Header.Set("Origin", test.origin)
+		}
+		if test.method == "OPTIONS" {
+			r.Header.Set("Access-Control-Request-Method", "POST")
+		}
+		w := httptest.NewRecorder()
+		chain.ServeHTTP(w, r)
+		if w.Code != test.code || w.Header().Get(allowOriginHeader) != test.allow {
+			t.Fatalf("unexpected response %v %v for %+v", w.Code, w.Header(), test)
+		}
+		if test.method == "OPTIONS" && w.Header().Get(allowMethodsHeader) != "GET, HEAD, POST" {
+			t.Fatalf("unexpected response %v for %+v", w.Header(), test)
+		}
+	}
+}
+
+func TestFilterAllowAll(t *testing.T) {
+	f := NewFilter([]string{"*"})
+	chain := newChain(f)
+	r, _ := http.NewRequest("GET", "/", nil)
+	r.Header.Set("Origin", "http://example.com")
+	w := httptest.NewRecorder()
+	chain.ServeHTTP(w, r)
+	if w.Header().Get(allowOriginHeader) != "*" {
+		t.Fatalf("unexpected response %v", w.Header())
+	}
+	// Wildcard is not allowed with credentials.
+	f.AllowCredentials = true
+	w = httptest.NewRecorder()
+	chain.ServeHTTP(w, r)
+	if w.Header().Get(allowOriginHeader) != "http://example.com" || w.Header().Get(allowCredentialsHeader) != "true" {
+		t.Fatalf("unexpected response %v", w.Header())
+	}
+}
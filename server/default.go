@@ -1,7 +1,7 @@
 package server
 
 import (
-	"net/http"
+	"strings"
 
 	"github.com/goburrow/gomelon/core"
 )
@@ -11,33 +11,57 @@ import (
 type DefaultFactory struct {
 	commonFactory
 
+	// ApplicationContextPath is the path prefix of all application routes,
+	// e.g. "/api". Routes are served from root if it is empty.
+	ApplicationContextPath string
+
 	ApplicationConnectors []Connector `valid:"nonzero"`
 	AdminConnectors       []Connector `valid:"nonzero"`
 }
 
 var _ core.ServerFactory = (*DefaultFactory)(nil)
 
+func newDefaultFactory() interface{} {
+	return &DefaultFactory{}
+}
+
 func (factory *DefaultFactory) Build(env *core.Environment) (core.Server, error) {
 	// Application
-	appHandler := NewHandler()
-	appHandler.ServeMux.Use(func(h http.Handler) http.Handler {
-		return appHandler.FilterChain.Build(h)
-	})
+	appHandler, err := factory.commonFactory.newApplicationHandler(env)
+	if err != nil {
+		return nil, err
+	}
+	appHandler.pathPrefix = strings.TrimSuffix(factory.ApplicationContextPath, "/")
 	env.Server.ServerHandler = appHandler
 	env.Server.AddResourceHandler(newResourceHandler(appHandler, env.Server))
 
 	// Admin
 	adminHandler := NewHandler()
-	adminHandler.ServeMux.Use(func(h http.Handler) http.Handler {
-		return adminHandler.FilterChain.Build(h)
-	})
 	env.Admin.ServerHandler = adminHandler
 
 	if err := factory.commonFactory.AddFilters(env, appHandler, adminHandler); err != nil {
 		return nil, err
 	}
-	server := NewServer()
-	server.addConnectors(appHandler.ServeMux, factory.ApplicationConnectors)
-	server.addConnectors(adminHandler.ServeMux, factory.AdminConnectors)
+	if err := factory.commonFactory.AddApplicationFilters(env, appHandler); err != nil {
+		return nil, err
+	}
+	if err := factory.commonFactory.AddAdminFilters(env, adminHandler); err != nil {
+		return nil, err
+	}
+	server, err := factory.commonFactory.newServer(env)
+	if err != nil {
+		return nil, err
+	}
+	if appHandler.pathPrefix == "" {
+		server.addConnectors(appHandler, factory.ApplicationConnectors)
+	} else {
+		root := NewHandler()
+		mountHandlers(root.Router, appHandler)
+		server.addConnectors(root, factory.ApplicationConnectors)
+	}
+	for i := range factory.AdminConnectors {
+		factory.AdminConnectors[i].admin = true
+	}
+	server.addConnectors(adminHandler, factory.AdminConnectors)
 	return server, nil
 }
@@ -1,6 +1,8 @@
 package server
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/goburrow/gomelon/core"
@@ -24,3 +26,35 @@ func TestDefaultFactory(t *testing.T) {
 		t.Fatal("Admin.ServerHandler is nil")
 	}
 }
+
+func TestDefaultFactoryApplicationContextPath(t *testing.T) {
+	env := core.NewEnvironment()
+	factory := &DefaultFactory{
+		ApplicationContextPath: "/api/",
+		ApplicationConnectors:  []Connector{{Type: "http"}},
+	}
+	s, err := factory.Build(env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if env.Server.ServerHandler.PathPrefix() != "/api" {
+		t.Fatalf("unexpected path prefix %v", env.Server.ServerHandler.PathPrefix())
+	}
+	env.Server.ServerHandler.Handle("GET", "/test", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.Path))
+	}))
+	handler := s.(*Server).Connectors[0].server.Handler
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/api/test", nil)
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusOK || w.Body.String() != "/test" {
+		t.Fatalf("unexpected response %v %v", w.Code, w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	r, _ = http.NewRequest("GET", "/test", nil)
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("unexpected response %v", w.Code)
+	}
+}
@@ -8,7 +8,8 @@ import (
 )
 
 const (
-	chainEndName = "handler"
+	chainEndName   = "handler"
+	middlewareName = "middleware"
 )
 
 // Filter performs filtering tasks on the request and response to a HTTP resource.
@@ -90,3 +91,29 @@ func (f *chainEnd) Name() string {
 func (f *chainEnd) ServeHTTP(w http.ResponseWriter, r *http.Request, _ []Filter) {
 	f.handler.ServeHTTP(w, r)
 }
+
+// middleware is a Filter wrapping a http.Handler middleware.
+type middleware struct {
+	wrap func(http.Handler) http.Handler
+}
+
+// Middleware converts a function wrapping http.Handler, which is the common
+// form of middlewares, to a Filter.
+func Middleware(wrap func(http.Handler) http.Handler) Filter {
+	return &middleware{wrap}
+}
+
+func (f *middleware) Name() string {
+	return middlewareName
+}
+
+func (f *middleware) ServeHTTP(w http.ResponseWriter, r *http.Request, chain []Filter) {
+	f.wrap(chainHandler(chain)).ServeHTTP(w, r)
+}
+
+// chainHandler is a http.Handler which continues the filter chain.
+type chainHandler []Filter
+
+func (chain chainHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	chain[0].ServeHTTP(w, r, chain[1:])
+}
@@ -66,3 +66,22 @@ func TestInsertFilter(t *testing.T) {
 		t.Fatalf("unexpected body: %v", recorder.Body.String())
 	}
 }
+
+func TestMiddleware(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	builder := NewChain()
+	builder.Add(&test{"1"})
+	builder.Add(Middleware(func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("M"))
+			h.ServeHTTP(w, r)
+		})
+	}))
+	builder.Add(&test{"2"})
+
+	chain := builder.Build(http.HandlerFunc(end))
+	chain.ServeHTTP(recorder, nil)
+	if recorder.Body.String() != "1M2END" {
+		t.Fatalf("unexpected response %s", recorder.Body.String())
+	}
+}
@@ -0,0 +1,193 @@
+/*
+Package forwarded provides a filter which applies client information given by
+trusted proxies in Forwarded (RFC 7239) and X-Forwarded-* headers.
+*/
+package forwarded
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/goburrow/gomelon/server/filter"
+)
+
+const (
+	filterName = "forwarded"
+
+	forwarded        = "Forwarded"
+	xForwardedFor    = "X-Forwarded-For"
+	xForwardedProto  = "X-Forwarded-Proto"
+	xForwardedHost   = "X-Forwarded-Host"
+	unknownPort      = "0"
+	forwardedUnknown = "unknown"
+)
+
+// Filter rewrites RemoteAddr, URL scheme and Host of requests coming from
+// trusted proxies. The forwarded headers are removed once applied so
+// subsequent handlers only see the resolved values.
+type Filter struct {
+	trusted []*net.IPNet
+}
+
+var _ filter.Filter = (*Filter)(nil)
+
+// loopbackProxies are trusted when no proxies are given.
+var loopbackProxies = []string{"127.0.0.0/8", "::1"}
+
+// NewFilter allocates and returns a new Filter. Trusted proxies are IP
+// addresses or CIDR ranges. Only loopback addresses are trusted if it is
+// empty, so clients cannot spoof their addresses by sending forwarded
+// headers directly.
+func NewFilter(trustedProxies []string) (*Filter, error) {
+	if len(trustedProxies) == 0 {
+		trustedProxies = loopbackProxies
+	}
+	f := &Filter{}
+	for _, s := range trustedProxies {
+		if !strings.Contains(s, "/") {
+			if strings.Contains(s, ":") {
+				s += "/128"
+			} else {
+				s += "/32"
+			}
+		}
+		_, ipNet, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, fmt.Errorf("forwarded: invalid trusted proxy %v", err)
+		}
+		f.trusted = append(f.trusted, ipNet)
+	}
+	return f, nil
+}
+
+func (f *Filter) Name() string {
+	return filterName
+}
+
+func (f *Filter) ServeHTTP(w http.ResponseWriter, r *http.Request, chain []filter.Filter) {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err == nil && f.isTrusted(host) {
+		f.apply(r)
+	}
+	chain[0].ServeHTTP(w, r, chain[1:])
+}
+
+func (f *Filter) apply(r *http.Request) {
+	var info forwardedInfo
+	if values, ok := r.Header[forwarded]; ok {
+		info = parseForwarded(values)
+	} else {
+		info = parseXForwarded(r.Header)
+	}
+	r.Header.Del(forwarded)
+	r.Header.Del(xForwardedFor)
+	r.Header.Del(xForwardedProto)
+	r.Header.Del(xForwardedHost)
+
+	if client := f.client(info.addrs); client != "" {
+		r.RemoteAddr = client
+	}
+	if info.proto != "" {
+		r.URL.Scheme = strings.ToLower(info.proto)
+	}
+	if info.host != "" {
+		r.Host = info.host
+	}
+}
+
+// client returns the right-most untrusted address in the chain or the
+// left-most one if all of them are trusted.
+func (f *Filter) client(addrs []string) string {
+	for i := len(addrs) - 1; i >= 0; i-- {
+		host, _, err := net.SplitHostPort(addrs[i])
+		if err != nil {
+			return ""
+		}
+		if i == 0 || !f.isTrusted(host) {
+			return addrs[i]
+		}
+	}
+	return ""
+}
+
+func (f *Filter) isTrusted(host string) bool {
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, ipNet := range f.trusted {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+type forwardedInfo struct {
+	// addrs are client and proxy addresses in host:port format.
+	addrs []string
+	proto string
+	host  string
+}
+
+// parseForwarded parses Forwarded headers, e.g.
+//
+//	Forwarded: for=192.0.2.60;proto=http;by=203.0.113.43, for="[2001:db8::1]:4711"
+func parseForwarded(values []string) forwardedInfo {
+	var info forwardedInfo
+	for _, value := range values {
+		for _, element := range strings.Split(value, ",") {
+			for _, pair := range strings.Split(element, ";") {
+				idx := strings.Index(pair, "=")
+				if idx < 0 {
+					continue
+				}
+				name := strings.ToLower(strings.TrimSpace(pair[:idx]))
+				v := strings.Trim(strings.TrimSpace(pair[idx+1:]), `"`)
+				switch name {
+				case "for":
+					info.addrs = append(info.addrs, normalizeAddr(v))
+				case "proto":
+					info.proto = v
+				case "host":
+					info.host = v
+				}
+			}
+		}
+	}
+	return info
+}
+
+func parseXForwarded(header http.Header) forwardedInfo {
+	var info forwardedInfo
+	for _, value := range header[xForwardedFor] {
+		for _, addr := range strings.Split(value, ",") {
+			info.addrs = append(info.addrs, normalizeAddr(strings.TrimSpace(addr)))
+		}
+	}
+	info.proto = lastValue(header.Get(xForwardedProto))
+	info.host = lastValue(header.Get(xForwardedHost))
+	return info
+}
+
+// normalizeAddr returns address in host:port format.
+func normalizeAddr(addr string) string {
+	if _, _, err := net.SplitHostPort(addr); err == nil {
+		return addr
+	}
+	addr = strings.TrimSuffix(strings.TrimPrefix(addr, "["), "]")
+	if addr == "" || addr == forwardedUnknown {
+		return ""
+	}
+	return net.JoinHostPort(addr, unknownPort)
+}
+
+// lastValue returns the value added by the closest proxy.
+func lastValue(s string) string {
+	if idx := strings.LastIndex(s, ","); idx >= 0 {
+		s = s[idx+1:]
+	}
+	return strings.TrimSpace(s)
+}
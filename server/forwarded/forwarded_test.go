@@ -0,0 +1,89 @@
+package forwarded
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/goburrow/gomelon/server/filter"
+)
+
+func TestFilter(t *testing.T) {
+	f, err := NewFilter([]string{"10.0.0.0/8", "::1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		remoteAddr string
+		header     map[string]string
+		addr       string
+		scheme     string
+		host       string
+	}{
+		{"10.0.0.1:1234", map[string]string{"X-Forwarded-For": "1.2.3.4, 10.0.0.2", "X-Forwarded-Proto": "https", "X-Forwarded-Host": "example.com"},
+			"1.2.3.4:0", "https", "example.com"},
+		// Spoofed address on the left is ignored
+		{"10.0.0.1:1234", map[string]string{"X-Forwarded-For": "5.6.7.8, 1.2.3.4"},
+			"1.2.3.4:0", "", "localhost"},
+		{"[::1]:1234", map[string]string{"Forwarded": `for="[2001:db8::1]:4711";proto=HTTPS;host=example.com`},
+			"[2001:db8::1]:4711", "https", "example.com"},
+		// Untrusted proxy
+		{"4.3.2.1:1234", map[string]string{"X-Forwarded-For": "1.2.3.4", "X-Forwarded-Proto": "https"},
+			"4.3.2.1:1234", "", "localhost"},
+	}
+	for _, test := range tests {
+		builder := filter.NewChain()
+		builder.Add(f)
+		var r *http.Request
+		chain := builder.Build(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			r = req
+		}))
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.Host = "localhost"
+		req.RemoteAddr = test.remoteAddr
+		for k, v := range test.header {
+			req.Header.Set(k, v)
+		}
+		chain.ServeHTTP(httptest.NewRecorder(), req)
+		if r.RemoteAddr != test.addr || r.URL.Scheme != test.scheme || r.Host != test.host {
+			t.Fatalf("unexpected request %v %v %v for %+v", r.RemoteAddr, r.URL.Scheme, r.Host, test)
+		}
+	}
+}
+
+func TestFilterDefaultTrustedProxies(t *testing.T) {
+	f, err := NewFilter(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		remoteAddr string
+		addr       string
+	}{
+		// Headers sent directly by clients are ignored.
+		{"1.2.3.4:1234", "1.2.3.4:1234"},
+		{"127.0.0.1:1234", "5.6.7.8:0"},
+		{"[::1]:1234", "5.6.7.8:0"},
+	}
+	for _, test := range tests {
+		builder := filter.NewChain()
+		builder.Add(f)
+		var r *http.Request
+		chain := builder.Build(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			r = req
+		}))
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.RemoteAddr = test.remoteAddr
+		req.Header.Set("X-Forwarded-For", "5.6.7.8")
+		chain.ServeHTTP(httptest.NewRecorder(), req)
+		if r.RemoteAddr != test.addr {
+			t.Fatalf("unexpected address %v for %+v", r.RemoteAddr, test)
+		}
+	}
+}
+
+func TestInvalidTrustedProxy(t *testing.T) {
+	if _, err := NewFilter([]string{"10.0.0.0/99"}); err == nil {
+		t.Fatal("error expected")
+	}
+}
@@ -0,0 +1,203 @@
+/*
+Package gzip provides a filter which compresses HTTP responses.
+*/
+package gzip
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/goburrow/gomelon/server/filter"
+)
+
+const (
+	filterName = "gzip"
+
+	// DefaultMinimumEntitySize is the default minimum size of responses to
+	// be compressed.
+	DefaultMinimumEntitySize = 256
+)
+
+// Filter compresses responses if clients accept gzip encoding.
+type Filter struct {
+	// MinimumEntitySize is the minimum size in bytes of responses to be
+	// compressed.
+	MinimumEntitySize int
+	// MIMETypes are the content types to be compressed. All types are
+	// compressed if it is empty.
+	MIMETypes []string
+}
+
+var _ filter.Filter = (*Filter)(nil)
+
+// NewFilter allocates and returns a new Filter.
+func NewFilter() *Filter {
+	return &Filter{
+		MinimumEntitySize: DefaultMinimumEntitySize,
+	}
+}
+
+func (f *Filter) Name() string {
+	return filterName
+}
+
+func (f *Filter) ServeHTTP(w http.ResponseWriter, r *http.Request, chain []filter.Filter) {
+	if r.Method == "HEAD" || !acceptsGzip(r) {
+		chain[0].ServeHTTP(w, r, chain[1:])
+		return
+	}
+	w.Header().Add("Vary", "Accept-Encoding")
+	gw := &responseWriter{writer: w, filter: f}
+	defer gw.close()
+	chain[0].ServeHTTP(gw, r, chain[1:])
+}
+
+// isCompressible checks if the content type is allowed to be compressed.
+func (f *Filter) isCompressible(contentType string) bool {
+	if len(f.MIMETypes) == 0 {
+		return true
+	}
+	if idx := strings.IndexByte(contentType, ';'); idx >= 0 {
+		contentType = contentType[:idx]
+	}
+	contentType = strings.TrimSpace(contentType)
+	for _, t := range f.MIMETypes {
+		if strings.EqualFold(t, contentType) {
+			return true
+		}
+	}
+	return false
+}
+
+func acceptsGzip(r *http.Request) bool {
+	for _, encoding := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		encoding = strings.TrimSpace(encoding)
+		if idx := strings.IndexByte(encoding, ';'); idx >= 0 {
+			// Ignore gzip;q=0
+			if strings.TrimSpace(encoding[idx+1:]) == "q=0" {
+				continue
+			}
+			encoding = strings.TrimSpace(encoding[:idx])
+		}
+		if encoding == "gzip" || encoding == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// responseWriter buffers response until it reaches minimum size to decide
+// whether the response is compressed.
+type responseWriter struct {
+	writer http.ResponseWriter
+	filter *Filter
+
+	status  int
+	buf     bytes.Buffer
+	decided bool
+	gzip    *gzip.Writer
+}
+
+func (w *responseWriter) Header() http.Header {
+	return w.writer.Header()
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	if w.decided || w.status != 0 {
+		return
+	}
+	w.status = status
+	// No body is allowed for these responses.
+	if status < 200 || status == http.StatusNoContent || status == http.StatusNotModified {
+		w.decide(false)
+	}
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if !w.decided {
+		if w.buf.Len()+len(b) < w.filter.MinimumEntitySize {
+			return w.buf.Write(b)
+		}
+		w.buf.Write(b)
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+	if w.gzip != nil {
+		return w.gzip.Write(b)
+	}
+	return w.writer.Write(b)
+}
+
+// decide writes the response header and buffered data.
+func (w *responseWriter) decide(compress bool) error {
+	w.decided = true
+	header := w.writer.Header()
+	if compress {
+		if header.Get("Content-Encoding") != "" {
+			compress = false
+		} else {
+			contentType := header.Get("Content-Type")
+			if contentType == "" {
+				contentType = http.DetectContentType(w.buf.Bytes())
+				header.Set("Content-Type", contentType)
+			}
+			compress = w.filter.isCompressible(contentType)
+		}
+	}
+	if compress {
+		header.Set("Content-Encoding", "gzip")
+		header.Del("Content-Length")
+		w.gzip = gzip.NewWriter(w.writer)
+	}
+	if w.status != 0 {
+		w.writer.WriteHeader(w.status)
+	}
+	if w.buf.Len() == 0 {
+		return nil
+	}
+	var err error
+	if w.gzip != nil {
+		_, err = w.gzip.Write(w.buf.Bytes())
+	} else {
+		_, err = w.writer.Write(w.buf.Bytes())
+	}
+	w.buf.Reset()
+	return err
+}
+
+// close flushes all pending data.
+func (w *responseWriter) close() {
+	if !w.decided {
+		// Response is smaller than minimum size.
+		w.decide(false)
+	}
+	if w.gzip != nil {
+		w.gzip.Close()
+	}
+}
+
+func (w *responseWriter) Flush() {
+	if !w.decided {
+		w.decide(w.buf.Len() > 0)
+	}
+	if w.gzip != nil {
+		w.gzip.Flush()
+	}
+	if flusher, ok := w.writer.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if hijacker, ok := w.writer.(http.Hijacker); ok {
+		return hijacker.Hijack()
+	}
+	return nil, nil, errors.New("gzip: http.Hijack is not implemented")
+}
@@ -0,0 +1,89 @@
+package gzip
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/goburrow/gomelon/server/filter"
+)
+
+func serve(f *Filter, h http.HandlerFunc, acceptEncoding string) *httptest.ResponseRecorder {
+	builder := filter.NewChain()
+	builder.Add(f)
+	chain := builder.Build(h)
+
+	r, _ := http.NewRequest("GET", "/", nil)
+	r.Header.Set("Accept-Encoding", acceptEncoding)
+	w := httptest.NewRecorder()
+	chain.ServeHTTP(w, r)
+	return w
+}
+
+func TestCompress(t *testing.T) {
+	body := strings.Repeat("gomelon", 100)
+	h := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(body[:10]))
+		w.Write([]byte(body[10:]))
+	}
+	w := serve(NewFilter(), h, "deflate, gzip")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("unexpected code %v", w.Code)
+	}
+	if w.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("unexpected header %v", w.Header())
+	}
+	r, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != body {
+		t.Fatalf("unexpected body %s", b)
+	}
+}
+
+func TestNoCompress(t *testing.T) {
+	body := strings.Repeat("gomelon", 100)
+	h := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte(body))
+	}
+	f := NewFilter()
+	f.MIMETypes = []string{"text/plain", "application/json"}
+	tests := []struct {
+		filter         *Filter
+		h              http.HandlerFunc
+		acceptEncoding string
+	}{
+		// Not accepted
+		{NewFilter(), h, ""},
+		{NewFilter(), h, "gzip;q=0"},
+		// Not allowed content type
+		{f, h, "gzip"},
+		// Too small
+		{NewFilter(), func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("gomelon"))
+		}, "gzip"},
+	}
+	for _, test := range tests {
+		w := serve(test.filter, test.h, test.acceptEncoding)
+		if w.Header().Get("Content-Encoding") != "" {
+			t.Fatalf("unexpected header %v", w.Header())
+		}
+		if w.Code != http.StatusOK {
+			t.Fatalf("unexpected code %v", w.Code)
+		}
+		if !strings.HasPrefix(body, w.Body.String()) {
+			t.Fatalf("unexpected body %s", w.Body.String())
+		}
+	}
+}
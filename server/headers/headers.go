@@ -0,0 +1,62 @@
+/*
+Package headers provides a filter which adds standard security headers to
+responses.
+*/
+package headers
+
+import (
+	"net/http"
+
+	"github.com/goburrow/gomelon/server/filter"
+)
+
+const (
+	filterName = "headers"
+)
+
+// Filter sets headers to all responses before passing requests to the next
+// filter, so that handlers are still able to override them.
+type Filter struct {
+	headers map[string]string
+}
+
+var _ filter.Filter = (*Filter)(nil)
+
+// NewFilter allocates and returns a new Filter with the given headers.
+func NewFilter(headers map[string]string) *Filter {
+	return &Filter{
+		headers: headers,
+	}
+}
+
+// NewSecurityFilter allocates and returns a new Filter with headers which
+// disallow content sniffing, framing, referrer and caching.
+func NewSecurityFilter() *Filter {
+	return NewFilter(map[string]string{
+		"X-Content-Type-Options": "nosniff",
+		"X-Frame-Options":        "DENY",
+		"Referrer-Policy":        "no-referrer",
+		"Cache-Control":          "must-revalidate,no-cache,no-store",
+	})
+}
+
+// Set adds or overrides the header. An empty value removes the header.
+func (f *Filter) Set(name, value string) {
+	if value == "" {
+		delete(f.headers, name)
+	} else {
+		f.headers[name] = value
+	}
+}
+
+func (f *Filter) Name() string {
+	return filterName
+}
+
+func (f *Filter) ServeHTTP(w http.ResponseWriter, r *http.Request, chain []filter.Filter) {
+	header := w.Header()
+	for name, value := range f.headers {
+		header.Set(name, value)
+	}
+	chain[0].ServeHTTP(w, r, chain[1:])
+}
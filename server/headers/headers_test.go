@@ -0,0 +1,36 @@
+package headers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/goburrow/gomelon/server/filter"
+)
+
+func TestSecurityFilter(t *testing.T) {
+	f := NewSecurityFilter()
+	f.Set("X-Frame-Options", "SAMEORIGIN")
+	f.Set("Referrer-Policy", "")
+	builder := filter.NewChain()
+	builder.Add(f)
+	chain := builder.Build(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Write([]byte("ok"))
+	}))
+
+	r, _ := http.NewRequest("GET", "/", nil)
+	w := httptest.NewRecorder()
+	chain.ServeHTTP(w, r)
+	expected := map[string]string{
+		"X-Content-Type-Options": "nosniff",
+		"X-Frame-Options":        "SAMEORIGIN",
+		"Referrer-Policy":        "",
+		"Cache-Control":          "max-age=60",
+	}
+	for name, value := range expected {
+		if w.Header().Get(name) != value {
+			t.Fatalf("unexpected header %s: %q", name, w.Header().Get(name))
+		}
+	}
+}
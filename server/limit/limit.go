@@ -0,0 +1,110 @@
+/*
+Package limit provides filters which limit resources used by HTTP requests.
+*/
+package limit
+
+import (
+	"net/http"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/goburrow/gomelon/server/filter"
+)
+
+const (
+	bodyFilterName        = "bodylimit"
+	concurrencyFilterName = "concurrencylimit"
+
+	defaultRetryAfter = 1
+)
+
+// BodyFilter limits size of request bodies. Requests with larger
+// Content-Length are rejected with status 413. For requests with unknown
+// length, reading more than the limit from the body returns an error of
+// type *http.MaxBytesError.
+type BodyFilter struct {
+	maxSize int64
+}
+
+var _ filter.Filter = (*BodyFilter)(nil)
+
+// NewBodyFilter allocates and returns a new BodyFilter.
+func NewBodyFilter(maxSize int64) *BodyFilter {
+	return &BodyFilter{maxSize: maxSize}
+}
+
+func (f *BodyFilter) Name() string {
+	return bodyFilterName
+}
+
+func (f *BodyFilter) ServeHTTP(w http.ResponseWriter, r *http.Request, chain []filter.Filter) {
+	if r.ContentLength > f.maxSize {
+		http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+		return
+	}
+	if r.Body != nil {
+		r.Body = http.MaxBytesReader(w, r.Body, f.maxSize)
+	}
+	chain[0].ServeHTTP(w, r, chain[1:])
+}
+
+// ConcurrencyFilter limits number of in-flight requests. When all slots are
+// taken, requests wait in a queue of the given size. Requests are rejected
+// with status 503 and a Retry-After header when the queue is also full.
+type ConcurrencyFilter struct {
+	// RetryAfter is the number of seconds clients are suggested to wait
+	// before retrying. Default is 1.
+	RetryAfter int
+
+	slots    chan struct{}
+	maxQueue int64
+	queued   int64
+}
+
+var _ filter.Filter = (*ConcurrencyFilter)(nil)
+
+// NewConcurrencyFilter allocates and returns a new ConcurrencyFilter.
+func NewConcurrencyFilter(maxRequests, maxQueue int) *ConcurrencyFilter {
+	return &ConcurrencyFilter{
+		RetryAfter: defaultRetryAfter,
+		slots:      make(chan struct{}, maxRequests),
+		maxQueue:   int64(maxQueue),
+	}
+}
+
+func (f *ConcurrencyFilter) Name() string {
+	return concurrencyFilterName
+}
+
+func (f *ConcurrencyFilter) ServeHTTP(w http.ResponseWriter, r *http.Request, chain []filter.Filter) {
+	if !f.acquire(r) {
+		w.Header().Set("Retry-After", strconv.Itoa(f.RetryAfter))
+		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		return
+	}
+	defer f.release()
+	chain[0].ServeHTTP(w, r, chain[1:])
+}
+
+func (f *ConcurrencyFilter) acquire(r *http.Request) bool {
+	select {
+	case f.slots <- struct{}{}:
+		return true
+	default:
+	}
+	if atomic.AddInt64(&f.queued, 1) > f.maxQueue {
+		atomic.AddInt64(&f.queued, -1)
+		return false
+	}
+	defer atomic.AddInt64(&f.queued, -1)
+	select {
+	case f.slots <- struct{}{}:
+		return true
+	case <-r.Context().Done():
+		return false
+	}
+}
+
+func (f *ConcurrencyFilter) release() {
+	<-f.slots
+}
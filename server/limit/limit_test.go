@@ -0,0 +1,90 @@
+package limit
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/goburrow/gomelon/server/filter"
+)
+
+func TestBodyFilter(t *testing.T) {
+	builder := filter.NewChain()
+	builder.Add(NewBodyFilter(5))
+	chain := builder.Build(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := ioutil.ReadAll(r.Body)
+		var maxBytesError *http.MaxBytesError
+		if errors.As(err, &maxBytesError) {
+			http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+			return
+		}
+		w.Write(b)
+	}))
+
+	tests := []struct {
+		body   string
+		length int64
+		code   int
+	}{
+		{"12345", 5, http.StatusOK},
+		{"123456", 6, http.StatusRequestEntityTooLarge},
+		// Unknown length
+		{"123456", -1, http.StatusRequestEntityTooLarge},
+	}
+	for _, test := range tests {
+		r, _ := http.NewRequest("POST", "/", strings.NewReader(test.body))
+		r.ContentLength = test.length
+		w := httptest.NewRecorder()
+		chain.ServeHTTP(w, r)
+		if w.Code != test.code {
+			t.Fatalf("unexpected code %v for %+v", w.Code, test)
+		}
+	}
+}
+
+func TestConcurrencyFilter(t *testing.T) {
+	started := make(chan struct{})
+	done := make(chan struct{})
+	limit := NewConcurrencyFilter(1, 1)
+	builder := filter.NewChain()
+	builder.Add(limit)
+	chain := builder.Build(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-done
+	}))
+
+	codes := make(chan int, 2)
+	serve := func() {
+		r, _ := http.NewRequest("GET", "/", nil)
+		w := httptest.NewRecorder()
+		chain.ServeHTTP(w, r)
+		codes <- w.Code
+	}
+	// First request is processing
+	go serve()
+	<-started
+	// Second request is queued
+	go serve()
+	for atomic.LoadInt64(&limit.queued) != 1 {
+		runtime.Gosched()
+	}
+	// Third request is rejected
+	r, _ := http.NewRequest("GET", "/", nil)
+	w := httptest.NewRecorder()
+	chain.ServeHTTP(w, r)
+	if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") != "1" {
+		t.Fatalf("unexpected response %v %v", w.Code, w.Header())
+	}
+	close(done)
+	<-started
+	for i := 0; i < 2; i++ {
+		if code := <-codes; code != http.StatusOK {
+			t.Fatalf("unexpected code %v", code)
+		}
+	}
+}
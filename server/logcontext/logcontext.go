@@ -0,0 +1,52 @@
+/*
+Package logcontext provides a filter which adds request headers to the
+logging context of requests.
+*/
+package logcontext
+
+import (
+	"net/http"
+
+	"github.com/goburrow/gomelon/logging"
+	"github.com/goburrow/gomelon/server/filter"
+)
+
+const (
+	filterName = "logcontext"
+)
+
+// Filter copies values of request headers to logging fields, so that
+// loggers returned by logging.WithContext(r.Context(), logger) include them.
+type Filter struct {
+	headers map[string]string
+}
+
+var _ filter.Filter = (*Filter)(nil)
+
+// NewFilter allocates and returns a new Filter with the given mapping from
+// header names to field names, e.g. {"X-Request-Id": "requestId"}.
+func NewFilter(headers map[string]string) *Filter {
+	return &Filter{
+		headers: headers,
+	}
+}
+
+func (f *Filter) Name() string {
+	return filterName
+}
+
+func (f *Filter) ServeHTTP(w http.ResponseWriter, r *http.Request, chain []filter.Filter) {
+	var fields logging.Fields
+	for header, field := range f.headers {
+		if value := r.Header.Get(header); value != "" {
+			if fields == nil {
+				fields = make(logging.Fields, len(f.headers))
+			}
+			fields[field] = value
+		}
+	}
+	if fields != nil {
+		r = r.WithContext(logging.WithFields(r.Context(), fields))
+	}
+	chain[0].ServeHTTP(w, r, chain[1:])
+}
@@ -0,0 +1,27 @@
+package logcontext
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/goburrow/gomelon/logging"
+	"github.com/goburrow/gomelon/server/filter"
+)
+
+func TestFilter(t *testing.T) {
+	f := NewFilter(map[string]string{"X-Request-Id": "requestId", "X-User": "user"})
+	builder := filter.NewChain()
+	builder.Add(f)
+	var fields logging.Fields
+	chain := builder.Build(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fields = logging.FieldsFromContext(r.Context())
+	}))
+
+	r, _ := http.NewRequest("GET", "/", nil)
+	r.Header.Set("X-Request-Id", "abc")
+	chain.ServeHTTP(httptest.NewRecorder(), r)
+	if len(fields) != 1 || fields["requestId"] != "abc" {
+		t.Fatalf("unexpected fields: %v", fields)
+	}
+}
@@ -6,7 +6,6 @@ import (
 	"net/http"
 	"os"
 
-	"github.com/goburrow/gol/file/rotation"
 	"github.com/goburrow/gomelon/core"
 	"github.com/goburrow/gomelon/logging"
 	"github.com/goburrow/gomelon/server/filter"
@@ -28,11 +27,28 @@ type RequestLogFactory interface {
 type DefaultRequestLogFactory struct {
 	// TODO: Eliminate logging dependency
 	Appenders []logging.AppenderConfiguration
+	// Format is either "common", "combined" or a custom format, e.g.
+	// "%h %t \"%r\" %s %b %D". By default, logs are in combined format with
+	// response time and request ID.
+	Format string
 }
 
 var _ RequestLogFactory = (*DefaultRequestLogFactory)(nil)
 
 func (f *DefaultRequestLogFactory) Build(env *core.Environment) (filter.Filter, error) {
+	// Validate format before opening writers so they are not left open.
+	format := f.Format
+	switch format {
+	case "common":
+		format = slogging.CommonFormat
+	case "combined":
+		format = slogging.CombinedFormat
+	}
+	if format != "" {
+		if err := slogging.ValidateFormat(format); err != nil {
+			return nil, err
+		}
+	}
 	var writers []io.Writer
 
 	for _, appender := range f.Appenders {
@@ -44,7 +60,7 @@ func (f *DefaultRequestLogFactory) Build(env *core.Environment) (filter.Filter,
 			}
 			writers = append(writers, w)
 		case *logging.FileAppenderFactory:
-			w, err := buildFileWriter(appenderFactory)
+			w, err := buildFileWriter(env, appenderFactory)
 			if err != nil {
 				return nil, err
 			}
@@ -58,8 +74,14 @@ func (f *DefaultRequestLogFactory) Build(env *core.Environment) (filter.Filter,
 		return &noRequestLog{}, nil
 	}
 	asyncWriter := util.NewAsyncWriter(requestLogBufferSize, writers...)
-	env.Lifecycle.Manage(asyncWriter)
-	return slogging.NewFilter(asyncWriter), nil
+	var logFilter *slogging.Filter
+	if format == "" {
+		logFilter = slogging.NewFilter(asyncWriter)
+	} else {
+		logFilter, _ = slogging.NewFormatFilter(asyncWriter, format)
+	}
+	env.Lifecycle.Manage(asyncWriter, core.PhaseInfrastructure)
+	return logFilter, nil
 }
 
 func buildConsoleWriter(config *logging.ConsoleAppenderFactory) (io.Writer, error) {
@@ -74,24 +96,16 @@ func buildConsoleWriter(config *logging.ConsoleAppenderFactory) (io.Writer, erro
 	}
 }
 
-func buildFileWriter(config *logging.FileAppenderFactory) (io.Writer, error) {
-	writer := rotation.NewFile(config.CurrentLogFilename)
-	if err := writer.Open(); err != nil {
+func buildFileWriter(env *core.Environment, config *logging.FileAppenderFactory) (io.Writer, error) {
+	writer, err := config.BuildWriter()
+	if err != nil {
 		return nil, err
 	}
-	if config.Archive {
-		triggeringPolicy := rotation.NewTimeTriggeringPolicy()
-		if err := triggeringPolicy.Start(); err != nil {
-			return nil, err
-		}
-		rollingPolicy := rotation.NewTimeRollingPolicy()
-		rollingPolicy.FilePattern = config.ArchivedLogFilenamePattern
-		rollingPolicy.FileCount = config.ArchivedFileCount
-
-		writer.SetTriggeringPolicy(triggeringPolicy)
-		writer.SetRollingPolicy(rollingPolicy)
-		// TODO: Close file
+	if err = writer.Start(); err != nil {
+		return nil, err
 	}
+	env.Lifecycle.Manage(writer, core.PhaseInfrastructure)
+	env.Lifecycle.AddReloadHook(writer.Reopen)
 	return writer, nil
 }
 
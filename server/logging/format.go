@@ -0,0 +1,185 @@
+package logging
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Predefined log formats. Supported directives are similar to Apache
+// mod_log_config:
+//
+//	%h  remote address
+//	%l  remote logname (always -)
+//	%u  remote user (always -)
+//	%t  time the request was received
+//	%r  first line of request
+//	%s  status (also %>s)
+//	%b  response size in bytes, or - if no bytes are sent
+//	%B  response size in bytes
+//	%D  time taken to serve the request in microseconds
+//	%T  time taken to serve the request in seconds
+//	%m  request method
+//	%U  URL path requested
+//	%q  query string (prepended with ? if exists)
+//	%H  request protocol
+//	%{Name}i  request header
+//	%{Name}o  response header
+//	%%  the percent sign
+const (
+	CommonFormat   = `%h %l %u %t "%r" %>s %b`
+	CombinedFormat = `%h %l %u %t "%r" %>s %b "%{Referer}i" "%{User-Agent}i"`
+)
+
+// record contains information of a request to be logged.
+type record struct {
+	request  *http.Request
+	response *responseWriter
+	start    time.Time
+	end      time.Time
+}
+
+type formatFunc func(*bytes.Buffer, *record)
+
+// parseFormat converts the format into a list of format functions.
+func parseFormat(format string) ([]formatFunc, error) {
+	var funcs []formatFunc
+	for len(format) > 0 {
+		idx := strings.IndexByte(format, '%')
+		if idx < 0 {
+			funcs = append(funcs, literal(format))
+			break
+		}
+		if idx > 0 {
+			funcs = append(funcs, literal(format[:idx]))
+		}
+		format = format[idx+1:]
+		if len(format) == 0 {
+			return nil, fmt.Errorf("logging: missing directive at the end of format")
+		}
+		// Modifier
+		if format[0] == '>' || format[0] == '<' {
+			format = format[1:]
+			if len(format) == 0 {
+				return nil, fmt.Errorf("logging: missing directive at the end of format")
+			}
+		}
+		var param string
+		if format[0] == '{' {
+			end := strings.IndexByte(format, '}')
+			if end < 0 || end == len(format)-1 {
+				return nil, fmt.Errorf("logging: invalid directive %%%s", format)
+			}
+			param = format[1:end]
+			format = format[end+1:]
+		}
+		f, err := directive(format[0], param)
+		if err != nil {
+			return nil, err
+		}
+		funcs = append(funcs, f)
+		format = format[1:]
+	}
+	return funcs, nil
+}
+
+func literal(s string) formatFunc {
+	return func(buf *bytes.Buffer, _ *record) {
+		buf.WriteString(s)
+	}
+}
+
+func directive(c byte, param string) (formatFunc, error) {
+	if param != "" {
+		switch c {
+		case 'i':
+			return func(buf *bytes.Buffer, r *record) {
+				writeOrDash(buf, r.request.Header.Get(param))
+			}, nil
+		case 'o':
+			return func(buf *bytes.Buffer, r *record) {
+				writeOrDash(buf, r.response.Header().Get(param))
+			}, nil
+		}
+		return nil, fmt.Errorf("logging: unsupported directive %%{%s}%c", param, c)
+	}
+	switch c {
+	case '%':
+		return literal("%"), nil
+	case 'h':
+		return func(buf *bytes.Buffer, r *record) {
+			buf.WriteString(getRemoteAddr(r.request))
+		}, nil
+	case 'l', 'u':
+		return literal("-"), nil
+	case 't':
+		return func(buf *bytes.Buffer, r *record) {
+			buf.WriteByte('[')
+			buf.WriteString(r.start.Format(timeFormat))
+			buf.WriteByte(']')
+		}, nil
+	case 'r':
+		return func(buf *bytes.Buffer, r *record) {
+			buf.WriteString(r.request.Method)
+			buf.WriteByte(' ')
+			buf.WriteString(r.request.RequestURI)
+			buf.WriteByte(' ')
+			buf.WriteString(r.request.Proto)
+		}, nil
+	case 's':
+		return func(buf *bytes.Buffer, r *record) {
+			buf.WriteString(strconv.Itoa(r.response.status))
+		}, nil
+	case 'b':
+		return func(buf *bytes.Buffer, r *record) {
+			if r.response.size == 0 {
+				buf.WriteByte('-')
+			} else {
+				buf.WriteString(strconv.FormatUint(r.response.size, 10))
+			}
+		}, nil
+	case 'B':
+		return func(buf *bytes.Buffer, r *record) {
+			buf.WriteString(strconv.FormatUint(r.response.size, 10))
+		}, nil
+	case 'D':
+		return func(buf *bytes.Buffer, r *record) {
+			buf.WriteString(strconv.FormatInt(int64(r.end.Sub(r.start)/time.Microsecond), 10))
+		}, nil
+	case 'T':
+		return func(buf *bytes.Buffer, r *record) {
+			buf.WriteString(strconv.FormatInt(int64(r.end.Sub(r.start)/time.Second), 10))
+		}, nil
+	case 'm':
+		return func(buf *bytes.Buffer, r *record) {
+			buf.WriteString(r.request.Method)
+		}, nil
+	case 'U':
+		return func(buf *bytes.Buffer, r *record) {
+			buf.WriteString(r.request.URL.Path)
+		}, nil
+	case 'q':
+		return func(buf *bytes.Buffer, r *record) {
+			if r.request.URL.RawQuery != "" {
+				buf.WriteByte('?')
+				buf.WriteString(r.request.URL.RawQuery)
+			}
+		}, nil
+	case 'H':
+		return func(buf *bytes.Buffer, r *record) {
+			buf.WriteString(r.request.Proto)
+		}, nil
+	}
+	return nil, fmt.Errorf("logging: unsupported directive %%%c", c)
+}
+
+func writeOrDash(buf *bytes.Buffer, s string) {
+	if s == "" {
+		buf.WriteByte('-')
+	} else {
+		buf.WriteString(s)
+	}
+}
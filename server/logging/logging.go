@@ -5,6 +5,7 @@ package logging
 
 import (
 	"bufio"
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
@@ -28,14 +29,33 @@ var now = time.Now
 
 type Filter struct {
 	writer io.Writer
+	format []formatFunc
 }
 
 var _ filter.Filter = (*Filter)(nil)
 
+// NewFilter allocates and returns a new Filter writing logs in combined log
+// format with response time in milliseconds and request ID.
 func NewFilter(writer io.Writer) *Filter {
 	return &Filter{writer: writer}
 }
 
+// NewFormatFilter allocates and returns a new Filter writing logs in the
+// given format. See CommonFormat for supported directives.
+func NewFormatFilter(writer io.Writer, format string) (*Filter, error) {
+	funcs, err := parseFormat(format)
+	if err != nil {
+		return nil, err
+	}
+	return &Filter{writer: writer, format: funcs}, nil
+}
+
+// ValidateFormat returns an error if the format is not supported.
+func ValidateFormat(format string) error {
+	_, err := parseFormat(format)
+	return err
+}
+
 func (f *Filter) Name() string {
 	return "logging"
 }
@@ -47,6 +67,15 @@ func (f *Filter) ServeHTTP(w http.ResponseWriter, r *http.Request, chain []filte
 	chain[0].ServeHTTP(responseWriter, r, chain[1:])
 	end := now()
 
+	if f.format != nil {
+		f.writeRecord(&record{
+			request:  r,
+			response: responseWriter,
+			start:    start,
+			end:      end,
+		})
+		return
+	}
 	remoteAddr := getRemoteAddr(r)
 	referer := r.Referer()
 	if referer == "" {
@@ -83,6 +112,17 @@ func (f *Filter) ServeHTTP(w http.ResponseWriter, r *http.Request, chain []filte
 	f.writer.Write([]byte(record))
 }
 
+// writeRecord writes the record in the custom format.
+func (f *Filter) writeRecord(r *record) {
+	// Buffer must not be reused as the writer might be asynchronous.
+	var buf bytes.Buffer
+	for _, format := range f.format {
+		format(&buf, r)
+	}
+	buf.WriteByte('\n')
+	f.writer.Write(buf.Bytes())
+}
+
 func getRemoteAddr(r *http.Request) string {
 	if s := r.Header.Get(xForwardedFor); s != "" {
 		return s
@@ -97,3 +97,46 @@ func TestResponseError(t *testing.T) {
 		t.Fatalf("unexpected access log %v", buf.String())
 	}
 }
+
+func TestCustomFormat(t *testing.T) {
+	var buf bytes.Buffer
+
+	logFilter, err := NewFormatFilter(&buf, CombinedFormat+` %m %U%q %H %B %D %T %{Content-Type}o %{X-None}i 100%%`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	builder := filter.NewChain()
+	builder.Add(logFilter)
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusNoContent)
+	}
+	chain := builder.Build(http.HandlerFunc(handler))
+
+	server := httptest.NewServer(chain)
+	defer server.Close()
+
+	req, err := http.NewRequest("GET", server.URL+"/test?a=1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("User-Agent", "gomelon/1.0")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	expected := `127.0.0.1 - - [14/Jan/2015:01:02:03 +0700] "GET /test?a=1 HTTP/1.1" 204 - "-" "gomelon/1.0" GET /test?a=1 HTTP/1.1 0 0 0 text/plain - 100%` + "\n"
+	if expected != buf.String() {
+		t.Fatalf("unexpected access log %v", buf.String())
+	}
+}
+
+func TestInvalidFormat(t *testing.T) {
+	for _, format := range []string{"%", "%>", "%z", "%{Name", "%{Name}x"} {
+		if _, err := NewFormatFilter(nil, format); err == nil {
+			t.Fatalf("error expected for %q", format)
+		}
+	}
+}
@@ -1,6 +1,9 @@
 package server
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/goburrow/gomelon/core"
@@ -45,3 +48,48 @@ func TestNoRequestLogFactory(t *testing.T) {
 		t.Fatalf("unexpected filter %#v", filter)
 	}
 }
+
+func TestRequestLogFormat(t *testing.T) {
+	env := core.NewEnvironment()
+	factory := DefaultRequestLogFactory{}
+	appender := logging.AppenderConfiguration{}
+	appender.SetValue(&logging.ConsoleAppenderFactory{})
+	factory.Appenders = []logging.AppenderConfiguration{
+		appender,
+	}
+
+	for _, format := range []string{"common", "combined", "%h %r %s"} {
+		factory.Format = format
+		if _, err := factory.Build(env); err != nil {
+			t.Fatal(err)
+		}
+	}
+	factory.Format = "%h %"
+	if _, err := factory.Build(env); err == nil {
+		t.Fatal("error expected")
+	}
+}
+
+func TestRequestLogInvalidFormat(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gomelon")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	env := core.NewEnvironment()
+	factory := DefaultRequestLogFactory{Format: "%h %"}
+	appender := logging.AppenderConfiguration{}
+	appender.SetValue(&logging.FileAppenderFactory{
+		CurrentLogFilename: filepath.Join(dir, "request.log"),
+	})
+	factory.Appenders = []logging.AppenderConfiguration{
+		appender,
+	}
+	if _, err = factory.Build(env); err == nil {
+		t.Fatal("error expected")
+	}
+	if _, err = os.Stat(filepath.Join(dir, "request.log")); !os.IsNotExist(err) {
+		t.Fatalf("unexpected log file: %v", err)
+	}
+}
@@ -0,0 +1,161 @@
+package server
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/goburrow/gomelon/metrics/registry"
+)
+
+// connectionMetrics tracks connection states of a connector.
+type connectionMetrics struct {
+	accepted *registry.Counter
+	hijacked *registry.Counter
+	active   int64
+	idle     int64
+
+	mu     sync.Mutex
+	states map[net.Conn]http.ConnState
+}
+
+// newConnectionMetrics creates metrics prefixed by "Connector.<name>".
+func newConnectionMetrics(metrics *registry.Registry, name string) *connectionMetrics {
+	prefix := "Connector." + name
+	m := &connectionMetrics{
+		accepted: metrics.Counter(prefix + ".Accepted"),
+		hijacked: metrics.Counter(prefix + ".Hijacked"),
+		states:   make(map[net.Conn]http.ConnState),
+	}
+	metrics.Gauge(prefix + ".Active").SetFunc(func() int64 {
+		return atomic.LoadInt64(&m.active)
+	})
+	metrics.Gauge(prefix + ".Idle").SetFunc(func() int64 {
+		return atomic.LoadInt64(&m.idle)
+	})
+	return m
+}
+
+// connState is used as http.Server.ConnState hook.
+func (m *connectionMetrics) connState(conn net.Conn, state http.ConnState) {
+	m.mu.Lock()
+	prev, ok := m.states[conn]
+	if state == http.StateHijacked || state == http.StateClosed {
+		delete(m.states, conn)
+	} else {
+		m.states[conn] = state
+	}
+	m.mu.Unlock()
+
+	if ok {
+		m.count(prev, -1)
+	}
+	switch state {
+	case http.StateNew:
+		m.accepted.Inc()
+	case http.StateHijacked:
+		m.hijacked.Inc()
+	}
+	m.count(state, 1)
+}
+
+func (m *connectionMetrics) count(state http.ConnState, delta int64) {
+	switch state {
+	case http.StateActive:
+		atomic.AddInt64(&m.active, delta)
+	case http.StateIdle:
+		atomic.AddInt64(&m.idle, delta)
+	}
+}
+
+// endpointMetrics records requests of a route: rate and latency of all
+// requests, sizes of response bodies in bytes and counts of each status
+// class.
+type endpointMetrics struct {
+	handler  http.Handler
+	requests *registry.Timer
+	sizes    *registry.Histogram
+	statuses [5]*registry.Counter
+}
+
+// newEndpointMetrics creates metrics prefixed by "Endpoint.<method>.<pattern>".
+func newEndpointMetrics(metrics *registry.Registry, method, pattern string, handler http.Handler) *endpointMetrics {
+	prefix := "Endpoint." + method + "." + pattern
+	m := &endpointMetrics{
+		handler:  handler,
+		requests: metrics.Timer(prefix + ".Requests"),
+		sizes:    metrics.Histogram(prefix + ".ResponseSize"),
+	}
+	for i := range m.statuses {
+		m.statuses[i] = metrics.Counter(prefix + "." + strconv.Itoa(i+1) + "xx")
+	}
+	return m
+}
+
+func (m *endpointMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	sw := &statusWriter{ResponseWriter: w}
+	completed := false
+	defer func() {
+		m.requests.UpdateSince(start)
+		m.sizes.Update(sw.size)
+		status := sw.status
+		if !completed {
+			// Panics are responded with 500 by the recovery filter.
+			status = http.StatusInternalServerError
+		} else if status == 0 {
+			status = http.StatusOK
+		}
+		if class := status/100 - 1; class >= 0 && class < len(m.statuses) {
+			m.statuses[class].Inc()
+		}
+	}()
+	m.handler.ServeHTTP(sw, r)
+	completed = true
+}
+
+// statusWriter records status and size of the response.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+	size   int64
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.size += int64(n)
+	return n, err
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *statusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if hijacker, ok := w.ResponseWriter.(http.Hijacker); ok {
+		return hijacker.Hijack()
+	}
+	return nil, nil, errors.New("server: http.Hijacker is not implemented")
+}
+
+// Unwrap allows http.ResponseController to access the original writer.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
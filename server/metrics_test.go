@@ -0,0 +1,61 @@
+package server
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/goburrow/gomelon/metrics/registry"
+)
+
+func TestConnectionMetrics(t *testing.T) {
+	m := newConnectionMetrics(registry.NewRegistry(), "test")
+	c1, c2 := net.Pipe()
+	defer c1.Close()
+	defer c2.Close()
+
+	m.connState(c1, http.StateNew)
+	m.connState(c1, http.StateActive)
+	m.connState(c2, http.StateNew)
+	m.connState(c2, http.StateActive)
+	if m.active != 2 || m.idle != 0 {
+		t.Fatalf("unexpected active %d, idle %d", m.active, m.idle)
+	}
+	m.connState(c1, http.StateIdle)
+	m.connState(c2, http.StateHijacked)
+	if m.active != 0 || m.idle != 1 {
+		t.Fatalf("unexpected active %d, idle %d", m.active, m.idle)
+	}
+	m.connState(c1, http.StateClosed)
+	if m.active != 0 || m.idle != 0 || len(m.states) != 0 {
+		t.Fatalf("unexpected active %d, idle %d, states %v", m.active, m.idle, m.states)
+	}
+}
+
+func TestEndpointMetrics(t *testing.T) {
+	metrics := registry.NewRegistry()
+	handler := NewHandler()
+	handler.Metrics = metrics
+	handler.Handle("GET", "/ok", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+	handler.Handle("GET", "/missing", http.NotFoundHandler())
+
+	for _, path := range []string{"/ok", "/ok", "/missing"} {
+		r, _ := http.NewRequest("GET", path, nil)
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+	}
+	if c := metrics.Timer("Endpoint.GET./ok.Requests").Count(); c != 2 {
+		t.Fatalf("unexpected requests %d", c)
+	}
+	if c := metrics.Counter("Endpoint.GET./ok.2xx").Count(); c != 2 {
+		t.Fatalf("unexpected 2xx %d", c)
+	}
+	if c := metrics.Counter("Endpoint.GET./missing.4xx").Count(); c != 1 {
+		t.Fatalf("unexpected 4xx %d", c)
+	}
+	if s := metrics.Histogram("Endpoint.GET./ok.ResponseSize").Snapshot(); s.Count != 2 || s.Max != 2 {
+		t.Fatalf("unexpected response size %+v", s)
+	}
+}
@@ -0,0 +1,55 @@
+package server
+
+import (
+	"io/ioutil"
+	"net"
+	"testing"
+)
+
+func TestProxyConn(t *testing.T) {
+	tests := []struct {
+		header string
+		addr   string
+	}{
+		{"PROXY TCP4 192.168.0.1 192.168.0.11 56324 443\r\n", "192.168.0.1:56324"},
+		{"PROXY TCP6 2001:db8::1 2001:db8::2 56324 443\r\n", "[2001:db8::1]:56324"},
+		{"PROXY UNKNOWN\r\n", "pipe"},
+		{"\r\n\r\n\x00\r\nQUIT\n\x21\x11\x00\x0c\x0a\x00\x00\x01\x0a\x00\x00\x02\x1f\x90\x01\xbb", "10.0.0.1:8080"},
+		// LOCAL command
+		{"\r\n\r\n\x00\r\nQUIT\n\x20\x00\x00\x00", "pipe"},
+	}
+	for _, test := range tests {
+		client, server := net.Pipe()
+		go func() {
+			client.Write([]byte(test.header + "data"))
+			client.Close()
+		}()
+		conn := newProxyConn(server)
+		if conn.RemoteAddr().String() != test.addr {
+			t.Fatalf("unexpected remote address %v for %q", conn.RemoteAddr(), test.header)
+		}
+		data, err := ioutil.ReadAll(conn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != "data" {
+			t.Fatalf("unexpected data %q", data)
+		}
+		conn.Close()
+	}
+}
+
+func TestProxyConnInvalid(t *testing.T) {
+	for _, header := range []string{"GET / HTTP/1.1\r\n", "PROXY TCP4 a b c d\r\n", "PROXY TCP4\n"} {
+		client, server := net.Pipe()
+		go func() {
+			client.Write([]byte(header))
+			client.Close()
+		}()
+		conn := newProxyConn(server)
+		if _, err := conn.Read(make([]byte, 1)); err == nil {
+			t.Fatalf("error expected for %q", header)
+		}
+		conn.Close()
+	}
+}
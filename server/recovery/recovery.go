@@ -9,8 +9,8 @@ import (
 	"net/http"
 	"runtime"
 
-	"github.com/codahale/metrics"
-	"github.com/goburrow/gol"
+	"github.com/goburrow/gomelon/core"
+	"github.com/goburrow/gomelon/metrics/registry"
 	"github.com/goburrow/gomelon/server/filter"
 )
 
@@ -21,17 +21,17 @@ const (
 )
 
 var (
-	panics metrics.Counter
-	logger gol.Logger
+	logger core.Logger
 )
 
 func init() {
-	panics = metrics.Counter("HTTP.Panics")
-	logger = gol.GetLogger("gomelon/server/recovery")
+	logger = core.GetLogger("gomelon/server/recovery")
 }
 
 // Filter handles panics.
 type Filter struct {
+	// Panics counts recovered panics if it is set.
+	Panics *registry.Counter
 }
 
 var _ filter.Filter = (*Filter)(nil)
@@ -47,7 +47,9 @@ func (f *Filter) Name() string {
 func (f *Filter) ServeHTTP(w http.ResponseWriter, r *http.Request, chain []filter.Filter) {
 	defer func() {
 		if err := recover(); err != nil {
-			panics.Add()
+			if f.Panics != nil {
+				f.Panics.Inc()
+			}
 			logger.Error("%v\n%s", err, stack())
 			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 		}
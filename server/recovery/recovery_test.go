@@ -12,7 +12,7 @@ import (
 
 func init() {
 	// Disable logger
-	logger.(*gol.DefaultLogger).SetLevel(gol.LevelOff)
+	gol.GetLogger("gomelon/server/recovery").(*gol.DefaultLogger).SetLevel(gol.LevelOff)
 }
 
 func TestPanicHandler(t *testing.T) {
@@ -0,0 +1,229 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/goburrow/gomelon/core"
+)
+
+const (
+	// listenersEnv is the environment variable containing addresses and
+	// file descriptors of listening sockets passed from the parent process,
+	// e.g. ":8080=3,:8081=4".
+	listenersEnv = "GOMELON_LISTENERS"
+	// readyEnv is the environment variable containing the file descriptor
+	// of the pipe used by the new process to report that it is listening.
+	readyEnv = "GOMELON_READY"
+)
+
+// restartTimeout is the maximum duration waiting for the new process to
+// listen on restart.
+var restartTimeout = 30 * time.Second
+
+// listeners keeps track of listening sockets so they can be passed to a new
+// process on restart.
+var listeners = &listenerRegistry{}
+
+type listenerRegistry struct {
+	mu        sync.Mutex
+	once      sync.Once
+	inherited map[string]net.Listener
+	addrs     []string
+	active    []net.Listener
+}
+
+// listen returns the listener inherited from the parent process if there is
+// one for the address, otherwise it creates a new one.
+func (reg *listenerRegistry) listen(addr string) (net.Listener, error) {
+	reg.once.Do(func() {
+		inherited, err := inheritListeners(os.Getenv(listenersEnv))
+		if err != nil {
+			core.GetLogger(loggerName).Error("could not inherit listeners: %v", err)
+		}
+		reg.inherited = inherited
+	})
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	ln, ok := reg.inherited[addr]
+	if ok {
+		delete(reg.inherited, addr)
+		core.GetLogger(loggerName).Info("inherited listener %s", addr)
+	} else {
+		var err error
+		if ln, err = net.Listen("tcp", addr); err != nil {
+			return nil, err
+		}
+	}
+	reg.addrs = append(reg.addrs, addr)
+	reg.active = append(reg.active, ln)
+	return ln, nil
+}
+
+// files returns duplicated file descriptors of active listeners.
+func (reg *listenerRegistry) files() (string, []*os.File, error) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	var env []string
+	var files []*os.File
+	for i, ln := range reg.active {
+		filer, ok := ln.(interface {
+			File() (*os.File, error)
+		})
+		if !ok {
+			closeFiles(files)
+			return "", nil, fmt.Errorf("server: unsupported listener %T", ln)
+		}
+		f, err := filer.File()
+		if err != nil {
+			closeFiles(files)
+			return "", nil, err
+		}
+		// Extra files start from 3 after stdin, stdout and stderr.
+		env = append(env, reg.addrs[i]+"="+strconv.Itoa(len(files)+3))
+		files = append(files, f)
+	}
+	return strings.Join(env, ","), files, nil
+}
+
+// inheritListeners creates listeners from the value of listenersEnv.
+func inheritListeners(value string) (map[string]net.Listener, error) {
+	inherited := make(map[string]net.Listener)
+	if value == "" {
+		return inherited, nil
+	}
+	for _, s := range strings.Split(value, ",") {
+		idx := strings.LastIndex(s, "=")
+		if idx < 0 {
+			return inherited, fmt.Errorf("server: invalid inherited listener %q", s)
+		}
+		fd, err := strconv.Atoi(s[idx+1:])
+		if err != nil {
+			return inherited, fmt.Errorf("server: invalid inherited listener %q", s)
+		}
+		f := os.NewFile(uintptr(fd), s[:idx])
+		ln, err := net.FileListener(f)
+		// FileListener duplicates the file descriptor.
+		f.Close()
+		if err != nil {
+			return inherited, err
+		}
+		inherited[s[:idx]] = ln
+	}
+	return inherited, nil
+}
+
+func closeFiles(files []*os.File) {
+	for _, f := range files {
+		f.Close()
+	}
+}
+
+// notifyReady reports to the parent process, if any, that the server has
+// bound all listeners.
+func notifyReady() {
+	value := os.Getenv(readyEnv)
+	if value == "" {
+		return
+	}
+	os.Unsetenv(readyEnv)
+	fd, err := strconv.Atoi(value)
+	if err != nil {
+		core.GetLogger(loggerName).Error("invalid %s %q", readyEnv, value)
+		return
+	}
+	f := os.NewFile(uintptr(fd), readyEnv)
+	defer f.Close()
+	if _, err = f.Write([]byte{1}); err != nil {
+		core.GetLogger(loggerName).Error("could not notify parent process: %v", err)
+	}
+}
+
+// waitReady waits until the new process writes to the pipe r.
+func waitReady(r *os.File, timeout time.Duration) error {
+	if err := r.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		return err
+	}
+	var b [1]byte
+	if _, err := r.Read(b[:]); err != nil {
+		if err == io.EOF {
+			return fmt.Errorf("server: new process exited")
+		}
+		return err
+	}
+	return nil
+}
+
+// Restart starts a new process of the running executable, which takes over
+// the listening sockets, then gracefully shuts down this server once the
+// new process is listening. Managed objects of this process are stopped as
+// usual once in-flight requests have completed. The new process is killed
+// and this server keeps running if it is not ready within 30 seconds.
+// Restart returns an error if it has been called already.
+func (server *Server) Restart() (err error) {
+	if !atomic.CompareAndSwapInt32(&server.restarting, 0, 1) {
+		return fmt.Errorf("server: restart is already in progress")
+	}
+	defer func() {
+		if err != nil {
+			atomic.StoreInt32(&server.restarting, 0)
+		}
+	}()
+	env, files, err := listeners.files()
+	if err != nil {
+		return err
+	}
+	defer closeFiles(files)
+
+	path, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	cmd := exec.Command(path, os.Args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.ExtraFiles = append(files, w)
+	for _, e := range os.Environ() {
+		if !strings.HasPrefix(e, listenersEnv+"=") && !strings.HasPrefix(e, readyEnv+"=") {
+			cmd.Env = append(cmd.Env, e)
+		}
+	}
+	cmd.Env = append(cmd.Env, listenersEnv+"="+env, readyEnv+"="+strconv.Itoa(len(files)+3))
+	err = cmd.Start()
+	// The pipe is closed when the new process exits.
+	w.Close()
+	if err != nil {
+		return err
+	}
+	logger := core.GetLogger(loggerName)
+	logger.Info("started new process %d", cmd.Process.Pid)
+	if err = waitReady(r, restartTimeout); err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return fmt.Errorf("server: new process %d is not ready: %v", cmd.Process.Pid, err)
+	}
+	logger.Info("new process %d is ready", cmd.Process.Pid)
+	// Shutdown through the lifecycle so listeners are notified as usual.
+	go func() {
+		if server.Lifecycle == nil || !server.Lifecycle.Shutdown() {
+			server.Stop()
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,25 @@
+//go:build !windows
+// +build !windows
+
+package server
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/goburrow/gomelon/core"
+)
+
+// handleRestart restarts the server when receiving SIGUSR2.
+func (server *Server) handleRestart() {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, syscall.SIGUSR2)
+	go func() {
+		for _ = range c {
+			if err := server.Restart(); err != nil {
+				core.GetLogger(loggerName).Error("could not restart: %v", err)
+			}
+		}
+	}()
+}
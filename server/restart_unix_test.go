@@ -0,0 +1,109 @@
+//go:build !windows
+// +build !windows
+
+package server
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestInheritListeners(t *testing.T) {
+	reg := &listenerRegistry{}
+	reg.once.Do(func() {})
+	ln, err := reg.listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	_, files, err := reg.files()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeFiles(files)
+	if len(files) != 1 {
+		t.Fatalf("unexpected files %v", files)
+	}
+	// Not started in a new process, so use a duplicated file descriptor
+	// which is owned by the inherited listener.
+	fd, err := syscall.Dup(int(files[0].Fd()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	inherited, err := inheritListeners("127.0.0.1:0=" + strconv.Itoa(fd))
+	if err != nil {
+		t.Fatal(err)
+	}
+	child, ok := inherited["127.0.0.1:0"]
+	if !ok {
+		t.Fatalf("unexpected inherited listeners %v", inherited)
+	}
+	defer child.Close()
+	if child.Addr().String() != ln.Addr().String() {
+		t.Fatalf("unexpected address %v, want %v", child.Addr(), ln.Addr())
+	}
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+}
+
+func TestInheritListenersInvalid(t *testing.T) {
+	for _, value := range []string{":8080", ":8080=a"} {
+		if _, err := inheritListeners(value); err == nil {
+			t.Fatalf("error expected for %q", value)
+		}
+	}
+}
+
+func TestRestartInProgress(t *testing.T) {
+	server := NewServer()
+	server.restarting = 1
+	if err := server.Restart(); err == nil {
+		t.Fatal("error expected")
+	}
+}
+
+func TestRestartReady(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	// notifyReady closes the file descriptor as the new process does.
+	fd, err := syscall.Dup(int(w.Fd()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+	os.Setenv(readyEnv, strconv.Itoa(fd))
+	defer os.Unsetenv(readyEnv)
+	notifyReady()
+	if os.Getenv(readyEnv) != "" {
+		t.Fatalf("unexpected %s: %q", readyEnv, os.Getenv(readyEnv))
+	}
+	if err = waitReady(r, time.Second); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRestartNotReady(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if err = waitReady(r, 10*time.Millisecond); err == nil {
+		t.Fatal("timeout error expected")
+	}
+	// The new process exited without notifying.
+	w.Close()
+	if err = waitReady(r, time.Second); err == nil {
+		t.Fatal("error expected")
+	}
+}
@@ -0,0 +1,10 @@
+package server
+
+import (
+	"github.com/goburrow/gomelon/core"
+)
+
+// handleRestart is not supported on Windows.
+func (server *Server) handleRestart() {
+	core.GetLogger(loggerName).Warn("graceful restart is not supported")
+}
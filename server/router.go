@@ -0,0 +1,121 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/goburrow/gomelon/core"
+	"github.com/zenazn/goji/web"
+)
+
+const (
+	defaultRouter = "goji"
+)
+
+// Router is the HTTP request multiplexer used by Handler. Third-party
+// routers (e.g. httprouter, gorilla/mux or chi) can be used by registering
+// an adapter with RegisterRouter.
+type Router interface {
+	// Handle registers the handler for the given method and pattern.
+	// Method "*" matches all methods. A router must at least support
+	// http.Handler and should provide path parameters to handlers
+	// via core.WithPathParams.
+	Handle(method, pattern string, handler interface{})
+	http.Handler
+}
+
+var (
+	routersMu sync.Mutex
+	routers   = map[string]func() Router{
+		defaultRouter: func() Router {
+			return newGojiRouter()
+		},
+	}
+)
+
+// RegisterRouter makes a router available by the given name in server
+// configuration.
+func RegisterRouter(name string, newRouter func() Router) {
+	routersMu.Lock()
+	defer routersMu.Unlock()
+	routers[name] = newRouter
+}
+
+// newRouter creates a router registered with the given name.
+func newRouter(name string) (Router, error) {
+	if name == "" {
+		name = defaultRouter
+	}
+	routersMu.Lock()
+	newRouter, ok := routers[name]
+	routersMu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("server: unsupported router %s", name)
+	}
+	return newRouter(), nil
+}
+
+// gojiRouter is the default Router which utilizes Goji web.Mux.
+// Besides http.Handler, it also supports web.Handler and other handler
+// types accepted by Goji.
+type gojiRouter struct {
+	mux *web.Mux
+}
+
+func newGojiRouter() *gojiRouter {
+	return &gojiRouter{
+		mux: web.New(),
+	}
+}
+
+func (router *gojiRouter) Handle(method, pattern string, handler interface{}) {
+	var f func(web.PatternType, web.HandlerType)
+
+	switch method {
+	case "GET":
+		f = router.mux.Get
+	case "HEAD":
+		f = router.mux.Head
+	case "POST":
+		f = router.mux.Post
+	case "PUT":
+		f = router.mux.Put
+	case "DELETE":
+		f = router.mux.Delete
+	case "TRACE":
+		f = router.mux.Trace
+	case "OPTIONS":
+		f = router.mux.Options
+	case "CONNECT":
+		f = router.mux.Connect
+	case "PATCH":
+		f = router.mux.Patch
+	case "*":
+		f = router.mux.Handle
+	default:
+		panic("server: unsupported method " + method)
+	}
+	if h, ok := handler.(http.Handler); ok {
+		if _, ok = handler.(web.Handler); !ok {
+			handler = pathParamsHandler{h}
+		}
+	}
+	f(pattern, handler)
+}
+
+func (router *gojiRouter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	router.mux.ServeHTTP(w, r)
+}
+
+// pathParamsHandler passes Goji URL parameters to http.Handler.
+type pathParamsHandler struct {
+	handler http.Handler
+}
+
+func (h pathParamsHandler) ServeHTTPC(c web.C, w http.ResponseWriter, r *http.Request) {
+	if len(c.URLParams) > 0 {
+		r = core.WithPathParams(r, c.URLParams)
+	}
+	h.handler.ServeHTTP(w, r)
+}
@@ -4,49 +4,97 @@ Package server supports dynamic routes.
 package server
 
 import (
+	"context"
+	"crypto/tls"
 	"fmt"
+	"net"
 	"net/http"
 	"strings"
 	"sync"
+	"time"
 
-	"github.com/goburrow/gol"
 	"github.com/goburrow/gomelon/core"
+	"github.com/goburrow/gomelon/metrics/registry"
 	"github.com/goburrow/gomelon/server/filter"
+	"github.com/goburrow/gomelon/server/limit"
 	"github.com/goburrow/polytype"
 	"github.com/zenazn/goji/graceful"
-	"github.com/zenazn/goji/web"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 )
 
 const (
 	loggerName = "gomelon/server"
+
+	defaultMaxConcurrentStreams = 250
 )
 
 func init() {
-	polytype.Register("DefaultServer", func() interface{} {
-		return &DefaultFactory{}
-	})
-	polytype.Register("SimpleServer", func() interface{} {
-		return &SimpleFactory{
-			ApplicationContextPath: "/application",
-			AdminContextPath:       "/admin",
-		}
-	})
+	polytype.Register("DefaultServer", newDefaultFactory)
+	polytype.Register("default", newDefaultFactory)
+	polytype.Register("SimpleServer", newSimpleFactory)
+	polytype.Register("simple", newSimpleFactory)
 	polytype.Register("DefaultRequestLog", func() interface{} {
 		return &DefaultRequestLogFactory{}
 	})
 }
 
-// Connector utilizes graceful.Server.
+// Connector utilizes graceful.Server. TLS connectors are served by
+// http.Server so HTTP/2 is negotiated and Request.TLS is set.
 // Each connector has its own listener which will be closed when closing the
 // server it belongs to. SetHandler() must be called before listening.
+// Supported types are "http", "https", "h2" (HTTP/2 over TLS), "h2c"
+// (HTTP/2 over cleartext TCP, falling back to HTTP/1.1) and "acme" (HTTPS
+// and HTTP/2 with certificates obtained automatically).
 type Connector struct {
 	Type string `valid:"nonzero"`
 	Addr string
 
 	CertFile string
 	KeyFile  string
+	// MinVersion is the minimum TLS version: TLS1.0, TLS1.1, TLS1.2 (default)
+	// or TLS1.3.
+	MinVersion string
+	// CipherSuites is the list of enabled cipher suite names. Go defaults
+	// are used if it is empty.
+	CipherSuites []string
+	// ClientCAFile contains certificates for verifying clients (mTLS).
+	ClientCAFile string
+	// ClientAuth is the policy for TLS client authentication: none, request,
+	// require, verify-if-given or require-and-verify (default when
+	// ClientCAFile is set).
+	ClientAuth string
+	// ReloadCertificate allows certificate and key files to be changed
+	// without restarting the server. Files are checked every minute and
+	// when the application is reloaded (SIGHUP).
+	ReloadCertificate bool
+
+	// ACME is used by "acme" connector type.
+	ACME ACMEConfiguration
+
+	// MaxConcurrentStreams is the maximum number of concurrent streams
+	// per HTTP/2 connection. Default is 250.
+	MaxConcurrentStreams uint32
+
+	// ProxyProtocol requires HAProxy PROXY protocol (version 1 or 2) header
+	// in every connection, so the real client address is available when
+	// running behind TCP load balancers.
+	ProxyProtocol bool
+
+	// MaxConcurrentRequests is the maximum number of requests processed
+	// at the same time. Default is no limit.
+	MaxConcurrentRequests int
+	// MaxQueuedRequests is the maximum number of requests waiting when
+	// MaxConcurrentRequests is reached. Further requests are rejected with
+	// status 503.
+	MaxQueuedRequests int
 
 	server *graceful.Server
+	// tlsServer is set for TLS connectors, which are served by net/http as
+	// graceful.Server hides *tls.Conn needed by HTTP/2 and Request.TLS.
+	tlsServer  *http.Server
+	admin      bool
+	certLoader *certificateLoader
 }
 
 // SetHandler setup the server with the given handler.
@@ -54,26 +102,156 @@ func (connector *Connector) SetHandler(handler http.Handler) {
 	if connector.server == nil {
 		connector.server = &graceful.Server{}
 	}
+	if connector.MaxConcurrentRequests > 0 {
+		chain := filter.NewChain()
+		chain.Add(limit.NewConcurrencyFilter(connector.MaxConcurrentRequests, connector.MaxQueuedRequests))
+		handler = chain.Build(handler)
+	}
 	connector.server.Handler = handler
 }
 
 // Listen creates and serves a listerner.
 func (connector *Connector) Listen() error {
+	ln, err := connector.bind()
+	if err != nil {
+		return err
+	}
+	return connector.serve(ln)
+}
+
+// bind creates the listener of the connector.
+func (connector *Connector) bind() (net.Listener, error) {
 	connector.server.Addr = connector.Addr
 
 	switch connector.Type {
 	case "http":
-		return connector.server.ListenAndServe()
+		return connector.listen(":http")
 	case "https":
-		return connector.server.ListenAndServeTLS(connector.CertFile, connector.KeyFile)
+		config, err := connector.tlsConfig()
+		if err != nil {
+			return nil, err
+		}
+		return connector.listenTLS(config, false)
+	case "h2":
+		config, err := connector.tlsConfig()
+		if err != nil {
+			return nil, err
+		}
+		return connector.listenTLS(config, true)
+	case "acme":
+		config, err := connector.acmeTLSConfig()
+		if err != nil {
+			return nil, err
+		}
+		return connector.listenTLS(config, true)
+	case "h2c":
+		connector.server.Handler = h2c.NewHandler(connector.server.Handler, connector.http2Server())
+		return connector.listen(":http")
+	}
+	return nil, fmt.Errorf("server: unsupported connector type %s", connector.Type)
+}
+
+func (connector *Connector) serve(ln net.Listener) error {
+	if connector.tlsServer != nil {
+		err := connector.tlsServer.Serve(ln)
+		if err == http.ErrServerClosed {
+			return nil
+		}
+		return err
+	}
+	return connector.server.Serve(ln)
+}
+
+// shutdown gracefully stops the TLS connector and closes its connections
+// when ctx is done.
+func (connector *Connector) shutdown(ctx context.Context) {
+	if connector.tlsServer == nil {
+		return
+	}
+	if err := connector.tlsServer.Shutdown(ctx); err != nil {
+		connector.tlsServer.Close()
+	}
+}
+
+// name returns the identity of the connector used in metrics.
+func (connector *Connector) name() string {
+	if connector.Addr == "" {
+		return connector.Type
+	}
+	return connector.Addr
+}
+
+func (connector *Connector) listenTLS(config *tls.Config, h2 bool) (net.Listener, error) {
+	connector.server.TLSConfig = config
+	connector.tlsServer = (*http.Server)(connector.server)
+	if h2 {
+		if err := http2.ConfigureServer(connector.tlsServer, connector.http2Server()); err != nil {
+			return nil, err
+		}
+	} else {
+		config.NextProtos = append(config.NextProtos, "http/1.1")
+	}
+	ln, err := connector.listen(":https")
+	if err != nil {
+		return nil, err
+	}
+	return tls.NewListener(ln, config), nil
+}
+
+// listen announces on the connector address, or reuses the socket inherited
+// from the parent process, accepting PROXY protocol headers if enabled.
+func (connector *Connector) listen(defaultAddr string) (net.Listener, error) {
+	addr := connector.Addr
+	if addr == "" {
+		addr = defaultAddr
+	}
+	ln, err := listeners.listen(addr)
+	if err != nil {
+		return nil, err
+	}
+	if connector.ProxyProtocol {
+		ln = &proxyListener{ln}
+	}
+	return ln, nil
+}
+
+func (connector *Connector) http2Server() *http2.Server {
+	maxConcurrentStreams := connector.MaxConcurrentStreams
+	if maxConcurrentStreams == 0 {
+		maxConcurrentStreams = defaultMaxConcurrentStreams
+	}
+	return &http2.Server{
+		MaxConcurrentStreams: maxConcurrentStreams,
 	}
-	return fmt.Errorf("server: unsupported connector type %s", connector.Type)
 }
 
 // Server implements Server interface. Each server can have multiple
 // connectors (listeners).
 type Server struct {
 	Connectors []*Connector
+	// ShutdownGracePeriod is the maximum duration waiting for in-flight
+	// requests before closing all connections. Zero means no timeout.
+	ShutdownGracePeriod time.Duration
+
+	// Timeouts applied to all connectors. See http.Server.
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+
+	// GracefulRestart enables restarting on SIGUSR2 without closing
+	// listening sockets. See Restart.
+	GracefulRestart bool
+	// Metrics is the registry for connection metrics of all connectors.
+	Metrics *registry.Registry
+	// Listener is notified after all connectors are bound.
+	Listener core.ServerLifecycleListener
+	// Lifecycle is shut down when the server is restarted.
+	Lifecycle *core.LifecycleEnvironment
+
+	restarting int32
+	stopOnce   sync.Once
+	done       chan struct{}
 }
 
 var _ core.Server = (*Server)(nil)
@@ -85,10 +263,11 @@ func NewServer() *Server {
 
 // Start starts all connectors of the server.
 func (server *Server) Start() error {
-	logger := gol.GetLogger(loggerName)
+	logger := core.GetLogger(loggerName)
 
-	// Handle SIGINT
-	graceful.HandleSignals()
+	// SIGINT and SIGTERM are handled by the server command, which shuts
+	// down the lifecycle and the server.
+	graceful.Timeout(server.ShutdownGracePeriod)
 	graceful.PreHook(func() {
 		logger.Info("stopping")
 	})
@@ -96,6 +275,28 @@ func (server *Server) Start() error {
 		logger.Info("stopped")
 	})
 	defer graceful.Wait()
+	if server.GracefulRestart {
+		server.handleRestart()
+	}
+
+	// Bind all connectors before serving so listeners know their addresses.
+	lns := make([]net.Listener, 0, len(server.Connectors))
+	for _, connector := range server.Connectors {
+		ln, err := connector.bind()
+		if err != nil {
+			for _, ln := range lns {
+				ln.Close()
+			}
+			return err
+		}
+		logger.Info("listening %s", ln.Addr())
+		lns = append(lns, ln)
+	}
+	server.watchCertificates()
+	if server.Listener != nil {
+		server.Listener.ServerStarted(server.serverConnectors(lns))
+	}
+	notifyReady()
 
 	errorChan := make(chan error, len(server.Connectors))
 	defer close(errorChan)
@@ -103,19 +304,18 @@ func (server *Server) Start() error {
 	wg := sync.WaitGroup{}
 	defer wg.Wait()
 
-	for _, connector := range server.Connectors {
-		logger.Info("listening %s", connector.Addr)
+	for i, connector := range server.Connectors {
 		wg.Add(1)
-		go func(c *Connector) {
+		go func(c *Connector, ln net.Listener) {
 			defer wg.Done()
-			errorChan <- c.Listen()
-		}(connector)
+			errorChan <- c.serve(ln)
+		}(connector, lns[i])
 	}
 	for _ = range server.Connectors {
 		select {
 		case err := <-errorChan:
 			if err != nil {
-				graceful.ShutdownNow()
+				server.shutdownNow()
 				return err
 			}
 		}
@@ -123,70 +323,145 @@ func (server *Server) Start() error {
 	return nil
 }
 
-// Stop stops all running connectors of the server.
+// watchCertificates reloads certificates of connectors periodically and
+// when the lifecycle is reloaded.
+func (server *Server) watchCertificates() {
+	server.done = make(chan struct{})
+	for _, connector := range server.Connectors {
+		loader := connector.certLoader
+		if loader == nil {
+			continue
+		}
+		go loader.watch(certificateCheckInterval, server.done)
+		if server.Lifecycle != nil {
+			server.Lifecycle.AddReloadHook(loader.reload)
+		}
+	}
+}
+
+// Stop stops all running connectors of the server. It waits for in-flight
+// requests to complete up to ShutdownGracePeriod.
 func (server *Server) Stop() error {
-	graceful.Shutdown()
+	server.stopOnce.Do(func() {
+		if server.done != nil {
+			close(server.done)
+		}
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		var wg sync.WaitGroup
+		for _, connector := range server.Connectors {
+			wg.Add(1)
+			go func(c *Connector) {
+				defer wg.Done()
+				c.shutdown(ctx)
+			}(connector)
+		}
+		graceful.Shutdown()
+		wg.Wait()
+		close(done)
+	}()
+	if server.ShutdownGracePeriod > 0 {
+		select {
+		case <-done:
+		case <-time.After(server.ShutdownGracePeriod):
+			core.GetLogger(loggerName).Warn("timeout waiting for requests after %v", server.ShutdownGracePeriod)
+			cancel()
+			graceful.ShutdownNow()
+		}
+	}
 	graceful.Wait()
+	<-done
 	return nil
 }
 
+// shutdownNow closes all connections of the server immediately.
+func (server *Server) shutdownNow() {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, connector := range server.Connectors {
+		connector.shutdown(ctx)
+	}
+	graceful.ShutdownNow()
+}
+
+func (server *Server) serverConnectors(lns []net.Listener) []core.ServerConnector {
+	connectors := make([]core.ServerConnector, len(lns))
+	for i, ln := range lns {
+		c := server.Connectors[i]
+		connectors[i] = core.ServerConnector{
+			Type:  c.Type,
+			Admin: c.admin,
+			Addr:  ln.Addr(),
+		}
+	}
+	return connectors
+}
+
 // addConnectors adds a new connector to the server.
 func (server *Server) addConnectors(handler http.Handler, connectors []Connector) {
 	for i := range connectors {
 		connectors[i].SetHandler(handler)
+		s := connectors[i].server
+		s.ReadTimeout = server.ReadTimeout
+		s.ReadHeaderTimeout = server.ReadHeaderTimeout
+		s.WriteTimeout = server.WriteTimeout
+		s.IdleTimeout = server.IdleTimeout
+		if server.Metrics != nil {
+			s.ConnState = newConnectionMetrics(server.Metrics, connectors[i].name()).connState
+		}
 		server.Connectors = append(server.Connectors, &connectors[i])
 	}
 }
 
 // Handler handles HTTP requests.
 type Handler struct {
-	// ServerMux is the HTTP request router.
-	ServeMux *web.Mux
+	// Router is the HTTP request router.
+	Router Router
 	// FilterChain is the builder for HTTP filters.
 	FilterChain filter.Chain
+	// Metrics records requests of each route registered after it is set.
+	Metrics *registry.Registry
 
 	pathPrefix string
+
+	once  sync.Once
+	chain http.Handler
 }
 
 // Handler implements gomelon.ServerHandler
 var _ core.ServerHandler = (*Handler)(nil)
 
-// NewHandler creates a new multiplexer if not provided.
+// NewHandler creates a new handler using the default router.
 func NewHandler() *Handler {
+	return NewRouterHandler(newGojiRouter())
+}
+
+// NewRouterHandler creates a new handler using the given router.
+func NewRouterHandler(router Router) *Handler {
 	return &Handler{
-		ServeMux: web.New(),
+		Router: router,
 	}
 }
 
 // Handle registers the handler for the given pattern.
 func (h *Handler) Handle(method, pattern string, handler interface{}) {
-	var f func(web.PatternType, web.HandlerType)
-
-	switch method {
-	case "GET":
-		f = h.ServeMux.Get
-	case "HEAD":
-		f = h.ServeMux.Head
-	case "POST":
-		f = h.ServeMux.Post
-	case "PUT":
-		f = h.ServeMux.Put
-	case "DELETE":
-		f = h.ServeMux.Delete
-	case "TRACE":
-		f = h.ServeMux.Trace
-	case "OPTIONS":
-		f = h.ServeMux.Options
-	case "CONNECT":
-		f = h.ServeMux.Connect
-	case "PATCH":
-		f = h.ServeMux.Patch
-	case "*":
-		f = h.ServeMux.Handle
-	default:
-		panic("server: unsupported method " + method)
-	}
-	f(pattern, handler)
+	if h.Metrics != nil {
+		switch v := handler.(type) {
+		case http.Handler:
+			handler = newEndpointMetrics(h.Metrics, method, pattern, v)
+		case func(http.ResponseWriter, *http.Request):
+			handler = newEndpointMetrics(h.Metrics, method, pattern, http.HandlerFunc(v))
+		}
+	}
+	h.Router.Handle(method, pattern, handler)
+}
+
+// AddFilter adds the middleware to the end of the filter chain.
+func (h *Handler) AddFilter(middleware func(http.Handler) http.Handler) {
+	h.FilterChain.Add(filter.Middleware(middleware))
 }
 
 // PathPrefix returns server root context path.
@@ -194,17 +469,43 @@ func (h *Handler) PathPrefix() string {
 	return h.pathPrefix
 }
 
-// ServeHTTP strips path prefix in the request URL path.
-// This method is actually only used when path prefix is set
-// (i.e. simple server - the handler acts as subrouter).
+// ServeHTTP strips path prefix in the request URL path and passes the
+// request through the filter chain to the router. Filters must be added
+// before the first request is served.
 func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.once.Do(func() {
+		h.chain = h.FilterChain.Build(h.Router)
+	})
 	if h.pathPrefix != "" {
 		r.URL.Path = strings.TrimPrefix(r.URL.Path, h.pathPrefix)
 	}
-	h.ServeMux.ServeHTTP(w, r)
+	h.chain.ServeHTTP(w, r)
+}
+
+// mountHandlers registers the handlers as sub routers at their
+// path prefixes.
+func mountHandlers(router Router, handlers ...*Handler) {
+	for _, h := range handlers {
+		router.Handle("*", h.pathPrefix+"/*", h)
+		router.Handle("*", h.pathPrefix, http.RedirectHandler(h.pathPrefix+"/", http.StatusMovedPermanently))
+	}
 }
 
-// Factory is an union of DefaultFactory and SimpleFactory.
+// RegisterFactory makes a server factory available by the given name as
+// type of server configuration. Bundles providing alternative HTTP stacks
+// should call it during initialization, e.g.
+//
+//	server.RegisterFactory("fasthttp", func() core.ServerFactory {
+//		return &FastFactory{}
+//	})
+func RegisterFactory(name string, newFactory func() core.ServerFactory) {
+	polytype.Register(name, func() interface{} {
+		return newFactory()
+	})
+}
+
+// Factory is an union of server factories selected by type in server
+// configuration: "default", "simple" or the name given in RegisterFactory.
 type Factory struct {
 	polytype.Type
 }
@@ -212,6 +513,9 @@ type Factory struct {
 var _ core.ServerFactory = (*Factory)(nil)
 
 func (factory *Factory) Build(environment *core.Environment) (core.Server, error) {
+	if factory.Value() == nil {
+		return nil, fmt.Errorf("server: type is not specified")
+	}
 	if f, ok := factory.Value().(core.ServerFactory); ok {
 		return f.Build(environment)
 	}
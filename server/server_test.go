@@ -1,6 +1,9 @@
 package server
 
 import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/goburrow/gomelon/core"
@@ -34,3 +37,137 @@ func TestInvalidFactory(t *testing.T) {
 		t.Fatal("error expected")
 	}
 }
+
+func TestConnectorHTTP2Server(t *testing.T) {
+	connector := &Connector{Type: "h2c"}
+	if connector.http2Server().MaxConcurrentStreams != defaultMaxConcurrentStreams {
+		t.Fatalf("unexpected max concurrent streams %v", connector.http2Server().MaxConcurrentStreams)
+	}
+	connector.MaxConcurrentStreams = 10
+	if connector.http2Server().MaxConcurrentStreams != 10 {
+		t.Fatalf("unexpected max concurrent streams %v", connector.http2Server().MaxConcurrentStreams)
+	}
+}
+
+func TestHandlerAddFilter(t *testing.T) {
+	handler := NewHandler()
+	handler.Handle("GET", "/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("handler"))
+	}))
+	handler.AddFilter(func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Filter", "1")
+			h.ServeHTTP(w, r)
+		})
+	})
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(w, r)
+	if w.Header().Get("X-Filter") != "1" || w.Body.String() != "handler" {
+		t.Fatalf("unexpected response %+v", w)
+	}
+}
+
+// stubRouter only matches exact paths and passes request path as parameter.
+type stubRouter map[string]http.Handler
+
+func (router stubRouter) Handle(method, pattern string, handler interface{}) {
+	router[method+" "+pattern] = handler.(http.Handler)
+}
+
+func (router stubRouter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h, ok := router[r.Method+" "+r.URL.Path]; ok {
+		h.ServeHTTP(w, core.WithPathParams(r, map[string]string{"path": r.URL.Path}))
+		return
+	}
+	http.NotFound(w, r)
+}
+
+func TestRegisterRouter(t *testing.T) {
+	RegisterRouter("stub", func() Router {
+		return stubRouter{}
+	})
+	factory := &commonFactory{Router: "stub"}
+	handler, err := factory.newApplicationHandler(core.NewEnvironment())
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler.Handle("GET", "/users", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(core.PathParams(r)["path"]))
+	}))
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/users", nil)
+	handler.ServeHTTP(w, r)
+	if w.Body.String() != "/users" {
+		t.Fatalf("unexpected response %+v", w)
+	}
+	factory.Router = "none"
+	if _, err = factory.newApplicationHandler(core.NewEnvironment()); err == nil {
+		t.Fatal("error expected")
+	}
+}
+
+func TestGojiRouterPathParams(t *testing.T) {
+	handler := NewHandler()
+	handler.Handle("GET", "/users/:id", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(core.PathParams(r)["id"]))
+	}))
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/users/1", nil)
+	handler.ServeHTTP(w, r)
+	if w.Body.String() != "1" {
+		t.Fatalf("unexpected response %+v", w)
+	}
+}
+
+func TestRegisterFactory(t *testing.T) {
+	RegisterFactory("stub", func() core.ServerFactory {
+		return &stubFactory{}
+	})
+	for _, typ := range []string{"default", "simple", "stub"} {
+		factory := &Factory{}
+		if err := json.Unmarshal([]byte(`{"type": "`+typ+`"}`), factory); err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := factory.Value().(core.ServerFactory); !ok {
+			t.Fatalf("unexpected factory %#v", factory.Value())
+		}
+	}
+}
+
+func TestServerListener(t *testing.T) {
+	server := NewServer()
+	server.addConnectors(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}), []Connector{{Type: "http", Addr: "127.0.0.1:0"}})
+	started := make(chan []core.ServerConnector, 1)
+	server.Listener = core.ServerLifecycleListenerFunc(func(connectors []core.ServerConnector) {
+		started <- connectors
+	})
+	errc := make(chan error, 1)
+	go func() {
+		errc <- server.Start()
+	}()
+	var connectors []core.ServerConnector
+	select {
+	case connectors = <-started:
+	case err := <-errc:
+		t.Fatal(err)
+	}
+	if len(connectors) != 1 || connectors[0].Type != "http" || connectors[0].Admin {
+		t.Fatalf("unexpected connectors %+v", connectors)
+	}
+	resp, err := http.Get("http://" + connectors[0].Addr.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status %v", resp.Status)
+	}
+	// graceful shuts down all servers in the process and only once.
+	server.Stop()
+	if err = <-errc; err != nil {
+		t.Fatal(err)
+	}
+}
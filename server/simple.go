@@ -1,8 +1,6 @@
 package server
 
 import (
-	"net/http"
-
 	"github.com/goburrow/gomelon/core"
 )
 
@@ -17,41 +15,54 @@ type SimpleFactory struct {
 
 var _ core.ServerFactory = (*SimpleFactory)(nil)
 
+// newSimpleFactory returns a SimpleFactory with default settings, which
+// serves application at /application and admin at /admin on port 8080.
+func newSimpleFactory() interface{} {
+	return &SimpleFactory{
+		ApplicationContextPath: "/application",
+		AdminContextPath:       "/admin",
+		Connector: Connector{
+			Type: "http",
+			Addr: ":8080",
+		},
+	}
+}
+
 func (factory *SimpleFactory) Build(env *core.Environment) (core.Server, error) {
 	// Both application and admin share same handler
-	appHandler := NewHandler()
+	appHandler, err := factory.commonFactory.newApplicationHandler(env)
+	if err != nil {
+		return nil, err
+	}
 	appHandler.pathPrefix = factory.ApplicationContextPath
-	appHandler.ServeMux.Use(func(h http.Handler) http.Handler {
-		return appHandler.FilterChain.Build(h)
-	})
 	env.Server.ServerHandler = appHandler
 	env.Server.AddResourceHandler(newResourceHandler(appHandler, env.Server))
 
 	adminHandler := NewHandler()
 	adminHandler.pathPrefix = factory.AdminContextPath
-	adminHandler.ServeMux.Use(func(h http.Handler) http.Handler {
-		return adminHandler.FilterChain.Build(h)
-	})
 	env.Admin.ServerHandler = adminHandler
 
+	if err := factory.commonFactory.AddApplicationFilters(env, appHandler); err != nil {
+		return nil, err
+	}
+	if err := factory.commonFactory.AddAdminFilters(env, adminHandler); err != nil {
+		return nil, err
+	}
 	return factory.buildServer(env, appHandler, adminHandler)
 }
 
 func (factory *SimpleFactory) buildServer(env *core.Environment, handlers ...*Handler) (core.Server, error) {
 	handler := NewHandler()
-	handler.ServeMux.Use(func(h http.Handler) http.Handler {
-		return handler.FilterChain.Build(h)
-	})
 	// Sub routers
-	for _, h := range handlers {
-		handler.ServeMux.Handle(h.pathPrefix+"/*", h)
-		handler.ServeMux.Handle(h.pathPrefix, http.RedirectHandler(h.pathPrefix+"/", http.StatusMovedPermanently))
-	}
+	mountHandlers(handler.Router, handlers...)
 	// Only need filters in the root handler.
 	if err := factory.commonFactory.AddFilters(env, handler); err != nil {
 		return nil, err
 	}
-	server := NewServer()
-	server.addConnectors(handler.ServeMux, []Connector{factory.Connector})
+	server, err := factory.commonFactory.newServer(env)
+	if err != nil {
+		return nil, err
+	}
+	server.addConnectors(handler, []Connector{factory.Connector})
 	return server, nil
 }
@@ -1,6 +1,7 @@
 package server
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/goburrow/gomelon/core"
@@ -24,3 +25,21 @@ func TestSimpleFactory(t *testing.T) {
 		t.Fatal("Admin.ServerHandler is nil")
 	}
 }
+
+func TestSimpleFactoryType(t *testing.T) {
+	factory := &Factory{}
+	err := json.Unmarshal([]byte(`{"type": "simple", "connector": {"addr": ":9090"}}`), factory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	simple, ok := factory.Value().(*SimpleFactory)
+	if !ok {
+		t.Fatalf("unexpected factory %#v", factory.Value())
+	}
+	if simple.ApplicationContextPath != "/application" || simple.AdminContextPath != "/admin" {
+		t.Fatalf("unexpected context paths %+v", simple)
+	}
+	if simple.Connector.Type != "http" || simple.Connector.Addr != ":9090" {
+		t.Fatalf("unexpected connector %+v", simple.Connector)
+	}
+}
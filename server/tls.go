@@ -0,0 +1,211 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goburrow/gomelon/core"
+)
+
+var (
+	tlsVersions = map[string]uint16{
+		"TLS1.0": tls.VersionTLS10,
+		"TLS1.1": tls.VersionTLS11,
+		"TLS1.2": tls.VersionTLS12,
+		"TLS1.3": tls.VersionTLS13,
+	}
+	clientAuthTypes = map[string]tls.ClientAuthType{
+		"none":               tls.NoClientCert,
+		"request":            tls.RequestClientCert,
+		"require":            tls.RequireAnyClientCert,
+		"verify-if-given":    tls.VerifyClientCertIfGiven,
+		"require-and-verify": tls.RequireAndVerifyClientCert,
+	}
+)
+
+const (
+	defaultTLSVersion = tls.VersionTLS12
+	// certificateCheckInterval is how often reloadable certificates are
+	// checked for modification.
+	certificateCheckInterval = time.Minute
+)
+
+// tlsConfig creates TLS configuration for the connector including its
+// certificates.
+func (connector *Connector) tlsConfig() (*tls.Config, error) {
+	config, err := connector.baseTLSConfig()
+	if err != nil {
+		return nil, err
+	}
+	if connector.ReloadCertificate {
+		loader := &certificateLoader{
+			certFile: connector.CertFile,
+			keyFile:  connector.KeyFile,
+		}
+		if err = loader.reload(); err != nil {
+			return nil, err
+		}
+		config.GetCertificate = loader.GetCertificate
+		connector.certLoader = loader
+	} else {
+		cert, err := tls.LoadX509KeyPair(connector.CertFile, connector.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
+
+// baseTLSConfig creates TLS configuration without certificates.
+func (connector *Connector) baseTLSConfig() (*tls.Config, error) {
+	config := &tls.Config{
+		MinVersion: defaultTLSVersion,
+	}
+	if connector.MinVersion != "" {
+		version, ok := tlsVersions[strings.ToUpper(connector.MinVersion)]
+		if !ok {
+			return nil, fmt.Errorf("server: unsupported TLS version %s", connector.MinVersion)
+		}
+		config.MinVersion = version
+	}
+	if len(connector.CipherSuites) > 0 {
+		cipherSuites, err := getCipherSuites(connector.CipherSuites)
+		if err != nil {
+			return nil, err
+		}
+		config.CipherSuites = cipherSuites
+	}
+	if err := connector.configureClientAuth(config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+func (connector *Connector) configureClientAuth(config *tls.Config) error {
+	if connector.ClientCAFile != "" {
+		pem, err := ioutil.ReadFile(connector.ClientCAFile)
+		if err != nil {
+			return err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("server: no certificates found in %s", connector.ClientCAFile)
+		}
+		config.ClientCAs = pool
+		// Verify client certificates by default when CA is provided.
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	if connector.ClientAuth != "" {
+		clientAuth, ok := clientAuthTypes[strings.ToLower(connector.ClientAuth)]
+		if !ok {
+			return fmt.Errorf("server: unsupported client auth %s", connector.ClientAuth)
+		}
+		config.ClientAuth = clientAuth
+	}
+	return nil
+}
+
+// getCipherSuites returns IDs of the cipher suites with given names,
+// e.g. TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256.
+func getCipherSuites(names []string) ([]uint16, error) {
+	suites := make(map[string]uint16)
+	for _, s := range tls.CipherSuites() {
+		suites[s.Name] = s.ID
+	}
+	for _, s := range tls.InsecureCipherSuites() {
+		suites[s.Name] = s.ID
+	}
+	ids := make([]uint16, len(names))
+	for i, name := range names {
+		id, ok := suites[strings.ToUpper(name)]
+		if !ok {
+			return nil, fmt.Errorf("server: unsupported cipher suite %s", name)
+		}
+		ids[i] = id
+	}
+	return ids, nil
+}
+
+// certificateLoader reloads certificate when the certificate or key file
+// has been modified. Files are checked periodically and on lifecycle reload,
+// never during TLS handshakes.
+type certificateLoader struct {
+	certFile string
+	keyFile  string
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	modTime time.Time
+}
+
+// GetCertificate is used in tls.Config.
+func (l *certificateLoader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	l.mu.Lock()
+	cert := l.cert
+	l.mu.Unlock()
+	if cert == nil {
+		return nil, fmt.Errorf("server: certificate %s is not loaded", l.certFile)
+	}
+	return cert, nil
+}
+
+// reload loads the certificate if the files have been modified. The current
+// certificate is kept when the files are invalid.
+func (l *certificateLoader) reload() error {
+	modTime, err := latestModTime(l.certFile, l.keyFile)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.cert != nil && !modTime.After(l.modTime) {
+		return nil
+	}
+	cert, err := tls.LoadX509KeyPair(l.certFile, l.keyFile)
+	if err != nil {
+		return err
+	}
+	if l.cert != nil {
+		core.GetLogger(loggerName).Info("reloaded certificate %s", l.certFile)
+	}
+	l.cert = &cert
+	l.modTime = modTime
+	return nil
+}
+
+// watch reloads the certificate every interval until done is closed.
+func (l *certificateLoader) watch(interval time.Duration, done <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := l.reload(); err != nil {
+				core.GetLogger(loggerName).Error("could not reload certificate: %v", err)
+			}
+		case <-done:
+			return
+		}
+	}
+}
+
+func latestModTime(files ...string) (time.Time, error) {
+	var t time.Time
+	for _, f := range files {
+		info, err := os.Stat(f)
+		if err != nil {
+			return t, err
+		}
+		if info.ModTime().After(t) {
+			t = info.ModTime()
+		}
+	}
+	return t, nil
+}
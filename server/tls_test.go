@@ -0,0 +1,287 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/goburrow/gomelon/core"
+	"golang.org/x/net/http2"
+)
+
+// writeCertificate generates a self-signed certificate to the given files.
+func writeCertificate(t *testing.T, certFile, keyFile string, serial int64) {
+	certPEM, keyPEM := generateCertificate(t, "localhost", serial)
+	if err := ioutil.WriteFile(certFile, certPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(keyFile, keyPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// generateCertificate returns a self-signed certificate for host and its
+// private key in PEM format.
+func generateCertificate(t *testing.T, host string, serial int64) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: host},
+		DNSNames:     []string{host},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+		IsCA:         true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+}
+
+// startServer starts a server with the given connector and returns its
+// address. Only TLS connectors can be used as graceful shuts down all
+// servers in the process and only once.
+func startServer(t *testing.T, connector Connector) (*Server, string) {
+	server := NewServer()
+	server.addConnectors(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS == nil {
+			http.Error(w, "no TLS", http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(r.Proto))
+	}), []Connector{connector})
+	started := make(chan []core.ServerConnector, 1)
+	server.Listener = core.ServerLifecycleListenerFunc(func(connectors []core.ServerConnector) {
+		started <- connectors
+	})
+	errc := make(chan error, 1)
+	go func() {
+		errc <- server.Start()
+	}()
+	select {
+	case connectors := <-started:
+		return server, connectors[0].Addr.String()
+	case err := <-errc:
+		t.Fatal(err)
+	}
+	return nil, ""
+}
+
+// getHTTP2 requests the server with HTTP/2 and returns the response body.
+func getHTTP2(t *testing.T, addr, serverName string) string {
+	transport := &http2.Transport{
+		TLSClientConfig: &tls.Config{
+			ServerName:         serverName,
+			InsecureSkipVerify: true,
+		},
+	}
+	defer transport.CloseIdleConnections()
+	resp, err := (&http.Client{Transport: transport}).Get("https://" + addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status %v: %s", resp.Status, body)
+	}
+	return string(body)
+}
+
+func TestTLSConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	writeCertificate(t, certFile, keyFile, 1)
+
+	connector := &Connector{
+		Type:         "https",
+		CertFile:     certFile,
+		KeyFile:      keyFile,
+		MinVersion:   "tls1.3",
+		CipherSuites: []string{"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256"},
+		ClientCAFile: certFile,
+	}
+	config, err := connector.tlsConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.MinVersion != tls.VersionTLS13 {
+		t.Fatalf("unexpected min version %v", config.MinVersion)
+	}
+	if len(config.CipherSuites) != 1 || config.CipherSuites[0] != tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256 {
+		t.Fatalf("unexpected cipher suites %v", config.CipherSuites)
+	}
+	if config.ClientAuth != tls.RequireAndVerifyClientCert || config.ClientCAs == nil {
+		t.Fatalf("unexpected client auth %v", config.ClientAuth)
+	}
+	if len(config.Certificates) != 1 {
+		t.Fatalf("unexpected certificates %v", config.Certificates)
+	}
+	connector.ClientAuth = "request"
+	if config, err = connector.tlsConfig(); err != nil {
+		t.Fatal(err)
+	}
+	if config.ClientAuth != tls.RequestClientCert {
+		t.Fatalf("unexpected client auth %v", config.ClientAuth)
+	}
+	for _, c := range []Connector{
+		{CertFile: certFile, KeyFile: keyFile, MinVersion: "SSL3"},
+		{CertFile: certFile, KeyFile: keyFile, CipherSuites: []string{"NONE"}},
+		{CertFile: certFile, KeyFile: keyFile, ClientAuth: "any"},
+		{CertFile: certFile, KeyFile: keyFile, ClientCAFile: filepath.Join(dir, "none")},
+	} {
+		if _, err = c.tlsConfig(); err == nil {
+			t.Fatalf("error expected %+v", c)
+		}
+	}
+}
+
+func TestReloadCertificate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	writeCertificate(t, certFile, keyFile, 1)
+
+	connector := &Connector{
+		CertFile:          certFile,
+		KeyFile:           keyFile,
+		ReloadCertificate: true,
+	}
+	config, err := connector.tlsConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert1, err := config.GetCertificate(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeCertificate(t, certFile, keyFile, 2)
+	future := time.Now().Add(time.Minute)
+	os.Chtimes(certFile, future, future)
+	// Files are not checked during handshakes.
+	if cert, _ := config.GetCertificate(nil); cert != cert1 {
+		t.Fatal("certificate is reloaded in handshake")
+	}
+	if err = connector.certLoader.reload(); err != nil {
+		t.Fatal(err)
+	}
+	cert2, err := config.GetCertificate(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cert1 == cert2 {
+		t.Fatal("certificate is not reloaded")
+	}
+	// Broken files do not affect the current certificate.
+	ioutil.WriteFile(keyFile, []byte("invalid"), 0600)
+	future = future.Add(time.Minute)
+	os.Chtimes(keyFile, future, future)
+	if err = connector.certLoader.reload(); err == nil {
+		t.Fatal("error expected")
+	}
+	cert3, err := config.GetCertificate(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cert3 != cert2 {
+		t.Fatal("unexpected certificate")
+	}
+}
+
+func TestACMETLSConfig(t *testing.T) {
+	connector := &Connector{Type: "acme"}
+	if _, err := connector.acmeTLSConfig(); err == nil {
+		t.Fatal("error expected")
+	}
+	connector.ACME.Hosts = []string{"example.com"}
+	config, err := connector.acmeTLSConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.GetCertificate == nil {
+		t.Fatal("GetCertificate is not set")
+	}
+	if len(config.NextProtos) != 1 || config.NextProtos[0] != "acme-tls/1" {
+		t.Fatalf("unexpected next protos %v", config.NextProtos)
+	}
+}
+
+func TestConnectorH2(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	writeCertificate(t, certFile, keyFile, 1)
+
+	server, addr := startServer(t, Connector{
+		Type:     "h2",
+		Addr:     "127.0.0.1:0",
+		CertFile: certFile,
+		KeyFile:  keyFile,
+	})
+	defer server.Stop()
+	if proto := getHTTP2(t, addr, "localhost"); proto != "HTTP/2.0" {
+		t.Fatalf("unexpected protocol %s", proto)
+	}
+}
+
+func TestConnectorACME(t *testing.T) {
+	dir, err := ioutil.TempDir("", "acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	// Certificate obtained previously is loaded from autocert cache.
+	certPEM, keyPEM := generateCertificate(t, "example.com", 1)
+	if err = ioutil.WriteFile(filepath.Join(dir, "example.com"), append(keyPEM, certPEM...), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	server, addr := startServer(t, Connector{
+		Type: "acme",
+		Addr: "127.0.0.1:0",
+		ACME: ACMEConfiguration{
+			Hosts:    []string{"example.com"},
+			CacheDir: dir,
+		},
+	})
+	defer server.Stop()
+	if proto := getHTTP2(t, addr, "example.com"); proto != "HTTP/2.0" {
+		t.Fatalf("unexpected protocol %s", proto)
+	}
+}
@@ -0,0 +1,66 @@
+package gomelon
+
+import (
+	"bytes"
+	"os"
+	"os/signal"
+	"runtime/pprof"
+	"syscall"
+
+	"github.com/goburrow/gomelon/core"
+)
+
+// handleSignals shuts down the lifecycle on SIGINT and SIGTERM, reloads it
+// on SIGHUP and logs stacks of all goroutines on SIGUSR1. The returned
+// function stops handling signals.
+func handleSignals(env *core.Environment) func() {
+	logger := core.GetLogger(serverLoggerName)
+	c := make(chan os.Signal, 1)
+	signals := []os.Signal{os.Interrupt, syscall.SIGTERM}
+	signals = append(signals, reloadSignals...)
+	signals = append(signals, dumpSignals...)
+	signal.Notify(c, signals...)
+	done := make(chan struct{})
+	go func() {
+		shuttingDown := false
+		for {
+			select {
+			case <-done:
+				return
+			case sig := <-c:
+				switch {
+				case isSignal(sig, reloadSignals):
+					env.Lifecycle.Reload()
+				case isSignal(sig, dumpSignals):
+					logger.Info("goroutine dump:\n%s", dumpGoroutines())
+				case shuttingDown:
+					logger.Warn("received %v, already shutting down", sig)
+				default:
+					logger.Info("received %v", sig)
+					shuttingDown = true
+					// Shutdown blocks until the server stops.
+					go env.Lifecycle.Shutdown()
+				}
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(c)
+		close(done)
+	}
+}
+
+func isSignal(sig os.Signal, signals []os.Signal) bool {
+	for _, s := range signals {
+		if s == sig {
+			return true
+		}
+	}
+	return false
+}
+
+func dumpGoroutines() string {
+	var buf bytes.Buffer
+	pprof.Lookup("goroutine").WriteTo(&buf, 2)
+	return buf.String()
+}
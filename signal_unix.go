@@ -0,0 +1,14 @@
+//go:build !windows
+// +build !windows
+
+package gomelon
+
+import (
+	"os"
+	"syscall"
+)
+
+var (
+	reloadSignals = []os.Signal{syscall.SIGHUP}
+	dumpSignals   = []os.Signal{syscall.SIGUSR1}
+)
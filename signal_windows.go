@@ -0,0 +1,9 @@
+package gomelon
+
+import "os"
+
+// Reloading and dumping goroutines are not supported on Windows.
+var (
+	reloadSignals []os.Signal
+	dumpSignals   []os.Signal
+)
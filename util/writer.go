@@ -5,13 +5,13 @@ import (
 	"sync"
 	"time"
 
-	"github.com/goburrow/gol"
+	"github.com/goburrow/gomelon/core"
 )
 
-var writerLogger gol.Logger
+var writerLogger core.Logger
 
 func init() {
-	writerLogger = gol.GetLogger("gomelon/util/writer")
+	writerLogger = core.GetLogger("gomelon/util/writer")
 }
 
 // AsyncWriter writes asynchronously to the given writers.
@@ -11,7 +11,7 @@ import (
 
 func init() {
 	// Mute logger
-	writerLogger.(*gol.DefaultLogger).SetLevel(gol.LevelOff)
+	gol.GetLogger("gomelon/util/writer").(*gol.DefaultLogger).SetLevel(gol.LevelOff)
 }
 
 // chanWriter is used for testing async writer,
@@ -4,6 +4,10 @@ Package validation provides validator for applications.
 package validation
 
 import (
+	"fmt"
+	"reflect"
+	"sort"
+
 	"github.com/goburrow/gomelon/core"
 	"github.com/goburrow/validator"
 )
@@ -24,9 +28,55 @@ func (f *Factory) Initialize() {
 	f.validator = v
 }
 
+// Validator returns the validator which reports invalid fields as
+// core.ValidationErrors.
 func (f *Factory) Validator() core.Validator {
 	if f.validator == nil {
 		f.Initialize()
 	}
-	return f.validator
+	return &fieldValidator{f.validator}
+}
+
+// fieldValidator converts errors of the underlying validator.
+type fieldValidator struct {
+	validator *validator.Validator
+}
+
+func (v *fieldValidator) Validate(obj interface{}) error {
+	return toValidationErrors(v.validator.Validate(obj))
+}
+
+// toValidationErrors converts errors keyed by field names, i.e.
+// validator.ErrorMap, to core.ValidationErrors. Other errors are returned
+// unchanged.
+func toValidationErrors(err error) error {
+	if err == nil {
+		return nil
+	}
+	m := reflect.ValueOf(err)
+	if m.Kind() != reflect.Map || m.Type().Key().Kind() != reflect.String {
+		return err
+	}
+	var violations core.ValidationErrors
+	keys := m.MapKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].String() < keys[j].String()
+	})
+	for _, key := range keys {
+		value := m.MapIndex(key)
+		if value.Kind() == reflect.Slice {
+			for i := 0; i < value.Len(); i++ {
+				violations = append(violations, core.Violation{
+					Field:   key.String(),
+					Message: fmt.Sprint(value.Index(i).Interface()),
+				})
+			}
+			continue
+		}
+		violations = append(violations, core.Violation{
+			Field:   key.String(),
+			Message: fmt.Sprint(value.Interface()),
+		})
+	}
+	return violations
 }
@@ -1,7 +1,10 @@
 package validation
 
 import (
+	"errors"
 	"testing"
+
+	"github.com/goburrow/gomelon/core"
 )
 
 func TestFactory(t *testing.T) {
@@ -81,3 +84,27 @@ func TestValidateStruct(t *testing.T) {
 		t.Fatalf("unexpected error %v", err)
 	}
 }
+
+type errorMap map[string][]error
+
+func (e errorMap) Error() string {
+	return "invalid"
+}
+
+func TestToValidationErrors(t *testing.T) {
+	err := toValidationErrors(errorMap{
+		"Name": {errors.New("zero value")},
+		"Age":  {errors.New("less than min"), errors.New("invalid")},
+	})
+	violations, ok := err.(core.ValidationErrors)
+	if !ok || len(violations) != 3 {
+		t.Fatalf("unexpected error %#v", err)
+	}
+	if violations.Error() != "Age: less than min; Age: invalid; Name: zero value" {
+		t.Fatalf("unexpected error %v", violations)
+	}
+	other := errors.New("other")
+	if err = toValidationErrors(other); err != other {
+		t.Fatalf("unexpected error %#v", err)
+	}
+}
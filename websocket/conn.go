@@ -0,0 +1,347 @@
+package websocket
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Message types defined in RFC 6455, section 11.8.
+const (
+	TextMessage   = 1
+	BinaryMessage = 2
+	CloseMessage  = 8
+	PingMessage   = 9
+	PongMessage   = 10
+
+	continuationFrame = 0
+)
+
+// Close codes defined in RFC 6455, section 7.4.1.
+const (
+	CloseNormalClosure    = 1000
+	CloseGoingAway        = 1001
+	CloseProtocolError    = 1002
+	CloseUnsupportedData  = 1003
+	CloseNoStatusReceived = 1005
+	CloseInvalidPayload   = 1007
+	CloseMessageTooBig    = 1009
+	CloseInternalError    = 1011
+)
+
+const (
+	// DefaultMaxMessageSize is the default maximum size in bytes of a
+	// received message.
+	DefaultMaxMessageSize = 1 << 20
+
+	maxControlPayload = 125
+)
+
+// ErrClosed is returned when writing to a connection which has been closed.
+var ErrClosed = errors.New("websocket: connection closed")
+
+// CloseError is returned by ReadMessage when the connection has been closed
+// by the peer, or by this side because the peer violated the protocol.
+type CloseError struct {
+	Code int
+	Text string
+}
+
+func (e *CloseError) Error() string {
+	if e.Text == "" {
+		return fmt.Sprintf("websocket: closed %d", e.Code)
+	}
+	return fmt.Sprintf("websocket: closed %d %s", e.Code, e.Text)
+}
+
+// Conn is a server side WebSocket connection. ReadMessage must be called
+// from one goroutine at a time, while writing methods can be called
+// concurrently.
+type Conn struct {
+	// MaxMessageSize is the maximum size in bytes of a received message.
+	// Larger messages close the connection with CloseMessageTooBig.
+	MaxMessageSize int64
+	// WriteTimeout is the maximum duration of writing a message. Zero means
+	// no timeout.
+	WriteTimeout time.Duration
+
+	conn        net.Conn
+	reader      *bufio.Reader
+	request     *http.Request
+	subprotocol string
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	writeMu   sync.Mutex
+	closeSent bool
+	closeOnce sync.Once
+}
+
+func newConn(conn net.Conn, reader *bufio.Reader, r *http.Request) *Conn {
+	c := &Conn{
+		MaxMessageSize: DefaultMaxMessageSize,
+		conn:           conn,
+		reader:         reader,
+		request:        r,
+	}
+	c.ctx, c.cancel = context.WithCancel(r.Context())
+	return c
+}
+
+// Request returns the HTTP request which has been upgraded.
+func (c *Conn) Request() *http.Request {
+	return c.request
+}
+
+// Subprotocol returns the negotiated subprotocol or empty if there is none.
+func (c *Conn) Subprotocol() string {
+	return c.subprotocol
+}
+
+// RemoteAddr returns the network address of the peer.
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+// Context returns a context which is done when the connection is closed or
+// the server is shutting down. Handlers which only write should stop when
+// it is done.
+func (c *Conn) Context() context.Context {
+	return c.ctx
+}
+
+// ReadMessage reads the next text or binary message. Ping frames are
+// answered automatically. It returns CloseError when the connection has
+// been closed.
+func (c *Conn) ReadMessage() (messageType int, data []byte, err error) {
+	limit := c.MaxMessageSize
+	if limit <= 0 {
+		limit = DefaultMaxMessageSize
+	}
+	for {
+		fin, opcode, payload, err := c.readFrame(limit - int64(len(data)))
+		if err != nil {
+			return 0, nil, err
+		}
+		switch opcode {
+		case PingMessage:
+			if err = c.writeFrame(PongMessage, payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case PongMessage:
+			continue
+		case CloseMessage:
+			return 0, nil, c.handleClose(payload)
+		case continuationFrame:
+			if messageType == 0 {
+				return 0, nil, c.fail(CloseProtocolError, "unexpected continuation frame")
+			}
+		case TextMessage, BinaryMessage:
+			if messageType != 0 {
+				return 0, nil, c.fail(CloseProtocolError, "expected continuation frame")
+			}
+			messageType = opcode
+		default:
+			return 0, nil, c.fail(CloseProtocolError, "unknown opcode")
+		}
+		data = append(data, payload...)
+		if fin {
+			if messageType == TextMessage && !utf8.Valid(data) {
+				return 0, nil, c.fail(CloseInvalidPayload, "invalid UTF-8 text")
+			}
+			return messageType, data, nil
+		}
+	}
+}
+
+// ReadJSON reads the next message and decodes it as JSON into v.
+func (c *Conn) ReadJSON(v interface{}) error {
+	_, data, err := c.ReadMessage()
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// WriteMessage writes a text, binary, ping or pong message.
+func (c *Conn) WriteMessage(messageType int, data []byte) error {
+	switch messageType {
+	case TextMessage, BinaryMessage:
+	case PingMessage, PongMessage:
+		if len(data) > maxControlPayload {
+			return fmt.Errorf("websocket: control message too long")
+		}
+	default:
+		return fmt.Errorf("websocket: unsupported message type %d", messageType)
+	}
+	return c.writeFrame(messageType, data)
+}
+
+// WriteJSON writes v encoded as JSON in a text message.
+func (c *Conn) WriteJSON(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.writeFrame(TextMessage, data)
+}
+
+// Close sends a close frame with the given code and reason then closes the
+// underlying connection. Endpoints usually just return, which closes the
+// connection normally.
+func (c *Conn) Close(code int, text string) error {
+	err := c.writeClose(code, text)
+	c.close()
+	return err
+}
+
+// close closes the underlying connection without the closing handshake.
+func (c *Conn) close() {
+	c.closeOnce.Do(func() {
+		c.cancel()
+		c.conn.Close()
+	})
+}
+
+// shutdown starts the closing handshake when the server is stopping. The
+// endpoint returns after receiving the close frame from the peer.
+func (c *Conn) shutdown() {
+	c.writeClose(CloseGoingAway, "server is shutting down")
+	c.cancel()
+}
+
+func (c *Conn) readFrame(limit int64) (fin bool, opcode int, payload []byte, err error) {
+	var b [8]byte
+	if _, err = io.ReadFull(c.reader, b[:2]); err != nil {
+		return
+	}
+	fin = b[0]&0x80 != 0
+	opcode = int(b[0] & 0x0f)
+	if b[0]&0x70 != 0 {
+		err = c.fail(CloseProtocolError, "reserved bits are set")
+		return
+	}
+	if b[1]&0x80 == 0 {
+		err = c.fail(CloseProtocolError, "frame is not masked")
+		return
+	}
+	length := int64(b[1] & 0x7f)
+	switch length {
+	case 126:
+		if _, err = io.ReadFull(c.reader, b[:2]); err != nil {
+			return
+		}
+		length = int64(binary.BigEndian.Uint16(b[:2]))
+	case 127:
+		if _, err = io.ReadFull(c.reader, b[:8]); err != nil {
+			return
+		}
+		length = int64(binary.BigEndian.Uint64(b[:8]))
+		if length < 0 {
+			err = c.fail(CloseProtocolError, "invalid frame length")
+			return
+		}
+	}
+	if opcode >= CloseMessage {
+		if !fin || length > maxControlPayload {
+			err = c.fail(CloseProtocolError, "invalid control frame")
+			return
+		}
+	} else if length > limit {
+		err = c.fail(CloseMessageTooBig, "message too big")
+		return
+	}
+	var mask [4]byte
+	if _, err = io.ReadFull(c.reader, mask[:]); err != nil {
+		return
+	}
+	payload = make([]byte, length)
+	if _, err = io.ReadFull(c.reader, payload); err != nil {
+		return
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return
+}
+
+// handleClose replies the close frame received from the peer.
+func (c *Conn) handleClose(payload []byte) error {
+	code, text := CloseNoStatusReceived, ""
+	switch {
+	case len(payload) == 1:
+		return c.fail(CloseProtocolError, "invalid close frame")
+	case len(payload) >= 2:
+		code = int(binary.BigEndian.Uint16(payload))
+		text = string(payload[2:])
+	}
+	reply := code
+	if reply == CloseNoStatusReceived {
+		reply = CloseNormalClosure
+	}
+	c.writeClose(reply, "")
+	return &CloseError{Code: code, Text: text}
+}
+
+// fail closes the connection because of a protocol violation of the peer.
+func (c *Conn) fail(code int, text string) error {
+	c.writeClose(code, text)
+	return &CloseError{Code: code, Text: text}
+}
+
+func (c *Conn) writeClose(code int, text string) error {
+	payload := make([]byte, 2, 2+len(text))
+	binary.BigEndian.PutUint16(payload, uint16(code))
+	payload = append(payload, text...)
+	if len(payload) > maxControlPayload {
+		payload = payload[:maxControlPayload]
+	}
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if c.closeSent {
+		return nil
+	}
+	c.closeSent = true
+	return c.writeFrameLocked(CloseMessage, payload)
+}
+
+func (c *Conn) writeFrame(opcode int, data []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if c.closeSent {
+		return ErrClosed
+	}
+	return c.writeFrameLocked(opcode, data)
+}
+
+func (c *Conn) writeFrameLocked(opcode int, data []byte) error {
+	frame := make([]byte, 0, 10+len(data))
+	frame = append(frame, 0x80|byte(opcode))
+	switch n := len(data); {
+	case n <= maxControlPayload:
+		frame = append(frame, byte(n))
+	case n <= 0xffff:
+		frame = append(frame, 126, byte(n>>8), byte(n))
+	default:
+		var b [8]byte
+		binary.BigEndian.PutUint64(b[:], uint64(n))
+		frame = append(append(frame, 127), b[:]...)
+	}
+	frame = append(frame, data...)
+	if c.WriteTimeout > 0 {
+		c.conn.SetWriteDeadline(time.Now().Add(c.WriteTimeout))
+	}
+	_, err := c.conn.Write(frame)
+	return err
+}
@@ -0,0 +1,143 @@
+package websocket
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// Upgrader upgrades HTTP connections to the WebSocket protocol.
+type Upgrader struct {
+	// CheckOrigin returns true if the request origin is allowed. If it is
+	// nil, requests with an Origin header not matching the Host are
+	// rejected with status 403.
+	CheckOrigin func(r *http.Request) bool
+	// Subprotocols are supported subprotocols in order of preference.
+	Subprotocols []string
+	// MaxMessageSize is the maximum size in bytes of received messages. It
+	// is DefaultMaxMessageSize if zero.
+	MaxMessageSize int64
+	// WriteTimeout is the maximum duration of writing a message. Zero means
+	// no timeout.
+	WriteTimeout time.Duration
+}
+
+// Upgrade performs the opening handshake and takes over the connection from
+// the HTTP server. It replies with an HTTP error and returns the error if
+// the request is not a valid WebSocket handshake.
+func (u *Upgrader) Upgrade(w http.ResponseWriter, r *http.Request) (*Conn, error) {
+	if r.Method != "GET" {
+		return nil, u.error(w, http.StatusMethodNotAllowed, "request method is not GET")
+	}
+	if !headerContains(r.Header, "Connection", "upgrade") || !headerContains(r.Header, "Upgrade", "websocket") {
+		return nil, u.error(w, http.StatusBadRequest, "request is not a websocket handshake")
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		return nil, u.error(w, http.StatusUpgradeRequired, "unsupported version")
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if k, err := base64.StdEncoding.DecodeString(key); err != nil || len(k) != 16 {
+		return nil, u.error(w, http.StatusBadRequest, "invalid Sec-WebSocket-Key")
+	}
+	checkOrigin := u.CheckOrigin
+	if checkOrigin == nil {
+		checkOrigin = sameOrigin
+	}
+	if !checkOrigin(r) {
+		return nil, u.error(w, http.StatusForbidden, "origin is not allowed")
+	}
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		return nil, u.error(w, http.StatusInternalServerError, "response does not support hijacking")
+	}
+	netConn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, u.error(w, http.StatusInternalServerError, err.Error())
+	}
+	c := newConn(netConn, rw.Reader, r)
+	c.subprotocol = u.selectSubprotocol(r)
+	if u.MaxMessageSize > 0 {
+		c.MaxMessageSize = u.MaxMessageSize
+	}
+	c.WriteTimeout = u.WriteTimeout
+
+	var response strings.Builder
+	response.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n")
+	fmt.Fprintf(&response, "Sec-WebSocket-Accept: %s\r\n", acceptKey(key))
+	if c.subprotocol != "" {
+		fmt.Fprintf(&response, "Sec-WebSocket-Protocol: %s\r\n", c.subprotocol)
+	}
+	response.WriteString("\r\n")
+	// Deadlines set by the HTTP server do not apply to the connection.
+	netConn.SetDeadline(time.Time{})
+	if _, err = netConn.Write([]byte(response.String())); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func (u *Upgrader) error(w http.ResponseWriter, status int, msg string) error {
+	http.Error(w, msg, status)
+	return fmt.Errorf("websocket: %s", msg)
+}
+
+func (u *Upgrader) selectSubprotocol(r *http.Request) string {
+	requested := headerTokens(r.Header, "Sec-WebSocket-Protocol")
+	for _, supported := range u.Subprotocols {
+		for _, p := range requested {
+			if p == supported {
+				return p
+			}
+		}
+	}
+	return ""
+}
+
+func acceptKey(key string) string {
+	h := sha1.New()
+	h.Write([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// sameOrigin returns true if there is no Origin header or its host matches
+// the request host.
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, r.Host)
+}
+
+func headerTokens(header http.Header, name string) []string {
+	var tokens []string
+	for _, value := range header[http.CanonicalHeaderKey(name)] {
+		for _, token := range strings.Split(value, ",") {
+			if token = strings.TrimSpace(token); token != "" {
+				tokens = append(tokens, token)
+			}
+		}
+	}
+	return tokens
+}
+
+func headerContains(header http.Header, name, token string) bool {
+	for _, t := range headerTokens(header, name) {
+		if strings.EqualFold(t, token) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,209 @@
+/*
+Package websocket provides WebSocket endpoints which are registered in the
+server environment along with other resources.
+
+An endpoint implements Endpoint and is served on its path once the bundle
+is added to the application:
+
+	type echoEndpoint struct{}
+
+	func (*echoEndpoint) Path() string {
+		return "/echo"
+	}
+
+	func (*echoEndpoint) ServeWebSocket(conn *websocket.Conn) {
+		for {
+			t, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if err = conn.WriteMessage(t, data); err != nil {
+				return
+			}
+		}
+	}
+
+	bootstrap.AddBundle(websocket.NewBundle())
+	environment.Server.Register(&echoEndpoint{})
+
+The connection is closed when ServeWebSocket returns. When the application
+is stopping, new handshakes are rejected and open connections receive a
+close frame with CloseGoingAway, so endpoints can finish before the
+lifecycle stop timeout, after which connections are closed forcibly.
+*/
+package websocket
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/goburrow/gomelon/core"
+)
+
+const (
+	resourceLoggerName = "gomelon/websocket"
+)
+
+// Endpoint is a WebSocket resource.
+type Endpoint interface {
+	Path() string
+	// ServeWebSocket handles the upgraded connection. The connection is
+	// closed when it returns.
+	ServeWebSocket(conn *Conn)
+}
+
+// Bundle adds support for WebSocket endpoints.
+type Bundle struct {
+	// Upgrader is used by all endpoints.
+	Upgrader Upgrader
+}
+
+var _ core.Bundle = (*Bundle)(nil)
+
+// NewBundle allocates and returns a new Bundle.
+func NewBundle() *Bundle {
+	return &Bundle{}
+}
+
+// Initialize does nothing.
+func (bundle *Bundle) Initialize(bootstrap *core.Bootstrap) {
+}
+
+// Run registers the resource handler of endpoints and manages their
+// connections in the lifecycle of the application.
+func (bundle *Bundle) Run(conf interface{}, env *core.Environment) error {
+	h := NewResourceHandler(env)
+	h.Upgrader = bundle.Upgrader
+	env.Server.AddResourceHandler(h)
+	env.Lifecycle.ManageContext(h, core.PhaseService)
+	return nil
+}
+
+// ResourceHandler implements core.ResourceHandler for endpoints. It also
+// implements core.ManagedContext which closes connections when stopped.
+type ResourceHandler struct {
+	Upgrader Upgrader
+
+	serverHandler  core.ServerHandler
+	endpointLogger core.EndpointLogger
+	logger         core.Logger
+
+	mu       sync.Mutex
+	wg       sync.WaitGroup
+	conns    map[*Conn]struct{}
+	draining bool
+}
+
+var _ core.ResourceHandler = (*ResourceHandler)(nil)
+var _ core.ManagedContext = (*ResourceHandler)(nil)
+
+// NewResourceHandler allocates and returns a new ResourceHandler.
+func NewResourceHandler(env *core.Environment) *ResourceHandler {
+	return &ResourceHandler{
+		serverHandler:  env.Server.ServerHandler,
+		endpointLogger: env.Server,
+		logger:         core.GetLogger(resourceLoggerName),
+		conns:          make(map[*Conn]struct{}),
+	}
+}
+
+// HandleResource registers v if it is an Endpoint.
+func (h *ResourceHandler) HandleResource(v interface{}) {
+	if e, ok := v.(Endpoint); ok {
+		h.serverHandler.Handle("GET", e.Path(), &endpointHandler{h, e})
+		h.endpointLogger.LogEndpoint("GET", e.Path(), v)
+	}
+}
+
+// Start does nothing.
+func (h *ResourceHandler) Start(ctx context.Context) error {
+	return nil
+}
+
+// Stop rejects new connections and sends a close frame to open connections,
+// then waits for their endpoints to return. Remaining connections are
+// closed when ctx is done.
+func (h *ResourceHandler) Stop(ctx context.Context) error {
+	h.mu.Lock()
+	h.draining = true
+	for c := range h.conns {
+		c.shutdown()
+	}
+	h.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		h.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		h.mu.Lock()
+		n := len(h.conns)
+		for c := range h.conns {
+			c.close()
+		}
+		h.mu.Unlock()
+		h.logger.Warn("closed %d websocket connections forcibly", n)
+		return ctx.Err()
+	}
+}
+
+// Len returns the number of open connections.
+func (h *ResourceHandler) Len() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.conns)
+}
+
+func (h *ResourceHandler) serve(e Endpoint, w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	if h.draining {
+		h.mu.Unlock()
+		w.Header().Set("Connection", "close")
+		http.Error(w, "server is shutting down", http.StatusServiceUnavailable)
+		return
+	}
+	h.wg.Add(1)
+	h.mu.Unlock()
+	defer h.wg.Done()
+
+	conn, err := h.Upgrader.Upgrade(w, r)
+	if err != nil {
+		h.logger.Debug("could not upgrade %s: %v", r.URL.Path, err)
+		return
+	}
+	h.mu.Lock()
+	h.conns[conn] = struct{}{}
+	if h.draining {
+		conn.shutdown()
+	}
+	h.mu.Unlock()
+	defer func() {
+		h.mu.Lock()
+		delete(h.conns, conn)
+		h.mu.Unlock()
+		// Response has been hijacked so panics are not recovered by the
+		// server.
+		if v := recover(); v != nil {
+			h.logger.Error("panic serving websocket %s: %v", r.URL.Path, v)
+			conn.Close(CloseInternalError, "")
+			return
+		}
+		conn.Close(CloseNormalClosure, "")
+	}()
+	e.ServeWebSocket(conn)
+}
+
+// endpointHandler is the http.Handler of an endpoint.
+type endpointHandler struct {
+	h        *ResourceHandler
+	endpoint Endpoint
+}
+
+func (e *endpointHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	e.h.serve(e.endpoint, w, r)
+}
@@ -0,0 +1,254 @@
+package websocket
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/goburrow/gomelon/core"
+	"github.com/goburrow/gomelon/server"
+)
+
+type echoEndpoint struct {
+}
+
+func (*echoEndpoint) Path() string {
+	return "/echo"
+}
+
+func (*echoEndpoint) ServeWebSocket(conn *Conn) {
+	for {
+		t, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		if err = conn.WriteMessage(t, data); err != nil {
+			return
+		}
+	}
+}
+
+func newTestServer(t *testing.T) (*httptest.Server, *ResourceHandler) {
+	env := core.NewEnvironment()
+	handler := server.NewHandler()
+	env.Server.ServerHandler = handler
+	h := NewResourceHandler(env)
+	h.Upgrader.MaxMessageSize = 16
+	h.HandleResource(&echoEndpoint{})
+	return httptest.NewServer(handler), h
+}
+
+// testClient writes masked frames and reads frames from the server.
+type testClient struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+func dial(t *testing.T, s *httptest.Server, header ...string) (*testClient, *http.Response) {
+	conn, err := net.Dial("tcp", s.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, _ := http.NewRequest("GET", s.URL+"/echo", nil)
+	r.Header.Set("Connection", "Upgrade")
+	r.Header.Set("Upgrade", "websocket")
+	r.Header.Set("Sec-WebSocket-Version", "13")
+	r.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	for i := 0; i+1 < len(header); i += 2 {
+		r.Header.Set(header[i], header[i+1])
+	}
+	if err = r.Write(conn); err != nil {
+		t.Fatal(err)
+	}
+	c := &testClient{conn: conn, reader: bufio.NewReader(conn)}
+	resp, err := http.ReadResponse(c.reader, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c, resp
+}
+
+func (c *testClient) write(t *testing.T, fin bool, opcode int, data []byte, masked bool) {
+	b0 := byte(opcode)
+	if fin {
+		b0 |= 0x80
+	}
+	frame := []byte{b0, byte(len(data))}
+	if masked {
+		mask := []byte{1, 2, 3, 4}
+		frame[1] |= 0x80
+		frame = append(frame, mask...)
+		for i, b := range data {
+			frame = append(frame, b^mask[i%4])
+		}
+	} else {
+		frame = append(frame, data...)
+	}
+	if _, err := c.conn.Write(frame); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (c *testClient) read(t *testing.T) (int, []byte) {
+	c.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var b [2]byte
+	if _, err := c.reader.Read(b[:1]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.reader.Read(b[1:]); err != nil {
+		t.Fatal(err)
+	}
+	data := make([]byte, b[1]&0x7f)
+	for n := 0; n < len(data); {
+		m, err := c.reader.Read(data[n:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		n += m
+	}
+	return int(b[0] & 0x0f), data
+}
+
+func (c *testClient) readClose(t *testing.T) int {
+	opcode, data := c.read(t)
+	if opcode != CloseMessage || len(data) < 2 {
+		t.Fatalf("close frame expected: %d %q", opcode, data)
+	}
+	return int(binary.BigEndian.Uint16(data))
+}
+
+func TestEcho(t *testing.T) {
+	s, _ := newTestServer(t)
+	defer s.Close()
+	c, resp := dial(t, s, "Sec-WebSocket-Protocol", "chat")
+	defer c.conn.Close()
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("unexpected status %v", resp.StatusCode)
+	}
+	if resp.Header.Get("Sec-WebSocket-Accept") != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("unexpected accept key %v", resp.Header)
+	}
+	if resp.Header.Get("Sec-WebSocket-Protocol") != "" {
+		t.Fatalf("unexpected subprotocol %v", resp.Header)
+	}
+	c.write(t, true, TextMessage, []byte("hello"), true)
+	if opcode, data := c.read(t); opcode != TextMessage || string(data) != "hello" {
+		t.Fatalf("unexpected message %d %q", opcode, data)
+	}
+	// Fragmented message with a ping in between.
+	c.write(t, false, BinaryMessage, []byte("ab"), true)
+	c.write(t, true, PingMessage, []byte("p"), true)
+	c.write(t, true, continuationFrame, []byte("cd"), true)
+	if opcode, data := c.read(t); opcode != PongMessage || string(data) != "p" {
+		t.Fatalf("unexpected pong %d %q", opcode, data)
+	}
+	if opcode, data := c.read(t); opcode != BinaryMessage || string(data) != "abcd" {
+		t.Fatalf("unexpected message %d %q", opcode, data)
+	}
+	c.write(t, true, CloseMessage, []byte{0x03, 0xe8}, true)
+	if code := c.readClose(t); code != CloseNormalClosure {
+		t.Fatalf("unexpected close code %v", code)
+	}
+}
+
+func TestProtocolErrors(t *testing.T) {
+	s, _ := newTestServer(t)
+	defer s.Close()
+	tests := []struct {
+		opcode int
+		data   string
+		masked bool
+		code   int
+	}{
+		{TextMessage, "a", false, CloseProtocolError},
+		{TextMessage, "\xff", true, CloseInvalidPayload},
+		{BinaryMessage, strings.Repeat("a", 17), true, CloseMessageTooBig},
+		{continuationFrame, "a", true, CloseProtocolError},
+	}
+	for _, test := range tests {
+		c, _ := dial(t, s)
+		c.write(t, true, test.opcode, []byte(test.data), test.masked)
+		if code := c.readClose(t); code != test.code {
+			t.Fatalf("unexpected close code %v for %+v", code, test)
+		}
+		c.conn.Close()
+	}
+}
+
+func TestUpgradeErrors(t *testing.T) {
+	s, _ := newTestServer(t)
+	defer s.Close()
+	tests := []struct {
+		header []string
+		status int
+	}{
+		{[]string{"Upgrade", "h2c"}, http.StatusBadRequest},
+		{[]string{"Sec-WebSocket-Version", "8"}, http.StatusUpgradeRequired},
+		{[]string{"Sec-WebSocket-Key", "abc"}, http.StatusBadRequest},
+		{[]string{"Origin", "http://example.com"}, http.StatusForbidden},
+	}
+	for _, test := range tests {
+		c, resp := dial(t, s, test.header...)
+		c.conn.Close()
+		if resp.StatusCode != test.status {
+			t.Fatalf("unexpected status %v for %v", resp.StatusCode, test.header)
+		}
+	}
+}
+
+func TestStop(t *testing.T) {
+	s, h := newTestServer(t)
+	defer s.Close()
+	c, _ := dial(t, s)
+	defer c.conn.Close()
+	// Wait until the connection is registered.
+	c.write(t, true, TextMessage, []byte("a"), true)
+	c.read(t)
+
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- h.Stop(context.Background())
+	}()
+	if code := c.readClose(t); code != CloseGoingAway {
+		t.Fatalf("unexpected close code %v", code)
+	}
+	c.write(t, true, CloseMessage, []byte{0x03, 0xe9}, true)
+	select {
+	case err := <-stopped:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out stopping")
+	}
+	if h.Len() != 0 {
+		t.Fatalf("unexpected connections %v", h.Len())
+	}
+	c2, resp := dial(t, s)
+	c2.conn.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("unexpected status %v", resp.StatusCode)
+	}
+}
+
+func TestStopTimeout(t *testing.T) {
+	s, h := newTestServer(t)
+	defer s.Close()
+	c, _ := dial(t, s)
+	defer c.conn.Close()
+	c.write(t, true, TextMessage, []byte("a"), true)
+	c.read(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	// The client does not reply the close frame.
+	if err := h.Stop(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("unexpected error %v", err)
+	}
+}